@@ -0,0 +1,123 @@
+package main
+
+import (
+	"avito-tech-go-task/internal/application/service"
+	"avito-tech-go-task/internal/infrastructure/idempotency"
+	"avito-tech-go-task/internal/infrastructure/migrate"
+	"avito-tech-go-task/internal/infrastructure/webhook"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+var errNoMigrations = errors.New("migrations are not supported by this storage")
+
+// idempotencyStore - хранилище Idempotency-Key для middleware и команды очистки.
+type idempotencyStore interface {
+	idempotency.Store
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// webhookStore - хранилище подписок для админских ручек и доставок для dispatcher.
+type webhookStore interface {
+	service.WebhookRepository
+	webhook.Store
+}
+
+// runCommand выполняет административную команду вместо запуска HTTP сервера.
+// migrator равен nil для хранилищ без схемы (memory).
+func runCommand(ctx context.Context, out io.Writer, args []string, prService *service.PRService, idempotencyRepo idempotencyStore, migrator *migrate.Migrator) error {
+	switch args[0] {
+	case "rebuild-stats":
+		return rebuildStats(ctx, out, prService)
+	case "purge-idempotency-keys":
+		return purgeIdempotencyKeys(ctx, out, idempotencyRepo)
+	case "migrate":
+		return runMigrate(ctx, out, args[1:], migrator)
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// runMigrate выполняет migrate up|down|status|redo со встроенными миграциями.
+func runMigrate(ctx context.Context, out io.Writer, args []string, migrator *migrate.Migrator) error {
+	if migrator == nil {
+		return errNoMigrations
+	}
+	if len(args) != 1 {
+		return errors.New("usage: migrate up|down|status|redo")
+	}
+
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx)
+	case "redo":
+		return migrator.Redo(ctx)
+	case "status":
+		return migrationStatus(ctx, out, migrator)
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+// migrationStatus печатает встроенные миграции и время их применения.
+func migrationStatus(ctx context.Context, out io.Writer, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "APPLIED AT\tMIGRATION")
+	for _, status := range statuses {
+		appliedAt := "Pending"
+		if !status.AppliedAt.IsZero() {
+			appliedAt = status.AppliedAt.UTC().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", appliedAt, status.Source.Path)
+	}
+
+	return w.Flush()
+}
+
+// rebuildStats пересобирает user_review_stats из истории событий и печатает найденные расхождения.
+func rebuildStats(ctx context.Context, out io.Writer, prService *service.PRService) error {
+	drifts, err := prService.RebuildStats(ctx)
+	if err != nil {
+		return fmt.Errorf("rebuild stats: %w", err)
+	}
+
+	if len(drifts) == 0 {
+		_, err = fmt.Fprintln(out, "user_review_stats rebuilt, no drift found")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER_ID\tSTORED (total/active/merged)\tACTUAL (total/active/merged)")
+	for _, d := range drifts {
+		fmt.Fprintf(w, "%s\t%d/%d/%d\t%d/%d/%d\n",
+			d.UserID,
+			d.Stored.TotalReviews, d.Stored.ActiveReviews, d.Stored.MergedReviews,
+			d.Actual.TotalReviews, d.Actual.ActiveReviews, d.Actual.MergedReviews,
+		)
+	}
+	fmt.Fprintf(w, "user_review_stats rebuilt, drift found for %d user(s)\n", len(drifts))
+
+	return w.Flush()
+}
+
+// purgeIdempotencyKeys удаляет сохранённые ответы с истёкшим Idempotency-Key.
+func purgeIdempotencyKeys(ctx context.Context, out io.Writer, idempotencyRepo idempotencyStore) error {
+	deleted, err := idempotencyRepo.DeleteExpired(ctx)
+	if err != nil {
+		return fmt.Errorf("purge idempotency keys: %w", err)
+	}
+
+	_, err = fmt.Fprintf(out, "%d expired idempotency key(s) deleted\n", deleted)
+	return err
+}
@@ -4,59 +4,437 @@ import (
 	_ "avito-tech-go-task/docs"
 	"avito-tech-go-task/internal/application/service"
 	"avito-tech-go-task/internal/clients/postgres"
+	"avito-tech-go-task/internal/clients/sqlite"
+	"avito-tech-go-task/internal/config"
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/auth"
+	"avito-tech-go-task/internal/infrastructure/health"
 	"avito-tech-go-task/internal/infrastructure/http/controller"
+	"avito-tech-go-task/internal/infrastructure/idempotency"
+	"avito-tech-go-task/internal/infrastructure/logging"
+	"avito-tech-go-task/internal/infrastructure/metrics"
+	"avito-tech-go-task/internal/infrastructure/migrate"
+	"avito-tech-go-task/internal/infrastructure/outbox"
+	"avito-tech-go-task/internal/infrastructure/ratelimit"
+	"avito-tech-go-task/internal/infrastructure/scheduler"
 	"avito-tech-go-task/internal/infrastructure/storage"
-	"log"
+	"avito-tech-go-task/internal/infrastructure/storage/memory"
+	sqliterepo "avito-tech-go-task/internal/infrastructure/storage/sqlite"
+	"avito-tech-go-task/internal/infrastructure/tracing"
+	"avito-tech-go-task/internal/infrastructure/webhook"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
 )
 
-var (
-	dsn string
+// Имена пулов соединений в метриках и /admin/getPoolStats.
+const (
+	poolPrimary = "primary"
+	poolReplica = "replica"
 )
 
-func init() {
-	dsn = os.Getenv("DSN")
-}
+// rateLimitIdleTTL - через сколько после последнего запроса bucket удаляется из postgres.
+// Удалённый bucket создаётся заново полным, поэтому время наполнения лимитов должно быть меньше.
+const rateLimitIdleTTL = time.Hour
 
 func main() {
-	r := gin.Default()
+	cfg, args, err := config.Load(os.Args[1:], os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		slog.Error("load config", slog.Any("error", err))
+		os.Exit(2)
+	}
+	if len(args) > 0 && args[0] == "config" {
+		fmt.Print(cfg.Redacted())
+		return
+	}
+
+	logger, err := logging.New(os.Stdout, cfg.Log.Level)
+	if err != nil {
+		slog.Error("init logger", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// SIGINT/SIGTERM отменяют ctx: сервер перестаёт принимать соединения, фоновые задачи останавливаются
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// команды CLI и фоновые задачи выполняются от имени сервиса, HTTP запросы получают автора в middleware
+	ctx = domain.WithActor(ctx, domain.SystemActor)
 
-	db, err := postgres.Connect(dsn)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "pr-reviewer-service",
+		Exporter:    cfg.Tracing.Exporter,
+		FilePath:    cfg.Tracing.File,
+	})
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("setup tracing", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	var (
+		prRepo          service.PullRequestRepository
+		teamRepo        service.TeamRepository
+		userRepo        service.UserRepository
+		txManager       service.TxManager
+		idempotencyRepo idempotencyStore
+		tokenRepo       service.TokenRepository
+		auditRepo       service.AuditRepository
+		outboxRepo      outbox.Store
+		webhookRepo     webhookStore
+		rateLimitRepo   *storage.RateLimitRepo
+		migrator        *migrate.Migrator
+		checks          []health.Check
+		pools           = map[string]*sql.DB{}
+		replicated      *storage.ReplicatedDB
+	)
+	switch cfg.Storage.StorageType() {
+	case config.StorageMemory:
+		// данные живут до перезапуска процесса, postgres не нужен
+		store := memory.NewStore()
+		prRepo = memory.NewPRRepo(store, logger)
+		teamRepo = memory.NewTeamRepo(store, logger)
+		userRepo = memory.NewUserRepo(store, logger)
+		idempotencyRepo = memory.NewIdempotencyRepo(store, logger)
+		tokenRepo = memory.NewTokenRepo(store, logger)
+		auditRepo = memory.NewAuditRepo(store, logger)
+		outboxRepo = memory.NewOutboxRepo(store, logger)
+		webhookRepo = memory.NewWebhookRepo(store, logger)
+		txManager = memory.NewTxManager(store)
+	case config.StorageSQLite:
+		var lite *sqlite.Client
+		err = scheduler.Retry(ctx, cfg.Storage.ConnectTimeout, "connect-sqlite", func(context.Context) (err error) {
+			lite, err = sqlite.Connect(string(cfg.Storage.DSN))
+			return err
+		}, logger)
+		if err != nil {
+			logger.Error("connect to sqlite", slog.Any("error", err))
+			os.Exit(1)
+		}
+		defer lite.Close()
+		cfg.Storage.Pool.Apply(lite.DB())
+		pools[poolPrimary] = lite.DB()
+
+		migrator, err = migrate.New(migrate.DialectSQLite, lite.DB(), logger)
+		if err != nil {
+			logger.Error("init migrator", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		checks = []health.Check{{Name: "database", Check: lite.DB().PingContext}}
+		db := storage.NewTracedDB(lite)
+
+		prRepo = sqliterepo.NewPRRepo(db, logger)
+		teamRepo = sqliterepo.NewTeamRepo(db, logger)
+		userRepo = sqliterepo.NewUserRepo(db, logger)
+		idempotencyRepo = sqliterepo.NewIdempotencyRepo(db, logger)
+		tokenRepo = sqliterepo.NewTokenRepo(db, logger)
+		auditRepo = sqliterepo.NewAuditRepo(db, logger)
+		outboxRepo = sqliterepo.NewOutboxRepo(db, logger)
+		webhookRepo = sqliterepo.NewWebhookRepo(db, logger)
+		txManager = storage.NewTxManager(db, logger)
+	case config.StoragePostgres:
+		var pg *postgres.Client
+		err = scheduler.Retry(ctx, cfg.Storage.ConnectTimeout, "connect-postgres", func(context.Context) (err error) {
+			pg, err = postgres.Connect(string(cfg.Storage.DSN))
+			return err
+		}, logger)
+		if err != nil {
+			logger.Error("connect to postgres", slog.Any("error", err))
+			os.Exit(1)
+		}
+		defer pg.Close()
+		cfg.Storage.Pool.Apply(pg.DB())
+		pools[poolPrimary] = pg.DB()
+
+		migrator, err = migrate.New(migrate.DialectPostgres, pg.DB(), logger)
+		if err != nil {
+			logger.Error("init migrator", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		checks = []health.Check{{Name: "database", Check: pg.DB().PingContext}}
+		var db storage.DB = storage.NewTracedDB(pg)
+		if cfg.Storage.Replica.DSN != "" {
+			// реплика не проверяется при старте: пока она недоступна, чтение идёт в primary
+			replica, err := postgres.Open(string(cfg.Storage.Replica.DSN))
+			if err != nil {
+				logger.Error("open postgres replica", slog.Any("error", err))
+				os.Exit(1)
+			}
+			defer replica.Close()
+			cfg.Storage.Pool.Apply(replica.DB())
+			pools[poolReplica] = replica.DB()
+
+			replicated = storage.NewReplicatedDB(db, storage.NewTracedDB(replica), storage.PostgresReplicationLag,
+				cfg.Storage.Replica.MaxStaleness, logger)
+			db = replicated
+		}
+
+		prRepo = storage.NewPRRepo(db, logger)
+		teamRepo = storage.NewTeamRepo(db, logger)
+		userRepo = storage.NewUserRepo(db, logger)
+		idempotencyRepo = storage.NewIdempotencyRepo(db, logger)
+		tokenRepo = storage.NewTokenRepo(db, logger)
+		auditRepo = storage.NewAuditRepo(db, logger)
+		outboxRepo = storage.NewOutboxRepo(db, logger)
+		webhookRepo = storage.NewWebhookRepo(db, logger)
+		rateLimitRepo = storage.NewRateLimitRepo(db, logger)
+		txManager = storage.NewTxManager(db, logger)
+	}
+
+	prService := service.NewPRService(prRepo, userRepo, teamRepo, auditRepo, txManager, cfg.Assignment.ReviewersCount, logger)
+	tokenService := service.NewTokenService(tokenRepo, userRepo, auditRepo, txManager, logger)
+	auditService := service.NewAuditService(auditRepo, logger)
+	webhookService := service.NewWebhookService(webhookRepo, auditRepo, txManager, logger)
+
+	if len(args) > 0 {
+		if err = runCommand(ctx, os.Stdout, args, prService, idempotencyRepo, migrator); err != nil {
+			logger.Error("run command", slog.String("command", args[0]), slog.Any("error", err))
+			os.Exit(1)
+		}
+		return
 	}
-	defer db.Close()
 
-	prRepo := storage.NewPRRepo(db)
-	teamRepo := storage.NewTeamRepo(db)
-	userRepo := storage.NewUserRepo(db)
+	if cfg.Storage.MigrateOnStart && migrator != nil {
+		if err = migrator.Up(ctx); err != nil {
+			logger.Error("migrate on start", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+	if cfg.Auth.BootstrapToken != "" {
+		if err = tokenService.EnsureBootstrapToken(ctx, string(cfg.Auth.BootstrapToken)); err != nil {
+			logger.Error("save bootstrap token", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+	if migrator != nil {
+		checks = append(checks, health.Check{Name: "migrations", Check: migrator.CheckVersion})
+	}
+	checker := health.NewChecker(logger, checks...)
 
-	prService := service.NewPRService(prRepo, userRepo, teamRepo)
-	c := controller.NewApiService(prService)
+	var background sync.WaitGroup
+	if replicated != nil {
+		// CheckReplica сам логирует смену состояния реплики, ошибка каждой проверки не нужна в логе
+		checkReplica := func(ctx context.Context) error {
+			_ = replicated.CheckReplica(ctx)
+			return nil
+		}
+		_ = checkReplica(ctx)
+		background.Go(func() {
+			scheduler.Every(ctx, cfg.Storage.Replica.CheckInterval, "check-replica", checkReplica, logger)
+		})
+	}
+	if interval := cfg.Scheduler.IdempotencyPurgeInterval; interval > 0 {
+		background.Go(func() {
+			scheduler.Every(ctx, interval, "purge-idempotency-keys", func(ctx context.Context) error {
+				_, err := idempotencyRepo.DeleteExpired(ctx)
+				return err
+			}, logger)
+		})
+	}
 
-	teams := r.Group("/teams")
+	if interval := cfg.Outbox.RelayInterval; interval > 0 {
+		var sinks []outbox.Sink
+		if cfg.Outbox.LogSink {
+			sinks = append(sinks, outbox.NewLogSink(logger))
+		}
+		if cfg.Webhooks.DispatchInterval > 0 {
+			sinks = append(sinks, webhook.NewSink(webhookRepo, logger))
+		}
+		// аренда живёт несколько проходов, чтобы relay на другой реплике не перехватил её между ними
+		relay := outbox.NewRelay(outboxRepo, sinks, uint64(cfg.Outbox.BatchSize), cfg.Outbox.MaxAttempts, 3*interval, logger)
+		background.Go(func() {
+			scheduler.Every(ctx, interval, "relay-outbox", func(ctx context.Context) error {
+				_, err := relay.Relay(ctx)
+				return err
+			}, logger)
+		})
+	}
+	if interval := cfg.Webhooks.DispatchInterval; interval > 0 {
+		dispatcher := webhook.NewDispatcher(webhookRepo, cfg.Webhooks.RetryPolicy(), uint64(cfg.Webhooks.BatchSize), cfg.Webhooks.Timeout, cfg.Webhooks.AllowPrivateNetworks, logger)
+		background.Go(func() {
+			scheduler.Every(ctx, interval, "dispatch-webhooks", func(ctx context.Context) error {
+				_, err := dispatcher.Dispatch(ctx)
+				return err
+			}, logger)
+		})
+	}
+	if retention := cfg.Outbox.Retention; retention > 0 {
+		background.Go(func() {
+			scheduler.Every(ctx, time.Hour, "purge-outbox-events", func(ctx context.Context) error {
+				_, err := outboxRepo.DeletePublished(ctx, time.Now().Add(-retention))
+				return err
+			}, logger)
+		})
+	}
+
+	var rateLimits ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Enabled && cfg.RateLimit.Store == config.RateLimitStorePostgres {
+		rateLimits = rateLimitRepo
+		background.Go(func() {
+			scheduler.Every(ctx, rateLimitIdleTTL, "purge-rate-limit-buckets", func(ctx context.Context) error {
+				_, err := rateLimitRepo.DeleteIdle(ctx, time.Now().Add(-rateLimitIdleTTL))
+				return err
+			}, logger)
+		})
+	}
+
+	c := controller.NewApiService(prService, logger)
+	admin := controller.NewAdminService(pools, tokenService, auditService, webhookService, logger)
+
+	registry := prometheus.NewRegistry()
+	metrics.Register(registry)
+	registry.MustRegister(
+		metrics.NewFairnessCollector(prService, cfg.Assignment.FairnessWindow, cfg.Assignment.FairnessThreshold),
+		metrics.NewTeamLoadCollector(prService),
+	)
+	for name, pool := range pools {
+		registry.MustRegister(collectors.NewDBStatsCollector(pool, name))
+	}
+
+	r := gin.New()
+	// без доверенных прокси X-Forwarded-For игнорируется, иначе клиент подставит любой IP и обойдёт лимит
+	if err := r.SetTrustedProxies(cfg.Server.Proxies()); err != nil {
+		logger.Error("set trusted proxies", slog.Any("error", err))
+		os.Exit(1)
+	}
+	r.Use(gin.Recovery(), metrics.GinMiddleware(), tracing.GinMiddleware(), logging.GinMiddleware(logger))
+
+	// при выключенной аутентификации API открыто, запросы выполняются от имени сервиса и не помечаются токеном
+	authenticate := auth.System()
+	require := func(domain.Scope) gin.HandlerFunc { return func(ctx *gin.Context) { ctx.Next() } }
+	if cfg.Auth.Enabled {
+		authenticate = auth.GinMiddleware(tokenService, newJWTVerifier(ctx, cfg.Auth.JWT, logger), logger)
+		require = auth.Require
+	} else {
+		logger.Warn("authentication is disabled, API is open to any client")
+	}
+	limit := func(string) gin.HandlerFunc { return func(ctx *gin.Context) { ctx.Next() } }
+	perIP := limit("")
+	if cfg.RateLimit.Enabled {
+		limits := cfg.RateLimit.Limits()
+		limit = func(group string) gin.HandlerFunc {
+			return ratelimit.GinMiddleware(rateLimits, group, limits[group], logger)
+		}
+		perIP = ratelimit.IPMiddleware(rateLimits, config.RouteGroupIP, limits[config.RouteGroupIP], logger)
+	}
+
+	idempotent := idempotency.GinMiddleware(idempotencyRepo, cfg.Idempotency.TTL, logger)
+	teams := r.Group("/teams", perIP, authenticate, limit(config.RouteGroupTeams))
 	{
-		teams.POST("add", c.AddTeamHandler)
+		teams.POST("add", require(domain.ScopeTeamsWrite), idempotent, c.AddTeamHandler)
 		teams.GET("get", c.GetTeamHandler)
-		teams.PATCH("deactivate", c.DeactivateTeamHandler)
+		teams.PATCH("deactivate", require(domain.ScopeTeamsWrite), idempotent, c.DeactivateTeamHandler)
 	}
-	users := r.Group("/users")
+	users := r.Group("/users", perIP, authenticate, limit(config.RouteGroupUsers))
 	{
-		users.POST("setIsActive", c.SetIsActiveUserHandler)
+		// свой флаг меняет любой участник, поэтому кому можно вызывать setIsActive, решает роль в PRService
+		users.POST("setIsActive", idempotent, c.SetIsActiveUserHandler)
+		users.POST("setRole", require(domain.ScopeTeamsWrite), idempotent, c.SetUserRoleHandler)
 		users.GET("getReview", c.GetReviewerUserHandler)
-		users.GET("getStats", c.GetStatsHandler)
+		users.GET("getStats", require(domain.ScopeStatsRead), c.GetStatsHandler)
+	}
+	stats := r.Group("/stats", perIP, authenticate, limit(config.RouteGroupStats), require(domain.ScopeStatsRead))
+	{
+		stats.GET("getReviews", c.GetReviewStatsHandler)
+		stats.GET("getTurnaround", c.GetTurnaroundHandler)
+		stats.GET("getFairness", c.GetFairnessHandler)
 	}
-	pullRequests := r.Group("/pullRequests")
+	pullRequests := r.Group("/pullRequests", perIP, authenticate, limit(config.RouteGroupPullRequests), require(domain.ScopePRsWrite))
 	{
-		pullRequests.POST("create", c.CreatePullRequestHandler)
-		pullRequests.POST("merge", c.MergePullRequestHandler)
-		pullRequests.POST("reassign", c.ReassignPullRequestHandler)
+		pullRequests.POST("create", idempotent, c.CreatePullRequestHandler)
+		pullRequests.POST("approve", idempotent, c.ApprovePullRequestHandler)
+		pullRequests.POST("merge", idempotent, c.MergePullRequestHandler)
+		pullRequests.POST("reassign", idempotent, c.ReassignPullRequestHandler)
 	}
 
+	adminGroup := r.Group("/admin", perIP, authenticate, limit(config.RouteGroupAdmin), require(domain.ScopeAdmin))
+	{
+		adminGroup.GET("getPoolStats", admin.GetPoolStatsHandler)
+		adminGroup.POST("issueToken", admin.IssueTokenHandler)
+		adminGroup.GET("listTokens", admin.ListTokensHandler)
+		adminGroup.POST("revokeToken", admin.RevokeTokenHandler)
+		adminGroup.GET("getAuditLog", admin.GetAuditLogHandler)
+		adminGroup.POST("createWebhook", admin.CreateWebhookHandler)
+		adminGroup.GET("listWebhooks", admin.ListWebhooksHandler)
+		adminGroup.POST("deleteWebhook", admin.DeleteWebhookHandler)
+		adminGroup.GET("listWebhookDeliveries", admin.ListWebhookDeliveriesHandler)
+		adminGroup.GET("getWebhookDelivery", admin.GetWebhookDeliveryHandler)
+		adminGroup.POST("replayWebhookDelivery", admin.ReplayWebhookDeliveryHandler)
+	}
+
+	r.GET("/healthz", checker.LivenessHandler)
+	r.GET("/readyz", checker.ReadinessHandler)
+	r.GET("/metrics", gin.WrapH(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	r.Run(":8080")
+	server := &http.Server{
+		Addr:              cfg.Server.Addr(),
+		Handler:           r,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("http server started", slog.String("addr", server.Addr), slog.String("storage", cfg.Storage.StorageType()))
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serverErr:
+		logger.Error("run http server", slog.Any("error", err))
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down", slog.Duration("timeout", cfg.Server.ShutdownTimeout))
+	checker.Drain()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("shutdown http server", slog.Any("error", err))
+	}
+	background.Wait()
+	logger.Info("http server stopped")
+}
+
+// newJWTVerifier возвращает проверку JWT по JWKS из конфигурации; nil, если JWKS не задан.
+// Недоступность JWKS при старте не мешает запуску: ключи загрузятся при первом запросе с JWT.
+func newJWTVerifier(ctx context.Context, cfg config.JWT, logger *slog.Logger) *auth.JWTVerifier {
+	if cfg.JWKS == "" {
+		return nil
+	}
+
+	keys := auth.NewKeySet(cfg.JWKS, cfg.RefreshInterval, logger)
+	_ = keys.Refresh(ctx)
+
+	return auth.NewJWTVerifier(keys, auth.JWTConfig{
+		Issuer:        cfg.Issuer,
+		Audience:      cfg.Audience,
+		UserClaim:     cfg.UserClaim,
+		RoleClaim:     cfg.RoleClaim,
+		ScopeClaim:    cfg.ScopeClaim,
+		DefaultScopes: cfg.Scopes(),
+		Leeway:        cfg.Leeway,
+	})
 }
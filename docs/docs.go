@@ -15,8 +15,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/pullRequests/create": {
+        "/admin/createWebhook": {
             "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "events: pr.created, pr.reviewer_assigned, pr.reviewer_removed, pr.approved, pr.merged, user.deactivated, team.deactivated; пустой список - все события.\nКаждая доставка - POST с событием в теле и подписью X-Webhook-Signature: sha256=HMAC-SHA256(secret, \"\u003cX-Webhook-Timestamp\u003e.\u003cтело\u003e\").",
                 "consumes": [
                     "application/json"
                 ],
@@ -24,25 +30,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "PullRequests"
+                    "Admin"
                 ],
-                "summary": "Создать PR и автоматически назначить до 2 ревьюверов из команды автора",
+                "summary": "Подписать URL на доменные события",
                 "parameters": [
                     {
-                        "description": "pull_request",
+                        "description": "webhook",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.CreatePullRequestRequest"
+                            "$ref": "#/definitions/model.CreateWebhookRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.CreatePullRequestResponse"
+                            "$ref": "#/definitions/model.CreateWebhookResponse"
                         }
                     },
                     "400": {
@@ -51,8 +57,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -66,8 +78,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/pullRequests/merge": {
+        "/admin/deleteWebhook": {
             "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Вместе с подпиской удаляются её доставки, включая неотправленные.",
                 "consumes": [
                     "application/json"
                 ],
@@ -75,26 +93,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "PullRequests"
+                    "Admin"
                 ],
-                "summary": "Пометить PR как MERGED (идемпотентная операция)",
+                "summary": "Удалить подписку на события",
                 "parameters": [
                     {
-                        "description": "pull_request_id",
+                        "description": "webhook",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.MergePullRequestRequest"
+                            "$ref": "#/definitions/model.DeleteWebhookRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/model.MergePullRequestResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -102,6 +117,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -117,34 +144,88 @@ const docTemplate = `{
                 }
             }
         },
-        "/pullRequests/reassign": {
-            "post": {
-                "consumes": [
-                    "application/json"
+        "/admin/getAuditLog": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Записи отдаются от новых к старым; пустые фильтры не применяются. Период [from, to) задаётся в RFC3339.\naction: team.save | team.deactivate | user.set_is_active | user.set_role | pull_request.create | pull_request.approve | pull_request.merge | pull_request.reassign | token.issue | token.revoke | webhook.create | webhook.delete.\nentity_type: team | user | pull_request | token | webhook. Для следующей страницы передайте next_cursor из ответа в cursor.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "PullRequests"
+                    "Admin"
                 ],
-                "summary": "Переназначить конкретного ревьювера на другого из его команды",
+                "summary": "Получить журнал аудита",
                 "parameters": [
                     {
-                        "description": "pull_request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.ReassignPullRequestRequest"
-                        }
+                        "type": "string",
+                        "description": "ID токена автора изменения",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "пользователь, от имени которого действовал автор",
+                        "name": "actor_user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "action",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "entity_type",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "entity_id",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Request-ID запроса",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "конец периода, не включительно (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.ReassignPullRequestResponse"
+                            "$ref": "#/definitions/model.GetAuditLogResponse"
                         }
                     },
                     "400": {
@@ -153,8 +234,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -168,34 +255,60 @@ const docTemplate = `{
                 }
             }
         },
-        "/teams/add": {
-            "post": {
-                "consumes": [
+        "/admin/getPoolStats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "in_use и idle - текущие соединения, wait_count и wait_duration_ms - сколько раз и как долго запросы ждали свободного соединения с момента запуска.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Получить статистику пулов соединений с БД",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetPoolStatsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/getWebhookDelivery": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "attempts - все попытки доставки по порядку, включая попытки до повторов через replayWebhookDelivery.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Teams"
+                    "Admin"
                 ],
-                "summary": "Создать команду с участниками (создаёт/обновляет пользователей)",
+                "summary": "Получить доставку и её попытки",
                 "parameters": [
                     {
-                        "description": "team",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.AddTeamRequest"
-                        }
+                        "type": "integer",
+                        "description": "ID доставки",
+                        "name": "delivery_id",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Team"
+                            "$ref": "#/definitions/model.GetWebhookDeliveryResponse"
                         }
                     },
                     "400": {
@@ -204,6 +317,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -219,8 +344,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/teams/deactivate": {
-            "patch": {
+        "/admin/issueToken": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "scopes: teams:write, prs:write, stats:read, admin. Секрет возвращается один раз, сервис хранит только его хеш.\nС user_id токен действует от имени пользователя, и его действия ограничены ролью пользователя.",
                 "consumes": [
                     "application/json"
                 ],
@@ -228,23 +359,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Teams"
+                    "Admin"
                 ],
-                "summary": "Деактивировать команду",
+                "summary": "Выпустить API токен",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "team_name",
-                        "name": "team_name",
-                        "in": "query",
-                        "required": true
+                        "description": "token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.IssueTokenRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.DeactivateTeamResponse"
+                            "$ref": "#/definitions/model.IssueTokenResponse"
                         }
                     },
                     "400": {
@@ -253,6 +386,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -268,42 +413,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/teams/get": {
+        "/admin/listTokens": {
             "get": {
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Teams"
-                ],
-                "summary": "Получить команду с участниками",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "team_name",
-                        "name": "team_name",
-                        "in": "query",
-                        "required": true
-                    }
+                    "Admin"
                 ],
+                "summary": "Получить список API токенов, включая отозванные",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Team"
+                            "$ref": "#/definitions/model.ListTokensResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -317,32 +455,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/getReview": {
+        "/admin/listWebhookDeliveries": {
             "get": {
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Доставки отдаются от новых к старым. status: pending - ждёт попытки, delivered - доставлена, dead - попытки исчерпаны.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Получить PR'ы, где пользователь назначен ревьювером",
+                "summary": "Получить доставки событий подписчикам",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user_id",
-                        "name": "user_id",
-                        "in": "query",
-                        "required": true
+                        "description": "ID подписки",
+                        "name": "webhook_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.GetReviewUserResponse"
+                            "$ref": "#/definitions/model.ListWebhookDeliveriesResponse"
                         }
                     },
                     "400": {
@@ -351,8 +503,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -366,43 +524,36 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/getStats": {
+        "/admin/listWebhooks": {
             "get": {
-                "description": "limit = 0 - убирает лимит",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Секреты подписок не возвращаются.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
-                ],
-                "summary": "Получить cтатистику пользователей по PR",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
-                    }
+                    "Admin"
                 ],
+                "summary": "Получить список подписок на события",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.GetStatsResponse"
+                            "$ref": "#/definitions/model.ListWebhooksResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -416,8 +567,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/setIsActive": {
+        "/admin/replayWebhookDelivery": {
             "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Доставка возвращается в статус pending с новым счётчиком попыток и отправляется при ближайшем проходе.\nПовторить можно только доставку в статусе dead.",
                 "consumes": [
                     "application/json"
                 ],
@@ -425,17 +582,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Установить флаг активности пользователя",
+                "summary": "Повторить доставку из dead letter",
                 "parameters": [
                     {
-                        "description": "user",
+                        "description": "delivery",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.SetIsActiveUserRequest"
+                            "$ref": "#/definitions/model.ReplayWebhookDeliveryRequest"
                         }
                     }
                 ],
@@ -443,7 +600,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.SetIsActiveUserResponse"
+                            "$ref": "#/definitions/model.ReplayWebhookDeliveryResponse"
                         }
                     },
                     "400": {
@@ -452,12 +609,30 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -466,13 +641,1214 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.AddTeamRequest": {
-            "type": "object",
-            "required": [
-                "members",
+        },
+        "/admin/revokeToken": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отозванный токен сразу перестаёт проходить аутентификацию. Повторный отзыв не является ошибкой.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Отозвать API токен",
+                "parameters": [
+                    {
+                        "description": "token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RevokeTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Liveness проба: процесс запущен и обслуживает HTTP",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pullRequests/approve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Одобрить PR может только сам ревьюер или admin. От первого одобрения считается time_to_first_review в stats/getTurnaround.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PullRequests"
+                ],
+                "summary": "Одобрить PR назначенным ревьюером",
+                "parameters": [
+                    {
+                        "description": "pull_request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ApprovePullRequestRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ключ идемпотентности: повтор с тем же ключом вернёт первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ApprovePullRequestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pullRequests/create": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PullRequests"
+                ],
+                "summary": "Создать PR и автоматически назначить до 2 ревьюверов из команды автора",
+                "parameters": [
+                    {
+                        "description": "pull_request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatePullRequestRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ключ идемпотентности: повтор с тем же ключом вернёт первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatePullRequestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pullRequests/merge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Мержить может автор PR, лид его команды или admin.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PullRequests"
+                ],
+                "summary": "Пометить PR как MERGED (идемпотентная операция)",
+                "parameters": [
+                    {
+                        "description": "pull_request_id",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MergePullRequestRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ключ идемпотентности: повтор с тем же ключом вернёт первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag PR (его версия), изменение выполнится только для этой версии",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MergePullRequestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.VersionConflictResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pullRequests/reassign": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PullRequests"
+                ],
+                "summary": "Переназначить конкретного ревьювера на другого из его команды",
+                "parameters": [
+                    {
+                        "description": "pull_request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ReassignPullRequestRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag PR (его версия), изменение выполнится только для этой версии",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ключ идемпотентности: повтор с тем же ключом вернёт первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ReassignPullRequestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness проба: БД доступна и схема БД на версии, которую ожидает сервис",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/getFairness": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Доля назначений каждого участника за период сравнивается с равной долей в команде.\nУчастник помечается overloaded/underused, если отклонение больше threshold (по умолчанию 0.5 = ±50%).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stats"
+                ],
+                "summary": "Получить отчёт о равномерности распределения ревью в командах",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "конец периода, не включительно (RFC3339)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "team_name",
+                        "name": "team_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "threshold",
+                        "name": "threshold",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetFairnessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/getReviews": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "group_by: user | team, sort_by: key | assigned | merged | reassigned | median_time_to_merge, order: asc | desc.\nДля следующей страницы передайте next_cursor из ответа в cursor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stats"
+                ],
+                "summary": "Получить статистику ревью за период с группировкой по пользователю или команде",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "конец периода, не включительно (RFC3339)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "group_by",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sort_by",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetReviewStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/getTurnaround": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Считается по PR, созданным в период [from, to). group_by: team (команда автора) | reviewer | week.\nformat=csv отдаёт ту же таблицу в CSV.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Stats"
+                ],
+                "summary": "Получить перцентили времени до первого ревью (одобрения) и до мержа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "конец периода, не включительно (RFC3339)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "group_by",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json | csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetTurnaroundResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/add": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Создать команду с участниками (создаёт/обновляет пользователей)",
+                "parameters": [
+                    {
+                        "description": "team",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AddTeamRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ключ идемпотентности: повтор с тем же ключом вернёт первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Team"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/deactivate": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Деактивировать команду",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "team_name",
+                        "name": "team_name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ключ идемпотентности: повтор с тем же ключом вернёт первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.DeactivateTeamResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/get": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Получить команду с участниками",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "team_name",
+                        "name": "team_name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Team"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/getReview": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Получить PR'ы, где пользователь назначен ревьювером",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user_id",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetReviewUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/getStats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "limit = 0 - убирает лимит",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Получить cтатистику пользователей по PR",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/setIsActive": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "member меняет только свой флаг, team_lead - флаги участников своей команды, admin - любые.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Установить флаг активности пользователя",
+                "parameters": [
+                    {
+                        "description": "user",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.SetIsActiveUserRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ключ идемпотентности: повтор с тем же ключом вернёт первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SetIsActiveUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/setRole": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "role: admin, team_lead, member. Менять роли может только admin.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Назначить пользователю роль",
+                "parameters": [
+                    {
+                        "description": "role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.SetUserRoleRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ключ идемпотентности: повтор с тем же ключом вернёт первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SetUserRoleResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "model.APIToken": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "ci-pipeline"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "prs:write",
+                        "stats:read"
+                    ]
+                },
+                "token_id": {
+                    "type": "string",
+                    "example": "3f9a1c0b7d2e4a58"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "u1"
+                }
+            }
+        },
+        "model.AddTeamRequest": {
+            "type": "object",
+            "required": [
+                "members",
                 "team_name"
             ],
             "properties": {
@@ -488,94 +1864,465 @@ const docTemplate = `{
                 }
             }
         },
-        "model.CreatePullRequestRequest": {
+        "model.ApprovePullRequestRequest": {
+            "type": "object",
+            "required": [
+                "pull_request_id",
+                "reviewer_id"
+            ],
+            "properties": {
+                "pull_request_id": {
+                    "type": "string",
+                    "example": "pr-1001"
+                },
+                "reviewer_id": {
+                    "type": "string",
+                    "example": "u2"
+                }
+            }
+        },
+        "model.ApprovePullRequestResponse": {
+            "type": "object",
+            "properties": {
+                "pr": {
+                    "$ref": "#/definitions/model.PullRequest"
+                }
+            }
+        },
+        "model.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "team.deactivate"
+                },
+                "actor_id": {
+                    "type": "string",
+                    "example": "3f9a1c0b7d2e4a58"
+                },
+                "actor_user_id": {
+                    "type": "string",
+                    "example": "u1"
+                },
+                "after": {
+                    "description": "After - состояние сущности после изменения",
+                    "type": "object"
+                },
+                "before": {
+                    "description": "Before - состояние сущности до изменения, null у созданных сущностей",
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "entity_id": {
+                    "type": "string",
+                    "example": "payments"
+                },
+                "entity_type": {
+                    "type": "string",
+                    "example": "team"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "9b2f6c1e0a7d4e3b"
+                }
+            }
+        },
+        "model.CreatePullRequestRequest": {
+            "type": "object",
+            "required": [
+                "pull_request_id",
+                "pull_request_name"
+            ],
+            "properties": {
+                "author_id": {
+                    "type": "string",
+                    "example": "u1"
+                },
+                "pull_request_id": {
+                    "type": "string",
+                    "example": "pr-1001"
+                },
+                "pull_request_name": {
+                    "type": "string",
+                    "example": "Add search"
+                }
+            }
+        },
+        "model.CreatePullRequestResponse": {
+            "type": "object",
+            "properties": {
+                "pr": {
+                    "$ref": "#/definitions/model.PullRequest"
+                }
+            }
+        },
+        "model.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "secret",
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "pr.reviewer_assigned",
+                        "pr.merged"
+                    ]
+                },
+                "secret": {
+                    "description": "Secret - ключ HMAC-SHA256 подписи доставок, не короче 16 символов; в ответах не возвращается",
+                    "type": "string",
+                    "example": "whsec-4f1c9a7e2b6d"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://chat.example.com/hooks/reviews"
+                }
+            }
+        },
+        "model.CreateWebhookResponse": {
+            "type": "object",
+            "properties": {
+                "webhook": {
+                    "$ref": "#/definitions/model.Webhook"
+                }
+            }
+        },
+        "model.DeactivateTeamResponse": {
+            "type": "object",
+            "properties": {
+                "pull_requests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PullRequest"
+                    }
+                }
+            }
+        },
+        "model.DeleteWebhookRequest": {
+            "type": "object",
+            "required": [
+                "webhook_id"
+            ],
+            "properties": {
+                "webhook_id": {
+                    "type": "string",
+                    "example": "7c41d0e9a3b25f68"
+                }
+            }
+        },
+        "model.ErrorDetail": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "NOT_FOUND"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "resource not found"
+                }
+            }
+        },
+        "model.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/model.ErrorDetail"
+                }
+            }
+        },
+        "model.GetAuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AuditEntry"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor - cursor следующей страницы, пустой на последней странице",
+                    "type": "string"
+                }
+            }
+        },
+        "model.GetFairnessResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "teams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TeamFairness"
+                    }
+                },
+                "threshold": {
+                    "type": "number",
+                    "example": 0.5
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.GetPoolStatsResponse": {
+            "type": "object",
+            "properties": {
+                "pools": {
+                    "description": "Pools - статистика по каждому пулу соединений, ключ - имя пула (primary)",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/model.PoolStats"
+                    }
+                }
+            }
+        },
+        "model.GetReviewStatsResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "group_by": {
+                    "type": "string",
+                    "example": "team"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "stats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.GroupStat"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.GetReviewUserResponse": {
+            "type": "object",
+            "properties": {
+                "pull_requests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PullRequestShort"
+                    }
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "u2"
+                }
+            }
+        },
+        "model.GetStatsResponse": {
+            "type": "object",
+            "properties": {
+                "user_review_stats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.UserStat"
+                    }
+                }
+            }
+        },
+        "model.GetTurnaroundResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "group_by": {
+                    "type": "string",
+                    "example": "team"
+                },
+                "stats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TurnaroundStat"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.GetWebhookDeliveryResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.WebhookAttempt"
+                    }
+                },
+                "delivery": {
+                    "$ref": "#/definitions/model.WebhookDelivery"
+                },
+                "payload": {
+                    "description": "Payload - тело запроса к подписчику",
+                    "type": "object"
+                }
+            }
+        },
+        "model.GroupStat": {
             "type": "object",
-            "required": [
-                "author_id",
-                "pull_request_id",
-                "pull_request_name"
-            ],
             "properties": {
-                "author_id": {
+                "key": {
                     "type": "string",
-                    "example": "u1"
+                    "example": "payments"
                 },
-                "pull_request_id": {
-                    "type": "string",
-                    "example": "pr-1001"
+                "median_time_to_merge_seconds": {
+                    "type": "number",
+                    "example": 5400
                 },
-                "pull_request_name": {
-                    "type": "string",
-                    "example": "Add search"
+                "reassigned_away": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "reviews_assigned": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "reviews_merged": {
+                    "type": "integer",
+                    "example": 9
                 }
             }
         },
-        "model.CreatePullRequestResponse": {
+        "model.HealthResponse": {
             "type": "object",
             "properties": {
-                "pr": {
-                    "$ref": "#/definitions/model.PullRequest"
+                "checks": {
+                    "description": "Checks - результат каждой проверки готовности: ok или текст ошибки",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "description": "Status - ok или unavailable",
+                    "type": "string"
                 }
             }
         },
-        "model.DeactivateTeamResponse": {
+        "model.IssueTokenRequest": {
             "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
             "properties": {
-                "pull_requests": {
+                "name": {
+                    "type": "string",
+                    "example": "ci-pipeline"
+                },
+                "scopes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/model.PullRequest"
-                    }
+                        "type": "string"
+                    },
+                    "example": [
+                        "prs:write",
+                        "stats:read"
+                    ]
+                },
+                "user_id": {
+                    "description": "UserID - пользователь, от имени которого действует токен; без него токен сервисный и роли к нему не применяются",
+                    "type": "string",
+                    "example": "u1"
                 }
             }
         },
-        "model.ErrorDetail": {
+        "model.IssueTokenResponse": {
             "type": "object",
             "properties": {
-                "code": {
+                "secret": {
+                    "description": "Secret - значение для заголовка Authorization: Bearer, показывается только при выпуске",
                     "type": "string",
-                    "example": "NOT_FOUND"
+                    "example": "prs_9c1d..."
                 },
-                "message": {
-                    "type": "string",
-                    "example": "resource not found"
+                "token": {
+                    "$ref": "#/definitions/model.APIToken"
                 }
             }
         },
-        "model.ErrorResponse": {
+        "model.ListTokensResponse": {
             "type": "object",
             "properties": {
-                "error": {
-                    "$ref": "#/definitions/model.ErrorDetail"
+                "tokens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.APIToken"
+                    }
                 }
             }
         },
-        "model.GetReviewUserResponse": {
+        "model.ListWebhookDeliveriesResponse": {
             "type": "object",
             "properties": {
-                "pull_requests": {
+                "deliveries": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/model.PullRequestShort"
+                        "$ref": "#/definitions/model.WebhookDelivery"
                     }
-                },
-                "user_id": {
-                    "type": "string",
-                    "example": "u2"
                 }
             }
         },
-        "model.GetStatsResponse": {
+        "model.ListWebhooksResponse": {
             "type": "object",
             "properties": {
-                "user_review_stats": {
+                "webhooks": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/model.UserStat"
+                        "$ref": "#/definitions/model.Webhook"
                     }
                 }
             }
         },
+        "model.MemberWorkload": {
+            "type": "object",
+            "properties": {
+                "assigned": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "deviation": {
+                    "type": "number",
+                    "example": 0.8
+                },
+                "expected_share": {
+                    "type": "number",
+                    "example": 0.333
+                },
+                "share": {
+                    "type": "number",
+                    "example": 0.6
+                },
+                "status": {
+                    "type": "string",
+                    "example": "overloaded"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "u2"
+                }
+            }
+        },
         "model.MergePullRequestRequest": {
             "type": "object",
             "required": [
@@ -596,6 +2343,64 @@ const docTemplate = `{
                 }
             }
         },
+        "model.Percentiles": {
+            "type": "object",
+            "properties": {
+                "p50_seconds": {
+                    "type": "number",
+                    "example": 60
+                },
+                "p90_seconds": {
+                    "type": "number",
+                    "example": 3600
+                },
+                "p99_seconds": {
+                    "type": "number",
+                    "example": 86400
+                }
+            }
+        },
+        "model.PoolStats": {
+            "type": "object",
+            "properties": {
+                "idle": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "in_use": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "max_idle_closed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "max_idle_time_closed": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "max_lifetime_closed": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "max_open_connections": {
+                    "type": "integer",
+                    "example": 25
+                },
+                "open_connections": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "wait_count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "wait_duration_ms": {
+                    "type": "integer",
+                    "example": 37
+                }
+            }
+        },
         "model.PullRequest": {
             "type": "object",
             "properties": {
@@ -609,6 +2414,9 @@ const docTemplate = `{
                     "type": "string",
                     "example": "u1"
                 },
+                "createdAt": {
+                    "type": "string"
+                },
                 "mergedAt": {
                     "type": "string"
                 },
@@ -623,6 +2431,10 @@ const docTemplate = `{
                 "status": {
                     "type": "string",
                     "example": "OPEN"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
@@ -676,6 +2488,38 @@ const docTemplate = `{
                 }
             }
         },
+        "model.ReplayWebhookDeliveryRequest": {
+            "type": "object",
+            "required": [
+                "delivery_id"
+            ],
+            "properties": {
+                "delivery_id": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "model.ReplayWebhookDeliveryResponse": {
+            "type": "object",
+            "properties": {
+                "delivery": {
+                    "$ref": "#/definitions/model.WebhookDelivery"
+                }
+            }
+        },
+        "model.RevokeTokenRequest": {
+            "type": "object",
+            "required": [
+                "token_id"
+            ],
+            "properties": {
+                "token_id": {
+                    "type": "string",
+                    "example": "3f9a1c0b7d2e4a58"
+                }
+            }
+        },
         "model.SetIsActiveUserRequest": {
             "type": "object",
             "required": [
@@ -701,6 +2545,31 @@ const docTemplate = `{
                 }
             }
         },
+        "model.SetUserRoleRequest": {
+            "type": "object",
+            "required": [
+                "role",
+                "user_id"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "example": "team_lead"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "u2"
+                }
+            }
+        },
+        "model.SetUserRoleResponse": {
+            "type": "object",
+            "properties": {
+                "user": {
+                    "$ref": "#/definitions/model.User"
+                }
+            }
+        },
         "model.Team": {
             "type": "object",
             "properties": {
@@ -716,6 +2585,29 @@ const docTemplate = `{
                 }
             }
         },
+        "model.TeamFairness": {
+            "type": "object",
+            "properties": {
+                "max_deviation": {
+                    "type": "number",
+                    "example": 0.8
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MemberWorkload"
+                    }
+                },
+                "team_name": {
+                    "type": "string",
+                    "example": "payments"
+                },
+                "total_assigned": {
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
         "model.TeamMember": {
             "type": "object",
             "required": [
@@ -738,6 +2630,29 @@ const docTemplate = `{
                 }
             }
         },
+        "model.TurnaroundStat": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string",
+                    "example": "payments"
+                },
+                "merged_pull_requests": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "pull_requests": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "time_to_first_review": {
+                    "$ref": "#/definitions/model.Percentiles"
+                },
+                "time_to_merge": {
+                    "$ref": "#/definitions/model.Percentiles"
+                }
+            }
+        },
         "model.User": {
             "type": "object",
             "properties": {
@@ -745,6 +2660,10 @@ const docTemplate = `{
                     "type": "boolean",
                     "example": false
                 },
+                "role": {
+                    "type": "string",
+                    "example": "member"
+                },
                 "team_name": {
                     "type": "string",
                     "example": "backend"
@@ -782,6 +2701,120 @@ const docTemplate = `{
                     "example": "u2"
                 }
             }
+        },
+        "model.VersionConflictResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/model.ErrorDetail"
+                },
+                "pr": {
+                    "$ref": "#/definitions/model.PullRequest"
+                }
+            }
+        },
+        "model.Webhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "description": "Events - типы событий подписки; пустой список - все события",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "pr.reviewer_assigned",
+                        "pr.merged"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://chat.example.com/hooks/reviews"
+                },
+                "webhook_id": {
+                    "type": "string",
+                    "example": "7c41d0e9a3b25f68"
+                }
+            }
+        },
+        "model.WebhookAttempt": {
+            "type": "object",
+            "properties": {
+                "attempted_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "error": {
+                    "type": "string",
+                    "example": "unexpected status 503"
+                },
+                "status_code": {
+                    "description": "StatusCode - HTTP статус ответа подписчика, 0 - ответа не было",
+                    "type": "integer",
+                    "example": 503
+                }
+            }
+        },
+        "model.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "delivery_id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "event_id": {
+                    "type": "integer",
+                    "example": 1001
+                },
+                "event_type": {
+                    "type": "string",
+                    "example": "pr.merged"
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "unexpected status 503"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "delivered",
+                        "dead"
+                    ],
+                    "example": "dead"
+                },
+                "webhook_id": {
+                    "type": "string",
+                    "example": "7c41d0e9a3b25f68"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "Bearer \u003ctoken\u003e, если включена аутентификация (AUTH_ENABLED)",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"avito-tech-go-task/internal/infrastructure/logging"
+	"context"
+	"log/slog"
+)
+
+// AuditService отдаёт журнал аудита. Записи в журнал добавляют PRService, TokenService и WebhookService
+// в транзакциях изменений.
+type AuditService struct {
+	auditRepo AuditRepository
+	logger    *slog.Logger
+}
+
+func NewAuditService(auditRepo AuditRepository, logger *slog.Logger) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		logger:    logger,
+	}
+}
+
+func (s *AuditService) Query(ctx context.Context, q domain.AuditQuery) (domain.AuditPage, error) {
+	ctx, span := tracer.Start(ctx, "AuditService.Query")
+	defer span.End()
+
+	entries, err := s.auditRepo.Query(ctx, q)
+	if err != nil {
+		return domain.AuditPage{}, err
+	}
+
+	return domain.NewAuditPage(entries, q), nil
+}
+
+// audit записывает изменение в журнал аудита от имени автора запроса.
+// Вызывается внутри s.tx.Do, чтобы запись сохранилась или откатилась вместе с изменением.
+func (s *PRService) audit(ctx context.Context, action domain.AuditAction, entityType domain.AuditEntity, entityID string, before, after any) error {
+	return appendAudit(ctx, s.auditRepo, action, entityType, entityID, before, after)
+}
+
+// appendAudit добавляет в auditRepo запись об изменении с автором и request ID из ctx.
+func appendAudit(ctx context.Context, auditRepo AuditRepository, action domain.AuditAction, entityType domain.AuditEntity, entityID string, before, after any) error {
+	entry, err := domain.NewAuditEntry(action, entityType, entityID, before, after)
+	if err != nil {
+		return err
+	}
+	if actor, ok := domain.ActorFromContext(ctx); ok {
+		entry.ActorID = actor.TokenID
+		entry.ActorUserID = actor.UserID
+	}
+	entry.RequestID = logging.RequestIDFromContext(ctx)
+
+	return auditRepo.Append(ctx, entry)
+}
+
+// teamState - состав команды в журнале аудита; nil, если команды ещё нет.
+func teamState(members []domain.User) any {
+	if len(members) == 0 {
+		return nil
+	}
+
+	state := make([]model.User, 0, len(members))
+	for _, member := range members {
+		state = append(state, member.ToJSON())
+	}
+	return state
+}
@@ -7,11 +7,64 @@ package mock_service
 import (
 	domain "avito-tech-go-task/internal/domain"
 	context "context"
+	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
 
+// MockTxManager is a mock of TxManager interface.
+type MockTxManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxManagerMockRecorder
+}
+
+// MockTxManagerMockRecorder is the mock recorder for MockTxManager.
+type MockTxManagerMockRecorder struct {
+	mock *MockTxManager
+}
+
+// NewMockTxManager creates a new mock instance.
+func NewMockTxManager(ctrl *gomock.Controller) *MockTxManager {
+	mock := &MockTxManager{ctrl: ctrl}
+	mock.recorder = &MockTxManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTxManager) EXPECT() *MockTxManagerMockRecorder {
+	return m.recorder
+}
+
+// Do mocks base method.
+func (m *MockTxManager) Do(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockTxManagerMockRecorder) Do(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockTxManager)(nil).Do), ctx, fn)
+}
+
+// DoWith mocks base method.
+func (m *MockTxManager) DoWith(ctx context.Context, opts *sql.TxOptions, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoWith", ctx, opts, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoWith indicates an expected call of DoWith.
+func (mr *MockTxManagerMockRecorder) DoWith(ctx, opts, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoWith", reflect.TypeOf((*MockTxManager)(nil).DoWith), ctx, opts, fn)
+}
+
 // MockTeamRepository is a mock of TeamRepository interface.
 type MockTeamRepository struct {
 	ctrl     *gomock.Controller
@@ -35,14 +88,28 @@ func (m *MockTeamRepository) EXPECT() *MockTeamRepositoryMockRecorder {
 	return m.recorder
 }
 
+// DeactivateTeam mocks base method.
+func (m *MockTeamRepository) DeactivateTeam(ctx context.Context, teamName string) ([]domain.PullRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivateTeam", ctx, teamName)
+	ret0, _ := ret[0].([]domain.PullRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeactivateTeam indicates an expected call of DeactivateTeam.
+func (mr *MockTeamRepositoryMockRecorder) DeactivateTeam(ctx, teamName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateTeam", reflect.TypeOf((*MockTeamRepository)(nil).DeactivateTeam), ctx, teamName)
+}
+
 // FindByName mocks base method.
-func (m *MockTeamRepository) FindByName(ctx context.Context, teamName string) (*domain.Team, []*domain.User, error) {
+func (m *MockTeamRepository) FindByName(ctx context.Context, teamName string) ([]domain.User, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindByName", ctx, teamName)
-	ret0, _ := ret[0].(*domain.Team)
-	ret1, _ := ret[1].([]*domain.User)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret0, _ := ret[0].([]domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // FindByName indicates an expected call of FindByName.
@@ -51,8 +118,23 @@ func (mr *MockTeamRepositoryMockRecorder) FindByName(ctx, teamName interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByName", reflect.TypeOf((*MockTeamRepository)(nil).FindByName), ctx, teamName)
 }
 
+// GetTeamLoad mocks base method.
+func (m *MockTeamRepository) GetTeamLoad(ctx context.Context) ([]domain.TeamLoad, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTeamLoad", ctx)
+	ret0, _ := ret[0].([]domain.TeamLoad)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTeamLoad indicates an expected call of GetTeamLoad.
+func (mr *MockTeamRepositoryMockRecorder) GetTeamLoad(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTeamLoad", reflect.TypeOf((*MockTeamRepository)(nil).GetTeamLoad), ctx)
+}
+
 // Save mocks base method.
-func (m *MockTeamRepository) Save(ctx context.Context, team *domain.Team, teamMembers []*domain.User) error {
+func (m *MockTeamRepository) Save(ctx context.Context, team domain.Team, teamMembers []domain.User) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Save", ctx, team, teamMembers)
 	ret0, _ := ret[0].(error)
@@ -88,25 +170,39 @@ func (m *MockPullRequestRepository) EXPECT() *MockPullRequestRepositoryMockRecor
 	return m.recorder
 }
 
-// DeleteByID mocks base method.
-func (m *MockPullRequestRepository) DeleteByID(ctx context.Context, prID string) error {
+// ApprovePR mocks base method.
+func (m *MockPullRequestRepository) ApprovePR(ctx context.Context, prID, reviewerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApprovePR", ctx, prID, reviewerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApprovePR indicates an expected call of ApprovePR.
+func (mr *MockPullRequestRepositoryMockRecorder) ApprovePR(ctx, prID, reviewerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApprovePR", reflect.TypeOf((*MockPullRequestRepository)(nil).ApprovePR), ctx, prID, reviewerID)
+}
+
+// CreatePR mocks base method.
+func (m *MockPullRequestRepository) CreatePR(ctx context.Context, pr domain.PullRequest) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteByID", ctx, prID)
+	ret := m.ctrl.Call(m, "CreatePR", ctx, pr)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteByID indicates an expected call of DeleteByID.
-func (mr *MockPullRequestRepositoryMockRecorder) DeleteByID(ctx, prID interface{}) *gomock.Call {
+// CreatePR indicates an expected call of CreatePR.
+func (mr *MockPullRequestRepositoryMockRecorder) CreatePR(ctx, pr interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByID", reflect.TypeOf((*MockPullRequestRepository)(nil).DeleteByID), ctx, prID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePR", reflect.TypeOf((*MockPullRequestRepository)(nil).CreatePR), ctx, pr)
 }
 
 // FindByID mocks base method.
-func (m *MockPullRequestRepository) FindByID(ctx context.Context, prID string) (*domain.PullRequest, error) {
+func (m *MockPullRequestRepository) FindByID(ctx context.Context, prID string) (domain.PullRequest, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindByID", ctx, prID)
-	ret0, _ := ret[0].(*domain.PullRequest)
+	ret0, _ := ret[0].(domain.PullRequest)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -117,11 +213,26 @@ func (mr *MockPullRequestRepositoryMockRecorder) FindByID(ctx, prID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockPullRequestRepository)(nil).FindByID), ctx, prID)
 }
 
+// FindByIDForUpdate mocks base method.
+func (m *MockPullRequestRepository) FindByIDForUpdate(ctx context.Context, prID string) (domain.PullRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIDForUpdate", ctx, prID)
+	ret0, _ := ret[0].(domain.PullRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIDForUpdate indicates an expected call of FindByIDForUpdate.
+func (mr *MockPullRequestRepositoryMockRecorder) FindByIDForUpdate(ctx, prID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDForUpdate", reflect.TypeOf((*MockPullRequestRepository)(nil).FindByIDForUpdate), ctx, prID)
+}
+
 // FindByReviewerID mocks base method.
-func (m *MockPullRequestRepository) FindByReviewerID(ctx context.Context, reviewerID string) ([]*domain.PullRequest, error) {
+func (m *MockPullRequestRepository) FindByReviewerID(ctx context.Context, reviewerID string) ([]domain.PullRequest, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindByReviewerID", ctx, reviewerID)
-	ret0, _ := ret[0].([]*domain.PullRequest)
+	ret0, _ := ret[0].([]domain.PullRequest)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -132,18 +243,47 @@ func (mr *MockPullRequestRepositoryMockRecorder) FindByReviewerID(ctx, reviewerI
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByReviewerID", reflect.TypeOf((*MockPullRequestRepository)(nil).FindByReviewerID), ctx, reviewerID)
 }
 
-// Save mocks base method.
-func (m *MockPullRequestRepository) Save(ctx context.Context, pr *domain.PullRequest) error {
+// MergePR mocks base method.
+func (m *MockPullRequestRepository) MergePR(ctx context.Context, pr domain.PullRequest) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Save", ctx, pr)
+	ret := m.ctrl.Call(m, "MergePR", ctx, pr)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Save indicates an expected call of Save.
-func (mr *MockPullRequestRepositoryMockRecorder) Save(ctx, pr interface{}) *gomock.Call {
+// MergePR indicates an expected call of MergePR.
+func (mr *MockPullRequestRepositoryMockRecorder) MergePR(ctx, pr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergePR", reflect.TypeOf((*MockPullRequestRepository)(nil).MergePR), ctx, pr)
+}
+
+// QueryTurnaround mocks base method.
+func (m *MockPullRequestRepository) QueryTurnaround(ctx context.Context, q domain.TurnaroundQuery) ([]domain.TurnaroundStat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryTurnaround", ctx, q)
+	ret0, _ := ret[0].([]domain.TurnaroundStat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryTurnaround indicates an expected call of QueryTurnaround.
+func (mr *MockPullRequestRepositoryMockRecorder) QueryTurnaround(ctx, q interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockPullRequestRepository)(nil).Save), ctx, pr)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryTurnaround", reflect.TypeOf((*MockPullRequestRepository)(nil).QueryTurnaround), ctx, q)
+}
+
+// ReassignPR mocks base method.
+func (m *MockPullRequestRepository) ReassignPR(ctx context.Context, pr domain.PullRequest, oldReviewer, newReviewer string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReassignPR", ctx, pr, oldReviewer, newReviewer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReassignPR indicates an expected call of ReassignPR.
+func (mr *MockPullRequestRepositoryMockRecorder) ReassignPR(ctx, pr, oldReviewer, newReviewer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReassignPR", reflect.TypeOf((*MockPullRequestRepository)(nil).ReassignPR), ctx, pr, oldReviewer, newReviewer)
 }
 
 // MockUserRepository is a mock of UserRepository interface.
@@ -200,10 +340,10 @@ func (mr *MockUserRepositoryMockRecorder) FindActiveUserIDsByTeamExcludeAuthor(c
 }
 
 // FindByID mocks base method.
-func (m *MockUserRepository) FindByID(ctx context.Context, userID string) (*domain.User, error) {
+func (m *MockUserRepository) FindByID(ctx context.Context, userID string) (domain.User, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindByID", ctx, userID)
-	ret0, _ := ret[0].(*domain.User)
+	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -229,18 +369,64 @@ func (mr *MockUserRepositoryMockRecorder) FindTeamByUserID(ctx, userID interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTeamByUserID", reflect.TypeOf((*MockUserRepository)(nil).FindTeamByUserID), ctx, userID)
 }
 
-// Save mocks base method.
-func (m *MockUserRepository) Save(ctx context.Context, user *domain.User) error {
+// GetStats mocks base method.
+func (m *MockUserRepository) GetStats(ctx context.Context, limit uint64) ([]domain.UserStat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Save", ctx, user)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetStats", ctx, limit)
+	ret0, _ := ret[0].([]domain.UserStat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Save indicates an expected call of Save.
-func (mr *MockUserRepositoryMockRecorder) Save(ctx, user interface{}) *gomock.Call {
+// GetStats indicates an expected call of GetStats.
+func (mr *MockUserRepositoryMockRecorder) GetStats(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockUserRepository)(nil).GetStats), ctx, limit)
+}
+
+// QueryStats mocks base method.
+func (m *MockUserRepository) QueryStats(ctx context.Context, q domain.StatsQuery) ([]domain.GroupStat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryStats", ctx, q)
+	ret0, _ := ret[0].([]domain.GroupStat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryStats indicates an expected call of QueryStats.
+func (mr *MockUserRepositoryMockRecorder) QueryStats(ctx, q interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockUserRepository)(nil).Save), ctx, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryStats", reflect.TypeOf((*MockUserRepository)(nil).QueryStats), ctx, q)
+}
+
+// QueryWorkload mocks base method.
+func (m *MockUserRepository) QueryWorkload(ctx context.Context, q domain.FairnessQuery) ([]domain.MemberWorkload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryWorkload", ctx, q)
+	ret0, _ := ret[0].([]domain.MemberWorkload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryWorkload indicates an expected call of QueryWorkload.
+func (mr *MockUserRepositoryMockRecorder) QueryWorkload(ctx, q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryWorkload", reflect.TypeOf((*MockUserRepository)(nil).QueryWorkload), ctx, q)
+}
+
+// RebuildStats mocks base method.
+func (m *MockUserRepository) RebuildStats(ctx context.Context) ([]domain.UserStatDrift, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebuildStats", ctx)
+	ret0, _ := ret[0].([]domain.UserStatDrift)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RebuildStats indicates an expected call of RebuildStats.
+func (mr *MockUserRepositoryMockRecorder) RebuildStats(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildStats", reflect.TypeOf((*MockUserRepository)(nil).RebuildStats), ctx)
 }
 
 // SetIsActive mocks base method.
@@ -256,3 +442,276 @@ func (mr *MockUserRepositoryMockRecorder) SetIsActive(ctx, userID, isActive inte
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIsActive", reflect.TypeOf((*MockUserRepository)(nil).SetIsActive), ctx, userID, isActive)
 }
+
+// SetRole mocks base method.
+func (m *MockUserRepository) SetRole(ctx context.Context, userID string, role domain.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRole", ctx, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockUserRepositoryMockRecorder) SetRole(ctx, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*MockUserRepository)(nil).SetRole), ctx, userID, role)
+}
+
+// MockTokenRepository is a mock of TokenRepository interface.
+type MockTokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenRepositoryMockRecorder
+}
+
+// MockTokenRepositoryMockRecorder is the mock recorder for MockTokenRepository.
+type MockTokenRepositoryMockRecorder struct {
+	mock *MockTokenRepository
+}
+
+// NewMockTokenRepository creates a new mock instance.
+func NewMockTokenRepository(ctrl *gomock.Controller) *MockTokenRepository {
+	mock := &MockTokenRepository{ctrl: ctrl}
+	mock.recorder = &MockTokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenRepository) EXPECT() *MockTokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindByHash mocks base method.
+func (m *MockTokenRepository) FindByHash(ctx context.Context, hash string) (domain.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByHash", ctx, hash)
+	ret0, _ := ret[0].(domain.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByHash indicates an expected call of FindByHash.
+func (mr *MockTokenRepositoryMockRecorder) FindByHash(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByHash", reflect.TypeOf((*MockTokenRepository)(nil).FindByHash), ctx, hash)
+}
+
+// List mocks base method.
+func (m *MockTokenRepository) List(ctx context.Context) ([]domain.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]domain.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockTokenRepositoryMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockTokenRepository)(nil).List), ctx)
+}
+
+// Revoke mocks base method.
+func (m *MockTokenRepository) Revoke(ctx context.Context, tokenID string, revokedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, tokenID, revokedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockTokenRepositoryMockRecorder) Revoke(ctx, tokenID, revokedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockTokenRepository)(nil).Revoke), ctx, tokenID, revokedAt)
+}
+
+// Save mocks base method.
+func (m *MockTokenRepository) Save(ctx context.Context, token domain.APIToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockTokenRepositoryMockRecorder) Save(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockTokenRepository)(nil).Save), ctx, token)
+}
+
+// MockAuditRepository is a mock of AuditRepository interface.
+type MockAuditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryMockRecorder
+}
+
+// MockAuditRepositoryMockRecorder is the mock recorder for MockAuditRepository.
+type MockAuditRepositoryMockRecorder struct {
+	mock *MockAuditRepository
+}
+
+// NewMockAuditRepository creates a new mock instance.
+func NewMockAuditRepository(ctrl *gomock.Controller) *MockAuditRepository {
+	mock := &MockAuditRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepository) EXPECT() *MockAuditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockAuditRepository) Append(ctx context.Context, entry domain.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockAuditRepositoryMockRecorder) Append(ctx, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockAuditRepository)(nil).Append), ctx, entry)
+}
+
+// Query mocks base method.
+func (m *MockAuditRepository) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Query", ctx, q)
+	ret0, _ := ret[0].([]domain.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockAuditRepositoryMockRecorder) Query(ctx, q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockAuditRepository)(nil).Query), ctx, q)
+}
+
+// MockWebhookRepository is a mock of WebhookRepository interface.
+type MockWebhookRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookRepositoryMockRecorder
+}
+
+// MockWebhookRepositoryMockRecorder is the mock recorder for MockWebhookRepository.
+type MockWebhookRepositoryMockRecorder struct {
+	mock *MockWebhookRepository
+}
+
+// NewMockWebhookRepository creates a new mock instance.
+func NewMockWebhookRepository(ctrl *gomock.Controller) *MockWebhookRepository {
+	mock := &MockWebhookRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookRepository) EXPECT() *MockWebhookRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateSubscription mocks base method.
+func (m *MockWebhookRepository) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubscription", ctx, subscription)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSubscription indicates an expected call of CreateSubscription.
+func (mr *MockWebhookRepositoryMockRecorder) CreateSubscription(ctx, subscription interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubscription", reflect.TypeOf((*MockWebhookRepository)(nil).CreateSubscription), ctx, subscription)
+}
+
+// DeleteSubscription mocks base method.
+func (m *MockWebhookRepository) DeleteSubscription(ctx context.Context, subscriptionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscription", ctx, subscriptionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubscription indicates an expected call of DeleteSubscription.
+func (mr *MockWebhookRepositoryMockRecorder) DeleteSubscription(ctx, subscriptionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockWebhookRepository)(nil).DeleteSubscription), ctx, subscriptionID)
+}
+
+// FindDelivery mocks base method.
+func (m *MockWebhookRepository) FindDelivery(ctx context.Context, deliveryID int64) (domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDelivery", ctx, deliveryID)
+	ret0, _ := ret[0].(domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDelivery indicates an expected call of FindDelivery.
+func (mr *MockWebhookRepositoryMockRecorder) FindDelivery(ctx, deliveryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDelivery", reflect.TypeOf((*MockWebhookRepository)(nil).FindDelivery), ctx, deliveryID)
+}
+
+// ListAttempts mocks base method.
+func (m *MockWebhookRepository) ListAttempts(ctx context.Context, deliveryID int64) ([]domain.WebhookAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAttempts", ctx, deliveryID)
+	ret0, _ := ret[0].([]domain.WebhookAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAttempts indicates an expected call of ListAttempts.
+func (mr *MockWebhookRepositoryMockRecorder) ListAttempts(ctx, deliveryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAttempts", reflect.TypeOf((*MockWebhookRepository)(nil).ListAttempts), ctx, deliveryID)
+}
+
+// ListDeliveries mocks base method.
+func (m *MockWebhookRepository) ListDeliveries(ctx context.Context, q domain.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", ctx, q)
+	ret0, _ := ret[0].([]domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockWebhookRepositoryMockRecorder) ListDeliveries(ctx, q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockWebhookRepository)(nil).ListDeliveries), ctx, q)
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockWebhookRepository) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptions", ctx)
+	ret0, _ := ret[0].([]domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockWebhookRepositoryMockRecorder) ListSubscriptions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockWebhookRepository)(nil).ListSubscriptions), ctx)
+}
+
+// ReplayDelivery mocks base method.
+func (m *MockWebhookRepository) ReplayDelivery(ctx context.Context, deliveryID int64, at time.Time) (domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayDelivery", ctx, deliveryID, at)
+	ret0, _ := ret[0].(domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayDelivery indicates an expected call of ReplayDelivery.
+func (mr *MockWebhookRepositoryMockRecorder) ReplayDelivery(ctx, deliveryID, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayDelivery", reflect.TypeOf((*MockWebhookRepository)(nil).ReplayDelivery), ctx, deliveryID, at)
+}
@@ -3,130 +3,427 @@ package service
 import (
 	"avito-tech-go-task/internal/domain"
 	"avito-tech-go-task/internal/infrastructure/http/model"
+	"avito-tech-go-task/internal/infrastructure/logging"
+	"avito-tech-go-task/internal/infrastructure/metrics"
 	"avito-tech-go-task/pkg/helper"
 	"context"
+	"database/sql"
 	"errors"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("avito-tech-go-task/internal/application/service")
+
 type PRService struct {
-	prRepo   PullRequestRepository
-	userRepo UserRepository
-	teamRepo TeamRepository
+	prRepo    PullRequestRepository
+	userRepo  UserRepository
+	teamRepo  TeamRepository
+	auditRepo AuditRepository
+	tx        TxManager
+	// reviewersCount - сколько ревьюеров назначается на новый PR
+	reviewersCount int64
+	logger         *slog.Logger
 }
 
-func NewPRService(prRepo PullRequestRepository, userRepo UserRepository, teamRepo TeamRepository) *PRService {
+// serializable - изоляция вызовов, которые выбирают ревьюеров среди активных участников команды или меняют,
+// кто активен. При READ COMMITTED назначение и параллельная деактивация кандидата не видят друг друга,
+// и на PR мог остаться неактивный ревьюер; в SERIALIZABLE одна из транзакций откатывается и TxManager её повторяет.
+var serializable = &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+func NewPRService(prRepo PullRequestRepository, userRepo UserRepository, teamRepo TeamRepository, auditRepo AuditRepository, tx TxManager, reviewersCount int64, logger *slog.Logger) *PRService {
 	return &PRService{
-		prRepo:   prRepo,
-		userRepo: userRepo,
-		teamRepo: teamRepo,
+		prRepo:         prRepo,
+		userRepo:       userRepo,
+		teamRepo:       teamRepo,
+		auditRepo:      auditRepo,
+		tx:             tx,
+		reviewersCount: reviewersCount,
+		logger:         logger,
 	}
 }
 
+// CreatePR создаёт PR и назначает ревьюеров. Если запрос привязан к пользователю, автор - всегда он,
+// authorID из запроса может быть пустым.
 func (s *PRService) CreatePR(ctx context.Context, prID, prName, authorID string) (domain.PullRequest, error) {
-	_, err := s.prRepo.FindByID(ctx, prID)
-	if !errors.Is(err, domain.ErrPRNotFound) {
-		return domain.PullRequest{}, domain.ErrPRExists
-	}
+	ctx, span := tracer.Start(ctx, "PRService.CreatePR")
+	defer span.End()
 
-	teamID, err := s.userRepo.FindTeamByUserID(ctx, authorID)
+	authorID, err := domain.ActingUserID(ctx, authorID)
 	if err != nil {
 		return domain.PullRequest{}, err
 	}
+	ctx = logging.With(ctx, slog.String("pr_id", prID), slog.String("author_id", authorID))
+
+	var (
+		pr     *domain.PullRequest
+		teamID string
+	)
+	err = s.tx.DoWith(ctx, serializable, func(ctx context.Context) error {
+		_, err := s.prRepo.FindByID(ctx, prID)
+		if err == nil {
+			return domain.ErrPRExists
+		}
+		if !errors.Is(err, domain.ErrPRNotFound) {
+			return err
+		}
 
-	reviewersIDs, err := s.userRepo.FindActiveUserIDsByTeamExcludeAuthor(ctx, teamID, authorID, domain.ReviewersMaxCount)
-	if err != nil {
-		return domain.PullRequest{}, err
-	}
+		teamID, err = s.userRepo.FindTeamByUserID(ctx, authorID)
+		if err != nil {
+			return err
+		}
+
+		reviewersIDs, err := s.userRepo.FindActiveUserIDsByTeamExcludeAuthor(ctx, teamID, authorID, s.reviewersCount)
+		if err != nil {
+			return err
+		}
 
-	pr, err := domain.NewPullRequest(prID, prName, authorID, reviewersIDs)
+		pr, err = domain.NewPullRequest(prID, prName, authorID, reviewersIDs)
+		if err != nil {
+			return err
+		}
+
+		err = s.prRepo.CreatePR(ctx, *pr)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, domain.AuditPRCreate, domain.AuditEntityPullRequest, pr.ID, nil, pr.ToJSON())
+	})
 	if err != nil {
 		return domain.PullRequest{}, err
 	}
 
-	err = s.prRepo.CreatePR(ctx, *pr)
-	if err != nil {
-		return domain.PullRequest{}, err
+	outcome := domain.NewAssignmentOutcome(int(s.reviewersCount), len(pr.ReviewersIDs))
+	metrics.ObserveAssignment(domain.AssignmentOperationCreate, outcome)
+
+	reason := "enough active members in author's team"
+	switch outcome {
+	case domain.AssignmentPartial:
+		reason = "fewer active members in author's team than reviewers required"
+	case domain.AssignmentNoCandidate:
+		reason = "no other active members in author's team"
 	}
+	s.logger.InfoContext(ctx, "reviewers assigned",
+		slog.String("team_name", teamID),
+		slog.Any("reviewers_ids", pr.ReviewersIDs),
+		slog.String("outcome", string(outcome)),
+		slog.String("reason", reason),
+	)
 
 	return *pr, nil
 }
 
-func (s *PRService) MergePR(ctx context.Context, prID string) (domain.PullRequest, error) {
-	pr, err := s.prRepo.FindByID(ctx, prID)
+// MergePR мержит PR. Мержить может автор PR, лид его команды или admin.
+// version - версия PR, которую видел клиент (0 - без проверки версии).
+func (s *PRService) MergePR(ctx context.Context, prID string, version int64) (domain.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "PRService.MergePR")
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("pr_id", prID))
+
+	var (
+		pr     domain.PullRequest
+		merged bool
+	)
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		pr, err = s.prRepo.FindByIDForUpdate(ctx, prID)
+		if err != nil {
+			return err
+		}
+
+		err = s.authorizeAuthor(ctx, pr.AuthorID)
+		if err != nil {
+			return err
+		}
+
+		err = pr.CheckVersion(version)
+		if err != nil {
+			return err
+		}
+
+		if pr.IsMerged() {
+			return nil
+		}
+
+		before := pr.Clone()
+		pr.SetMergedStatus()
+		merged = true
+
+		err = s.prRepo.MergePR(ctx, pr)
+		if errors.Is(err, domain.ErrPRVersionConflict) {
+			return s.versionConflict(ctx, prID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, domain.AuditPRMerge, domain.AuditEntityPullRequest, prID, before.ToJSON(), pr.ToJSON())
+	})
 	if err != nil {
 		return domain.PullRequest{}, err
 	}
 
-	if pr.IsMerged() {
-		return pr, nil
+	if merged {
+		s.logger.InfoContext(ctx, "pull request merged", slog.Any("reviewers_ids", pr.ReviewersIDs))
 	}
 
-	pr.SetMergedStatus()
+	return pr, nil
+}
+
+// ApprovePR записывает одобрение PR ревьюером: от первого одобрения считается время до ревью.
+// Одобрить PR может только назначенный ревьюер (или admin от его имени).
+func (s *PRService) ApprovePR(ctx context.Context, prID, reviewerID string) (domain.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "PRService.ApprovePR")
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("pr_id", prID), slog.String("reviewer_id", reviewerID))
+
+	var pr domain.PullRequest
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		// права проверяются до чтения PR, как и в ReassignPR
+		err := s.authorizeReviewer(ctx, reviewerID)
+		if err != nil {
+			return err
+		}
+
+		pr, err = s.prRepo.FindByIDForUpdate(ctx, prID)
+		if err != nil {
+			return err
+		}
+
+		err = pr.CheckApprove(reviewerID)
+		if err != nil {
+			return err
+		}
 
-	err = s.prRepo.MergePR(ctx, pr)
+		err = s.prRepo.ApprovePR(ctx, prID, reviewerID)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, domain.AuditPRApprove, domain.AuditEntityPullRequest, prID, nil, model.ReviewerEvent{
+			PullRequestID: prID,
+			ReviewerID:    reviewerID,
+		})
+	})
 	if err != nil {
 		return domain.PullRequest{}, err
 	}
 
+	s.logger.InfoContext(ctx, "pull request approved")
+
 	return pr, nil
 }
 
-func (s *PRService) ReassignPR(ctx context.Context, prID, oldReviewerID string) (prVal domain.PullRequest, newReviewerID string, err error) {
-	pr, err := s.prRepo.FindByID(ctx, prID)
-	if err != nil {
-		return domain.PullRequest{}, "", err
-	}
+// ReassignPR заменяет ревьюера на случайного активного участника его команды.
+// version - версия PR, которую видел клиент (0 - без проверки версии).
+func (s *PRService) ReassignPR(ctx context.Context, prID, oldReviewerID string, version int64) (prVal domain.PullRequest, newReviewerID string, err error) {
+	ctx, span := tracer.Start(ctx, "PRService.ReassignPR")
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("pr_id", prID), slog.String("old_reviewer_id", oldReviewerID))
+
+	var (
+		pr                        domain.PullRequest
+		oldReviewerTeam           string
+		activeCandidatesForReview []string
+	)
+	err = s.tx.DoWith(ctx, serializable, func(ctx context.Context) error {
+		// права проверяются до чтения PR, чтобы без них нельзя было узнать, существует ли PR и кто его ревьюеры;
+		// несуществующего ревьюера может снять только admin, дальше это отклоняется как ErrReviewerNotAssigned
+		var err error
+		oldReviewerTeam, err = s.userRepo.FindTeamByUserID(ctx, oldReviewerID)
+		if err != nil && !errors.Is(err, domain.ErrUserNotExist) {
+			return err
+		}
 
-	oldReviewerIndexInPR, exist := pr.GetReviewerIndex(oldReviewerID)
-	if !exist {
-		return domain.PullRequest{}, "", domain.ErrReviewerNotAssigned
-	}
+		err = s.authorizeUser(ctx, domain.User{ID: oldReviewerID, TeamName: oldReviewerTeam})
+		if err != nil {
+			return err
+		}
 
-	oldReviewerTeam, err := s.userRepo.FindTeamByUserID(ctx, oldReviewerID)
-	if err != nil {
-		return domain.PullRequest{}, "", err
-	}
+		pr, err = s.prRepo.FindByIDForUpdate(ctx, prID)
+		if err != nil {
+			return err
+		}
 
-	activeCandidatesForReview, err := s.userRepo.FindActiveUserIDsByTeam(ctx, oldReviewerTeam)
-	if err != nil {
-		return domain.PullRequest{}, "", err
-	}
+		err = pr.CheckVersion(version)
+		if err != nil {
+			return err
+		}
 
-	activeCandidatesForReview = helper.RemoveElement(activeCandidatesForReview, pr.AuthorID)
-	for _, id := range pr.ReviewersIDs {
-		activeCandidatesForReview = helper.RemoveElement(activeCandidatesForReview, id)
-	}
+		oldReviewerIndexInPR, exist := pr.GetReviewerIndex(oldReviewerID)
+		if !exist {
+			return domain.ErrReviewerNotAssigned
+		}
 
-	newReviewerID, err = pr.ReassignReviewer(oldReviewerIndexInPR, activeCandidatesForReview)
-	if err != nil {
-		return domain.PullRequest{}, "", err
-	}
+		activeCandidatesForReview, err = s.userRepo.FindActiveUserIDsByTeam(ctx, oldReviewerTeam)
+		if err != nil {
+			return err
+		}
+
+		activeCandidatesForReview = helper.RemoveElement(activeCandidatesForReview, pr.AuthorID)
+		for _, id := range pr.ReviewersIDs {
+			activeCandidatesForReview = helper.RemoveElement(activeCandidatesForReview, id)
+		}
 
-	err = s.prRepo.ReassignPR(ctx, pr, oldReviewerID, newReviewerID)
+		before := pr.Clone()
+		newReviewerID, err = pr.ReassignReviewer(oldReviewerIndexInPR, activeCandidatesForReview)
+		if err != nil {
+			return err
+		}
+
+		err = s.prRepo.ReassignPR(ctx, pr, oldReviewerID, newReviewerID)
+		if errors.Is(err, domain.ErrPRVersionConflict) {
+			return s.versionConflict(ctx, prID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, domain.AuditPRReassign, domain.AuditEntityPullRequest, prID, before.ToJSON(), pr.ToJSON())
+	})
+	if errors.Is(err, domain.ErrNoCandidate) {
+		metrics.ObserveAssignment(domain.AssignmentOperationReassign, domain.AssignmentNoCandidate)
+		s.logger.InfoContext(ctx, "reviewer not reassigned",
+			slog.String("team_name", oldReviewerTeam),
+			slog.String("outcome", string(domain.AssignmentNoCandidate)),
+			slog.String("reason", "no active team member who is neither author nor assigned reviewer"),
+		)
+	}
 	if err != nil {
 		return domain.PullRequest{}, "", err
 	}
 
+	metrics.ObserveAssignment(domain.AssignmentOperationReassign, domain.AssignmentSuccess)
+	s.logger.InfoContext(ctx, "reviewer reassigned",
+		slog.String("team_name", oldReviewerTeam),
+		slog.String("new_reviewer_id", newReviewerID),
+		slog.Int("candidates", len(activeCandidatesForReview)),
+		slog.String("outcome", string(domain.AssignmentSuccess)),
+		slog.String("reason", "random active member of old reviewer's team"),
+	)
+
 	return pr, newReviewerID, nil
 }
 
 func (s *PRService) SetIsActiveUser(ctx context.Context, userID string, isActive bool) (domain.User, error) {
-	user, err := s.userRepo.FindByID(ctx, userID)
-	if errors.Is(err, domain.ErrUserNotExist) {
+	ctx, span := tracer.Start(ctx, "PRService.SetIsActiveUser")
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("user_id", userID))
+
+	var user domain.User
+	err := s.tx.DoWith(ctx, serializable, func(ctx context.Context) error {
+		var err error
+		user, err = s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		err = s.authorizeUser(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		// деактивированный пользователь снимается с ревью открытых PR в той же транзакции
+		var prsBefore []domain.PullRequest
+		if !isActive {
+			prsBefore, err = s.prRepo.FindByReviewerID(ctx, userID)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.userRepo.SetIsActive(ctx, userID, isActive)
+		if err != nil {
+			return err
+		}
+
+		before := user.ToJSON()
+		user.IsActive = isActive
+		err = s.audit(ctx, domain.AuditUserSetIsActive, domain.AuditEntityUser, userID, before, user.ToJSON())
+		if err != nil {
+			return err
+		}
+
+		for _, prBefore := range prsBefore {
+			if prBefore.IsMerged() {
+				continue
+			}
+			pr, err := s.prRepo.FindByID(ctx, prBefore.ID)
+			if err != nil {
+				return err
+			}
+			err = s.audit(ctx, domain.AuditPRReassign, domain.AuditEntityPullRequest, pr.ID, prBefore.ToJSON(), pr.ToJSON())
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
 		return domain.User{}, err
 	}
 
-	err = s.userRepo.SetIsActive(ctx, userID, isActive)
+	s.logger.InfoContext(ctx, "user activity changed", slog.Bool("is_active", isActive))
+
+	return user, nil
+}
+
+// SetUserRole назначает пользователю роль. Менять роли может только admin.
+func (s *PRService) SetUserRole(ctx context.Context, userID string, role string) (domain.User, error) {
+	ctx, span := tracer.Start(ctx, "PRService.SetUserRole")
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("user_id", userID))
+
+	newRole, err := domain.ParseRole(role)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	var (
+		user    domain.User
+		oldRole domain.Role
+	)
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		principal, err := s.principal(ctx)
+		if err != nil {
+			return err
+		}
+		if !principal.IsAdmin() {
+			return domain.ErrForbidden
+		}
+
+		user, err = s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		err = s.userRepo.SetRole(ctx, userID, newRole)
+		if err != nil {
+			return err
+		}
+
+		before := user.ToJSON()
+		oldRole = user.Role
+		user.Role = newRole
+		return s.audit(ctx, domain.AuditUserSetRole, domain.AuditEntityUser, userID, before, user.ToJSON())
+	})
 	if err != nil {
 		return domain.User{}, err
 	}
 
-	user.IsActive = isActive
+	s.logger.InfoContext(ctx, "user role changed", slog.String("old_role", string(oldRole)), slog.String("role", string(newRole)))
 
 	return user, nil
 }
 
 func (s *PRService) GetReviewUser(ctx context.Context, userID string) ([]domain.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "PRService.GetReviewUser")
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("user_id", userID))
+
 	prs, err := s.prRepo.FindByReviewerID(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -136,6 +433,10 @@ func (s *PRService) GetReviewUser(ctx context.Context, userID string) ([]domain.
 }
 
 func (s *PRService) AddTeam(ctx context.Context, teamName string, members []model.TeamMember) error {
+	ctx, span := tracer.Start(ctx, "PRService.AddTeam")
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("team_name", teamName))
+
 	for _, m := range members {
 		if !m.Validate() {
 			return domain.ErrTeamMemberIsNotValid
@@ -154,15 +455,43 @@ func (s *PRService) AddTeam(ctx context.Context, teamName string, members []mode
 		)
 	}
 
-	err := s.teamRepo.Save(ctx, *team, domainMembers)
+	err := s.tx.DoWith(ctx, serializable, func(ctx context.Context) error {
+		err := s.authorizeTeamMembers(ctx, teamName, domainMembers)
+		if err != nil {
+			return err
+		}
+
+		before, err := s.teamRepo.FindByName(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		err = s.teamRepo.Save(ctx, *team, domainMembers)
+		if err != nil {
+			return err
+		}
+
+		after, err := s.teamRepo.FindByName(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, domain.AuditTeamSave, domain.AuditEntityTeam, teamName, teamState(before), teamState(after))
+	})
 	if err != nil {
 		return err
 	}
 
+	s.logger.InfoContext(ctx, "team saved", slog.Int("members", len(domainMembers)))
+
 	return nil
 }
 
 func (s *PRService) GetTeam(ctx context.Context, teamName string) ([]domain.User, error) {
+	ctx, span := tracer.Start(ctx, "PRService.GetTeam")
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("team_name", teamName))
+
 	teamMembers, err := s.teamRepo.FindByName(ctx, teamName)
 	if err != nil {
 		return nil, err
@@ -176,6 +505,9 @@ func (s *PRService) GetTeam(ctx context.Context, teamName string) ([]domain.User
 }
 
 func (s *PRService) GetStats(ctx context.Context, limit uint64) ([]domain.UserStat, error) {
+	ctx, span := tracer.Start(ctx, "PRService.GetStats")
+	defer span.End()
+
 	userStats, err := s.userRepo.GetStats(ctx, limit)
 	if err != nil {
 		return nil, err
@@ -184,11 +516,258 @@ func (s *PRService) GetStats(ctx context.Context, limit uint64) ([]domain.UserSt
 	return userStats, nil
 }
 
+func (s *PRService) GetGroupStats(ctx context.Context, q domain.StatsQuery) (domain.StatsPage, error) {
+	ctx, span := tracer.Start(ctx, "PRService.GetGroupStats")
+	defer span.End()
+
+	stats, err := s.userRepo.QueryStats(ctx, q)
+	if err != nil {
+		return domain.StatsPage{}, err
+	}
+
+	return domain.NewStatsPage(stats, q), nil
+}
+
+func (s *PRService) GetTurnaround(ctx context.Context, q domain.TurnaroundQuery) ([]domain.TurnaroundStat, error) {
+	ctx, span := tracer.Start(ctx, "PRService.GetTurnaround")
+	defer span.End()
+
+	stats, err := s.prRepo.QueryTurnaround(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetFairness сравнивает нагрузку участников команд с равным распределением назначений.
+func (s *PRService) GetFairness(ctx context.Context, q domain.FairnessQuery) ([]domain.TeamFairness, error) {
+	ctx, span := tracer.Start(ctx, "PRService.GetFairness")
+	defer span.End()
+
+	workloads, err := s.userRepo.QueryWorkload(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewFairnessReport(workloads, q.Threshold), nil
+}
+
+func (s *PRService) GetTeamLoad(ctx context.Context) ([]domain.TeamLoad, error) {
+	ctx, span := tracer.Start(ctx, "PRService.GetTeamLoad")
+	defer span.End()
+
+	teams, err := s.teamRepo.GetTeamLoad(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// RebuildStats пересобирает статистику ревьюеров из истории событий и возвращает найденные расхождения.
+func (s *PRService) RebuildStats(ctx context.Context) ([]domain.UserStatDrift, error) {
+	ctx, span := tracer.Start(ctx, "PRService.RebuildStats")
+	defer span.End()
+
+	drifts, err := s.userRepo.RebuildStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return drifts, nil
+}
+
 func (s *PRService) DeactivateTeam(ctx context.Context, teamName string) ([]domain.PullRequest, error) {
-	userStats, err := s.teamRepo.DeactivateTeam(ctx, teamName)
+	ctx, span := tracer.Start(ctx, "PRService.DeactivateTeam")
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("team_name", teamName))
+
+	var prs []domain.PullRequest
+	err := s.tx.DoWith(ctx, serializable, func(ctx context.Context) error {
+		err := s.authorizeTeam(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		before, err := s.teamRepo.FindByName(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		// состояние PR до переназначения: ревьюеры из команды заменятся на участников других команд
+		prsBefore := make(map[string]domain.PullRequest)
+		for _, member := range before {
+			if !member.IsActive {
+				continue
+			}
+			memberPRs, err := s.prRepo.FindByReviewerID(ctx, member.ID)
+			if err != nil {
+				return err
+			}
+			for _, pr := range memberPRs {
+				prsBefore[pr.ID] = pr
+			}
+		}
+
+		prs, err = s.teamRepo.DeactivateTeam(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		after, err := s.teamRepo.FindByName(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		err = s.audit(ctx, domain.AuditTeamDeactivate, domain.AuditEntityTeam, teamName, teamState(before), teamState(after))
+		if err != nil {
+			return err
+		}
+
+		for _, pr := range prs {
+			var prBefore any
+			if old, ok := prsBefore[pr.ID]; ok {
+				prBefore = old.ToJSON()
+			}
+			err = s.audit(ctx, domain.AuditPRReassign, domain.AuditEntityPullRequest, pr.ID, prBefore, pr.ToJSON())
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return userStats, nil
+	for _, pr := range prs {
+		outcome := domain.NewAssignmentOutcome(1, len(pr.ReviewersIDs))
+		metrics.ObserveAssignment(domain.AssignmentOperationDeactivateTeam, outcome)
+		s.logger.InfoContext(ctx, "reviewers reassigned after team deactivation",
+			slog.String("pr_id", pr.ID),
+			slog.Any("reviewers_ids", pr.ReviewersIDs),
+			slog.String("outcome", string(outcome)),
+			slog.String("reason", "random active member of another team"),
+		)
+	}
+
+	return prs, nil
+}
+
+// principal возвращает права автора запроса: пользователя, к которому привязан его токен.
+func (s *PRService) principal(ctx context.Context) (domain.Principal, error) {
+	return domain.PrincipalFromContext(ctx, s.userRepo.FindByID)
+}
+
+// authorizeTeam проверяет, что автор запроса - admin или лид команды teamName.
+func (s *PRService) authorizeTeam(ctx context.Context, teamName string) error {
+	principal, err := s.principal(ctx)
+	if err != nil {
+		return err
+	}
+	if !principal.CanManageTeam(teamName) {
+		return s.forbidden(ctx, principal, slog.String("team_name", teamName))
+	}
+
+	return nil
+}
+
+// authorizeUser проверяет, что автор запроса - сам пользователь, лид его команды или admin.
+func (s *PRService) authorizeUser(ctx context.Context, user domain.User) error {
+	principal, err := s.principal(ctx)
+	if err != nil {
+		return err
+	}
+	if !principal.CanManageUser(user) {
+		return s.forbidden(ctx, principal, slog.String("target_user_id", user.ID))
+	}
+
+	return nil
+}
+
+// authorizeAuthor проверяет, что автор запроса - автор PR, лид его команды или admin.
+func (s *PRService) authorizeAuthor(ctx context.Context, authorID string) error {
+	principal, err := s.principal(ctx)
+	if err != nil {
+		return err
+	}
+	if principal.IsAdmin() {
+		return nil
+	}
+
+	authorTeam, err := s.userRepo.FindTeamByUserID(ctx, authorID)
+	if err != nil && !errors.Is(err, domain.ErrUserNotExist) {
+		return err
+	}
+	if !principal.CanManageUser(domain.User{ID: authorID, TeamName: authorTeam}) {
+		return s.forbidden(ctx, principal, slog.String("author_id", authorID))
+	}
+
+	return nil
+}
+
+// authorizeReviewer разрешает действие от имени ревьюера только ему самому и admin.
+func (s *PRService) authorizeReviewer(ctx context.Context, reviewerID string) error {
+	principal, err := s.principal(ctx)
+	if err != nil {
+		return err
+	}
+	if !principal.IsAdmin() && principal.UserID != reviewerID {
+		return s.forbidden(ctx, principal, slog.String("reviewer_id", reviewerID))
+	}
+
+	return nil
+}
+
+// authorizeTeamMembers проверяет, что автор запроса может сохранить состав команды:
+// лид не может создавать другие команды и забирать к себе участников чужих команд.
+func (s *PRService) authorizeTeamMembers(ctx context.Context, teamName string, members []domain.User) error {
+	principal, err := s.principal(ctx)
+	if err != nil {
+		return err
+	}
+	if !principal.CanManageTeam(teamName) {
+		return s.forbidden(ctx, principal, slog.String("team_name", teamName))
+	}
+	if principal.IsAdmin() {
+		return nil
+	}
+
+	for _, member := range members {
+		memberTeam, err := s.userRepo.FindTeamByUserID(ctx, member.ID)
+		if errors.Is(err, domain.ErrUserNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if memberTeam != teamName {
+			return s.forbidden(ctx, principal, slog.String("target_user_id", member.ID))
+		}
+	}
+
+	return nil
+}
+
+func (s *PRService) forbidden(ctx context.Context, principal domain.Principal, target slog.Attr) error {
+	s.logger.InfoContext(ctx, "action forbidden by role",
+		slog.String("principal_id", principal.UserID),
+		slog.String("role", string(principal.Role)),
+		target,
+	)
+	return domain.ErrForbidden
+}
+
+// versionConflict возвращает ошибку конфликта версий с актуальным состоянием PR.
+func (s *PRService) versionConflict(ctx context.Context, prID string) error {
+	current, err := s.prRepo.FindByID(ctx, prID)
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "pull request version conflict", slog.Int64("current_version", current.Version))
+
+	return &domain.PRVersionConflictError{Current: current}
 }
@@ -3,29 +3,72 @@ package service
 import (
 	"avito-tech-go-task/internal/domain"
 	"context"
+	"database/sql"
+	"time"
 )
 
 //go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
 
+// TxManager выполняет fn в одной транзакции: репозитории, вызванные с ctx из fn, работают в ней.
+// При конфликте с другой транзакцией (serialization failure, deadlock, занятая база) fn может быть выполнена повторно.
+type TxManager interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+	// DoWith выполняет fn в транзакции с opts: уровнем изоляции и режимом только для чтения.
+	DoWith(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error
+}
+
 type TeamRepository interface {
 	Save(ctx context.Context, team domain.Team, teamMembers []domain.User) (err error)
 	FindByName(ctx context.Context, teamName string) ([]domain.User, error)
 	DeactivateTeam(ctx context.Context, teamName string) ([]domain.PullRequest, error)
+	GetTeamLoad(ctx context.Context) ([]domain.TeamLoad, error)
 }
 
 type PullRequestRepository interface {
 	CreatePR(ctx context.Context, pr domain.PullRequest) error
 	MergePR(ctx context.Context, pr domain.PullRequest) error
+	ApprovePR(ctx context.Context, prID, reviewerID string) error
 	ReassignPR(ctx context.Context, pr domain.PullRequest, oldReviewer, newReviewer string) error
 	FindByID(ctx context.Context, prID string) (domain.PullRequest, error)
+	FindByIDForUpdate(ctx context.Context, prID string) (domain.PullRequest, error)
 	FindByReviewerID(ctx context.Context, reviewerID string) ([]domain.PullRequest, error)
+	QueryTurnaround(ctx context.Context, q domain.TurnaroundQuery) ([]domain.TurnaroundStat, error)
 }
 
 type UserRepository interface {
 	SetIsActive(ctx context.Context, userID string, isActive bool) (err error)
+	SetRole(ctx context.Context, userID string, role domain.Role) error
 	FindByID(ctx context.Context, userID string) (domain.User, error)
 	FindTeamByUserID(ctx context.Context, userID string) (string, error)
 	FindActiveUserIDsByTeam(ctx context.Context, team string) ([]string, error)
 	FindActiveUserIDsByTeamExcludeAuthor(ctx context.Context, team, excludeAuthorID string, reviewersCount int64) ([]string, error)
 	GetStats(ctx context.Context, limit uint64) ([]domain.UserStat, error)
+	RebuildStats(ctx context.Context) ([]domain.UserStatDrift, error)
+	QueryStats(ctx context.Context, q domain.StatsQuery) ([]domain.GroupStat, error)
+	QueryWorkload(ctx context.Context, q domain.FairnessQuery) ([]domain.MemberWorkload, error)
+}
+
+type TokenRepository interface {
+	Save(ctx context.Context, token domain.APIToken) error
+	FindByHash(ctx context.Context, hash string) (domain.APIToken, error)
+	List(ctx context.Context) ([]domain.APIToken, error)
+	Revoke(ctx context.Context, tokenID string, revokedAt time.Time) error
+}
+
+// AuditRepository - журнал аудита. Append вызывается с ctx транзакции изменения, чтобы запись и изменение
+// сохранились или откатились вместе.
+type AuditRepository interface {
+	Append(ctx context.Context, entry domain.AuditEntry) error
+	Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error)
+}
+
+// WebhookRepository - подписки на события и их доставки. Доставки создаёт и отправляет пакет webhook.
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionID string) error
+	ListDeliveries(ctx context.Context, q domain.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error)
+	FindDelivery(ctx context.Context, deliveryID int64) (domain.WebhookDelivery, error)
+	ListAttempts(ctx context.Context, deliveryID int64) ([]domain.WebhookAttempt, error)
+	ReplayDelivery(ctx context.Context, deliveryID int64, at time.Time) (domain.WebhookDelivery, error)
 }
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/logging"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// TokenService выпускает, отзывает и проверяет API токены. Выпуск и отзыв пишутся в журнал аудита.
+type TokenService struct {
+	tokenRepo TokenRepository
+	userRepo  UserRepository
+	auditRepo AuditRepository
+	tx        TxManager
+	logger    *slog.Logger
+}
+
+func NewTokenService(tokenRepo TokenRepository, userRepo UserRepository, auditRepo AuditRepository, tx TxManager, logger *slog.Logger) *TokenService {
+	return &TokenService{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+		auditRepo: auditRepo,
+		tx:        tx,
+		logger:    logger,
+	}
+}
+
+// Issue выпускает токен и возвращает его секрет, который больше нигде не сохраняется.
+// Токен с userID действует от имени пользователя и ограничен его ролью; без userID токен сервисный.
+func (s *TokenService) Issue(ctx context.Context, name, userID string, scopes []domain.Scope) (domain.APIToken, string, error) {
+	ctx, span := tracer.Start(ctx, "TokenService.Issue")
+	defer span.End()
+
+	token, secret, err := domain.NewAPIToken(name, scopes)
+	if err != nil {
+		return domain.APIToken{}, "", err
+	}
+	if userID != "" {
+		if _, err = s.userRepo.FindByID(ctx, userID); err != nil {
+			return domain.APIToken{}, "", err
+		}
+		token.UserID = userID
+	}
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := s.tokenRepo.Save(ctx, *token); err != nil {
+			return err
+		}
+
+		return appendAudit(ctx, s.auditRepo, domain.AuditTokenIssue, domain.AuditEntityToken, token.ID, nil, token.ToJSON())
+	})
+	if err != nil {
+		return domain.APIToken{}, "", err
+	}
+
+	ctx = logging.With(ctx, slog.String("issued_token_id", token.ID))
+	s.logger.InfoContext(ctx, "token issued", slog.Any("scopes", token.Scopes), slog.String("user_id", token.UserID))
+
+	return *token, secret, nil
+}
+
+func (s *TokenService) List(ctx context.Context) ([]domain.APIToken, error) {
+	ctx, span := tracer.Start(ctx, "TokenService.List")
+	defer span.End()
+
+	return s.tokenRepo.List(ctx)
+}
+
+func (s *TokenService) Revoke(ctx context.Context, tokenID string) error {
+	ctx, span := tracer.Start(ctx, "TokenService.Revoke")
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("revoked_token_id", tokenID))
+
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		// токенов немного, а поиска по ID у репозитория нет, поэтому состояние до отзыва берётся из списка
+		tokens, err := s.tokenRepo.List(ctx)
+		if err != nil {
+			return err
+		}
+		index := slices.IndexFunc(tokens, func(token domain.APIToken) bool { return token.ID == tokenID })
+		if index < 0 {
+			return domain.ErrTokenNotFound
+		}
+		token := tokens[index]
+		before := token.ToJSON()
+
+		revokedAt := time.Now().UTC()
+		if err = s.tokenRepo.Revoke(ctx, tokenID, revokedAt); err != nil {
+			return err
+		}
+		// повторный отзыв не меняет время первого
+		if token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+		}
+
+		return appendAudit(ctx, s.auditRepo, domain.AuditTokenRevoke, domain.AuditEntityToken, tokenID, before, token.ToJSON())
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "token revoked")
+	return nil
+}
+
+// Authenticate находит действующий токен по секрету.
+func (s *TokenService) Authenticate(ctx context.Context, secret string) (domain.APIToken, error) {
+	ctx, span := tracer.Start(ctx, "TokenService.Authenticate")
+	defer span.End()
+
+	if secret == "" {
+		return domain.APIToken{}, domain.ErrTokenInvalid
+	}
+
+	token, err := s.tokenRepo.FindByHash(ctx, domain.HashToken(secret))
+	if errors.Is(err, domain.ErrTokenNotFound) {
+		return domain.APIToken{}, domain.ErrTokenInvalid
+	}
+	if err != nil {
+		return domain.APIToken{}, err
+	}
+	if token.IsRevoked() {
+		return domain.APIToken{}, domain.ErrTokenInvalid
+	}
+
+	return token, nil
+}
+
+// EnsureBootstrapToken сохраняет токен admin из конфигурации, чтобы через него выпустить остальные токены.
+// Смена секрета в конфигурации заменяет хеш; отозванный bootstrap токен остаётся отозванным.
+func (s *TokenService) EnsureBootstrapToken(ctx context.Context, secret string) error {
+	return s.tokenRepo.Save(ctx, *domain.NewBootstrapToken(secret))
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/logging"
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// WebhookService управляет подписками на доменные события и показывает их доставки.
+// Создание и удаление подписок пишутся в журнал аудита.
+type WebhookService struct {
+	webhookRepo WebhookRepository
+	auditRepo   AuditRepository
+	tx          TxManager
+	logger      *slog.Logger
+}
+
+func NewWebhookService(webhookRepo WebhookRepository, auditRepo AuditRepository, tx TxManager, logger *slog.Logger) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		auditRepo:   auditRepo,
+		tx:          tx,
+		logger:      logger,
+	}
+}
+
+// Create подписывает url на события events; пустой events - все события.
+func (s *WebhookService) Create(ctx context.Context, url string, events []domain.EventType, secret string) (domain.WebhookSubscription, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Create")
+	defer span.End()
+
+	subscription, err := domain.NewWebhookSubscription(url, events, secret)
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := s.webhookRepo.CreateSubscription(ctx, *subscription); err != nil {
+			return err
+		}
+
+		return appendAudit(ctx, s.auditRepo, domain.AuditWebhookCreate, domain.AuditEntityWebhook, subscription.ID, nil, subscription.ToJSON())
+	})
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+
+	ctx = logging.With(ctx, slog.String("webhook_id", subscription.ID))
+	s.logger.InfoContext(ctx, "webhook created", slog.String("url", subscription.URL), slog.Any("events", subscription.Events))
+
+	return *subscription, nil
+}
+
+// List возвращает подписки без секретов: секрет нужен только dispatcher'у и после создания не показывается.
+func (s *WebhookService) List(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.List")
+	defer span.End()
+
+	subscriptions, err := s.webhookRepo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, subscriptionID string) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.Delete")
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("webhook_id", subscriptionID))
+
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		// подписок немного, а поиска по ID у репозитория нет, поэтому удаляемая подписка берётся из списка
+		subscriptions, err := s.webhookRepo.ListSubscriptions(ctx)
+		if err != nil {
+			return err
+		}
+		index := slices.IndexFunc(subscriptions, func(subscription domain.WebhookSubscription) bool {
+			return subscription.ID == subscriptionID
+		})
+		if index < 0 {
+			return domain.ErrWebhookNotFound
+		}
+
+		if err = s.webhookRepo.DeleteSubscription(ctx, subscriptionID); err != nil {
+			return err
+		}
+
+		return appendAudit(ctx, s.auditRepo, domain.AuditWebhookDelete, domain.AuditEntityWebhook, subscriptionID, subscriptions[index].ToJSON(), nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "webhook deleted")
+	return nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, q domain.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.ListDeliveries")
+	defer span.End()
+
+	return s.webhookRepo.ListDeliveries(ctx, q)
+}
+
+// GetDelivery возвращает доставку и все её попытки, включая попытки до повторов.
+func (s *WebhookService) GetDelivery(ctx context.Context, deliveryID int64) (domain.WebhookDelivery, []domain.WebhookAttempt, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetDelivery")
+	defer span.End()
+
+	delivery, err := s.webhookRepo.FindDelivery(ctx, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, nil, err
+	}
+	attempts, err := s.webhookRepo.ListAttempts(ctx, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, nil, err
+	}
+
+	return delivery, attempts, nil
+}
+
+// Replay повторяет доставку из dead letter: она отправляется при ближайшем проходе с новым счётчиком попыток.
+func (s *WebhookService) Replay(ctx context.Context, deliveryID int64) (domain.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Replay")
+	defer span.End()
+	ctx = logging.With(ctx, slog.Int64("delivery_id", deliveryID))
+
+	delivery, err := s.webhookRepo.ReplayDelivery(ctx, deliveryID, time.Now().UTC())
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	s.logger.InfoContext(ctx, "webhook delivery replayed", slog.String("webhook_id", delivery.SubscriptionID))
+	return delivery, nil
+}
@@ -11,16 +11,32 @@ type Client struct {
 }
 
 func Connect(dsn string) (*Client, error) {
-	db, err := sql.Open("postgres", dsn)
+	c, err := Open(dsn)
 	if err != nil {
-		return nil, fmt.Errorf("sql.Open: %w", err)
+		return nil, err
 	}
-	if err = db.Ping(); err != nil {
+	if err = c.pg.Ping(); err != nil {
+		_ = c.pg.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
+	return c, nil
+}
+
+// Open создаёт клиента без проверки соединения: соединения открываются при первом запросе.
+// Подходит для реплики, недоступность которой при старте не должна мешать запуску.
+func Open(dsn string) (*Client, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open: %w", err)
+	}
 	return &Client{pg: db}, nil
 }
 
+// DB возвращает пул соединений, например для миграций.
+func (c *Client) DB() *sql.DB {
+	return c.pg
+}
+
 func (c *Client) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
 	return c.pg.ExecContext(ctx, query, args...)
 }
@@ -29,8 +45,10 @@ func (c *Client) Query(ctx context.Context, query string, args ...any) (*sql.Row
 	return c.pg.QueryContext(ctx, query, args...)
 }
 
-func (c *Client) Begin(ctx context.Context) (*sql.Tx, error) {
-	return c.pg.Begin()
+// Begin открывает транзакцию с уровнем изоляции и режимом только для чтения из opts.
+// Отмена ctx откатывает транзакцию.
+func (c *Client) Begin(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return c.pg.BeginTx(ctx, opts)
 }
 
 func (c *Client) Close() error {
@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// Scheme - префикс DSN, по которому выбирается хранилище SQLite, например sqlite://./data/pr_reviewer.db.
+const Scheme = "sqlite://"
+
+// pragmas добавляются к каждому DSN:
+// время хранится текстом в UTC, поэтому его можно сравнивать в запросах как строки,
+// транзакция сразу берёт блокировку на запись, а конкурентные писатели ждут её до busy_timeout.
+// _pragma из DSN заменяет одноимённую отсюда, например sqlite://pr.db?_pragma=busy_timeout(1000).
+var pragmas = url.Values{
+	"_time_format": {"sqlite"},
+	"_timezone":    {"UTC"},
+	"_txlock":      {"immediate"},
+	"_pragma":      {"busy_timeout(5000)", "journal_mode(WAL)", "foreign_keys(1)"},
+}
+
+type Client struct {
+	db *sql.DB
+}
+
+// IsDSN сообщает, указывает ли DSN на SQLite.
+func IsDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, Scheme)
+}
+
+func Connect(dsn string) (*Client, error) {
+	db, err := sql.Open("sqlite", DriverDSN(dsn))
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open: %w", err)
+	}
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return &Client{db: db}, nil
+}
+
+// DriverDSN переводит DSN сервиса (sqlite://path?params) в DSN драйвера с обязательными настройками соединения.
+func DriverDSN(dsn string) string {
+	path, query, _ := strings.Cut(strings.TrimPrefix(dsn, Scheme), "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		params = url.Values{}
+	}
+	for key, values := range pragmas {
+		if key == "_pragma" {
+			for _, pragma := range values {
+				if !hasPragma(params[key], pragma) {
+					params[key] = append(params[key], pragma)
+				}
+			}
+			continue
+		}
+		params[key] = values
+	}
+
+	return "file:" + path + "?" + params.Encode()
+}
+
+// hasPragma сообщает, задана ли в pragmas pragma с тем же именем, что и pragma.
+func hasPragma(pragmas []string, pragma string) bool {
+	name, _, _ := strings.Cut(pragma, "(")
+	return slices.ContainsFunc(pragmas, func(p string) bool {
+		other, _, _ := strings.Cut(p, "(")
+		return strings.EqualFold(strings.TrimSpace(other), name)
+	})
+}
+
+// DB возвращает пул соединений, например для миграций.
+func (c *Client) DB() *sql.DB {
+	return c.db
+}
+
+func (c *Client) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.db.ExecContext(ctx, query, args...)
+}
+
+func (c *Client) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.db.QueryContext(ctx, query, args...)
+}
+
+// Begin открывает транзакцию. В SQLite транзакции всегда serializable, поэтому уровень изоляции из opts не влияет;
+// транзакция только для чтения начинается без блокировки на запись (BEGIN вместо BEGIN IMMEDIATE).
+func (c *Client) Begin(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return c.db.BeginTx(ctx, opts)
+}
+
+func (c *Client) Close() error {
+	return c.db.Close()
+}
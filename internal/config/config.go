@@ -0,0 +1,421 @@
+// Package config собирает настройки сервиса из значений по умолчанию, YAML файла, переменных окружения и флагов.
+//
+// Приоритет (каждый следующий источник перекрывает предыдущий):
+//  1. значения по умолчанию (Default)
+//  2. YAML файл из флага -config или переменной CONFIG_FILE
+//  3. переменные окружения (тег env)
+//  4. флаги командной строки (тег flag)
+package config
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/tracing"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+)
+
+type Config struct {
+	Server      Server      `yaml:"server"`
+	Storage     Storage     `yaml:"storage"`
+	Assignment  Assignment  `yaml:"assignment"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Scheduler   Scheduler   `yaml:"scheduler"`
+	Outbox      Outbox      `yaml:"outbox"`
+	Webhooks    Webhooks    `yaml:"webhooks"`
+	Auth        Auth        `yaml:"auth"`
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+	Log         Log         `yaml:"log"`
+	Tracing     Tracing     `yaml:"tracing"`
+}
+
+type Server struct {
+	Host              string        `yaml:"host" env:"HTTP_HOST" flag:"http-host" usage:"host HTTP сервера (пусто - все интерфейсы)"`
+	Port              int           `yaml:"port" env:"PORT" flag:"port" usage:"порт HTTP сервера"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" flag:"http-read-header-timeout" usage:"время на чтение заголовков запроса"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" flag:"http-read-timeout" usage:"время на чтение запроса"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" flag:"http-write-timeout" usage:"время на запись ответа"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" flag:"http-idle-timeout" usage:"время жизни keep-alive соединения без запросов"`
+	// ShutdownTimeout - сколько ждать завершения запросов и фоновых задач после SIGTERM.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" flag:"http-shutdown-timeout" usage:"время на завершение запросов при остановке"`
+	// TrustedProxies - IP и подсети прокси через пробел, от которых принимается X-Forwarded-For.
+	// Пусто - заголовкам не доверяем, IP клиента берётся из соединения.
+	TrustedProxies string `yaml:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES" flag:"http-trusted-proxies" usage:"IP и подсети прокси через пробел, которым доверяется X-Forwarded-For"`
+}
+
+type Storage struct {
+	// Type - memory, sqlite или postgres. Пусто - выбирается по схеме DSN.
+	Type           string `yaml:"type" env:"STORAGE" flag:"storage" usage:"хранилище: memory, sqlite, postgres (по умолчанию по схеме DSN)"`
+	DSN            DSN    `yaml:"dsn" env:"DSN" flag:"dsn" usage:"DSN базы данных"`
+	MigrateOnStart bool   `yaml:"migrate_on_start" env:"MIGRATE_ON_START" flag:"migrate-on-start" usage:"применить миграции перед запуском сервера"`
+	// ConnectTimeout - сколько повторять подключение к БД при старте, 0 - одна попытка.
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT" flag:"db-connect-timeout" usage:"сколько повторять подключение к БД при старте (0 - одна попытка)"`
+	Pool           Pool          `yaml:"pool"`
+	Replica        Replica       `yaml:"replica"`
+}
+
+// Replica - реплика postgres для чтения в /users/getReview, /teams/get и /users/getStats.
+type Replica struct {
+	// DSN - пусто, если реплики нет и всё читается из primary.
+	DSN DSN `yaml:"dsn" env:"REPLICA_DSN" flag:"replica-dsn" usage:"DSN реплики postgres для чтения"`
+	// MaxStaleness - допустимое отставание реплики; при большем отставании чтение идёт в primary.
+	MaxStaleness  time.Duration `yaml:"max_staleness" env:"REPLICA_MAX_STALENESS" flag:"replica-max-staleness" usage:"допустимое отставание реплики"`
+	CheckInterval time.Duration `yaml:"check_interval" env:"REPLICA_CHECK_INTERVAL" flag:"replica-check-interval" usage:"как часто проверять доступность и отставание реплики"`
+}
+
+// Pool - настройки пула соединений database/sql, 0 - без ограничения.
+type Pool struct {
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" flag:"db-max-open-conns" usage:"максимум открытых соединений"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" flag:"db-max-idle-conns" usage:"максимум простаивающих соединений"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" flag:"db-conn-max-lifetime" usage:"время жизни соединения"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" flag:"db-conn-max-idle-time" usage:"время простоя соединения до закрытия"`
+}
+
+type Assignment struct {
+	ReviewersCount    int64         `yaml:"reviewers_count" env:"REVIEWERS_COUNT" flag:"reviewers-count" usage:"сколько ревьюеров назначать на новый PR"`
+	FairnessWindow    time.Duration `yaml:"fairness_window" env:"FAIRNESS_WINDOW" flag:"fairness-window" usage:"окно метрик равномерности распределения ревью"`
+	FairnessThreshold float64       `yaml:"fairness_threshold" env:"FAIRNESS_THRESHOLD" flag:"fairness-threshold" usage:"допустимое отклонение нагрузки в метриках равномерности"`
+}
+
+type Idempotency struct {
+	TTL time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" flag:"idempotency-ttl" usage:"время хранения ответа по Idempotency-Key"`
+}
+
+type Scheduler struct {
+	// IdempotencyPurgeInterval - период удаления истёкших Idempotency-Key, 0 - фоновая очистка выключена.
+	IdempotencyPurgeInterval time.Duration `yaml:"idempotency_purge_interval" env:"IDEMPOTENCY_PURGE_INTERVAL" flag:"idempotency-purge-interval" usage:"период удаления истёкших Idempotency-Key (0 - выключено)"`
+}
+
+// Outbox - доставка доменных событий из таблицы outbox_events во внешние системы.
+type Outbox struct {
+	// RelayInterval - как часто relay забирает неопубликованные события, 0 - relay выключен.
+	RelayInterval time.Duration `yaml:"relay_interval" env:"OUTBOX_RELAY_INTERVAL" flag:"outbox-relay-interval" usage:"период публикации событий из outbox (0 - выключено)"`
+	BatchSize     int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" flag:"outbox-batch-size" usage:"сколько событий публиковать за один проход"`
+	// MaxAttempts - число попыток, после которого событие переходит в dead letter и перестаёт задерживать свой агрегат.
+	MaxAttempts int `yaml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS" flag:"outbox-max-attempts" usage:"попыток публикации до перевода события в dead letter"`
+	// Retention - сколько хранить опубликованные события, 0 - не удалять.
+	Retention time.Duration `yaml:"retention" env:"OUTBOX_RETENTION" flag:"outbox-retention" usage:"время хранения опубликованных событий (0 - не удалять)"`
+	LogSink   bool          `yaml:"log_sink" env:"OUTBOX_LOG_SINK" flag:"outbox-log-sink" usage:"писать опубликованные события в лог"`
+}
+
+// Webhooks - доставка доменных событий подписчикам по HTTP. Доставки создаёт relay outbox,
+// поэтому webhooks работают только с включённым outbox.relay_interval.
+type Webhooks struct {
+	// DispatchInterval - как часто отправлять доставки, 0 - webhooks выключены.
+	DispatchInterval time.Duration `yaml:"dispatch_interval" env:"WEBHOOK_DISPATCH_INTERVAL" flag:"webhook-dispatch-interval" usage:"период отправки доставок webhooks (0 - выключено)"`
+	BatchSize        int           `yaml:"batch_size" env:"WEBHOOK_BATCH_SIZE" flag:"webhook-batch-size" usage:"сколько доставок отправлять за один проход"`
+	Timeout          time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT" flag:"webhook-timeout" usage:"время ожидания ответа подписчика"`
+	// MaxAttempts - число попыток, после которого доставка переходит в dead letter.
+	MaxAttempts int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" flag:"webhook-max-attempts" usage:"попыток до перевода доставки в dead letter"`
+	BackoffBase time.Duration `yaml:"backoff_base" env:"WEBHOOK_BACKOFF_BASE" flag:"webhook-backoff-base" usage:"задержка перед первым повтором, дальше удваивается"`
+	BackoffMax  time.Duration `yaml:"backoff_max" env:"WEBHOOK_BACKOFF_MAX" flag:"webhook-backoff-max" usage:"максимальная задержка между повторами"`
+	// AllowPrivateNetworks разрешает доставки на loopback, link-local и адреса частных сетей.
+	// По умолчанию выключено, чтобы через подписку нельзя было обратиться к внутренним сервисам.
+	AllowPrivateNetworks bool `yaml:"allow_private_networks" env:"WEBHOOK_ALLOW_PRIVATE_NETWORKS" flag:"webhook-allow-private-networks" usage:"разрешить доставки на адреса внутренних сетей"`
+}
+
+type Auth struct {
+	// Enabled включено по умолчанию: без токенов API открыто любому, кто до него достучится.
+	Enabled bool `yaml:"enabled" env:"AUTH_ENABLED" flag:"auth-enabled" usage:"требовать bearer токен на запросы к API"`
+	// BootstrapToken - токен со scope admin, который сохраняется при старте, чтобы выпустить через него первые токены.
+	BootstrapToken Secret `yaml:"bootstrap_token" env:"AUTH_BOOTSTRAP_TOKEN" flag:"auth-bootstrap-token" usage:"токен администратора для выпуска первых токенов"`
+	JWT            JWT    `yaml:"jwt"`
+}
+
+// JWT - проверка JWT от SSO в дополнение к API токенам.
+type JWT struct {
+	// JWKS - путь к файлу или URL с ключами SSO; пусто - JWT не принимаются.
+	JWKS            string        `yaml:"jwks" env:"AUTH_JWT_JWKS" flag:"auth-jwt-jwks" usage:"путь к файлу или URL с JWKS для проверки JWT"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"AUTH_JWT_REFRESH_INTERVAL" flag:"auth-jwt-refresh-interval" usage:"как часто перечитывать JWKS"`
+	Issuer          string        `yaml:"issuer" env:"AUTH_JWT_ISSUER" flag:"auth-jwt-issuer" usage:"ожидаемый iss (пусто - не проверяется)"`
+	Audience        string        `yaml:"audience" env:"AUTH_JWT_AUDIENCE" flag:"auth-jwt-audience" usage:"ожидаемый aud (пусто - не проверяется)"`
+	UserClaim       string        `yaml:"user_claim" env:"AUTH_JWT_USER_CLAIM" flag:"auth-jwt-user-claim" usage:"claim с ID пользователя"`
+	RoleClaim       string        `yaml:"role_claim" env:"AUTH_JWT_ROLE_CLAIM" flag:"auth-jwt-role-claim" usage:"claim с ролью (пусто - роль из БД)"`
+	ScopeClaim      string        `yaml:"scope_claim" env:"AUTH_JWT_SCOPE_CLAIM" flag:"auth-jwt-scope-claim" usage:"claim со scopes"`
+	// DefaultScopes - scopes через пробел для JWT без ScopeClaim.
+	DefaultScopes string        `yaml:"default_scopes" env:"AUTH_JWT_DEFAULT_SCOPES" flag:"auth-jwt-default-scopes" usage:"scopes через пробел для JWT без claim со scopes"`
+	Leeway        time.Duration `yaml:"leeway" env:"AUTH_JWT_LEEWAY" flag:"auth-jwt-leeway" usage:"допустимое расхождение часов с SSO"`
+}
+
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStorePostgres = "postgres"
+)
+
+// Группы маршрутов, для которых задаются лимиты.
+const (
+	RouteGroupTeams        = "teams"
+	RouteGroupUsers        = "users"
+	RouteGroupStats        = "stats"
+	RouteGroupPullRequests = "pull_requests"
+	RouteGroupAdmin        = "admin"
+	// RouteGroupIP - лимит для каждого IP на все группы, проверяется до аутентификации.
+	RouteGroupIP = "ip"
+)
+
+// RateLimit - ограничение частоты запросов для каждого токена (или IP при выключенной аутентификации)
+// отдельно в каждой группе маршрутов. RPS - запросов в секунду в среднем, Burst - сколько запросов можно сделать подряд;
+// RPS 0 - группа без ограничения.
+type RateLimit struct {
+	Enabled bool `yaml:"enabled" env:"RATE_LIMIT_ENABLED" flag:"rate-limit-enabled" usage:"ограничивать частоту запросов"`
+	// Store - memory (у каждой реплики свои лимиты) или postgres (общие лимиты для всех реплик).
+	Store             string  `yaml:"store" env:"RATE_LIMIT_STORE" flag:"rate-limit-store" usage:"хранилище лимитов: memory, postgres"`
+	TeamsRPS          float64 `yaml:"teams_rps" env:"RATE_LIMIT_TEAMS_RPS" flag:"rate-limit-teams-rps" usage:"запросов в секунду к /teams (0 - без ограничения)"`
+	TeamsBurst        int     `yaml:"teams_burst" env:"RATE_LIMIT_TEAMS_BURST" flag:"rate-limit-teams-burst" usage:"запросов подряд к /teams"`
+	UsersRPS          float64 `yaml:"users_rps" env:"RATE_LIMIT_USERS_RPS" flag:"rate-limit-users-rps" usage:"запросов в секунду к /users (0 - без ограничения)"`
+	UsersBurst        int     `yaml:"users_burst" env:"RATE_LIMIT_USERS_BURST" flag:"rate-limit-users-burst" usage:"запросов подряд к /users"`
+	StatsRPS          float64 `yaml:"stats_rps" env:"RATE_LIMIT_STATS_RPS" flag:"rate-limit-stats-rps" usage:"запросов в секунду к /stats (0 - без ограничения)"`
+	StatsBurst        int     `yaml:"stats_burst" env:"RATE_LIMIT_STATS_BURST" flag:"rate-limit-stats-burst" usage:"запросов подряд к /stats"`
+	PullRequestsRPS   float64 `yaml:"pull_requests_rps" env:"RATE_LIMIT_PULL_REQUESTS_RPS" flag:"rate-limit-pull-requests-rps" usage:"запросов в секунду к /pullRequests (0 - без ограничения)"`
+	PullRequestsBurst int     `yaml:"pull_requests_burst" env:"RATE_LIMIT_PULL_REQUESTS_BURST" flag:"rate-limit-pull-requests-burst" usage:"запросов подряд к /pullRequests"`
+	AdminRPS          float64 `yaml:"admin_rps" env:"RATE_LIMIT_ADMIN_RPS" flag:"rate-limit-admin-rps" usage:"запросов в секунду к /admin (0 - без ограничения)"`
+	AdminBurst        int     `yaml:"admin_burst" env:"RATE_LIMIT_ADMIN_BURST" flag:"rate-limit-admin-burst" usage:"запросов подряд к /admin"`
+	// IPRPS ограничивает каждый IP до проверки токена, чтобы перебор и поток неверных токенов не доходили до хранилища.
+	IPRPS   float64 `yaml:"ip_rps" env:"RATE_LIMIT_IP_RPS" flag:"rate-limit-ip-rps" usage:"запросов в секунду с одного IP до аутентификации (0 - без ограничения)"`
+	IPBurst int     `yaml:"ip_burst" env:"RATE_LIMIT_IP_BURST" flag:"rate-limit-ip-burst" usage:"запросов подряд с одного IP до аутентификации"`
+}
+
+type Log struct {
+	Level string `yaml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"уровень логов: debug, info, warn, error"`
+}
+
+type Tracing struct {
+	Exporter string `yaml:"exporter" env:"TRACES_EXPORTER" flag:"traces-exporter" usage:"exporter трассировки: none, stdout, file, otlp"`
+	File     string `yaml:"file" env:"TRACES_FILE" flag:"traces-file" usage:"файл для exporter file"`
+}
+
+// Default возвращает конфигурацию по умолчанию.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:              8080,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   15 * time.Second,
+		},
+		Storage: Storage{
+			ConnectTimeout: 30 * time.Second,
+			Pool: Pool{
+				MaxOpenConns:    25,
+				MaxIdleConns:    25,
+				ConnMaxLifetime: 30 * time.Minute,
+				ConnMaxIdleTime: 5 * time.Minute,
+			},
+			Replica: Replica{
+				MaxStaleness:  5 * time.Second,
+				CheckInterval: 5 * time.Second,
+			},
+		},
+		Assignment: Assignment{
+			ReviewersCount:    domain.ReviewersMaxCount,
+			FairnessWindow:    domain.FairnessDefaultWindow,
+			FairnessThreshold: domain.FairnessDefaultThreshold,
+		},
+		Auth: Auth{
+			Enabled: true,
+			JWT: JWT{
+				RefreshInterval: 10 * time.Minute,
+				UserClaim:       "sub",
+				RoleClaim:       "role",
+				ScopeClaim:      "scope",
+				Leeway:          30 * time.Second,
+			},
+		},
+		RateLimit: RateLimit{
+			Store:             RateLimitStoreMemory,
+			TeamsRPS:          5,
+			TeamsBurst:        20,
+			UsersRPS:          20,
+			UsersBurst:        50,
+			StatsRPS:          5,
+			StatsBurst:        10,
+			PullRequestsRPS:   10,
+			PullRequestsBurst: 30,
+			AdminRPS:          2,
+			AdminBurst:        10,
+			IPRPS:             50,
+			IPBurst:           100,
+		},
+		Idempotency: Idempotency{
+			TTL: domain.IdempotencyDefaultTTL,
+		},
+		Scheduler: Scheduler{
+			IdempotencyPurgeInterval: time.Hour,
+		},
+		Outbox: Outbox{
+			RelayInterval: time.Second,
+			BatchSize:     100,
+			MaxAttempts:   300,
+			Retention:     7 * 24 * time.Hour,
+		},
+		Webhooks: Webhooks{
+			DispatchInterval: time.Second,
+			BatchSize:        50,
+			Timeout:          10 * time.Second,
+			MaxAttempts:      8,
+			BackoffBase:      10 * time.Second,
+			BackoffMax:       time.Hour,
+		},
+		Log: Log{
+			Level: "info",
+		},
+		Tracing: Tracing{
+			Exporter: tracing.ExporterNone,
+		},
+	}
+}
+
+// Apply применяет настройки к пулу соединений.
+func (p Pool) Apply(db *sql.DB) {
+	db.SetMaxOpenConns(p.MaxOpenConns)
+	db.SetMaxIdleConns(p.MaxIdleConns)
+	db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+}
+
+// RetryPolicy возвращает политику повторов доставок.
+func (w Webhooks) RetryPolicy() domain.WebhookRetryPolicy {
+	return domain.WebhookRetryPolicy{
+		MaxAttempts: w.MaxAttempts,
+		BaseDelay:   w.BackoffBase,
+		MaxDelay:    w.BackoffMax,
+	}
+}
+
+// Scopes возвращает DefaultScopes списком.
+func (j JWT) Scopes() []domain.Scope {
+	return domain.ScopesFromStrings(strings.Fields(j.DefaultScopes))
+}
+
+// Limits возвращает лимиты по группам маршрутов.
+func (r RateLimit) Limits() map[string]domain.RateLimit {
+	return map[string]domain.RateLimit{
+		RouteGroupTeams:        {Rate: r.TeamsRPS, Burst: r.TeamsBurst},
+		RouteGroupUsers:        {Rate: r.UsersRPS, Burst: r.UsersBurst},
+		RouteGroupStats:        {Rate: r.StatsRPS, Burst: r.StatsBurst},
+		RouteGroupPullRequests: {Rate: r.PullRequestsRPS, Burst: r.PullRequestsBurst},
+		RouteGroupAdmin:        {Rate: r.AdminRPS, Burst: r.AdminBurst},
+		RouteGroupIP:           {Rate: r.IPRPS, Burst: r.IPBurst},
+	}
+}
+
+// Proxies возвращает TrustedProxies списком.
+func (s Server) Proxies() []string {
+	return strings.Fields(s.TrustedProxies)
+}
+
+// Addr возвращает адрес HTTP сервера.
+func (s Server) Addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// StorageType возвращает хранилище: заданное явно или выбранное по схеме DSN.
+func (s Storage) StorageType() string {
+	if s.Type != "" {
+		return s.Type
+	}
+	if s.DSN.IsSQLite() {
+		return StorageSQLite
+	}
+	return StoragePostgres
+}
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки сразу.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port: must be in 1..65535, got %d", c.Server.Port)
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout: must be positive")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout: must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout: must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout: must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout: must be positive")
+	for _, proxy := range c.Server.Proxies() {
+		_, addrErr := netip.ParseAddr(proxy)
+		_, prefixErr := netip.ParsePrefix(proxy)
+		check(addrErr == nil || prefixErr == nil, "server.trusted_proxies: %q is neither IP nor CIDR", proxy)
+	}
+
+	storageType := c.Storage.StorageType()
+	check(storageType == StorageMemory || storageType == StorageSQLite || storageType == StoragePostgres,
+		"storage.type: unknown storage %q", storageType)
+	check(storageType == StorageMemory || c.Storage.DSN != "", "storage.dsn: required for %s storage", storageType)
+	check(storageType != StorageSQLite || c.Storage.DSN.IsSQLite(), "storage.dsn: sqlite storage requires %s DSN", "sqlite://")
+	check(c.Storage.ConnectTimeout >= 0, "storage.connect_timeout: must not be negative")
+	check(c.Storage.Pool.MaxOpenConns >= 0, "storage.pool.max_open_conns: must not be negative")
+	check(c.Storage.Pool.MaxIdleConns >= 0, "storage.pool.max_idle_conns: must not be negative")
+	check(c.Storage.Pool.MaxOpenConns == 0 || c.Storage.Pool.MaxIdleConns <= c.Storage.Pool.MaxOpenConns,
+		"storage.pool.max_idle_conns: must not exceed max_open_conns")
+	check(c.Storage.Pool.ConnMaxLifetime >= 0, "storage.pool.conn_max_lifetime: must not be negative")
+	check(c.Storage.Pool.ConnMaxIdleTime >= 0, "storage.pool.conn_max_idle_time: must not be negative")
+	check(c.Storage.Replica.DSN == "" || storageType == StoragePostgres, "storage.replica.dsn: replica is supported only for postgres storage")
+	check(c.Storage.Replica.MaxStaleness > 0, "storage.replica.max_staleness: must be positive")
+	check(c.Storage.Replica.CheckInterval > 0, "storage.replica.check_interval: must be positive")
+
+	check(c.Assignment.ReviewersCount > 0, "assignment.reviewers_count: must be positive")
+	check(c.Assignment.FairnessWindow > 0, "assignment.fairness_window: must be positive")
+	check(c.Assignment.FairnessThreshold > 0, "assignment.fairness_threshold: must be positive")
+	check(c.Idempotency.TTL > 0, "idempotency.ttl: must be positive")
+	check(c.Auth.JWT.JWKS == "" || c.Auth.Enabled, "auth.jwt.jwks: requires auth.enabled")
+	check(c.Auth.JWT.RefreshInterval > 0, "auth.jwt.refresh_interval: must be positive")
+	check(c.Auth.JWT.Leeway >= 0, "auth.jwt.leeway: must not be negative")
+	check(c.Auth.JWT.JWKS == "" || c.Auth.JWT.UserClaim != "", "auth.jwt.user_claim: required with auth.jwt.jwks")
+	for _, scope := range c.Auth.JWT.Scopes() {
+		check(slices.Contains(domain.KnownScopes(), scope), "auth.jwt.default_scopes: unknown scope %q", scope)
+	}
+	check(c.RateLimit.Store == RateLimitStoreMemory || c.RateLimit.Store == RateLimitStorePostgres,
+		"rate_limit.store: unknown store %q", c.RateLimit.Store)
+	check(!c.RateLimit.Enabled || c.RateLimit.Store != RateLimitStorePostgres || storageType == StoragePostgres,
+		"rate_limit.store: postgres store requires postgres storage")
+	limits := c.RateLimit.Limits()
+	for _, group := range slices.Sorted(maps.Keys(limits)) {
+		limit := limits[group]
+		check(limit.Rate >= 0, "rate_limit.%s_rps: must not be negative", group)
+		check(limit.IsUnlimited() || limit.Burst >= 1, "rate_limit.%s_burst: must be at least 1", group)
+	}
+	check(c.Scheduler.IdempotencyPurgeInterval >= 0, "scheduler.idempotency_purge_interval: must not be negative")
+	check(c.Outbox.RelayInterval >= 0, "outbox.relay_interval: must not be negative")
+	check(c.Outbox.BatchSize > 0, "outbox.batch_size: must be positive")
+	check(c.Outbox.MaxAttempts > 0, "outbox.max_attempts: must be positive")
+	check(c.Outbox.Retention >= 0, "outbox.retention: must not be negative")
+	check(c.Webhooks.DispatchInterval >= 0, "webhooks.dispatch_interval: must not be negative")
+	check(c.Webhooks.DispatchInterval == 0 || c.Outbox.RelayInterval > 0, "webhooks.dispatch_interval: requires outbox.relay_interval")
+	check(c.Webhooks.BatchSize > 0, "webhooks.batch_size: must be positive")
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout: must be positive")
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts: must be positive")
+	check(c.Webhooks.BackoffBase > 0, "webhooks.backoff_base: must be positive")
+	check(c.Webhooks.BackoffMax >= c.Webhooks.BackoffBase, "webhooks.backoff_max: must not be less than backoff_base")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level: unknown level %q", c.Log.Level)
+	switch c.Tracing.Exporter {
+	case "", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	case tracing.ExporterFile:
+		check(c.Tracing.File != "", "tracing.file: required for file exporter")
+	default:
+		check(false, "tracing.exporter: unknown exporter %q", c.Tracing.Exporter)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv - переменная окружения с путём к YAML файлу конфигурации.
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Load собирает конфигурацию из значений по умолчанию, YAML файла, окружения и флагов args и проверяет её.
+// Разбор флагов останавливается на первом аргументе без "-", оставшиеся аргументы (команда) возвращаются.
+func Load(args []string, lookupEnv func(string) (string, bool), output io.Writer) (Config, []string, error) {
+	cfg := Default()
+	fields := leafFields(reflect.ValueOf(&cfg).Elem())
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(output)
+	configFile, _ := lookupEnv(ConfigFileEnv)
+	fs.StringVar(&configFile, "config", configFile, "путь к YAML файлу конфигурации (или "+ConfigFileEnv+")")
+
+	// флаги применяются последними, поэтому при разборе только запоминаются
+	flags := make(map[string]string)
+	for _, f := range fields {
+		if f.flag == "" {
+			continue
+		}
+		usage := f.usage
+		if f.env != "" {
+			usage += " (" + f.env + ")"
+		}
+		name := f.flag
+		set := func(value string) error {
+			flags[name] = value
+			return nil
+		}
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(name, usage, set)
+			continue
+		}
+		fs.Func(name, usage, set)
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	if configFile != "" {
+		if err := loadFile(&cfg, configFile); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	var errs []error
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		// пустая переменная считается незаданной, как раньше с os.Getenv
+		if value, ok := lookupEnv(f.env); ok && value != "" {
+			if err := f.set(value); err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", f.env, err))
+			}
+		}
+	}
+	for _, f := range fields {
+		if value, ok := flags[f.flag]; ok {
+			if err := f.set(value); err != nil {
+				errs = append(errs, fmt.Errorf("flag -%s: %w", f.flag, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// Redacted возвращает конфигурацию в YAML со скрытыми секретами.
+func (c Config) Redacted() string {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return fmt.Sprintf("encode config: %s", err)
+	}
+
+	return buf.String()
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err = dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// field - настройка конфигурации с её именами в окружении и флагах.
+type field struct {
+	value reflect.Value
+	env   string
+	flag  string
+	usage string
+}
+
+// leafFields обходит вложенные структуры конфигурации и возвращает настройки с тегами env и flag.
+func leafFields(v reflect.Value) []field {
+	fields := make([]field, 0, v.NumField())
+	for i := range v.NumField() {
+		sf := v.Type().Field(i)
+		if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, leafFields(v.Field(i))...)
+			continue
+		}
+		fields = append(fields, field{
+			value: v.Field(i),
+			env:   sf.Tag.Get("env"),
+			flag:  sf.Tag.Get("flag"),
+			usage: sf.Tag.Get("usage"),
+		})
+	}
+
+	return fields
+}
+
+func (f field) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch f.value.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(n)
+	case reflect.Float64:
+		x, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		f.value.SetFloat(x)
+	default:
+		return fmt.Errorf("unsupported type %s", f.value.Type())
+	}
+
+	return nil
+}
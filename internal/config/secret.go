@@ -0,0 +1,56 @@
+package config
+
+import (
+	"avito-tech-go-task/internal/clients/sqlite"
+	"log/slog"
+	"net/url"
+	"regexp"
+)
+
+// redacted заменяет секреты при выводе, как url.URL.Redacted.
+const redacted = "xxxxx"
+
+// Secret - значение, которое не должно попадать в логи и вывод конфигурации.
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// DSN - строка подключения к БД, при выводе из неё скрывается пароль.
+type DSN string
+
+// passwordParam - пароль в DSN формата "key=value" (host=... password=...) или в параметрах URL.
+var passwordParam = regexp.MustCompile(`(password=)[^&\s]+`)
+
+func (d DSN) String() string {
+	u, err := url.Parse(string(d))
+	if err != nil || u.Scheme == "" {
+		return passwordParam.ReplaceAllString(string(d), "${1}"+redacted)
+	}
+	return passwordParam.ReplaceAllString(u.Redacted(), "${1}"+redacted)
+}
+
+// IsSQLite сообщает, указывает ли DSN на SQLite.
+func (d DSN) IsSQLite() bool {
+	return sqlite.IsDSN(string(d))
+}
+
+func (d DSN) MarshalYAML() (any, error) {
+	return d.String(), nil
+}
+
+func (d DSN) LogValue() slog.Value {
+	return slog.StringValue(d.String())
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrAuthorRequired = errors.New("author_id is required when the request is not bound to a user")
+	ErrAuthorMismatch = errors.New("author_id must match the authenticated user")
+)
+
+// Actor - кто выполняет запрос. Изменения в хранилище помечаются его токеном.
+// UserID - пользователь, к которому привязан токен; пустой у сервисных токенов.
+// Role - роль из проверенного JWT; пустая, если роль берётся из хранилища.
+type Actor struct {
+	TokenID string
+	UserID  string
+	Role    Role
+	Scopes  []Scope
+}
+
+// SystemActor - сам сервис: запросы при выключенной аутентификации, команды CLI и фоновые задачи.
+// Токена у него нет, поэтому изменения не помечаются, а scope admin снимает проверку ролей.
+var SystemActor = Actor{Scopes: []Scope{ScopeAdmin}}
+
+type actorKey struct{}
+
+// HasScope сообщает, разрешён ли автору запроса доступ со scope. С admin разрешён везде.
+func (a Actor) HasScope(scope Scope) bool {
+	return hasScope(a.Scopes, scope)
+}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext возвращает автора запроса; false, если точка входа его не задала.
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
+
+// ActingUserID возвращает пользователя, от имени которого выполняется действие.
+// Если запрос привязан к пользователю, это всегда он, и requested должен быть пустым или совпадать с ним.
+// Иначе (сервисный токен, выключенная аутентификация) используется requested из тела запроса.
+func ActingUserID(ctx context.Context, requested string) (string, error) {
+	actor, ok := ActorFromContext(ctx)
+	if ok && actor.UserID != "" {
+		if requested != "" && requested != actor.UserID {
+			return "", ErrAuthorMismatch
+		}
+		return actor.UserID, nil
+	}
+
+	if requested == "" {
+		return "", ErrAuthorRequired
+	}
+	return requested, nil
+}
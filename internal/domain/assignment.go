@@ -0,0 +1,34 @@
+package domain
+
+const (
+	AssignmentOperationCreate         AssignmentOperation = "create"
+	AssignmentOperationReassign       AssignmentOperation = "reassign"
+	AssignmentOperationDeactivateTeam AssignmentOperation = "deactivate_team"
+
+	AssignmentSuccess     AssignmentOutcome = "success"
+	AssignmentPartial     AssignmentOutcome = "partial"
+	AssignmentNoCandidate AssignmentOutcome = "no_candidate"
+)
+
+type AssignmentOperation string
+
+// AssignmentOutcome - результат подбора ревьюеров: все нужные, часть или ни одного.
+type AssignmentOutcome string
+
+// TeamLoad - число открытых PR авторов команды и активных ревьюеров в ней.
+type TeamLoad struct {
+	TeamName         string
+	OpenPullRequests int64
+	ActiveReviewers  int64
+}
+
+func NewAssignmentOutcome(wanted, assigned int) AssignmentOutcome {
+	switch {
+	case assigned == 0:
+		return AssignmentNoCandidate
+	case assigned < wanted:
+		return AssignmentPartial
+	default:
+		return AssignmentSuccess
+	}
+}
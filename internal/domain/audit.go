@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	AuditEntityTeam        AuditEntity = "team"
+	AuditEntityUser        AuditEntity = "user"
+	AuditEntityPullRequest AuditEntity = "pull_request"
+	AuditEntityToken       AuditEntity = "token"
+	AuditEntityWebhook     AuditEntity = "webhook"
+
+	AuditTeamSave        AuditAction = "team.save"
+	AuditTeamDeactivate  AuditAction = "team.deactivate"
+	AuditUserSetIsActive AuditAction = "user.set_is_active"
+	AuditUserSetRole     AuditAction = "user.set_role"
+	AuditPRCreate        AuditAction = "pull_request.create"
+	AuditPRApprove       AuditAction = "pull_request.approve"
+	AuditPRMerge         AuditAction = "pull_request.merge"
+	AuditPRReassign      AuditAction = "pull_request.reassign"
+	AuditTokenIssue      AuditAction = "token.issue"
+	AuditTokenRevoke     AuditAction = "token.revoke"
+	AuditWebhookCreate   AuditAction = "webhook.create"
+	AuditWebhookDelete   AuditAction = "webhook.delete"
+
+	AuditDefaultLimit uint64 = 50
+	AuditMaxLimit     uint64 = 500
+)
+
+var (
+	ErrInvalidAuditQuery  = errors.New("audit query is not valid")
+	ErrInvalidAuditCursor = errors.New("audit cursor is not valid")
+)
+
+var (
+	knownAuditEntities = []AuditEntity{
+		AuditEntityTeam, AuditEntityUser, AuditEntityPullRequest, AuditEntityToken, AuditEntityWebhook,
+	}
+	knownAuditActions = []AuditAction{
+		AuditTeamSave, AuditTeamDeactivate, AuditUserSetIsActive, AuditUserSetRole,
+		AuditPRCreate, AuditPRApprove, AuditPRMerge, AuditPRReassign,
+		AuditTokenIssue, AuditTokenRevoke, AuditWebhookCreate, AuditWebhookDelete,
+	}
+)
+
+// AuditEntity - тип изменённой сущности.
+type AuditEntity string
+
+// AuditAction - изменение, записанное в журнал аудита.
+type AuditAction string
+
+// AuditEntry - запись журнала аудита. Пишется в той же транзакции, что и само изменение, и больше не меняется.
+// ActorID - токен автора запроса (jwt:<user_id> для JWT), пустой при выключенной аутентификации.
+// Before и After - состояние сущности в JSON до и после изменения; Before пустой у созданных сущностей.
+type AuditEntry struct {
+	ID          int64
+	CreatedAt   time.Time
+	ActorID     string
+	ActorUserID string
+	Action      AuditAction
+	EntityType  AuditEntity
+	EntityID    string
+	Before      json.RawMessage
+	After       json.RawMessage
+	RequestID   string
+}
+
+// AuditCursor - ID последней отданной записи для keyset-пагинации.
+type AuditCursor struct {
+	ID int64 `json:"id"`
+}
+
+// AuditQuery - фильтры журнала аудита, пустые поля не фильтруют. Записи отдаются от новых к старым.
+type AuditQuery struct {
+	ActorID     string
+	ActorUserID string
+	Action      AuditAction
+	EntityType  AuditEntity
+	EntityID    string
+	RequestID   string
+	// From и To - период [From, To); нулевое время - без ограничения.
+	From   time.Time
+	To     time.Time
+	Limit  uint64
+	Cursor *AuditCursor
+}
+
+type AuditPage struct {
+	Items      []AuditEntry
+	NextCursor string
+}
+
+// NewAuditEntry - запись об изменении сущности. before и after сериализуются в JSON, nil - состояния нет.
+// Автор и request ID заполняет вызывающий.
+func NewAuditEntry(action AuditAction, entityType AuditEntity, entityID string, before, after any) (AuditEntry, error) {
+	entry := AuditEntry{
+		CreatedAt:  time.Now().UTC(),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+	}
+
+	var err error
+	if before != nil {
+		if entry.Before, err = json.Marshal(before); err != nil {
+			return AuditEntry{}, fmt.Errorf("marshal audit before: %w", err)
+		}
+	}
+	if after != nil {
+		if entry.After, err = json.Marshal(after); err != nil {
+			return AuditEntry{}, fmt.Errorf("marshal audit after: %w", err)
+		}
+	}
+
+	return entry, nil
+}
+
+func NewAuditQuery(q AuditQuery, cursor string) (*AuditQuery, error) {
+	if q.Limit == 0 {
+		q.Limit = AuditDefaultLimit
+	}
+
+	switch {
+	case q.Limit > AuditMaxLimit:
+		return nil, ErrInvalidAuditQuery
+	case q.Action != "" && !slices.Contains(knownAuditActions, q.Action):
+		return nil, ErrInvalidAuditQuery
+	case q.EntityType != "" && !slices.Contains(knownAuditEntities, q.EntityType):
+		return nil, ErrInvalidAuditQuery
+	case !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To):
+		return nil, ErrInvalidAuditQuery
+	}
+
+	if cursor != "" {
+		c, err := DecodeAuditCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		q.Cursor = &c
+	}
+
+	return &q, nil
+}
+
+// Match сообщает, подходит ли запись под фильтры и курсор q. Нужна хранилищам, которые фильтруют журнал в памяти.
+func (q AuditQuery) Match(e AuditEntry) bool {
+	switch {
+	case q.ActorID != "" && e.ActorID != q.ActorID:
+		return false
+	case q.ActorUserID != "" && e.ActorUserID != q.ActorUserID:
+		return false
+	case q.Action != "" && e.Action != q.Action:
+		return false
+	case q.EntityType != "" && e.EntityType != q.EntityType:
+		return false
+	case q.EntityID != "" && e.EntityID != q.EntityID:
+		return false
+	case q.RequestID != "" && e.RequestID != q.RequestID:
+		return false
+	case !q.From.IsZero() && e.CreatedAt.Before(q.From):
+		return false
+	case !q.To.IsZero() && !e.CreatedAt.Before(q.To):
+		return false
+	case q.Cursor != nil && e.ID >= q.Cursor.ID:
+		return false
+	}
+	return true
+}
+
+// NewAuditPage собирает страницу из записей, выбранных с запасом в одну (Limit+1).
+func NewAuditPage(items []AuditEntry, q AuditQuery) AuditPage {
+	if uint64(len(items)) <= q.Limit {
+		return AuditPage{Items: items}
+	}
+
+	items = items[:q.Limit]
+	return AuditPage{
+		Items:      items,
+		NextCursor: AuditCursor{ID: items[len(items)-1].ID}.Encode(),
+	}
+}
+
+func (c AuditCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeAuditCursor(s string) (AuditCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return AuditCursor{}, ErrInvalidAuditCursor
+	}
+
+	var c AuditCursor
+	if err = json.Unmarshal(b, &c); err != nil || c.ID <= 0 {
+		return AuditCursor{}, ErrInvalidAuditCursor
+	}
+
+	return c, nil
+}
+
+func (e *AuditEntry) ToJSON() model.AuditEntry {
+	return model.AuditEntry{
+		ID:          e.ID,
+		CreatedAt:   e.CreatedAt,
+		ActorID:     e.ActorID,
+		ActorUserID: e.ActorUserID,
+		Action:      string(e.Action),
+		EntityType:  string(e.EntityType),
+		EntityID:    e.EntityID,
+		Before:      e.Before,
+		After:       e.After,
+		RequestID:   e.RequestID,
+	}
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventPRCreated        EventType = "pr.created"
+	EventReviewerAssigned EventType = "pr.reviewer_assigned"
+	EventReviewerRemoved  EventType = "pr.reviewer_removed"
+	EventPRApproved       EventType = "pr.approved"
+	EventPRMerged         EventType = "pr.merged"
+	EventUserDeactivated  EventType = "user.deactivated"
+	EventTeamDeactivated  EventType = "team.deactivated"
+
+	AggregatePullRequest AggregateType = "pull_request"
+	AggregateUser        AggregateType = "user"
+	AggregateTeam        AggregateType = "team"
+
+	EventPublished EventPublishOutcome = "published"
+	EventFailed    EventPublishOutcome = "failed"
+)
+
+// EventType - тип доменного события для внешних систем.
+type EventType string
+
+// AggregateType - сущность, к которой относится событие. События одной сущности публикуются по порядку.
+type AggregateType string
+
+// EventPublishOutcome - результат публикации события в метриках outbox.
+type EventPublishOutcome string
+
+// Event - доменное событие. В отличие от ReviewEvent, из которого считается статистика,
+// Event предназначено внешним системам: репозитории пишут его в outbox в транзакции изменения,
+// а relay публикует в подключённые sinks.
+// ID - порядковый номер в outbox, Attempts - число неудачных попыток публикации.
+type Event struct {
+	ID            int64
+	Type          EventType
+	AggregateType AggregateType
+	AggregateID   string
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+	RequestID     string
+	Attempts      int
+}
+
+func newEvent(eventType EventType, aggregateType AggregateType, aggregateID string, payload any) Event {
+	b, _ := json.Marshal(payload)
+	return Event{
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       b,
+		OccurredAt:    time.Now().UTC(),
+	}
+}
+
+// NewPRCreatedEvents возвращает создание PR и назначение каждого из его ревьюеров.
+func NewPRCreatedEvents(pr PullRequest) []Event {
+	events := []Event{newEvent(EventPRCreated, AggregatePullRequest, pr.ID, pr.ToJSON())}
+	return append(events, NewReviewerEvents(NewReviewEvents(pr.ID, ReviewEventAssigned, pr.ReviewersIDs...)...)...)
+}
+
+func NewPRApprovedEvent(prID, reviewerID string) Event {
+	return newEvent(EventPRApproved, AggregatePullRequest, prID, model.ReviewerEvent{PullRequestID: prID, ReviewerID: reviewerID})
+}
+
+func NewPRMergedEvent(pr PullRequest) Event {
+	return newEvent(EventPRMerged, AggregatePullRequest, pr.ID, pr.ToJSON())
+}
+
+// NewReviewerEvents переводит назначения и снятия ревьюеров из событий статистики в доменные события.
+// События мержа и одобрения пропускаются: о них сообщают pr.merged и pr.approved.
+func NewReviewerEvents(reviewEvents ...ReviewEvent) []Event {
+	events := make([]Event, 0, len(reviewEvents))
+	for _, e := range reviewEvents {
+		payload := model.ReviewerEvent{PullRequestID: e.PullRequestID, ReviewerID: e.UserID}
+		switch e.Type {
+		case ReviewEventAssigned:
+			events = append(events, newEvent(EventReviewerAssigned, AggregatePullRequest, e.PullRequestID, payload))
+		case ReviewEventUnassigned:
+			events = append(events, newEvent(EventReviewerRemoved, AggregatePullRequest, e.PullRequestID, payload))
+		}
+	}
+	return events
+}
+
+func NewUserDeactivatedEvent(userID, teamName string) Event {
+	return newEvent(EventUserDeactivated, AggregateUser, userID, model.UserDeactivatedEvent{UserID: userID, TeamName: teamName})
+}
+
+// NewTeamDeactivatedEvents возвращает деактивацию каждого участника и затем самой команды.
+// Если активных участников не было, команда уже деактивирована и событий нет.
+func NewTeamDeactivatedEvents(teamName string, userIDs []string) []Event {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	events := make([]Event, 0, len(userIDs)+1)
+	for _, userID := range userIDs {
+		events = append(events, NewUserDeactivatedEvent(userID, teamName))
+	}
+	return append(events, newEvent(EventTeamDeactivated, AggregateTeam, teamName, model.TeamDeactivatedEvent{
+		TeamName: teamName,
+		UserIDs:  userIDs,
+	}))
+}
+
+// Key - ключ порядка: события с одним ключом публикуются в порядке ID.
+func (e *Event) Key() string {
+	return string(e.AggregateType) + ":" + e.AggregateID
+}
+
+func (e *Event) ToJSON() model.Event {
+	return model.Event{
+		ID:            e.ID,
+		Type:          string(e.Type),
+		AggregateType: string(e.AggregateType),
+		AggregateID:   e.AggregateID,
+		OccurredAt:    e.OccurredAt,
+		RequestID:     e.RequestID,
+		Payload:       e.Payload,
+	}
+}
@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	WorkloadBalanced   WorkloadStatus = "balanced"
+	WorkloadOverloaded WorkloadStatus = "overloaded"
+	WorkloadUnderused  WorkloadStatus = "underused"
+
+	FairnessDefaultThreshold = 0.5
+	FairnessDefaultWindow    = 7 * 24 * time.Hour
+)
+
+var ErrInvalidFairnessThreshold = errors.New("fairness threshold must be in (0, 10]")
+
+type WorkloadStatus string
+
+// FairnessQuery - запрос отчёта о распределении назначений за период [From, To).
+// Threshold - допустимое относительное отклонение доли участника от равной (0.5 = ±50%).
+type FairnessQuery struct {
+	From      time.Time
+	To        time.Time
+	TeamName  string
+	Threshold float64
+}
+
+// MemberWorkload - сколько раз участника назначали ревьюером за период.
+type MemberWorkload struct {
+	UserID        string
+	TeamName      string
+	Assigned      int64
+	Share         float64
+	ExpectedShare float64
+	Deviation     float64
+	Status        WorkloadStatus
+}
+
+type TeamFairness struct {
+	TeamName      string
+	TotalAssigned int64
+	MaxDeviation  float64
+	Members       []MemberWorkload
+}
+
+func NewFairnessQuery(from, to time.Time, teamName string, threshold float64) (*FairnessQuery, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidStatsPeriod
+	}
+	if threshold == 0 {
+		threshold = FairnessDefaultThreshold
+	}
+	if threshold < 0 || threshold > 10 {
+		return nil, ErrInvalidFairnessThreshold
+	}
+
+	return &FairnessQuery{
+		From:      from,
+		To:        to,
+		TeamName:  teamName,
+		Threshold: threshold,
+	}, nil
+}
+
+// NewFairnessReport сравнивает долю назначений каждого участника с равной долей в его команде.
+// workloads должны быть отсортированы по команде.
+func NewFairnessReport(workloads []MemberWorkload, threshold float64) []TeamFairness {
+	report := make([]TeamFairness, 0)
+	for start := 0; start < len(workloads); {
+		end := start
+		for end < len(workloads) && workloads[end].TeamName == workloads[start].TeamName {
+			end++
+		}
+		report = append(report, newTeamFairness(workloads[start].TeamName, workloads[start:end], threshold))
+		start = end
+	}
+
+	return report
+}
+
+func newTeamFairness(teamName string, members []MemberWorkload, threshold float64) TeamFairness {
+	team := TeamFairness{
+		TeamName: teamName,
+		Members:  make([]MemberWorkload, 0, len(members)),
+	}
+	for _, m := range members {
+		team.TotalAssigned += m.Assigned
+	}
+
+	expected := 1 / float64(len(members))
+	for _, m := range members {
+		m.ExpectedShare = expected
+		m.Status = WorkloadBalanced
+		if team.TotalAssigned > 0 {
+			m.Share = float64(m.Assigned) / float64(team.TotalAssigned)
+			m.Deviation = m.Share/expected - 1
+		}
+
+		switch {
+		case m.Deviation > threshold:
+			m.Status = WorkloadOverloaded
+		case m.Deviation < -threshold:
+			m.Status = WorkloadUnderused
+		}
+
+		team.MaxDeviation = math.Max(team.MaxDeviation, math.Abs(m.Deviation))
+		team.Members = append(team.Members, m)
+	}
+
+	return team
+}
+
+// CountByStatus возвращает количество участников команды с каждым статусом нагрузки.
+func (t *TeamFairness) CountByStatus() map[WorkloadStatus]int {
+	counts := map[WorkloadStatus]int{
+		WorkloadBalanced:   0,
+		WorkloadOverloaded: 0,
+		WorkloadUnderused:  0,
+	}
+	for _, m := range t.Members {
+		counts[m.Status]++
+	}
+
+	return counts
+}
+
+func (m *MemberWorkload) ToJSON() model.MemberWorkload {
+	return model.MemberWorkload{
+		UserID:        m.UserID,
+		Assigned:      m.Assigned,
+		Share:         m.Share,
+		ExpectedShare: m.ExpectedShare,
+		Deviation:     m.Deviation,
+		Status:        string(m.Status),
+	}
+}
+
+func (t *TeamFairness) ToJSON() model.TeamFairness {
+	members := make([]model.MemberWorkload, 0, len(t.Members))
+	for _, m := range t.Members {
+		members = append(members, m.ToJSON())
+	}
+
+	return model.TeamFairness{
+		TeamName:      t.TeamName,
+		TotalAssigned: t.TotalAssigned,
+		MaxDeviation:  t.MaxDeviation,
+		Members:       members,
+	}
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	IdempotencyDefaultTTL = 24 * time.Hour
+	// IdempotencyLockTimeout - сколько ключ считается занятым запросом, который ещё не получил ответ.
+	// Если обработчик упал, не сохранив ответ, ключ освободится по истечении этого времени.
+	IdempotencyLockTimeout = time.Minute
+	IdempotencyKeyMaxLen   = 255
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key is already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is in progress")
+	ErrIdempotencyKeyInvalid    = errors.New("idempotency key is not valid")
+)
+
+// IdempotencyRecord - первый ответ на запрос с заголовком Idempotency-Key.
+// Ключ действует в пределах токена Owner: запрос другого клиента с тем же ключом не получит сохранённый ответ.
+// Пока запрос обрабатывается, StatusCode равен 0.
+type IdempotencyRecord struct {
+	Key          string
+	Owner        string
+	Endpoint     string
+	RequestHash  string
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+func NewIdempotencyRecord(key, owner, endpoint, requestHash string) (*IdempotencyRecord, error) {
+	if key == "" || len(key) > IdempotencyKeyMaxLen {
+		return nil, ErrIdempotencyKeyInvalid
+	}
+
+	now := time.Now()
+	return &IdempotencyRecord{
+		Key:         key,
+		Owner:       owner,
+		Endpoint:    endpoint,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(IdempotencyLockTimeout),
+	}, nil
+}
+
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.StatusCode != 0
+}
+
+// Check проверяет, можно ли вернуть сохранённый ответ на повторный запрос с телом requestHash.
+func (r *IdempotencyRecord) Check(requestHash string) error {
+	if r.RequestHash != requestHash {
+		return ErrIdempotencyKeyReused
+	}
+	if !r.IsCompleted() {
+		return ErrIdempotencyKeyInProgress
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"avito-tech-go-task/internal/infrastructure/http/model"
 	"errors"
 	"math/rand"
+	"slices"
 	"time"
 )
 
@@ -19,6 +20,7 @@ var (
 	ErrReviewerNotAssigned = errors.New("reviewer is not assigned to this PR")
 	ErrPRExists            = errors.New("PR already exists")
 	ErrPRNotFound          = errors.New("PR not found")
+	ErrPRVersionConflict   = errors.New("PR was modified by another request")
 )
 
 type PRStatus string
@@ -34,6 +36,22 @@ type PullRequest struct {
 	Status       PRStatus
 	ReviewersIDs []string
 	MergedAt     time.Time
+	CreatedAt    time.Time
+	// Version увеличивается при каждом изменении PR и используется для оптимистичной блокировки.
+	Version int64
+}
+
+// PRVersionConflictError - PR изменился с момента чтения, Current - его актуальное состояние.
+type PRVersionConflictError struct {
+	Current PullRequest
+}
+
+func (e *PRVersionConflictError) Error() string {
+	return ErrPRVersionConflict.Error()
+}
+
+func (e *PRVersionConflictError) Unwrap() error {
+	return ErrPRVersionConflict
 }
 
 func NewPullRequest(prID, name, authorID string, reviewersIDs []string) (*PullRequest, error) {
@@ -47,10 +65,12 @@ func NewPullRequest(prID, name, authorID string, reviewersIDs []string) (*PullRe
 		AuthorID:     authorID,
 		Status:       PRStatusOpen,
 		ReviewersIDs: reviewersIDs,
+		CreatedAt:    time.Now(),
+		Version:      1,
 	}, nil
 }
 
-func NewPullRequestFromStorage(prID, name, authorID string, status PRStatus, reviewersIDs []string, mergedAt time.Time) PullRequest {
+func NewPullRequestFromStorage(prID, name, authorID string, status PRStatus, reviewersIDs []string, mergedAt, createdAt time.Time, version int64) PullRequest {
 	return PullRequest{
 		ID:           prID,
 		Name:         name,
@@ -58,9 +78,18 @@ func NewPullRequestFromStorage(prID, name, authorID string, status PRStatus, rev
 		Status:       status,
 		ReviewersIDs: reviewersIDs,
 		MergedAt:     mergedAt,
+		CreatedAt:    createdAt,
+		Version:      version,
 	}
 }
 
+// Clone копирует PR вместе со списком ревьюеров, который ReassignReviewer меняет на месте.
+func (pr *PullRequest) Clone() PullRequest {
+	clone := *pr
+	clone.ReviewersIDs = slices.Clone(pr.ReviewersIDs)
+	return clone
+}
+
 func (pr *PullRequest) IsOpen() bool {
 	return pr.Status == PRStatusOpen
 }
@@ -72,6 +101,17 @@ func (pr *PullRequest) IsMerged() bool {
 func (pr *PullRequest) SetMergedStatus() {
 	pr.Status = PRStatusMerged
 	pr.MergedAt = time.Now()
+	pr.Version++
+}
+
+// CheckVersion проверяет, что клиент изменяет ту версию PR, которую прочитал.
+// expected = 0 - клиент не передал версию, проверка не нужна.
+func (pr *PullRequest) CheckVersion(expected int64) error {
+	if expected != 0 && expected != pr.Version {
+		return &PRVersionConflictError{Current: *pr}
+	}
+
+	return nil
 }
 
 func (pr *PullRequest) GetReviewerIndex(reviewerID string) (index int64, exist bool) {
@@ -83,6 +123,18 @@ func (pr *PullRequest) GetReviewerIndex(reviewerID string) (index int64, exist b
 	return -1, false
 }
 
+// CheckApprove проверяет, что ревьюер может одобрить PR: PR открыт и ревьюер на него назначен.
+func (pr *PullRequest) CheckApprove(reviewerID string) error {
+	if pr.IsMerged() {
+		return ErrPRMerged
+	}
+	if _, ok := pr.GetReviewerIndex(reviewerID); !ok {
+		return ErrReviewerNotAssigned
+	}
+
+	return nil
+}
+
 func (pr *PullRequest) ReassignReviewer(oldReviewerIndex int64, candidatesForReview []string) (string, error) {
 	if pr.IsMerged() {
 		return "", ErrPRMerged
@@ -96,6 +148,7 @@ func (pr *PullRequest) ReassignReviewer(oldReviewerIndex int64, candidatesForRev
 	randomReviewerID := candidatesForReview[randomInt]
 
 	pr.ReviewersIDs[oldReviewerIndex] = randomReviewerID
+	pr.Version++
 
 	return randomReviewerID, nil
 }
@@ -108,6 +161,8 @@ func (pr *PullRequest) ToJSON() model.PullRequest {
 		Status:            pr.Status.String(),
 		AssignedReviewers: pr.ReviewersIDs,
 		MergedAt:          pr.MergedAt,
+		CreatedAt:         pr.CreatedAt,
+		Version:           pr.Version,
 	}
 }
 
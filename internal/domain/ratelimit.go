@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	RateLimitAllowed  RateLimitOutcome = "allowed"
+	RateLimitRejected RateLimitOutcome = "rejected"
+	// RateLimitError - хранилище лимитов недоступно, запрос пропущен без проверки.
+	RateLimitError RateLimitOutcome = "error"
+)
+
+// RateLimitOutcome - решение по запросу в метриках ограничения частоты.
+type RateLimitOutcome string
+
+// RateLimit - token bucket: Burst запросов подряд, затем Rate запросов в секунду. Rate 0 - без ограничения.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l RateLimit) IsUnlimited() bool {
+	return l.Rate <= 0
+}
+
+// RateBucket - состояние token bucket автора запроса на момент UpdatedAt.
+type RateBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// RateDecision - результат проверки лимита. RetryAfter - через сколько появится токен, если запрос отклонён.
+type RateDecision struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// NewRateBucket возвращает полный bucket: новый автор запроса может сразу сделать Burst запросов.
+func NewRateBucket(limit RateLimit, now time.Time) RateBucket {
+	return RateBucket{Tokens: float64(limit.Burst), UpdatedAt: now}
+}
+
+// IsFull - наполнился ли bucket к моменту now; такой bucket не отличается от нового.
+func (b RateBucket) IsFull(limit RateLimit, now time.Time) bool {
+	return b.Tokens+now.Sub(b.UpdatedAt).Seconds()*limit.Rate >= float64(limit.Burst)
+}
+
+// Take пополняет bucket за время с UpdatedAt и забирает из него токен на запрос, если токен есть.
+func (b RateBucket) Take(limit RateLimit, now time.Time) (RateBucket, RateDecision) {
+	// часы реплик могут расходиться, bucket из будущего не пополняется
+	elapsed := max(now.Sub(b.UpdatedAt), 0)
+	tokens := math.Min(float64(limit.Burst), b.Tokens+elapsed.Seconds()*limit.Rate)
+	bucket := RateBucket{Tokens: tokens, UpdatedAt: now}
+
+	if tokens < 1 {
+		wait := time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+		return bucket, RateDecision{RetryAfter: wait}
+	}
+
+	bucket.Tokens--
+	return bucket, RateDecision{Allowed: true, Remaining: int(bucket.Tokens)}
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	ReviewEventAssigned   ReviewEventType = "ASSIGNED"
+	ReviewEventUnassigned ReviewEventType = "UNASSIGNED"
+	ReviewEventMerged     ReviewEventType = "MERGED"
+	ReviewEventApproved   ReviewEventType = "APPROVED"
+)
+
+// Причины снятия ревьюера: в reassigned_away статистики входят только переназначения.
+const (
+	ReviewReasonReassigned      ReviewEventReason = "REASSIGNED"
+	ReviewReasonUserDeactivated ReviewEventReason = "USER_DEACTIVATED"
+	ReviewReasonTeamDeactivated ReviewEventReason = "TEAM_DEACTIVATED"
+)
+
+type ReviewEventType string
+
+func (t ReviewEventType) String() string {
+	return string(t)
+}
+
+type ReviewEventReason string
+
+func (r ReviewEventReason) String() string {
+	return string(r)
+}
+
+// ReviewEvent - факт назначения, снятия ревьюера, одобрения или мержа PR.
+// Статистика в user_review_stats выводится из истории этих событий, одобрение на неё не влияет.
+type ReviewEvent struct {
+	PullRequestID string
+	UserID        string
+	Type          ReviewEventType
+	// Reason - причина снятия, задаётся только для UNASSIGNED
+	Reason ReviewEventReason
+	// TeamName - команда ревьюера на момент события, её записывает хранилище
+	TeamName  string
+	CreatedAt time.Time
+}
+
+func NewReviewEvent(prID, userID string, eventType ReviewEventType) *ReviewEvent {
+	return &ReviewEvent{
+		PullRequestID: prID,
+		UserID:        userID,
+		Type:          eventType,
+		CreatedAt:     time.Now(),
+	}
+}
+
+// NewUnassignedEvent возвращает снятие ревьюера с PR по причине reason.
+func NewUnassignedEvent(prID, userID string, reason ReviewEventReason) *ReviewEvent {
+	event := NewReviewEvent(prID, userID, ReviewEventUnassigned)
+	event.Reason = reason
+	return event
+}
+
+func NewReviewEvents(prID string, eventType ReviewEventType, userIDs ...string) []ReviewEvent {
+	events := make([]ReviewEvent, 0, len(userIDs))
+	for _, userID := range userIDs {
+		events = append(events, *NewReviewEvent(prID, userID, eventType))
+	}
+	return events
+}
+
+// ReviewersChangeEvents возвращает события для смены набора ревьюеров PR с oldReviewers на newReviewers.
+// Снятые ревьюеры получают причину reason.
+func ReviewersChangeEvents(prID string, oldReviewers, newReviewers []string, reason ReviewEventReason) []ReviewEvent {
+	events := make([]ReviewEvent, 0, len(oldReviewers)+len(newReviewers))
+	for _, id := range oldReviewers {
+		if !slices.Contains(newReviewers, id) {
+			events = append(events, *NewUnassignedEvent(prID, id, reason))
+		}
+	}
+	for _, id := range newReviewers {
+		if !slices.Contains(oldReviewers, id) {
+			events = append(events, *NewReviewEvent(prID, id, ReviewEventAssigned))
+		}
+	}
+	return events
+}
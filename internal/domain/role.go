@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// Role - роль пользователя в его команде.
+type Role string
+
+const (
+	// RoleAdmin управляет всеми командами и пользователями.
+	RoleAdmin Role = "admin"
+	// RoleTeamLead управляет составом и настройками только своей команды.
+	RoleTeamLead Role = "team_lead"
+	// RoleMember меняет только свой флаг активности и снимает с ревью только себя.
+	RoleMember Role = "member"
+)
+
+var (
+	ErrForbidden   = errors.New("action is not allowed for the user's role")
+	ErrInvalidRole = errors.New("role must be one of admin, team_lead, member")
+)
+
+var knownRoles = []Role{RoleAdmin, RoleTeamLead, RoleMember}
+
+func ParseRole(value string) (Role, error) {
+	role := Role(value)
+	if !slices.Contains(knownRoles, role) {
+		return "", ErrInvalidRole
+	}
+	return role, nil
+}
+
+// Principal - пользователь, от имени которого выполняется действие, с его командой и ролью.
+type Principal struct {
+	UserID   string
+	TeamName string
+	Role     Role
+}
+
+// SystemPrincipal - действие без проверки ролей: SystemActor или токен со scope admin.
+var SystemPrincipal = Principal{Role: RoleAdmin}
+
+// NewPrincipal возвращает права автора запроса. user - пользователь, к которому привязан токен.
+func NewPrincipal(user User) Principal {
+	return Principal{UserID: user.ID, TeamName: user.TeamName, Role: user.Role}
+}
+
+func (p Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+// CanManageTeam - может ли автор менять состав и настройки команды.
+func (p Principal) CanManageTeam(teamName string) bool {
+	return p.IsAdmin() || (p.Role == RoleTeamLead && p.TeamName == teamName)
+}
+
+// CanManageUser - может ли автор менять активность и ревью пользователя.
+func (p Principal) CanManageUser(user User) bool {
+	return p.UserID == user.ID || p.CanManageTeam(user.TeamName)
+}
+
+// PrincipalFromContext возвращает права автора запроса. findUser загружает пользователя, к которому привязан токен;
+// если пользователь не найден или токен не привязан к пользователю (сервисный токен без scope admin),
+// автор не может ничего, что проверяется ролями. Без автора в контексте действие тоже запрещено:
+// точка входа (HTTP, CLI, фоновая задача) задаёт его явно, при необходимости - SystemActor.
+// Роль из JWT заменяет роль из хранилища, команда всегда берётся из хранилища.
+func PrincipalFromContext(ctx context.Context, findUser func(ctx context.Context, userID string) (User, error)) (Principal, error) {
+	actor, ok := ActorFromContext(ctx)
+	if !ok {
+		return Principal{}, ErrForbidden
+	}
+	if actor.HasScope(ScopeAdmin) {
+		return SystemPrincipal, nil
+	}
+	if actor.UserID == "" {
+		return Principal{}, ErrForbidden
+	}
+
+	user, err := findUser(ctx, actor.UserID)
+	if errors.Is(err, ErrUserNotExist) {
+		return Principal{}, ErrForbidden
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+
+	principal := NewPrincipal(user)
+	if actor.Role != "" {
+		principal.Role = actor.Role
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	StatsGroupByUser StatsGroupBy = "user"
+	StatsGroupByTeam StatsGroupBy = "team"
+
+	StatsSortByKey               StatsSortBy = "key"
+	StatsSortByAssigned          StatsSortBy = "assigned"
+	StatsSortByMerged            StatsSortBy = "merged"
+	StatsSortByReassigned        StatsSortBy = "reassigned"
+	StatsSortByMedianTimeToMerge StatsSortBy = "median_time_to_merge"
+
+	StatsDefaultLimit uint64 = 50
+	StatsMaxLimit     uint64 = 500
+)
+
+var (
+	ErrInvalidStatsPeriod  = errors.New("stats period is not valid: from must be before to")
+	ErrInvalidStatsQuery   = errors.New("stats query is not valid")
+	ErrInvalidStatsCursor  = errors.New("stats cursor is not valid")
+	ErrStatsCursorMismatch = errors.New("stats cursor was issued for another group_by, sort_by or order")
+)
+
+type StatsGroupBy string
+
+type StatsSortBy string
+
+// StatsCursor - позиция последней отданной строки для keyset-пагинации.
+// Позиция имеет смысл только в том порядке, в котором выдана страница,
+// поэтому курсор хранит группировку и сортировку запроса и не принимается с другими.
+type StatsCursor struct {
+	SortValue float64      `json:"v"`
+	Key       string       `json:"k"`
+	GroupBy   StatsGroupBy `json:"g"`
+	SortBy    StatsSortBy  `json:"s"`
+	Desc      bool         `json:"d,omitempty"`
+}
+
+// StatsQuery - запрос статистики ревью за период [From, To) с группировкой по пользователю или команде.
+type StatsQuery struct {
+	From    time.Time
+	To      time.Time
+	GroupBy StatsGroupBy
+	SortBy  StatsSortBy
+	Desc    bool
+	Limit   uint64
+	Cursor  *StatsCursor
+}
+
+// GroupStat - статистика ревью пользователя или команды за период.
+type GroupStat struct {
+	Key               string
+	ReviewsAssigned   int64
+	ReviewsMerged     int64
+	ReassignedAway    int64
+	MedianTimeToMerge time.Duration
+}
+
+type StatsPage struct {
+	Items      []GroupStat
+	NextCursor string
+}
+
+func NewStatsQuery(from, to time.Time, groupBy, sortBy, order string, limit uint64, cursor string) (*StatsQuery, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidStatsPeriod
+	}
+
+	q := &StatsQuery{
+		From:    from,
+		To:      to,
+		GroupBy: StatsGroupByUser,
+		SortBy:  StatsSortByKey,
+		Desc:    order == "desc",
+		Limit:   limit,
+	}
+
+	if groupBy != "" {
+		q.GroupBy = StatsGroupBy(groupBy)
+	}
+	if sortBy != "" {
+		q.SortBy = StatsSortBy(sortBy)
+	}
+	if q.Limit == 0 {
+		q.Limit = StatsDefaultLimit
+	}
+
+	switch {
+	case q.GroupBy != StatsGroupByUser && q.GroupBy != StatsGroupByTeam:
+		return nil, ErrInvalidStatsQuery
+	case order != "" && order != "asc" && order != "desc":
+		return nil, ErrInvalidStatsQuery
+	case q.Limit > StatsMaxLimit:
+		return nil, ErrInvalidStatsQuery
+	}
+
+	switch q.SortBy {
+	case StatsSortByKey, StatsSortByAssigned, StatsSortByMerged, StatsSortByReassigned, StatsSortByMedianTimeToMerge:
+	default:
+		return nil, ErrInvalidStatsQuery
+	}
+
+	if cursor != "" {
+		c, err := DecodeStatsCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.GroupBy != q.GroupBy || c.SortBy != q.SortBy || c.Desc != q.Desc {
+			return nil, ErrStatsCursorMismatch
+		}
+		q.Cursor = &c
+	}
+
+	return q, nil
+}
+
+// SortValue возвращает значение поля сортировки, которое попадает в курсор.
+func (s *GroupStat) SortValue(sortBy StatsSortBy) float64 {
+	switch sortBy {
+	case StatsSortByAssigned:
+		return float64(s.ReviewsAssigned)
+	case StatsSortByMerged:
+		return float64(s.ReviewsMerged)
+	case StatsSortByReassigned:
+		return float64(s.ReassignedAway)
+	case StatsSortByMedianTimeToMerge:
+		return s.MedianTimeToMerge.Seconds()
+	default:
+		return 0
+	}
+}
+
+// SelectGroupStats отбирает строки после курсора q, сортирует их по q.SortBy и ключу
+// и оставляет Limit+1 строк - так же, как SQL запрос в postgres.
+// Нужна хранилищам, которые сортируют статистику в памяти.
+func SelectGroupStats(stats []GroupStat, q StatsQuery) []GroupStat {
+	if q.Cursor != nil {
+		stats = slices.DeleteFunc(stats, func(s GroupStat) bool {
+			return s.compare(q.Cursor.SortValue, q.Cursor.Key, q) <= 0
+		})
+	}
+
+	slices.SortFunc(stats, func(a, b GroupStat) int {
+		return a.compare(b.SortValue(q.SortBy), b.Key, q)
+	})
+	if uint64(len(stats)) > q.Limit+1 {
+		stats = stats[:q.Limit+1]
+	}
+
+	return stats
+}
+
+// compare сравнивает строку с позицией (sortValue, key) в порядке выдачи q.
+func (s *GroupStat) compare(sortValue float64, key string, q StatsQuery) int {
+	c := strings.Compare(s.Key, key)
+	if q.SortBy != StatsSortByKey {
+		c = cmp.Or(cmp.Compare(s.SortValue(q.SortBy), sortValue), c)
+	}
+	if q.Desc {
+		return -c
+	}
+	return c
+}
+
+// NewStatsPage собирает страницу из items, выбранных с запасом в одну строку (Limit+1).
+func NewStatsPage(items []GroupStat, q StatsQuery) StatsPage {
+	if uint64(len(items)) <= q.Limit {
+		return StatsPage{Items: items}
+	}
+
+	items = items[:q.Limit]
+	last := items[len(items)-1]
+
+	return StatsPage{
+		Items: items,
+		NextCursor: StatsCursor{
+			SortValue: last.SortValue(q.SortBy),
+			Key:       last.Key,
+			GroupBy:   q.GroupBy,
+			SortBy:    q.SortBy,
+			Desc:      q.Desc,
+		}.Encode(),
+	}
+}
+
+func (c StatsCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeStatsCursor(s string) (StatsCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return StatsCursor{}, ErrInvalidStatsCursor
+	}
+
+	var c StatsCursor
+	if err = json.Unmarshal(b, &c); err != nil || c.Key == "" {
+		return StatsCursor{}, ErrInvalidStatsCursor
+	}
+
+	return c, nil
+}
+
+func (s *GroupStat) ToJSON() model.GroupStat {
+	return model.GroupStat{
+		Key:                      s.Key,
+		ReviewsAssigned:          s.ReviewsAssigned,
+		ReviewsMerged:            s.ReviewsMerged,
+		ReassignedAway:           s.ReassignedAway,
+		MedianTimeToMergeSeconds: s.MedianTimeToMerge.Seconds(),
+	}
+}
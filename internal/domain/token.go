@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scope - право API токена на группу эндпоинтов.
+type Scope string
+
+const (
+	ScopeTeamsWrite Scope = "teams:write"
+	ScopePRsWrite   Scope = "prs:write"
+	ScopeStatsRead  Scope = "stats:read"
+	// ScopeAdmin даёт доступ к /admin и ко всем остальным эндпоинтам.
+	ScopeAdmin Scope = "admin"
+)
+
+const (
+	// TokenPrefix отличает токены сервиса от других секретов, например в логах и сканерах утечек.
+	TokenPrefix        = "prs_"
+	TokenNameMaxLen    = 255
+	BootstrapTokenID   = "bootstrap"
+	bootstrapTokenName = "bootstrap token from config"
+	tokenSecretBytes   = 32
+	tokenIDBytes       = 8
+)
+
+var (
+	ErrTokenNotFound = errors.New("token not found")
+	ErrTokenInvalid  = errors.New("token is missing, invalid or revoked")
+	ErrTokenScope    = errors.New("token does not have the required scope")
+	ErrInvalidToken  = errors.New("token name must be non-empty and scopes must be known")
+)
+
+var knownScopes = []Scope{ScopeTeamsWrite, ScopePRsWrite, ScopeStatsRead, ScopeAdmin}
+
+// APIToken - токен доступа к API. Сам секрет не хранится, только его SHA-256.
+// UserID - пользователь, от имени которого действует токен; пустой у сервисных токенов.
+type APIToken struct {
+	ID        string
+	Name      string
+	Hash      string
+	Scopes    []Scope
+	UserID    string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// NewAPIToken создаёт токен и возвращает его секрет. Секрет показывается клиенту один раз при выпуске.
+func NewAPIToken(name string, scopes []Scope) (*APIToken, string, error) {
+	if err := validateToken(name, scopes); err != nil {
+		return nil, "", err
+	}
+
+	secret := TokenPrefix + randomHex(tokenSecretBytes)
+	return &APIToken{
+		ID:        randomHex(tokenIDBytes),
+		Name:      name,
+		Hash:      HashToken(secret),
+		Scopes:    normalizeScopes(scopes),
+		CreatedAt: time.Now().UTC(),
+	}, secret, nil
+}
+
+// NewBootstrapToken - токен с правом admin из конфигурации, через который выпускаются остальные токены.
+func NewBootstrapToken(secret string) *APIToken {
+	return &APIToken{
+		ID:        BootstrapTokenID,
+		Name:      bootstrapTokenName,
+		Hash:      HashToken(secret),
+		Scopes:    []Scope{ScopeAdmin},
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// HashToken возвращает SHA-256 секрета. Секреты случайные и длинные, поэтому соль и медленный хеш не нужны.
+func HashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *APIToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// HasScope сообщает, разрешён ли токену доступ со scope. Токен с admin разрешён везде.
+func (t *APIToken) HasScope(scope Scope) bool {
+	return hasScope(t.Scopes, scope)
+}
+
+func hasScope(scopes []Scope, scope Scope) bool {
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
+
+// KnownScopes возвращает все scopes, которые понимает сервис.
+func KnownScopes() []Scope {
+	return slices.Clone(knownScopes)
+}
+
+func validateToken(name string, scopes []Scope) error {
+	if strings.TrimSpace(name) == "" || len(name) > TokenNameMaxLen || len(scopes) == 0 {
+		return ErrInvalidToken
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(knownScopes, scope) {
+			return ErrInvalidToken
+		}
+	}
+	return nil
+}
+
+func normalizeScopes(scopes []Scope) []Scope {
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	return slices.Compact(scopes)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func ScopesToStrings(scopes []Scope) []string {
+	values := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		values = append(values, string(scope))
+	}
+	return values
+}
+
+func ScopesFromStrings(values []string) []Scope {
+	scopes := make([]Scope, 0, len(values))
+	for _, value := range values {
+		scopes = append(scopes, Scope(value))
+	}
+	return scopes
+}
+
+func (t *APIToken) ToJSON() model.APIToken {
+	return model.APIToken{
+		TokenID:   t.ID,
+		Name:      t.Name,
+		Scopes:    ScopesToStrings(t.Scopes),
+		UserID:    t.UserID,
+		CreatedAt: t.CreatedAt,
+		RevokedAt: t.RevokedAt,
+	}
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"errors"
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	TurnaroundGroupByTeam     TurnaroundGroupBy = "team"
+	TurnaroundGroupByReviewer TurnaroundGroupBy = "reviewer"
+	TurnaroundGroupByWeek     TurnaroundGroupBy = "week"
+)
+
+var ErrInvalidTurnaroundQuery = errors.New("turnaround query is not valid")
+
+type TurnaroundGroupBy string
+
+// TurnaroundQuery - запрос аналитики по PR, созданным в период [From, To).
+type TurnaroundQuery struct {
+	From    time.Time
+	To      time.Time
+	GroupBy TurnaroundGroupBy
+}
+
+type Percentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+}
+
+// TurnaroundStat - перцентили времени до первого ревью (одобрения) и до мержа.
+// Для группировки по ревьюеру время до ревью считается от назначения этого ревьюера до его одобрения.
+type TurnaroundStat struct {
+	Key                string
+	PullRequests       int64
+	TimeToFirstReview  Percentiles
+	MergedPullRequests int64
+	TimeToMerge        Percentiles
+}
+
+func NewTurnaroundQuery(from, to time.Time, groupBy string) (*TurnaroundQuery, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidStatsPeriod
+	}
+
+	q := &TurnaroundQuery{
+		From:    from,
+		To:      to,
+		GroupBy: TurnaroundGroupByTeam,
+	}
+	if groupBy != "" {
+		q.GroupBy = TurnaroundGroupBy(groupBy)
+	}
+
+	switch q.GroupBy {
+	case TurnaroundGroupByTeam, TurnaroundGroupByReviewer, TurnaroundGroupByWeek:
+	default:
+		return nil, ErrInvalidTurnaroundQuery
+	}
+
+	return q, nil
+}
+
+// NewPercentiles собирает перцентили из значений p50, p90, p99 в секундах.
+func NewPercentiles(seconds []float64) Percentiles {
+	if len(seconds) != 3 {
+		return Percentiles{}
+	}
+
+	return Percentiles{
+		P50: secondsToDuration(seconds[0]),
+		P90: secondsToDuration(seconds[1]),
+		P99: secondsToDuration(seconds[2]),
+	}
+}
+
+// PercentileCont считает перцентили выборки с линейной интерполяцией, как percentile_cont в postgres.
+// Для пустой выборки возвращает nil.
+func PercentileCont(values []float64, fractions ...float64) []float64 {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sorted := slices.Sorted(slices.Values(values))
+	result := make([]float64, 0, len(fractions))
+	for _, fraction := range fractions {
+		pos := fraction * float64(len(sorted)-1)
+		lower, upper := int(math.Floor(pos)), int(math.Ceil(pos))
+		result = append(result, sorted[lower]+(pos-math.Floor(pos))*(sorted[upper]-sorted[lower]))
+	}
+
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func (p Percentiles) ToJSON() model.Percentiles {
+	return model.Percentiles{
+		P50Seconds: p.P50.Seconds(),
+		P90Seconds: p.P90.Seconds(),
+		P99Seconds: p.P99.Seconds(),
+	}
+}
+
+func (s *TurnaroundStat) ToJSON() model.TurnaroundStat {
+	return model.TurnaroundStat{
+		Key:                s.Key,
+		PullRequests:       s.PullRequests,
+		TimeToFirstReview:  s.TimeToFirstReview.ToJSON(),
+		MergedPullRequests: s.MergedPullRequests,
+		TimeToMerge:        s.TimeToMerge.ToJSON(),
+	}
+}
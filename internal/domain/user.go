@@ -13,6 +13,7 @@ type User struct {
 	Name     string
 	TeamName string
 	IsActive bool
+	Role     Role
 }
 
 type UserStat struct {
@@ -23,12 +24,20 @@ type UserStat struct {
 	UpdatedAt     time.Time
 }
 
+// UserStatDrift - расхождение между сохранённой статистикой и статистикой, восстановленной из истории событий.
+type UserStatDrift struct {
+	UserID string
+	Stored UserStat
+	Actual UserStat
+}
+
 func NewUser(id, name, teamName string, isActive bool) *User {
 	return &User{
 		ID:       id,
 		Name:     name,
 		TeamName: teamName,
 		IsActive: isActive,
+		Role:     RoleMember,
 	}
 }
 
@@ -42,6 +51,12 @@ func NewUserStat(userID string, totalReviews, activeReviews, mergedReviews int64
 	}
 }
 
+func (u *UserStat) CountersEqual(other UserStat) bool {
+	return u.TotalReviews == other.TotalReviews &&
+		u.ActiveReviews == other.ActiveReviews &&
+		u.MergedReviews == other.MergedReviews
+}
+
 func (u *User) SetIsActive(isActive bool) {
 	u.IsActive = isActive
 }
@@ -56,6 +71,7 @@ func (u *User) ToJSON() model.User {
 		Username: u.Name,
 		TeamName: u.TeamName,
 		IsActive: u.IsActive,
+		Role:     string(u.Role),
 	}
 }
 
@@ -0,0 +1,243 @@
+package domain
+
+import (
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// WebhookDeliveryStatus - состояние доставки события подписчику.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookPending - доставка ждёт первой или повторной попытки.
+	WebhookPending WebhookDeliveryStatus = "pending"
+	// WebhookDelivered - подписчик ответил 2xx.
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDead - попытки исчерпаны, доставку можно только повторить вручную.
+	WebhookDead WebhookDeliveryStatus = "dead"
+)
+
+const (
+	WebhookSecretMinLen         = 16
+	WebhookURLMaxLen            = 2048
+	WebhookDeliveriesDefaultMax = 50
+	WebhookDeliveriesMax        = 500
+	webhookIDBytes              = 8
+)
+
+var (
+	ErrWebhookNotFound          = errors.New("webhook subscription not found")
+	ErrInvalidWebhook           = errors.New("webhook url must be an absolute http(s) URL, events must be known and secret must be at least 16 characters")
+	ErrWebhookDeliveryNotFound  = errors.New("webhook delivery not found")
+	ErrInvalidDeliveryQuery     = errors.New("delivery status must be pending, delivered or dead and limit must be in 0..500")
+	ErrWebhookDeliveryNotFailed = errors.New("only dead webhook deliveries can be replayed")
+)
+
+var knownEventTypes = []EventType{
+	EventPRCreated, EventReviewerAssigned, EventReviewerRemoved, EventPRApproved, EventPRMerged, EventUserDeactivated, EventTeamDeactivated,
+}
+
+// WebhookSubscription - подписка внешней системы на доменные события.
+// Events - фильтр по типам событий, пустой фильтр - все события.
+// Secret хранится открытым: им подписываются доставки, подписчик проверяет подпись тем же секретом,
+// поэтому, в отличие от API токенов, его нельзя хранить хэшем. Наружу он не отдаётся: API его только принимает,
+// а WebhookService.List очищает.
+type WebhookSubscription struct {
+	ID        string
+	URL       string
+	Events    []EventType
+	Secret    string
+	CreatedAt time.Time
+}
+
+func NewWebhookSubscription(rawURL string, events []EventType, secret string) (*WebhookSubscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(rawURL) > WebhookURLMaxLen {
+		return nil, ErrInvalidWebhook
+	}
+	if len(secret) < WebhookSecretMinLen {
+		return nil, ErrInvalidWebhook
+	}
+	for _, event := range events {
+		if !slices.Contains(knownEventTypes, event) {
+			return nil, ErrInvalidWebhook
+		}
+	}
+
+	events = slices.Clone(events)
+	slices.Sort(events)
+	return &WebhookSubscription{
+		ID:        randomHex(webhookIDBytes),
+		URL:       rawURL,
+		Events:    slices.Compact(events),
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Matches сообщает, подписана ли подписка на события типа eventType.
+func (s *WebhookSubscription) Matches(eventType EventType) bool {
+	return len(s.Events) == 0 || slices.Contains(s.Events, eventType)
+}
+
+// WebhookDelivery - доставка одного события одному подписчику. Payload - тело запроса, одинаковое во всех попытках.
+// Attempts - число попыток с последнего создания или повтора доставки.
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID string
+	EventID        int64
+	EventType      EventType
+	Payload        json.RawMessage
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// NewWebhookDelivery создаёт доставку события, которая отправляется при ближайшем проходе.
+func NewWebhookDelivery(subscription WebhookSubscription, event Event) WebhookDelivery {
+	payload, _ := json.Marshal(event.ToJSON())
+	now := time.Now().UTC()
+	return WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        payload,
+		Status:         WebhookPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+}
+
+// WebhookAttempt - одна попытка доставки. StatusCode 0 - ответа не было (ошибка соединения или таймаут).
+type WebhookAttempt struct {
+	ID          int64
+	DeliveryID  int64
+	AttemptedAt time.Time
+	StatusCode  int
+	Error       string
+	Duration    time.Duration
+}
+
+// Succeeded сообщает, принял ли подписчик доставку.
+func (a *WebhookAttempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// WebhookRetryPolicy - повторы доставок: задержка перед n-й повторной попыткой BaseDelay * 2^(n-1), но не больше MaxDelay.
+// После MaxAttempts неудачных попыток доставка переходит в WebhookDead.
+type WebhookRetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff возвращает задержку после attempts неудачных попыток.
+func (p WebhookRetryPolicy) Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(attempts-1))
+	if delay > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// Record применяет к доставке результат попытки: успех, повтор через Backoff или dead letter.
+func (d *WebhookDelivery) Record(attempt WebhookAttempt, policy WebhookRetryPolicy) {
+	d.Attempts++
+	if attempt.Succeeded() {
+		at := attempt.AttemptedAt
+		d.Status = WebhookDelivered
+		d.DeliveredAt = &at
+		d.LastError = ""
+		return
+	}
+
+	d.LastError = attempt.Error
+	if d.Attempts >= policy.MaxAttempts {
+		d.Status = WebhookDead
+		return
+	}
+	d.NextAttemptAt = attempt.AttemptedAt.Add(policy.Backoff(d.Attempts))
+}
+
+// WebhookDeliveryQuery - фильтр списка доставок; пустые поля не применяются. Доставки отдаются от новых к старым.
+type WebhookDeliveryQuery struct {
+	SubscriptionID string
+	Status         WebhookDeliveryStatus
+	Limit          uint64
+}
+
+func NewWebhookDeliveryQuery(q WebhookDeliveryQuery) (*WebhookDeliveryQuery, error) {
+	switch q.Status {
+	case "", WebhookPending, WebhookDelivered, WebhookDead:
+	default:
+		return nil, ErrInvalidDeliveryQuery
+	}
+	if q.Limit > WebhookDeliveriesMax {
+		return nil, ErrInvalidDeliveryQuery
+	}
+	if q.Limit == 0 {
+		q.Limit = WebhookDeliveriesDefaultMax
+	}
+	return &q, nil
+}
+
+func EventTypesFromStrings(values []string) []EventType {
+	events := make([]EventType, 0, len(values))
+	for _, value := range values {
+		events = append(events, EventType(value))
+	}
+	return events
+}
+
+func EventTypesToStrings(events []EventType) []string {
+	values := make([]string, 0, len(events))
+	for _, event := range events {
+		values = append(values, string(event))
+	}
+	return values
+}
+
+// ToJSON возвращает подписку без секрета.
+func (s *WebhookSubscription) ToJSON() model.Webhook {
+	return model.Webhook{
+		WebhookID: s.ID,
+		URL:       s.URL,
+		Events:    EventTypesToStrings(s.Events),
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+func (d *WebhookDelivery) ToJSON() model.WebhookDelivery {
+	return model.WebhookDelivery{
+		DeliveryID:    d.ID,
+		WebhookID:     d.SubscriptionID,
+		EventID:       d.EventID,
+		EventType:     string(d.EventType),
+		Status:        string(d.Status),
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		LastError:     d.LastError,
+		CreatedAt:     d.CreatedAt,
+		DeliveredAt:   d.DeliveredAt,
+	}
+}
+
+func (a *WebhookAttempt) ToJSON() model.WebhookAttempt {
+	return model.WebhookAttempt{
+		AttemptedAt: a.AttemptedAt,
+		StatusCode:  a.StatusCode,
+		Error:       a.Error,
+		DurationMs:  a.Duration.Milliseconds(),
+	}
+}
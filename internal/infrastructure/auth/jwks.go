@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// jwksRefetchInterval - как часто можно перечитывать JWKS, встретив неизвестный kid.
+	jwksRefetchInterval = 30 * time.Second
+	jwksFetchTimeout    = 10 * time.Second
+	jwksMaxSize         = 1 << 20
+)
+
+var ErrKeyNotFound = errors.New("signing key not found in JWKS")
+
+// jwk - открытый ключ из JWKS (RFC 7517). Поддерживаются RSA и EC (P-256, P-384, P-521).
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet - ключи для проверки подписи JWT из файла или по URL (например локальной заглушки SSO).
+// Ключи перечитываются раз в refreshInterval, а при неизвестном kid - не чаще jwksRefetchInterval,
+// чтобы подхватить ротацию ключей без перезапуска.
+// Загрузка идёт без блокировки: одновременные запросы ждут одну загрузку, а известные ключи отдаются сразу.
+type KeySet struct {
+	source          string
+	refreshInterval time.Duration
+	client          *http.Client
+	logger          *slog.Logger
+	fetches         singleflight.Group
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewKeySet принимает путь к файлу или http(s) URL с JWKS. Ключи загружаются при первой проверке токена или в Refresh.
+func NewKeySet(source string, refreshInterval time.Duration, logger *slog.Logger) *KeySet {
+	return &KeySet{
+		source:          source,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: jwksFetchTimeout},
+		logger:          logger,
+	}
+}
+
+// Key возвращает ключ по kid. Пустой kid допускается, только если в JWKS один ключ.
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, ok, fetchedAt := k.lookup(kid)
+	stale := time.Since(fetchedAt) > k.refreshInterval
+	if (!ok || stale) && time.Since(fetchedAt) > jwksRefetchInterval {
+		if ok {
+			// известный ключ не ждёт SSO: JWKS обновляется в фоне
+			k.startRefresh(ctx)
+			return key, nil
+		}
+		if err := k.Refresh(ctx); err != nil {
+			return nil, err
+		}
+		key, ok, _ = k.lookup(kid)
+	}
+	if !ok {
+		k.mu.RLock()
+		loaded := k.keys != nil
+		k.mu.RUnlock()
+		if !loaded {
+			return nil, errors.New("jwks is not loaded")
+		}
+		return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+	}
+
+	return key, nil
+}
+
+// Refresh перечитывает JWKS. Если загрузка уже идёт, ждёт её результат, а не запускает новую.
+// Отмена ctx прекращает ожидание, но не загрузку, которую могут ждать другие запросы.
+func (k *KeySet) Refresh(ctx context.Context) error {
+	select {
+	case res := <-k.startRefresh(ctx):
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startRefresh запускает загрузку JWKS или присоединяется к уже идущей.
+func (k *KeySet) startRefresh(ctx context.Context) <-chan singleflight.Result {
+	return k.fetches.DoChan("jwks", func() (any, error) {
+		return nil, k.refresh(context.WithoutCancel(ctx))
+	})
+}
+
+// lookup возвращает ключ по kid и время последней загрузки JWKS.
+func (k *KeySet) lookup(kid string) (crypto.PublicKey, bool, time.Time) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true, k.fetchedAt
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok, k.fetchedAt
+}
+
+// refresh загружает JWKS. При ошибке прежние ключи не сбрасываются,
+// чтобы недоступность SSO не ломала уже выданные токены.
+func (k *KeySet) refresh(ctx context.Context) error {
+	keys, err := k.fetch(ctx)
+
+	// время фиксируется после загрузки: пока она идёт, запросы с неизвестным kid присоединяются к ней,
+	// а неудачная попытка тоже откладывает следующую на jwksRefetchInterval
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.fetchedAt = time.Now()
+	if err != nil {
+		return err
+	}
+	k.keys = keys
+	return nil
+}
+
+func (k *KeySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	data, err := k.load(ctx)
+	if err != nil {
+		k.logger.ErrorContext(ctx, "load jwks", slog.String("source", k.source), slog.Any("error", err))
+		return nil, fmt.Errorf("load jwks: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		k.logger.ErrorContext(ctx, "parse jwks", slog.String("source", k.source), slog.Any("error", err))
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	k.logger.InfoContext(ctx, "jwks loaded", slog.String("source", k.source), slog.Int("keys", len(keys)))
+	return keys, nil
+}
+
+func (k *KeySet) load(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(k.source, "http://") && !strings.HasPrefix(k.source, "https://") {
+		return os.ReadFile(k.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, jwksMaxSize))
+}
+
+// parseJWKS разбирает ключи подписи; ключи шифрования и неподдерживаемых типов пропускаются.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		pub, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key.Kid, err)
+		}
+		if pub != nil {
+			keys[key.Kid] = pub
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+
+	return keys, nil
+}
+
+func (key jwk) publicKey() (crypto.PublicKey, error) {
+	switch key.Kty {
+	case "RSA":
+		n, err := decodeBigInt(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBigInt(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("e: unsupported exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, err := curveByName(key.Crv)
+		if err != nil {
+			return nil, err
+		}
+		x, err := decodeFixed(key.X, curve)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeFixed(key.Y, curve)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		// ParseUncompressedPublicKey также проверяет, что точка лежит на кривой
+		point := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	default:
+		return nil, nil
+	}
+}
+
+func curveByName(name string) (elliptic.Curve, error) {
+	switch name {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", name)
+	}
+}
+
+// decodeFixed декодирует координату EC ключа, её длина фиксирована размером кривой.
+func decodeFixed(value string, curve elliptic.Curve) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != coordinateSize(curve) {
+		return nil, errors.New("invalid length")
+	}
+	return b, nil
+}
+
+func coordinateSize(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
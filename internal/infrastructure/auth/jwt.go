@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JWTTokenPrefix отличает в updated_by и логах изменения, сделанные по JWT, от изменений по API токенам.
+const JWTTokenPrefix = "jwt:"
+
+// JWTConfig - какие JWT принимаются и как их claims отображаются на пользователя, роль и scopes.
+type JWTConfig struct {
+	// Issuer и Audience - ожидаемые iss и aud, пустые не проверяются.
+	Issuer   string
+	Audience string
+	// UserClaim - claim с ID пользователя сервиса.
+	UserClaim string
+	// RoleClaim - claim с ролью (admin, team_lead, member); пустой или отсутствующий - роль из хранилища.
+	RoleClaim string
+	// ScopeClaim - claim со scopes: строка через пробел или массив. Если его нет в токене, выдаются DefaultScopes.
+	ScopeClaim    string
+	DefaultScopes []domain.Scope
+	// Leeway - допустимое расхождение часов с SSO при проверке exp и nbf.
+	Leeway time.Duration
+}
+
+// JWTVerifier проверяет JWT от SSO по ключам из JWKS. Принимаются только асимметричные подписи RS* и ES*.
+type JWTVerifier struct {
+	keys *KeySet
+	cfg  JWTConfig
+	now  func() time.Time
+}
+
+func NewJWTVerifier(keys *KeySet, cfg JWTConfig) *JWTVerifier {
+	return &JWTVerifier{keys: keys, cfg: cfg, now: time.Now}
+}
+
+// LooksLikeJWT отличает JWT (header.payload.signature) от API токенов, в которых нет точек.
+func LooksLikeJWT(secret string) bool {
+	return strings.Count(secret, ".") == 2
+}
+
+// Verify проверяет подпись и срок действия JWT и возвращает автора запроса.
+// Ошибки в самом токене оборачивают domain.ErrTokenInvalid, ошибки загрузки JWKS возвращаются как есть.
+func (v *JWTVerifier) Verify(ctx context.Context, raw string) (domain.Actor, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return domain.Actor{}, invalidJWT("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return domain.Actor{}, invalidJWT("malformed header")
+	}
+	alg, ok := jwtAlgorithms[header.Alg]
+	if !ok {
+		return domain.Actor{}, invalidJWT("unsupported alg " + header.Alg)
+	}
+
+	key, err := v.keys.Key(ctx, header.Kid)
+	if errors.Is(err, ErrKeyNotFound) {
+		return domain.Actor{}, invalidJWT(err.Error())
+	}
+	if err != nil {
+		return domain.Actor{}, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return domain.Actor{}, invalidJWT("malformed signature")
+	}
+	if !alg(key, []byte(parts[0]+"."+parts[1]), signature) {
+		return domain.Actor{}, invalidJWT("invalid signature")
+	}
+
+	var claims map[string]any
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return domain.Actor{}, invalidJWT("malformed claims")
+	}
+
+	return v.actor(claims)
+}
+
+// actor проверяет стандартные claims и отображает остальные на автора запроса.
+func (v *JWTVerifier) actor(claims map[string]any) (domain.Actor, error) {
+	now := v.now()
+
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return domain.Actor{}, invalidJWT("exp is required")
+	}
+	if now.After(exp.Add(v.cfg.Leeway)) {
+		return domain.Actor{}, invalidJWT("token expired")
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Before(nbf.Add(-v.cfg.Leeway)) {
+		return domain.Actor{}, invalidJWT("token not valid yet")
+	}
+	if v.cfg.Issuer != "" && claims["iss"] != v.cfg.Issuer {
+		return domain.Actor{}, invalidJWT("unexpected issuer")
+	}
+	if v.cfg.Audience != "" && !slices.Contains(stringsClaim(claims, "aud"), v.cfg.Audience) {
+		return domain.Actor{}, invalidJWT("unexpected audience")
+	}
+
+	userID, _ := claims[v.cfg.UserClaim].(string)
+	if userID == "" {
+		return domain.Actor{}, invalidJWT(v.cfg.UserClaim + " is required")
+	}
+
+	actor := domain.Actor{
+		TokenID: JWTTokenPrefix + userID,
+		UserID:  userID,
+		Scopes:  v.cfg.DefaultScopes,
+	}
+
+	if value, ok := claims[v.cfg.RoleClaim].(string); v.cfg.RoleClaim != "" && ok {
+		role, err := domain.ParseRole(value)
+		if err != nil {
+			return domain.Actor{}, invalidJWT(err.Error())
+		}
+		actor.Role = role
+	}
+
+	// scopes SSO, не относящиеся к сервису (openid, profile и т.п.), пропускаются
+	if _, ok := claims[v.cfg.ScopeClaim]; ok {
+		actor.Scopes = nil
+		for _, value := range stringsClaim(claims, v.cfg.ScopeClaim) {
+			if scope := domain.Scope(value); slices.Contains(domain.KnownScopes(), scope) {
+				actor.Scopes = append(actor.Scopes, scope)
+			}
+		}
+	}
+
+	return actor, nil
+}
+
+func invalidJWT(reason string) error {
+	return fmt.Errorf("%w: %s", domain.ErrTokenInvalid, reason)
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func numericClaim(claims map[string]any, name string) (time.Time, bool) {
+	value, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := value.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// stringsClaim читает claim, который может быть строкой через пробел или массивом строк (как aud и scope).
+func stringsClaim(claims map[string]any, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		return strings.Fields(value)
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// jwtAlgorithm проверяет подпись signed ключом key; false и для ключа не того типа.
+type jwtAlgorithm func(key crypto.PublicKey, signed, signature []byte) bool
+
+var jwtAlgorithms = map[string]jwtAlgorithm{
+	"RS256": rsaAlgorithm(crypto.SHA256),
+	"RS384": rsaAlgorithm(crypto.SHA384),
+	"RS512": rsaAlgorithm(crypto.SHA512),
+	"ES256": ecdsaAlgorithm(crypto.SHA256, "P-256"),
+	"ES384": ecdsaAlgorithm(crypto.SHA384, "P-384"),
+	"ES512": ecdsaAlgorithm(crypto.SHA512, "P-521"),
+}
+
+func rsaAlgorithm(hash crypto.Hash) jwtAlgorithm {
+	return func(key crypto.PublicKey, signed, signature []byte) bool {
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		return rsa.VerifyPKCS1v15(pub, hash, digest(hash, signed), signature) == nil
+	}
+}
+
+// ecdsaAlgorithm проверяет подпись JWS: r и s подряд, каждое длиной в размер кривой (RFC 7518, 3.4).
+func ecdsaAlgorithm(hash crypto.Hash, curve string) jwtAlgorithm {
+	return func(key crypto.PublicKey, signed, signature []byte) bool {
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve.Params().Name != curve {
+			return false
+		}
+		size := coordinateSize(pub.Curve)
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(pub, digest(hash, signed), r, s)
+	}
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"avito-tech-go-task/internal/infrastructure/logging"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeForbidden    = "FORBIDDEN"
+
+	bearerPrefix = "Bearer "
+)
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, secret string) (domain.APIToken, error)
+}
+
+// GinMiddleware проверяет токен из заголовка Authorization: Bearer <token> и кладёт автора запроса в контекст.
+// Если задан verifier, JWT проверяются по JWKS, остальные токены - как API токены.
+// Запрос без действующего токена отклоняется с 401.
+func GinMiddleware(authenticator Authenticator, verifier *JWTVerifier, logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("Authorization")
+		secret, ok := strings.CutPrefix(header, bearerPrefix)
+		if !ok {
+			ctx.Header("WWW-Authenticate", "Bearer")
+			abort(ctx, http.StatusUnauthorized, CodeUnauthorized, domain.ErrTokenInvalid.Error())
+			return
+		}
+		secret = strings.TrimSpace(secret)
+
+		var (
+			actor domain.Actor
+			err   error
+		)
+		if verifier != nil && LooksLikeJWT(secret) {
+			actor, err = verifier.Verify(ctx.Request.Context(), secret)
+		} else {
+			var token domain.APIToken
+			token, err = authenticator.Authenticate(ctx.Request.Context(), secret)
+			actor = domain.Actor{TokenID: token.ID, UserID: token.UserID, Scopes: token.Scopes}
+		}
+		if errors.Is(err, domain.ErrTokenInvalid) {
+			ctx.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			abort(ctx, http.StatusUnauthorized, CodeUnauthorized, err.Error())
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(ctx.Request.Context(), "authenticate token", slog.Any("error", err))
+			abort(ctx, http.StatusInternalServerError, "INTERNAL_ERROR", http.StatusText(http.StatusInternalServerError))
+			return
+		}
+
+		reqCtx := domain.WithActor(ctx.Request.Context(), actor)
+		reqCtx = logging.With(reqCtx, slog.String("token_id", actor.TokenID))
+		if actor.UserID != "" {
+			reqCtx = logging.With(reqCtx, slog.String("acting_user_id", actor.UserID))
+		}
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+
+		ctx.Next()
+	}
+}
+
+// System выполняет запрос от имени domain.SystemActor. Ставится вместо GinMiddleware, когда аутентификация выключена.
+func System() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Request = ctx.Request.WithContext(domain.WithActor(ctx.Request.Context(), domain.SystemActor))
+		ctx.Next()
+	}
+}
+
+// Require пропускает запрос, только если у токена есть scope (или admin). Ставится после GinMiddleware.
+func Require(scope domain.Scope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		actor, ok := domain.ActorFromContext(ctx.Request.Context())
+		if !ok {
+			abort(ctx, http.StatusUnauthorized, CodeUnauthorized, domain.ErrTokenInvalid.Error())
+			return
+		}
+		if !actor.HasScope(scope) {
+			abort(ctx, http.StatusForbidden, CodeForbidden, domain.ErrTokenScope.Error()+": "+string(scope))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func abort(ctx *gin.Context, status int, code, message string) {
+	ctx.AbortWithStatusJSON(status, model.ErrorResponse{
+		Error: &model.ErrorDetail{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
@@ -0,0 +1,98 @@
+package health
+
+import (
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+
+	checkTimeout = 2 * time.Second
+)
+
+var errShuttingDown = errors.New("server is shutting down")
+
+// Check - проверка готовности зависимости сервиса.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Checker отвечает на liveness и readiness пробы.
+// После Drain readiness возвращает 503, чтобы балансировщик перестал присылать запросы до остановки сервера.
+type Checker struct {
+	checks   []Check
+	draining atomic.Bool
+	logger   *slog.Logger
+}
+
+func NewChecker(logger *slog.Logger, checks ...Check) *Checker {
+	return &Checker{checks: checks, logger: logger}
+}
+
+// Drain переводит сервис в состояние остановки.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Ready выполняет все проверки и возвращает результат каждой.
+func (c *Checker) Ready(ctx context.Context) (map[string]string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	results := make(map[string]string, len(c.checks)+1)
+	ready := true
+	if c.draining.Load() {
+		results["server"] = errShuttingDown.Error()
+		ready = false
+	}
+	for _, check := range c.checks {
+		if err := check.Check(ctx); err != nil {
+			c.logger.WarnContext(ctx, "readiness check failed", slog.String("check", check.Name), slog.Any("error", err))
+			results[check.Name] = err.Error()
+			ready = false
+			continue
+		}
+		results[check.Name] = StatusOK
+	}
+
+	return results, ready
+}
+
+// LivenessHandler godoc
+//
+//	@Summary		Liveness проба: процесс запущен и обслуживает HTTP
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200	{object}	model.HealthResponse
+//	@Router			/healthz [get]
+func (c *Checker) LivenessHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, model.HealthResponse{Status: StatusOK})
+}
+
+// ReadinessHandler godoc
+//
+//	@Summary		Readiness проба: БД доступна и схема БД на версии, которую ожидает сервис
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200	{object}	model.HealthResponse
+//	@Failure		503	{object}	model.HealthResponse
+//	@Router			/readyz [get]
+func (c *Checker) ReadinessHandler(ctx *gin.Context) {
+	checks, ready := c.Ready(ctx)
+	if !ready {
+		ctx.JSON(http.StatusServiceUnavailable, model.HealthResponse{Status: StatusUnavailable, Checks: checks})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.HealthResponse{Status: StatusOK, Checks: checks})
+}
@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TokenService interface {
+	Issue(ctx context.Context, name, userID string, scopes []domain.Scope) (domain.APIToken, string, error)
+	List(ctx context.Context) ([]domain.APIToken, error)
+	Revoke(ctx context.Context, tokenID string) error
+}
+
+type AuditService interface {
+	Query(ctx context.Context, q domain.AuditQuery) (domain.AuditPage, error)
+}
+
+type WebhookService interface {
+	Create(ctx context.Context, url string, events []domain.EventType, secret string) (domain.WebhookSubscription, error)
+	List(ctx context.Context) ([]domain.WebhookSubscription, error)
+	Delete(ctx context.Context, subscriptionID string) error
+	ListDeliveries(ctx context.Context, q domain.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, deliveryID int64) (domain.WebhookDelivery, []domain.WebhookAttempt, error)
+	Replay(ctx context.Context, deliveryID int64) (domain.WebhookDelivery, error)
+}
+
+// AdminService обслуживает служебные эндпоинты /admin.
+type AdminService struct {
+	pools          map[string]*sql.DB
+	tokenService   TokenService
+	auditService   AuditService
+	webhookService WebhookService
+	logger         *slog.Logger
+}
+
+// NewAdminService принимает пулы соединений по именам; для хранилища в памяти pools пуст.
+func NewAdminService(pools map[string]*sql.DB, tokenService TokenService, auditService AuditService, webhookService WebhookService,
+	logger *slog.Logger) *AdminService {
+	return &AdminService{
+		pools:          pools,
+		tokenService:   tokenService,
+		auditService:   auditService,
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// GetPoolStatsHandler godoc
+//
+//	@Summary		Получить статистику пулов соединений с БД
+//	@Description	in_use и idle - текущие соединения, wait_count и wait_duration_ms - сколько раз и как долго запросы ждали свободного соединения с момента запуска.
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	model.GetPoolStatsResponse
+//	@Router			/admin/getPoolStats [get]
+func (s *AdminService) GetPoolStatsHandler(ctx *gin.Context) {
+	res := model.GetPoolStatsResponse{
+		Pools: make(map[string]model.PoolStats, len(s.pools)),
+	}
+	for name, pool := range s.pools {
+		res.Pools[name] = poolStatsToJSON(pool.Stats())
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+func poolStatsToJSON(stats sql.DBStats) model.PoolStats {
+	return model.PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
+
+// IssueTokenHandler godoc
+//
+//	@Summary		Выпустить API токен
+//	@Description	scopes: teams:write, prs:write, stats:read, admin. Секрет возвращается один раз, сервис хранит только его хеш.
+//	@Description	С user_id токен действует от имени пользователя, и его действия ограничены ролью пользователя.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		model.IssueTokenRequest	true	"token"
+//	@Success		201		{object}	model.IssueTokenResponse
+//	@Failure		400		{object}	model.ErrorResponse
+//	@Failure		401		{object}	model.ErrorResponse
+//	@Failure		403		{object}	model.ErrorResponse
+//	@Failure		404		{object}	model.ErrorResponse
+//	@Failure		500		{object}	model.ErrorResponse
+//	@Router			/admin/issueToken [post]
+func (s *AdminService) IssueTokenHandler(ctx *gin.Context) {
+	var req model.IssueTokenRequest
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	token, secret, err := s.tokenService.Issue(ctx.Request.Context(), req.Name, req.UserID, domain.ScopesFromStrings(req.Scopes))
+	if err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, model.IssueTokenResponse{
+		Token:  token.ToJSON(),
+		Secret: secret,
+	})
+}
+
+// ListTokensHandler godoc
+//
+//	@Summary		Получить список API токенов, включая отозванные
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	model.ListTokensResponse
+//	@Failure		401	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
+//	@Failure		500	{object}	model.ErrorResponse
+//	@Router			/admin/listTokens [get]
+func (s *AdminService) ListTokensHandler(ctx *gin.Context) {
+	tokens, err := s.tokenService.List(ctx.Request.Context())
+	if err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	jsonTokens := make([]model.APIToken, 0, len(tokens))
+	for _, token := range tokens {
+		jsonTokens = append(jsonTokens, token.ToJSON())
+	}
+
+	ctx.JSON(http.StatusOK, model.ListTokensResponse{Tokens: jsonTokens})
+}
+
+// RevokeTokenHandler godoc
+//
+//	@Summary		Отозвать API токен
+//	@Description	Отозванный токен сразу перестаёт проходить аутентификацию. Повторный отзыв не является ошибкой.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body	model.RevokeTokenRequest	true	"token"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		401	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
+//	@Failure		404	{object}	model.ErrorResponse
+//	@Failure		500	{object}	model.ErrorResponse
+//	@Router			/admin/revokeToken [post]
+func (s *AdminService) RevokeTokenHandler(ctx *gin.Context) {
+	var req model.RevokeTokenRequest
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err = s.tokenService.Revoke(ctx.Request.Context(), req.TokenID); err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetAuditLogHandler godoc
+//
+//	@Summary		Получить журнал аудита
+//	@Description	Записи отдаются от новых к старым; пустые фильтры не применяются. Период [from, to) задаётся в RFC3339.
+//	@Description	action: team.save | team.deactivate | user.set_is_active | user.set_role | pull_request.create | pull_request.approve | pull_request.merge | pull_request.reassign | token.issue | token.revoke | webhook.create | webhook.delete.
+//	@Description	entity_type: team | user | pull_request | token | webhook. Для следующей страницы передайте next_cursor из ответа в cursor.
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			actor_id		query		string	false	"ID токена автора изменения"
+//	@Param			actor_user_id	query		string	false	"пользователь, от имени которого действовал автор"
+//	@Param			action			query		string	false	"action"
+//	@Param			entity_type		query		string	false	"entity_type"
+//	@Param			entity_id		query		string	false	"entity_id"
+//	@Param			request_id		query		string	false	"X-Request-ID запроса"
+//	@Param			from			query		string	false	"начало периода (RFC3339)"
+//	@Param			to				query		string	false	"конец периода, не включительно (RFC3339)"
+//	@Param			limit			query		int		false	"limit (по умолчанию 50, максимум 500)"
+//	@Param			cursor			query		string	false	"cursor"
+//	@Success		200	{object}	model.GetAuditLogResponse
+//	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		401	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
+//	@Failure		500	{object}	model.ErrorResponse
+//	@Router			/admin/getAuditLog [get]
+func (s *AdminService) GetAuditLogHandler(ctx *gin.Context) {
+	var req model.GetAuditLogRequest
+
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	q, err := domain.NewAuditQuery(domain.AuditQuery{
+		ActorID:     req.ActorID,
+		ActorUserID: req.ActorUserID,
+		Action:      domain.AuditAction(req.Action),
+		EntityType:  domain.AuditEntity(req.EntityType),
+		EntityID:    req.EntityID,
+		RequestID:   req.RequestID,
+		From:        req.From,
+		To:          req.To,
+		Limit:       req.Limit,
+	}, req.Cursor)
+	if err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	page, err := s.auditService.Query(ctx.Request.Context(), *q)
+	if err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	entries := make([]model.AuditEntry, 0, len(page.Items))
+	for _, entry := range page.Items {
+		entries = append(entries, entry.ToJSON())
+	}
+
+	ctx.JSON(http.StatusOK, model.GetAuditLogResponse{
+		Entries:    entries,
+		NextCursor: page.NextCursor,
+	})
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodeInvalidRequest  = "INVALID_REQUEST"
+	CodeInvalidMember   = "INVALID_TEAM_MEMBER"
+	CodeNotFound        = "NOT_FOUND"
+	CodePRExists        = "PR_EXISTS"
+	CodePRMerged        = "PR_MERGED"
+	CodeNotAssigned     = "NOT_ASSIGNED"
+	CodeNoCandidate     = "NO_CANDIDATE"
+	CodeVersionConflict = "VERSION_CONFLICT"
+	CodeForbidden       = "FORBIDDEN"
+	CodeNotReplayable   = "NOT_REPLAYABLE"
+	CodeInternalError   = "INTERNAL_ERROR"
+)
+
+// domainError - HTTP статус и код ответа для ошибки доменного слоя.
+type domainError struct {
+	err    error
+	status int
+	code   string
+}
+
+// domainErrors сопоставляет ошибки доменного слоя с ответами API.
+// Сообщение ответа берётся из самой доменной ошибки, а не из обёрток над ней.
+var domainErrors = []domainError{
+	{domain.ErrPRNotFound, http.StatusNotFound, CodeNotFound},
+	{domain.ErrUserNotExist, http.StatusNotFound, CodeNotFound},
+	{domain.ErrTeamMembersNotFound, http.StatusNotFound, CodeNotFound},
+	{domain.ErrPRExists, http.StatusConflict, CodePRExists},
+	{domain.ErrPRMerged, http.StatusConflict, CodePRMerged},
+	{domain.ErrReviewerNotAssigned, http.StatusConflict, CodeNotAssigned},
+	{domain.ErrNoCandidate, http.StatusConflict, CodeNoCandidate},
+	{domain.ErrTeamMemberIsNotValid, http.StatusUnprocessableEntity, CodeInvalidMember},
+	{domain.ErrInvalidStatsPeriod, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrInvalidStatsQuery, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrInvalidStatsCursor, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrStatsCursorMismatch, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrInvalidAuditQuery, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrInvalidAuditCursor, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrInvalidTurnaroundQuery, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrInvalidFairnessThreshold, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrTokenNotFound, http.StatusNotFound, CodeNotFound},
+	{domain.ErrInvalidToken, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrInvalidRole, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrWebhookNotFound, http.StatusNotFound, CodeNotFound},
+	{domain.ErrWebhookDeliveryNotFound, http.StatusNotFound, CodeNotFound},
+	{domain.ErrInvalidWebhook, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrInvalidDeliveryQuery, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrWebhookDeliveryNotFailed, http.StatusConflict, CodeNotReplayable},
+	{domain.ErrForbidden, http.StatusForbidden, CodeForbidden},
+	{domain.ErrAuthorRequired, http.StatusBadRequest, CodeInvalidRequest},
+	{domain.ErrAuthorMismatch, http.StatusForbidden, CodeForbidden},
+}
+
+// writeError отвечает клиенту статусом и кодом доменной ошибки.
+// На конфликт версий PR в ответ добавляется его актуальное состояние.
+// Остальные ошибки логируются и возвращаются как 500 без внутренних подробностей.
+func (s *ApiService) writeError(ctx *gin.Context, err error) {
+	writeError(ctx, s.logger, err)
+}
+
+func writeError(ctx *gin.Context, logger *slog.Logger, err error) {
+	var conflict *domain.PRVersionConflictError
+	if errors.As(err, &conflict) {
+		ctx.Header("ETag", etag(conflict.Current.Version))
+		ctx.JSON(http.StatusConflict, model.VersionConflictResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeVersionConflict,
+				Message: conflict.Error(),
+			},
+			PR: conflict.Current.ToJSON(),
+		})
+		return
+	}
+
+	for _, de := range domainErrors {
+		if errors.Is(err, de.err) {
+			ctx.JSON(de.status, model.ErrorResponse{
+				Error: &model.ErrorDetail{
+					Code:    de.code,
+					Message: de.err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	logger.ErrorContext(ctx.Request.Context(), "request failed", slog.Any("error", err))
+	ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		Error: &model.ErrorDetail{
+			Code:    CodeInternalError,
+			Message: http.StatusText(http.StatusInternalServerError),
+		},
+	})
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidIfMatch = errors.New("If-Match must be a PR version ETag, e.g. \"3\"")
+
+// etag - ETag PR: его версия в кавычках.
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch возвращает версию PR из заголовка If-Match.
+// Без заголовка или с "*" возвращается 0 - версия не проверяется.
+func parseIfMatch(ctx *gin.Context) (int64, error) {
+	value := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		unquoted = value
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errInvalidIfMatch
+	}
+
+	return version, nil
+}
@@ -12,12 +12,17 @@ import (
 //	@Summary		Создать PR и автоматически назначить до 2 ревьюверов из команды автора
 //	@Description
 //	@Tags			PullRequests
+//	@Security		BearerAuth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request  body		model.CreatePullRequestRequest	true	"pull_request"
+//	@Param			Idempotency-Key	header	string	false	"ключ идемпотентности: повтор с тем же ключом вернёт первый ответ"
 //	@Success		200	{object}	model.CreatePullRequestResponse
 //	@Failure		400	{object}	model.ErrorResponse
 //	@Failure		404	{object}	model.ErrorResponse
+//	@Failure		409	{object}	model.ErrorResponse
+//	@Failure		422	{object}	model.ErrorResponse
+//	@Failure		429	{object}	model.ErrorResponse
 //	@Failure		500	{object}	model.ErrorResponse
 //	@Router			/pullRequests/create [post]
 func (s *ApiService) CreatePullRequestHandler(ctx *gin.Context) {
@@ -27,38 +32,41 @@ func (s *ApiService) CreatePullRequestHandler(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INVALID_REQUEST",
+				Code:    CodeInvalidRequest,
 				Message: err.Error(),
 			},
 		})
 		return
 	}
 
-	res, err := s.CreatePullRequest(ctx, &req)
+	res, err := s.CreatePullRequest(ctx.Request.Context(), &req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error: &model.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: err.Error(),
-			},
-		})
+		s.writeError(ctx, err)
 		return
 	}
 
+	ctx.Header("ETag", etag(res.PR.Version))
 	ctx.JSON(http.StatusOK, res)
 }
 
 // MergePullRequestHandler godoc
 //
 //	@Summary		Пометить PR как MERGED (идемпотентная операция)
-//	@Description
+//	@Description	Мержить может автор PR, лид его команды или admin.
 //	@Tags			PullRequests
+//	@Security		BearerAuth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request  body		model.MergePullRequestRequest	true	"pull_request_id"
+//	@Param			Idempotency-Key	header	string	false	"ключ идемпотентности: повтор с тем же ключом вернёт первый ответ"
+//	@Param			If-Match	header	string	false	"ETag PR (его версия), изменение выполнится только для этой версии"
 //	@Success		200	{object}	model.MergePullRequestResponse
 //	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
 //	@Failure		404	{object}	model.ErrorResponse
+//	@Failure		409	{object}	model.VersionConflictResponse
+//	@Failure		422	{object}	model.ErrorResponse
+//	@Failure		429	{object}	model.ErrorResponse
 //	@Failure		500	{object}	model.ErrorResponse
 //	@Router			/pullRequests/merge [post]
 func (s *ApiService) MergePullRequestHandler(ctx *gin.Context) {
@@ -68,24 +76,73 @@ func (s *ApiService) MergePullRequestHandler(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INVALID_REQUEST",
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	req.Version, err = parseIfMatch(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
 				Message: err.Error(),
 			},
 		})
 		return
 	}
 
-	res, err := s.MergePullRequest(ctx, &req)
+	res, err := s.MergePullRequest(ctx.Request.Context(), &req)
+	if err != nil {
+		s.writeError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", etag(res.PR.Version))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ApprovePullRequestHandler godoc
+//
+//	@Summary		Одобрить PR назначенным ревьюером
+//	@Description	Одобрить PR может только сам ревьюер или admin. От первого одобрения считается time_to_first_review в stats/getTurnaround.
+//	@Tags			PullRequests
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request  body		model.ApprovePullRequestRequest	true	"pull_request"
+//	@Param			Idempotency-Key	header	string	false	"ключ идемпотентности: повтор с тем же ключом вернёт первый ответ"
+//	@Success		200	{object}	model.ApprovePullRequestResponse
+//	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
+//	@Failure		404	{object}	model.ErrorResponse
+//	@Failure		409	{object}	model.ErrorResponse
+//	@Failure		422	{object}	model.ErrorResponse
+//	@Failure		429	{object}	model.ErrorResponse
+//	@Failure		500	{object}	model.ErrorResponse
+//	@Router			/pullRequests/approve [post]
+func (s *ApiService) ApprovePullRequestHandler(ctx *gin.Context) {
+	var req model.ApprovePullRequestRequest
+
+	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
+				Code:    CodeInvalidRequest,
 				Message: err.Error(),
 			},
 		})
 		return
 	}
 
+	res, err := s.ApprovePullRequest(ctx.Request.Context(), &req)
+	if err != nil {
+		s.writeError(ctx, err)
+		return
+	}
+
 	ctx.JSON(http.StatusOK, res)
 }
 
@@ -94,12 +151,19 @@ func (s *ApiService) MergePullRequestHandler(ctx *gin.Context) {
 //	@Summary		Переназначить конкретного ревьювера на другого из его команды
 //	@Description
 //	@Tags			PullRequests
+//	@Security		BearerAuth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request  body		model.ReassignPullRequestRequest	true	"pull_request"
+//	@Param			If-Match	header	string	false	"ETag PR (его версия), изменение выполнится только для этой версии"
+//	@Param			Idempotency-Key	header	string	false	"ключ идемпотентности: повтор с тем же ключом вернёт первый ответ"
 //	@Success		200	{object}	model.ReassignPullRequestResponse
 //	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
 //	@Failure		404	{object}	model.ErrorResponse
+//	@Failure		409	{object}	model.ErrorResponse
+//	@Failure		422	{object}	model.ErrorResponse
+//	@Failure		429	{object}	model.ErrorResponse
 //	@Failure		500	{object}	model.ErrorResponse
 //	@Router			/pullRequests/reassign [post]
 func (s *ApiService) ReassignPullRequestHandler(ctx *gin.Context) {
@@ -109,23 +173,30 @@ func (s *ApiService) ReassignPullRequestHandler(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INVALID_REQUEST",
+				Code:    CodeInvalidRequest,
 				Message: err.Error(),
 			},
 		})
 		return
 	}
 
-	res, err := s.ReassignPullRequest(ctx, &req)
+	req.Version, err = parseIfMatch(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
+				Code:    CodeInvalidRequest,
 				Message: err.Error(),
 			},
 		})
 		return
 	}
 
+	res, err := s.ReassignPullRequest(ctx.Request.Context(), &req)
+	if err != nil {
+		s.writeError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", etag(res.PR.Version))
 	ctx.JSON(http.StatusOK, res)
 }
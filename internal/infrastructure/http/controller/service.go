@@ -4,26 +4,36 @@ import (
 	"avito-tech-go-task/internal/domain"
 	"avito-tech-go-task/internal/infrastructure/http/model"
 	"context"
+	"log/slog"
 )
 
 type PRService interface {
 	CreatePR(ctx context.Context, prID, prName, authorID string) (domain.PullRequest, error)
-	MergePR(ctx context.Context, prID string) (domain.PullRequest, error)
-	ReassignPR(ctx context.Context, prID, oldReviewerID string) (prVal domain.PullRequest, newReviewerID string, err error)
+	MergePR(ctx context.Context, prID string, version int64) (domain.PullRequest, error)
+	ApprovePR(ctx context.Context, prID, reviewerID string) (domain.PullRequest, error)
+	ReassignPR(ctx context.Context, prID, oldReviewerID string, version int64) (prVal domain.PullRequest, newReviewerID string, err error)
 	SetIsActiveUser(ctx context.Context, userID string, isActive bool) (domain.User, error)
+	SetUserRole(ctx context.Context, userID string, role string) (domain.User, error)
 	GetReviewUser(ctx context.Context, userID string) ([]domain.PullRequest, error)
 	AddTeam(ctx context.Context, teamName string, members []model.TeamMember) error
 	GetTeam(ctx context.Context, teamName string) ([]domain.User, error)
 	GetStats(ctx context.Context, limit uint64) ([]domain.UserStat, error)
+	GetGroupStats(ctx context.Context, q domain.StatsQuery) (domain.StatsPage, error)
+	GetTurnaround(ctx context.Context, q domain.TurnaroundQuery) ([]domain.TurnaroundStat, error)
+	GetFairness(ctx context.Context, q domain.FairnessQuery) ([]domain.TeamFairness, error)
 	DeactivateTeam(ctx context.Context, teamName string) ([]domain.PullRequest, error)
 }
 
 type ApiService struct {
 	prService PRService
+	logger    *slog.Logger
 }
 
-func NewApiService(prService PRService) *ApiService {
-	return &ApiService{prService: prService}
+func NewApiService(prService PRService, logger *slog.Logger) *ApiService {
+	return &ApiService{
+		prService: prService,
+		logger:    logger,
+	}
 }
 
 func (s *ApiService) CreatePullRequest(ctx context.Context, req *model.CreatePullRequestRequest) (*model.CreatePullRequestResponse, error) {
@@ -40,7 +50,7 @@ func (s *ApiService) CreatePullRequest(ctx context.Context, req *model.CreatePul
 }
 
 func (s *ApiService) MergePullRequest(ctx context.Context, req *model.MergePullRequestRequest) (*model.MergePullRequestResponse, error) {
-	pr, err := s.prService.MergePR(ctx, req.PullRequestID)
+	pr, err := s.prService.MergePR(ctx, req.PullRequestID, req.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -52,8 +62,21 @@ func (s *ApiService) MergePullRequest(ctx context.Context, req *model.MergePullR
 	return res, nil
 }
 
+func (s *ApiService) ApprovePullRequest(ctx context.Context, req *model.ApprovePullRequestRequest) (*model.ApprovePullRequestResponse, error) {
+	pr, err := s.prService.ApprovePR(ctx, req.PullRequestID, req.ReviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &model.ApprovePullRequestResponse{
+		PR: pr.ToJSON(),
+	}
+
+	return res, nil
+}
+
 func (s *ApiService) ReassignPullRequest(ctx context.Context, req *model.ReassignPullRequestRequest) (*model.ReassignPullRequestResponse, error) {
-	pr, replacedBy, err := s.prService.ReassignPR(ctx, req.PullRequestID, req.OldReviewerID)
+	pr, replacedBy, err := s.prService.ReassignPR(ctx, req.PullRequestID, req.OldReviewerID, req.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -79,6 +102,19 @@ func (s *ApiService) SetIsActiveUser(ctx context.Context, req *model.SetIsActive
 	return res, nil
 }
 
+func (s *ApiService) SetUserRole(ctx context.Context, req *model.SetUserRoleRequest) (*model.SetUserRoleResponse, error) {
+	user, err := s.prService.SetUserRole(ctx, req.UserID, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &model.SetUserRoleResponse{
+		User: user.ToJSON(),
+	}
+
+	return res, nil
+}
+
 func (s *ApiService) GetReviewerUser(ctx context.Context, userID string) (*model.GetReviewUserResponse, error) {
 	prs, err := s.prService.GetReviewUser(ctx, userID)
 	if err != nil {
@@ -149,6 +185,85 @@ func (s *ApiService) GetStats(ctx context.Context, limit uint64) (*model.GetStat
 	return res, nil
 }
 
+func (s *ApiService) GetReviewStats(ctx context.Context, req *model.GetReviewStatsRequest) (*model.GetReviewStatsResponse, error) {
+	q, err := domain.NewStatsQuery(req.From, req.To, req.GroupBy, req.SortBy, req.Order, req.Limit, req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.prService.GetGroupStats(ctx, *q)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonStats := make([]model.GroupStat, 0, len(page.Items))
+	for _, stat := range page.Items {
+		jsonStats = append(jsonStats, stat.ToJSON())
+	}
+
+	res := &model.GetReviewStatsResponse{
+		GroupBy:    string(q.GroupBy),
+		From:       q.From,
+		To:         q.To,
+		Stats:      jsonStats,
+		NextCursor: page.NextCursor,
+	}
+
+	return res, nil
+}
+
+func (s *ApiService) GetTurnaround(ctx context.Context, req *model.GetTurnaroundRequest) (*model.GetTurnaroundResponse, error) {
+	q, err := domain.NewTurnaroundQuery(req.From, req.To, req.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.prService.GetTurnaround(ctx, *q)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonStats := make([]model.TurnaroundStat, 0, len(stats))
+	for _, stat := range stats {
+		jsonStats = append(jsonStats, stat.ToJSON())
+	}
+
+	res := &model.GetTurnaroundResponse{
+		GroupBy: string(q.GroupBy),
+		From:    q.From,
+		To:      q.To,
+		Stats:   jsonStats,
+	}
+
+	return res, nil
+}
+
+func (s *ApiService) GetFairness(ctx context.Context, req *model.GetFairnessRequest) (*model.GetFairnessResponse, error) {
+	q, err := domain.NewFairnessQuery(req.From, req.To, req.TeamName, req.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := s.prService.GetFairness(ctx, *q)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonTeams := make([]model.TeamFairness, 0, len(teams))
+	for _, team := range teams {
+		jsonTeams = append(jsonTeams, team.ToJSON())
+	}
+
+	res := &model.GetFairnessResponse{
+		From:      q.From,
+		To:        q.To,
+		Threshold: q.Threshold,
+		Teams:     jsonTeams,
+	}
+
+	return res, nil
+}
+
 func (s *ApiService) DeactivateTeam(ctx context.Context, teamName string) (*model.DeactivateTeamResponse, error) {
 	users, err := s.prService.DeactivateTeam(ctx, teamName)
 	if err != nil {
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReviewStatsHandler godoc
+//
+//	@Summary		Получить статистику ревью за период с группировкой по пользователю или команде
+//	@Description	group_by: user | team, sort_by: key | assigned | merged | reassigned | median_time_to_merge, order: asc | desc.
+//	@Description	Для следующей страницы передайте next_cursor из ответа в cursor.
+//	@Tags			Stats
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			from		query		string	true	"начало периода (RFC3339)"
+//	@Param			to			query		string	true	"конец периода, не включительно (RFC3339)"
+//	@Param			group_by	query		string	false	"group_by"
+//	@Param			sort_by		query		string	false	"sort_by"
+//	@Param			order		query		string	false	"order"
+//	@Param			limit		query		int		false	"limit (по умолчанию 50, максимум 500)"
+//	@Param			cursor		query		string	false	"cursor"
+//	@Success		200	{object}	model.GetReviewStatsResponse
+//	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		500	{object}	model.ErrorResponse
+//	@Router			/stats/getReviews [get]
+func (s *ApiService) GetReviewStatsHandler(ctx *gin.Context) {
+	var req model.GetReviewStatsRequest
+
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	res, err := s.GetReviewStats(ctx.Request.Context(), &req)
+	if err != nil {
+		s.writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetTurnaroundHandler godoc
+//
+//	@Summary		Получить перцентили времени до первого ревью (одобрения) и до мержа
+//	@Description	Считается по PR, созданным в период [from, to). group_by: team (команда автора) | reviewer | week.
+//	@Description	format=csv отдаёт ту же таблицу в CSV.
+//	@Tags			Stats
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			from		query		string	true	"начало периода (RFC3339)"
+//	@Param			to			query		string	true	"конец периода, не включительно (RFC3339)"
+//	@Param			group_by	query		string	false	"group_by"
+//	@Param			format		query		string	false	"json | csv"
+//	@Success		200	{object}	model.GetTurnaroundResponse
+//	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		500	{object}	model.ErrorResponse
+//	@Router			/stats/getTurnaround [get]
+func (s *ApiService) GetTurnaroundHandler(ctx *gin.Context) {
+	var req model.GetTurnaroundRequest
+
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	res, err := s.GetTurnaround(ctx.Request.Context(), &req)
+	if err != nil {
+		s.writeError(ctx, err)
+		return
+	}
+
+	if req.Format == "csv" {
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Header("Content-Disposition", `attachment; filename="turnaround.csv"`)
+		ctx.Status(http.StatusOK)
+		_ = writeTurnaroundCSV(ctx.Writer, res.Stats)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+func writeTurnaroundCSV(out io.Writer, stats []model.TurnaroundStat) error {
+	w := csv.NewWriter(out)
+
+	err := w.Write([]string{
+		"key",
+		"pull_requests",
+		"time_to_first_review_p50_seconds",
+		"time_to_first_review_p90_seconds",
+		"time_to_first_review_p99_seconds",
+		"merged_pull_requests",
+		"time_to_merge_p50_seconds",
+		"time_to_merge_p90_seconds",
+		"time_to_merge_p99_seconds",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range stats {
+		err = w.Write([]string{
+			stat.Key,
+			strconv.FormatInt(stat.PullRequests, 10),
+			formatSeconds(stat.TimeToFirstReview.P50Seconds),
+			formatSeconds(stat.TimeToFirstReview.P90Seconds),
+			formatSeconds(stat.TimeToFirstReview.P99Seconds),
+			strconv.FormatInt(stat.MergedPullRequests, 10),
+			formatSeconds(stat.TimeToMerge.P50Seconds),
+			formatSeconds(stat.TimeToMerge.P90Seconds),
+			formatSeconds(stat.TimeToMerge.P99Seconds),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
+
+// GetFairnessHandler godoc
+//
+//	@Summary		Получить отчёт о равномерности распределения ревью в командах
+//	@Description	Доля назначений каждого участника за период сравнивается с равной долей в команде.
+//	@Description	Участник помечается overloaded/underused, если отклонение больше threshold (по умолчанию 0.5 = ±50%).
+//	@Tags			Stats
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			from		query		string	true	"начало периода (RFC3339)"
+//	@Param			to			query		string	true	"конец периода, не включительно (RFC3339)"
+//	@Param			team_name	query		string	false	"team_name"
+//	@Param			threshold	query		number	false	"threshold"
+//	@Success		200	{object}	model.GetFairnessResponse
+//	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		500	{object}	model.ErrorResponse
+//	@Router			/stats/getFairness [get]
+func (s *ApiService) GetFairnessHandler(ctx *gin.Context) {
+	var req model.GetFairnessRequest
+
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	res, err := s.GetFairness(ctx.Request.Context(), &req)
+	if err != nil {
+		s.writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
@@ -1,3 +1,9 @@
+// Package controller - HTTP обработчики API.
+//
+//	@securityDefinitions.apikey	BearerAuth
+//	@in							header
+//	@name						Authorization
+//	@description				Bearer <token>, если включена аутентификация (AUTH_ENABLED)
 package controller
 
 import (
@@ -12,12 +18,17 @@ import (
 //	@Summary		Создать команду с участниками (создаёт/обновляет пользователей)
 //	@Description
 //	@Tags			Teams
+//	@Security		BearerAuth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request    body		model.AddTeamRequest	true	"team"
+//	@Param			Idempotency-Key	header	string	false	"ключ идемпотентности: повтор с тем же ключом вернёт первый ответ"
 //	@Success		200	{object}	model.Team
 //	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
 //	@Failure		404	{object}	model.ErrorResponse
+//	@Failure		409	{object}	model.ErrorResponse
+//	@Failure		422	{object}	model.ErrorResponse
 //	@Failure		500	{object}	model.ErrorResponse
 //	@Router			/teams/add [post]
 func (s *ApiService) AddTeamHandler(ctx *gin.Context) {
@@ -27,21 +38,16 @@ func (s *ApiService) AddTeamHandler(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INVALID_REQUEST",
+				Code:    CodeInvalidRequest,
 				Message: err.Error(),
 			},
 		})
 		return
 	}
 
-	res, err := s.AddTeam(ctx, &req)
+	res, err := s.AddTeam(ctx.Request.Context(), &req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error: &model.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: err.Error(),
-			},
-		})
+		s.writeError(ctx, err)
 		return
 	}
 
@@ -53,6 +59,7 @@ func (s *ApiService) AddTeamHandler(ctx *gin.Context) {
 //	@Summary		Получить команду с участниками
 //	@Description
 //	@Tags			Teams
+//	@Security		BearerAuth
 //	@Accept			json
 //	@Produce		json
 //	@Param			team_name	query		string	true	"team_name"
@@ -66,21 +73,16 @@ func (s *ApiService) GetTeamHandler(ctx *gin.Context) {
 	if teamName == "" {
 		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INVALID_REQUEST",
+				Code:    CodeInvalidRequest,
 				Message: "team_name can't be empty",
 			},
 		})
 		return
 	}
 
-	res, err := s.GetTeam(ctx, teamName)
+	res, err := s.GetTeam(ctx.Request.Context(), teamName)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error: &model.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: err.Error(),
-			},
-		})
+		s.writeError(ctx, err)
 		return
 	}
 
@@ -92,12 +94,17 @@ func (s *ApiService) GetTeamHandler(ctx *gin.Context) {
 //	@Summary		Деактивировать команду
 //	@Description
 //	@Tags			Teams
+//	@Security		BearerAuth
 //	@Accept			json
 //	@Produce		json
 //	@Param			team_name	query		string	true	"team_name"
+//	@Param			Idempotency-Key	header	string	false	"ключ идемпотентности: повтор с тем же ключом вернёт первый ответ"
 //	@Success		200	{object}	model.DeactivateTeamResponse
 //	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
 //	@Failure		404	{object}	model.ErrorResponse
+//	@Failure		409	{object}	model.ErrorResponse
+//	@Failure		422	{object}	model.ErrorResponse
 //	@Failure		500	{object}	model.ErrorResponse
 //	@Router			/teams/deactivate [patch]
 func (s *ApiService) DeactivateTeamHandler(ctx *gin.Context) {
@@ -105,21 +112,16 @@ func (s *ApiService) DeactivateTeamHandler(ctx *gin.Context) {
 	if teamName == "" {
 		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INVALID_REQUEST",
+				Code:    CodeInvalidRequest,
 				Message: "team_name can't be empty",
 			},
 		})
 		return
 	}
 
-	res, err := s.DeactivateTeam(ctx, teamName)
+	res, err := s.DeactivateTeam(ctx.Request.Context(), teamName)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error: &model.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: err.Error(),
-			},
-		})
+		s.writeError(ctx, err)
 		return
 	}
 
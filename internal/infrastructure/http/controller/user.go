@@ -11,14 +11,19 @@ import (
 // SetIsActiveUserHandler godoc
 //
 //	@Summary		Установить флаг активности пользователя
-//	@Description
+//	@Description	member меняет только свой флаг, team_lead - флаги участников своей команды, admin - любые.
 //	@Tags			Users
+//	@Security		BearerAuth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request body		model.SetIsActiveUserRequest	true	"user"
+//	@Param			Idempotency-Key	header	string	false	"ключ идемпотентности: повтор с тем же ключом вернёт первый ответ"
 //	@Success		200	{object}	model.SetIsActiveUserResponse
 //	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
 //	@Failure		404	{object}	model.ErrorResponse
+//	@Failure		409	{object}	model.ErrorResponse
+//	@Failure		422	{object}	model.ErrorResponse
 //	@Failure		500	{object}	model.ErrorResponse
 //	@Router			/users/setIsActive [post]
 func (s *ApiService) SetIsActiveUserHandler(ctx *gin.Context) {
@@ -28,24 +33,60 @@ func (s *ApiService) SetIsActiveUserHandler(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INVALID_REQUEST",
+				Code:    CodeInvalidRequest,
 				Message: err.Error(),
 			},
 		})
 		return
 	}
 
-	res, err := s.SetIsActiveUser(ctx, &req)
+	res, err := s.SetIsActiveUser(ctx.Request.Context(), &req)
+	if err != nil {
+		s.writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// SetUserRoleHandler godoc
+//
+//	@Summary		Назначить пользователю роль
+//	@Description	role: admin, team_lead, member. Менять роли может только admin.
+//	@Tags			Users
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request body		model.SetUserRoleRequest	true	"role"
+//	@Param			Idempotency-Key	header	string	false	"ключ идемпотентности: повтор с тем же ключом вернёт первый ответ"
+//	@Success		200	{object}	model.SetUserRoleResponse
+//	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
+//	@Failure		404	{object}	model.ErrorResponse
+//	@Failure		409	{object}	model.ErrorResponse
+//	@Failure		422	{object}	model.ErrorResponse
+//	@Failure		500	{object}	model.ErrorResponse
+//	@Router			/users/setRole [post]
+func (s *ApiService) SetUserRoleHandler(ctx *gin.Context) {
+	var req model.SetUserRoleRequest
+
+	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
+				Code:    CodeInvalidRequest,
 				Message: err.Error(),
 			},
 		})
 		return
 	}
 
+	res, err := s.SetUserRole(ctx.Request.Context(), &req)
+	if err != nil {
+		s.writeError(ctx, err)
+		return
+	}
+
 	ctx.JSON(http.StatusOK, res)
 }
 
@@ -54,6 +95,7 @@ func (s *ApiService) SetIsActiveUserHandler(ctx *gin.Context) {
 //	@Summary		Получить PR'ы, где пользователь назначен ревьювером
 //	@Description
 //	@Tags			Users
+//	@Security		BearerAuth
 //	@Accept			json
 //	@Produce		json
 //	@Param			user_id	   query		string	true	"user_id"
@@ -67,21 +109,16 @@ func (s *ApiService) GetReviewerUserHandler(ctx *gin.Context) {
 	if userID == "" {
 		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INVALID_REQUEST",
+				Code:    CodeInvalidRequest,
 				Message: "user_id can't be empty",
 			},
 		})
 		return
 	}
 
-	res, err := s.GetReviewerUser(ctx, userID)
+	res, err := s.GetReviewerUser(ctx.Request.Context(), userID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error: &model.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: err.Error(),
-			},
-		})
+		s.writeError(ctx, err)
 		return
 	}
 
@@ -93,6 +130,7 @@ func (s *ApiService) GetReviewerUserHandler(ctx *gin.Context) {
 //	@Summary		Получить cтатистику пользователей по PR
 //	@Description	limit = 0 - убирает лимит
 //	@Tags			Users
+//	@Security		BearerAuth
 //	@Accept			json
 //	@Produce		json
 //	@Param			limit	   query		string	true	"limit"
@@ -107,7 +145,7 @@ func (s *ApiService) GetStatsHandler(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INVALID_REQUEST",
+				Code:    CodeInvalidRequest,
 				Message: "limit can't be string",
 			},
 		})
@@ -116,21 +154,16 @@ func (s *ApiService) GetStatsHandler(ctx *gin.Context) {
 	if limitInt < 0 {
 		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Error: &model.ErrorDetail{
-				Code:    "INVALID_REQUEST",
+				Code:    CodeInvalidRequest,
 				Message: "limit can't be < 0",
 			},
 		})
 		return
 	}
 
-	res, err := s.GetStats(ctx, uint64(limitInt))
+	res, err := s.GetStats(ctx.Request.Context(), uint64(limitInt))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error: &model.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: err.Error(),
-			},
-		})
+		s.writeError(ctx, err)
 		return
 	}
 
@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWebhookHandler godoc
+//
+//	@Summary		Подписать URL на доменные события
+//	@Description	events: pr.created, pr.reviewer_assigned, pr.reviewer_removed, pr.approved, pr.merged, user.deactivated, team.deactivated; пустой список - все события.
+//	@Description	Каждая доставка - POST с событием в теле и подписью X-Webhook-Signature: sha256=HMAC-SHA256(secret, "<X-Webhook-Timestamp>.<тело>").
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		model.CreateWebhookRequest	true	"webhook"
+//	@Success		201		{object}	model.CreateWebhookResponse
+//	@Failure		400		{object}	model.ErrorResponse
+//	@Failure		401		{object}	model.ErrorResponse
+//	@Failure		403		{object}	model.ErrorResponse
+//	@Failure		500		{object}	model.ErrorResponse
+//	@Router			/admin/createWebhook [post]
+func (s *AdminService) CreateWebhookHandler(ctx *gin.Context) {
+	var req model.CreateWebhookRequest
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	subscription, err := s.webhookService.Create(ctx.Request.Context(), req.URL, domain.EventTypesFromStrings(req.Events), req.Secret)
+	if err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, model.CreateWebhookResponse{Webhook: subscription.ToJSON()})
+}
+
+// ListWebhooksHandler godoc
+//
+//	@Summary		Получить список подписок на события
+//	@Description	Секреты подписок не возвращаются.
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	model.ListWebhooksResponse
+//	@Failure		401	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
+//	@Failure		500	{object}	model.ErrorResponse
+//	@Router			/admin/listWebhooks [get]
+func (s *AdminService) ListWebhooksHandler(ctx *gin.Context) {
+	subscriptions, err := s.webhookService.List(ctx.Request.Context())
+	if err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	webhooks := make([]model.Webhook, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		webhooks = append(webhooks, subscription.ToJSON())
+	}
+
+	ctx.JSON(http.StatusOK, model.ListWebhooksResponse{Webhooks: webhooks})
+}
+
+// DeleteWebhookHandler godoc
+//
+//	@Summary		Удалить подписку на события
+//	@Description	Вместе с подпиской удаляются её доставки, включая неотправленные.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body	model.DeleteWebhookRequest	true	"webhook"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorResponse
+//	@Failure		401	{object}	model.ErrorResponse
+//	@Failure		403	{object}	model.ErrorResponse
+//	@Failure		404	{object}	model.ErrorResponse
+//	@Failure		500	{object}	model.ErrorResponse
+//	@Router			/admin/deleteWebhook [post]
+func (s *AdminService) DeleteWebhookHandler(ctx *gin.Context) {
+	var req model.DeleteWebhookRequest
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err = s.webhookService.Delete(ctx.Request.Context(), req.WebhookID); err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListWebhookDeliveriesHandler godoc
+//
+//	@Summary		Получить доставки событий подписчикам
+//	@Description	Доставки отдаются от новых к старым. status: pending - ждёт попытки, delivered - доставлена, dead - попытки исчерпаны.
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			webhook_id	query		string	false	"ID подписки"
+//	@Param			status		query		string	false	"status"
+//	@Param			limit		query		int		false	"limit (по умолчанию 50, максимум 500)"
+//	@Success		200			{object}	model.ListWebhookDeliveriesResponse
+//	@Failure		400			{object}	model.ErrorResponse
+//	@Failure		401			{object}	model.ErrorResponse
+//	@Failure		403			{object}	model.ErrorResponse
+//	@Failure		500			{object}	model.ErrorResponse
+//	@Router			/admin/listWebhookDeliveries [get]
+func (s *AdminService) ListWebhookDeliveriesHandler(ctx *gin.Context) {
+	var req model.ListWebhookDeliveriesRequest
+
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	q, err := domain.NewWebhookDeliveryQuery(domain.WebhookDeliveryQuery{
+		SubscriptionID: req.WebhookID,
+		Status:         domain.WebhookDeliveryStatus(req.Status),
+		Limit:          req.Limit,
+	})
+	if err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	deliveries, err := s.webhookService.ListDeliveries(ctx.Request.Context(), *q)
+	if err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	jsonDeliveries := make([]model.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		jsonDeliveries = append(jsonDeliveries, delivery.ToJSON())
+	}
+
+	ctx.JSON(http.StatusOK, model.ListWebhookDeliveriesResponse{Deliveries: jsonDeliveries})
+}
+
+// GetWebhookDeliveryHandler godoc
+//
+//	@Summary		Получить доставку и её попытки
+//	@Description	attempts - все попытки доставки по порядку, включая попытки до повторов через replayWebhookDelivery.
+//	@Tags			Admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			delivery_id	query		int	true	"ID доставки"
+//	@Success		200			{object}	model.GetWebhookDeliveryResponse
+//	@Failure		400			{object}	model.ErrorResponse
+//	@Failure		401			{object}	model.ErrorResponse
+//	@Failure		403			{object}	model.ErrorResponse
+//	@Failure		404			{object}	model.ErrorResponse
+//	@Failure		500			{object}	model.ErrorResponse
+//	@Router			/admin/getWebhookDelivery [get]
+func (s *AdminService) GetWebhookDeliveryHandler(ctx *gin.Context) {
+	var req model.GetWebhookDeliveryRequest
+
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	delivery, attempts, err := s.webhookService.GetDelivery(ctx.Request.Context(), req.DeliveryID)
+	if err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	jsonAttempts := make([]model.WebhookAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		jsonAttempts = append(jsonAttempts, attempt.ToJSON())
+	}
+
+	ctx.JSON(http.StatusOK, model.GetWebhookDeliveryResponse{
+		Delivery: delivery.ToJSON(),
+		Payload:  delivery.Payload,
+		Attempts: jsonAttempts,
+	})
+}
+
+// ReplayWebhookDeliveryHandler godoc
+//
+//	@Summary		Повторить доставку из dead letter
+//	@Description	Доставка возвращается в статус pending с новым счётчиком попыток и отправляется при ближайшем проходе.
+//	@Description	Повторить можно только доставку в статусе dead.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		model.ReplayWebhookDeliveryRequest	true	"delivery"
+//	@Success		200		{object}	model.ReplayWebhookDeliveryResponse
+//	@Failure		400		{object}	model.ErrorResponse
+//	@Failure		401		{object}	model.ErrorResponse
+//	@Failure		403		{object}	model.ErrorResponse
+//	@Failure		404		{object}	model.ErrorResponse
+//	@Failure		409		{object}	model.ErrorResponse
+//	@Failure		500		{object}	model.ErrorResponse
+//	@Router			/admin/replayWebhookDelivery [post]
+func (s *AdminService) ReplayWebhookDeliveryHandler(ctx *gin.Context) {
+	var req model.ReplayWebhookDeliveryRequest
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: &model.ErrorDetail{
+				Code:    CodeInvalidRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	delivery, err := s.webhookService.Replay(ctx.Request.Context(), req.DeliveryID)
+	if err != nil {
+		writeError(ctx, s.logger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.ReplayWebhookDeliveryResponse{Delivery: delivery.ToJSON()})
+}
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+type PoolStats struct {
+	MaxOpenConnections int   `json:"max_open_connections" example:"25"`
+	OpenConnections    int   `json:"open_connections" example:"4"`
+	InUse              int   `json:"in_use" example:"1"`
+	Idle               int   `json:"idle" example:"3"`
+	WaitCount          int64 `json:"wait_count" example:"12"`
+	WaitDurationMs     int64 `json:"wait_duration_ms" example:"37"`
+	MaxIdleClosed      int64 `json:"max_idle_closed" example:"0"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed" example:"5"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed" example:"2"`
+}
+
+type GetPoolStatsResponse struct {
+	// Pools - статистика по каждому пулу соединений, ключ - имя пула (primary)
+	Pools map[string]PoolStats `json:"pools"`
+}
+
+type APIToken struct {
+	TokenID   string     `json:"token_id" example:"3f9a1c0b7d2e4a58"`
+	Name      string     `json:"name" example:"ci-pipeline"`
+	Scopes    []string   `json:"scopes" example:"prs:write,stats:read"`
+	UserID    string     `json:"user_id,omitempty" example:"u1"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type IssueTokenRequest struct {
+	Name   string   `json:"name" binding:"required" example:"ci-pipeline"`
+	Scopes []string `json:"scopes" binding:"required" example:"prs:write,stats:read"`
+	// UserID - пользователь, от имени которого действует токен; без него токен сервисный и роли к нему не применяются
+	UserID string `json:"user_id,omitempty" example:"u1"`
+}
+
+type IssueTokenResponse struct {
+	Token APIToken `json:"token"`
+	// Secret - значение для заголовка Authorization: Bearer, показывается только при выпуске
+	Secret string `json:"secret" example:"prs_9c1d..."`
+}
+
+type ListTokensResponse struct {
+	Tokens []APIToken `json:"tokens"`
+}
+
+type RevokeTokenRequest struct {
+	TokenID string `json:"token_id" binding:"required" example:"3f9a1c0b7d2e4a58"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type GetAuditLogRequest struct {
+	ActorID     string    `form:"actor_id" example:"3f9a1c0b7d2e4a58"`
+	ActorUserID string    `form:"actor_user_id" example:"u1"`
+	Action      string    `form:"action" example:"team.deactivate"`
+	EntityType  string    `form:"entity_type" example:"team"`
+	EntityID    string    `form:"entity_id" example:"payments"`
+	RequestID   string    `form:"request_id"`
+	From        time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-11-01T00:00:00Z"`
+	To          time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-12-01T00:00:00Z"`
+	Limit       uint64    `form:"limit" example:"50"`
+	Cursor      string    `form:"cursor"`
+}
+
+type AuditEntry struct {
+	ID          int64     `json:"id" example:"42"`
+	CreatedAt   time.Time `json:"created_at"`
+	ActorID     string    `json:"actor_id,omitempty" example:"3f9a1c0b7d2e4a58"`
+	ActorUserID string    `json:"actor_user_id,omitempty" example:"u1"`
+	Action      string    `json:"action" example:"team.deactivate"`
+	EntityType  string    `json:"entity_type" example:"team"`
+	EntityID    string    `json:"entity_id" example:"payments"`
+	// Before - состояние сущности до изменения, null у созданных сущностей
+	Before json.RawMessage `json:"before" swaggertype:"object"`
+	// After - состояние сущности после изменения
+	After     json.RawMessage `json:"after" swaggertype:"object"`
+	RequestID string          `json:"request_id,omitempty" example:"9b2f6c1e0a7d4e3b"`
+}
+
+type GetAuditLogResponse struct {
+	Entries []AuditEntry `json:"entries"`
+	// NextCursor - cursor следующей страницы, пустой на последней странице
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event - доменное событие в том виде, в котором его получают подписчики.
+type Event struct {
+	ID            int64     `json:"id" example:"42"`
+	Type          string    `json:"type" example:"pr.reviewer_assigned"`
+	AggregateType string    `json:"aggregate_type" example:"pull_request"`
+	AggregateID   string    `json:"aggregate_id" example:"pr-1001"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	RequestID     string    `json:"request_id,omitempty" example:"9b2f6c1e0a7d4e3b"`
+	// Payload зависит от type: PullRequest для pr.created и pr.merged, ReviewerEvent для pr.reviewer_* и pr.approved,
+	// UserDeactivatedEvent для user.deactivated, TeamDeactivatedEvent для team.deactivated
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+}
+
+type ReviewerEvent struct {
+	PullRequestID string `json:"pull_request_id" example:"pr-1001"`
+	ReviewerID    string `json:"reviewer_id" example:"u2"`
+}
+
+type UserDeactivatedEvent struct {
+	UserID   string `json:"user_id" example:"u2"`
+	TeamName string `json:"team_name,omitempty" example:"payments"`
+}
+
+type TeamDeactivatedEvent struct {
+	TeamName string `json:"team_name" example:"payments"`
+	// UserIDs - участники, которые были активны до деактивации команды
+	UserIDs []string `json:"user_ids"`
+}
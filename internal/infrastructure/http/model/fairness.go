@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type GetFairnessRequest struct {
+	From      time.Time `form:"from" binding:"required" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-11-01T00:00:00Z"`
+	To        time.Time `form:"to" binding:"required" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-12-01T00:00:00Z"`
+	TeamName  string    `form:"team_name" example:"payments"`
+	Threshold float64   `form:"threshold" example:"0.5"`
+}
+
+type MemberWorkload struct {
+	UserID        string  `json:"user_id" example:"u2"`
+	Assigned      int64   `json:"assigned" example:"6"`
+	Share         float64 `json:"share" example:"0.6"`
+	ExpectedShare float64 `json:"expected_share" example:"0.333"`
+	Deviation     float64 `json:"deviation" example:"0.8"`
+	Status        string  `json:"status" example:"overloaded"`
+}
+
+type TeamFairness struct {
+	TeamName      string           `json:"team_name" example:"payments"`
+	TotalAssigned int64            `json:"total_assigned" example:"10"`
+	MaxDeviation  float64          `json:"max_deviation" example:"0.8"`
+	Members       []MemberWorkload `json:"members"`
+}
+
+type GetFairnessResponse struct {
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Threshold float64        `json:"threshold" example:"0.5"`
+	Teams     []TeamFairness `json:"teams"`
+}
@@ -0,0 +1,8 @@
+package model
+
+type HealthResponse struct {
+	// Status - ok или unavailable
+	Status string `json:"status"`
+	// Checks - результат каждой проверки готовности: ok или текст ошибки
+	Checks map[string]string `json:"checks,omitempty"`
+}
@@ -9,6 +9,8 @@ type PullRequest struct {
 	Status            string    `json:"status" example:"OPEN"`
 	AssignedReviewers []string  `json:"assigned_reviewers"`
 	MergedAt          time.Time `json:"mergedAt,omitempty"`
+	CreatedAt         time.Time `json:"createdAt,omitempty"`
+	Version           int64     `json:"version" example:"1"`
 }
 
 type PullRequestShort struct {
@@ -18,10 +20,11 @@ type PullRequestShort struct {
 	Status          string `json:"status" example:"OPEN"`
 }
 
+// CreatePullRequestRequest - AuthorID необязателен, если токен привязан к пользователю: автором тогда становится он.
 type CreatePullRequestRequest struct {
 	PullRequestID   string `json:"pull_request_id" binding:"required" example:"pr-1001"`
 	PullRequestName string `json:"pull_request_name" binding:"required" example:"Add search"`
-	AuthorID        string `json:"author_id" binding:"required" example:"u1"`
+	AuthorID        string `json:"author_id" example:"u1"`
 }
 
 type CreatePullRequestResponse struct {
@@ -30,18 +33,36 @@ type CreatePullRequestResponse struct {
 
 type MergePullRequestRequest struct {
 	PullRequestID string `json:"pull_request_id" binding:"required" example:"pr-1001"`
+	// Version - версия из заголовка If-Match, 0 - без проверки
+	Version int64 `json:"-"`
 }
 
 type MergePullRequestResponse struct {
 	PR PullRequest `json:"pr"`
 }
 
+type ApprovePullRequestRequest struct {
+	PullRequestID string `json:"pull_request_id" binding:"required" example:"pr-1001"`
+	ReviewerID    string `json:"reviewer_id" binding:"required" example:"u2"`
+}
+
+type ApprovePullRequestResponse struct {
+	PR PullRequest `json:"pr"`
+}
+
 type ReassignPullRequestRequest struct {
 	PullRequestID string `json:"pull_request_id" binding:"required" example:"pr-1001"`
 	OldReviewerID string `json:"old_reviewer_id" binding:"required" example:"u2"`
+	// Version - версия из заголовка If-Match, 0 - без проверки
+	Version int64 `json:"-"`
 }
 
 type ReassignPullRequestResponse struct {
 	PR         PullRequest `json:"pr"`
 	ReplacedBy string      `json:"replaced_by" example:"u5"`
 }
+
+type VersionConflictResponse struct {
+	Error *ErrorDetail `json:"error"`
+	PR    PullRequest  `json:"pr"`
+}
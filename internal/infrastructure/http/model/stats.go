@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+type GetReviewStatsRequest struct {
+	From    time.Time `form:"from" binding:"required" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-11-01T00:00:00Z"`
+	To      time.Time `form:"to" binding:"required" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-12-01T00:00:00Z"`
+	GroupBy string    `form:"group_by" example:"team"`
+	SortBy  string    `form:"sort_by" example:"merged"`
+	Order   string    `form:"order" example:"desc"`
+	Limit   uint64    `form:"limit" example:"50"`
+	Cursor  string    `form:"cursor"`
+}
+
+type GroupStat struct {
+	Key                      string  `json:"key" example:"payments"`
+	ReviewsAssigned          int64   `json:"reviews_assigned" example:"12"`
+	ReviewsMerged            int64   `json:"reviews_merged" example:"9"`
+	ReassignedAway           int64   `json:"reassigned_away" example:"2"`
+	MedianTimeToMergeSeconds float64 `json:"median_time_to_merge_seconds" example:"5400"`
+}
+
+type GetReviewStatsResponse struct {
+	GroupBy    string      `json:"group_by" example:"team"`
+	From       time.Time   `json:"from"`
+	To         time.Time   `json:"to"`
+	Stats      []GroupStat `json:"stats"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
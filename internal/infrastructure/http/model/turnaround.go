@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type GetTurnaroundRequest struct {
+	From    time.Time `form:"from" binding:"required" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-11-01T00:00:00Z"`
+	To      time.Time `form:"to" binding:"required" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-12-01T00:00:00Z"`
+	GroupBy string    `form:"group_by" example:"team"`
+	Format  string    `form:"format" binding:"omitempty,oneof=json csv" example:"json"`
+}
+
+type Percentiles struct {
+	P50Seconds float64 `json:"p50_seconds" example:"60"`
+	P90Seconds float64 `json:"p90_seconds" example:"3600"`
+	P99Seconds float64 `json:"p99_seconds" example:"86400"`
+}
+
+type TurnaroundStat struct {
+	Key                string      `json:"key" example:"payments"`
+	PullRequests       int64       `json:"pull_requests" example:"10"`
+	TimeToFirstReview  Percentiles `json:"time_to_first_review"`
+	MergedPullRequests int64       `json:"merged_pull_requests" example:"7"`
+	TimeToMerge        Percentiles `json:"time_to_merge"`
+}
+
+type GetTurnaroundResponse struct {
+	GroupBy string           `json:"group_by" example:"team"`
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Stats   []TurnaroundStat `json:"stats"`
+}
@@ -7,6 +7,7 @@ type User struct {
 	Username string `json:"username" example:"Bob"`
 	TeamName string `json:"team_name" example:"backend"`
 	IsActive bool   `json:"is_active" example:"false"`
+	Role     string `json:"role,omitempty" example:"member"`
 }
 
 type SetIsActiveUserRequest struct {
@@ -18,6 +19,15 @@ type SetIsActiveUserResponse struct {
 	User User `json:"user"`
 }
 
+type SetUserRoleRequest struct {
+	UserID string `json:"user_id" binding:"required" example:"u2"`
+	Role   string `json:"role" binding:"required" example:"team_lead"`
+}
+
+type SetUserRoleResponse struct {
+	User User `json:"user"`
+}
+
 type GetReviewUserResponse struct {
 	UserID       string             `json:"user_id" example:"u2"`
 	PullRequests []PullRequestShort `json:"pull_requests"`
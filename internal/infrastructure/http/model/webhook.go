@@ -0,0 +1,83 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Webhook struct {
+	WebhookID string `json:"webhook_id" example:"7c41d0e9a3b25f68"`
+	URL       string `json:"url" example:"https://chat.example.com/hooks/reviews"`
+	// Events - типы событий подписки; пустой список - все события
+	Events    []string  `json:"events" example:"pr.reviewer_assigned,pr.merged"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required" example:"https://chat.example.com/hooks/reviews"`
+	Events []string `json:"events,omitempty" example:"pr.reviewer_assigned,pr.merged"`
+	// Secret - ключ HMAC-SHA256 подписи доставок, не короче 16 символов; в ответах не возвращается
+	Secret string `json:"secret" binding:"required" example:"whsec-4f1c9a7e2b6d"`
+}
+
+type CreateWebhookResponse struct {
+	Webhook Webhook `json:"webhook"`
+}
+
+type ListWebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type DeleteWebhookRequest struct {
+	WebhookID string `json:"webhook_id" binding:"required" example:"7c41d0e9a3b25f68"`
+}
+
+type WebhookDelivery struct {
+	DeliveryID    int64      `json:"delivery_id" example:"42"`
+	WebhookID     string     `json:"webhook_id" example:"7c41d0e9a3b25f68"`
+	EventID       int64      `json:"event_id" example:"1001"`
+	EventType     string     `json:"event_type" example:"pr.merged"`
+	Status        string     `json:"status" example:"dead" enums:"pending,delivered,dead"`
+	Attempts      int        `json:"attempts" example:"8"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty" example:"unexpected status 503"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+type WebhookAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	// StatusCode - HTTP статус ответа подписчика, 0 - ответа не было
+	StatusCode int    `json:"status_code" example:"503"`
+	Error      string `json:"error,omitempty" example:"unexpected status 503"`
+	DurationMs int64  `json:"duration_ms" example:"120"`
+}
+
+type ListWebhookDeliveriesRequest struct {
+	WebhookID string `form:"webhook_id"`
+	Status    string `form:"status"`
+	Limit     uint64 `form:"limit"`
+}
+
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+type GetWebhookDeliveryRequest struct {
+	DeliveryID int64 `form:"delivery_id" binding:"required"`
+}
+
+type GetWebhookDeliveryResponse struct {
+	Delivery WebhookDelivery `json:"delivery"`
+	// Payload - тело запроса к подписчику
+	Payload  json.RawMessage  `json:"payload" swaggertype:"object"`
+	Attempts []WebhookAttempt `json:"attempts"`
+}
+
+type ReplayWebhookDeliveryRequest struct {
+	DeliveryID int64 `json:"delivery_id" binding:"required" example:"42"`
+}
+
+type ReplayWebhookDeliveryResponse struct {
+	Delivery WebhookDelivery `json:"delivery"`
+}
@@ -0,0 +1,142 @@
+package idempotency
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"avito-tech-go-task/internal/infrastructure/logging"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+
+	CodeKeyReused     = "IDEMPOTENCY_KEY_REUSED"
+	CodeKeyInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	CodeKeyInvalid    = "INVALID_REQUEST"
+)
+
+type Store interface {
+	Reserve(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, record domain.IdempotencyRecord) error
+	Release(ctx context.Context, record domain.IdempotencyRecord) error
+}
+
+// GinMiddleware сохраняет первый ответ на запрос с заголовком Idempotency-Key и возвращает его на повторы.
+// Ключ действует в пределах маршрута и токена автора запроса, повтор с другими параметрами или телом запроса отклоняется.
+// Ответы 5xx не сохраняются, чтобы клиент мог повторить запрос с тем же ключом.
+func GinMiddleware(store Store, ttl time.Duration, logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(KeyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			abort(ctx, http.StatusBadRequest, CodeKeyInvalid, "failed to read request body")
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// PATCH /teams/deactivate передаёт команду в query, поэтому query входит в хеш наравне с телом
+		hasher := sha256.New()
+		hasher.Write([]byte(ctx.Request.URL.RawQuery))
+		hasher.Write([]byte{0})
+		hasher.Write(body)
+		actor, _ := domain.ActorFromContext(ctx.Request.Context())
+		record, err := domain.NewIdempotencyRecord(key, actor.TokenID, ctx.Request.Method+" "+ctx.FullPath(), hex.EncodeToString(hasher.Sum(nil)))
+		if err != nil {
+			abort(ctx, http.StatusBadRequest, CodeKeyInvalid, err.Error())
+			return
+		}
+
+		reqCtx := logging.With(ctx.Request.Context(), slog.String("idempotency_key", key))
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+
+		stored, reserved, err := store.Reserve(reqCtx, *record)
+		if err != nil {
+			logger.ErrorContext(reqCtx, "reserve idempotency key", slog.Any("error", err))
+			abort(ctx, http.StatusInternalServerError, "INTERNAL_ERROR", http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		if !reserved {
+			replay(ctx, stored, record.RequestHash)
+			return
+		}
+
+		w := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = w
+
+		ctx.Next()
+
+		// запрос мог быть отменён клиентом, а ответ всё равно нужно сохранить
+		storeCtx := context.WithoutCancel(reqCtx)
+		if w.Status() >= http.StatusInternalServerError {
+			if err = store.Release(storeCtx, stored); err != nil {
+				logger.ErrorContext(reqCtx, "release idempotency key", slog.Any("error", err))
+			}
+			return
+		}
+
+		stored.StatusCode = w.Status()
+		stored.ContentType = w.Header().Get("Content-Type")
+		stored.ResponseBody = w.body.Bytes()
+		stored.ExpiresAt = time.Now().Add(ttl)
+		if err = store.Complete(storeCtx, stored); err != nil {
+			logger.ErrorContext(reqCtx, "save idempotent response", slog.Any("error", err))
+		}
+	}
+}
+
+func replay(ctx *gin.Context, stored domain.IdempotencyRecord, requestHash string) {
+	err := stored.Check(requestHash)
+	switch {
+	case errors.Is(err, domain.ErrIdempotencyKeyReused):
+		abort(ctx, http.StatusUnprocessableEntity, CodeKeyReused, err.Error())
+		return
+	case errors.Is(err, domain.ErrIdempotencyKeyInProgress):
+		abort(ctx, http.StatusConflict, CodeKeyInProgress, err.Error())
+		return
+	}
+
+	ctx.Header(ReplayedHeader, "true")
+	ctx.Data(stored.StatusCode, stored.ContentType, stored.ResponseBody)
+	ctx.Abort()
+}
+
+func abort(ctx *gin.Context, status int, code, message string) {
+	ctx.AbortWithStatusJSON(status, model.ErrorResponse{
+		Error: &model.ErrorDetail{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
+
+// responseRecorder копирует тело ответа, чтобы сохранить его для повторов.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
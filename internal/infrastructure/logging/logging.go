@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type fieldsKey struct{}
+
+// fields - атрибуты, накопленные за время обработки запроса (request_id, route, user_id, pr_id...).
+// Общие для всех логов запроса, поэтому атрибуты, добавленные в сервисе, попадают и в итоговый лог HTTP запроса.
+type fields struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// New создаёт JSON логгер, который дописывает в каждую запись атрибуты из контекста.
+func New(w io.Writer, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.ToUpper(level))); err != nil && level != "" {
+		return nil, fmt.Errorf("parse log level %q: %w", level, err)
+	}
+
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl})
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+// With добавляет атрибуты ко всем последующим логам с этим контекстом.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		f = &fields{}
+		ctx = context.WithValue(ctx, fieldsKey{}, f)
+	}
+
+	f.mu.Lock()
+	f.attrs = append(f.attrs, attrs...)
+	f.mu.Unlock()
+
+	return ctx
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]slog.Attr(nil), f.attrs...)
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		r.AddAttrs(attrsFromContext(ctx)...)
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type FairnessReporter interface {
+	GetFairness(ctx context.Context, q domain.FairnessQuery) ([]domain.TeamFairness, error)
+}
+
+// FairnessCollector считает отчёт о равномерности распределения ревью за последние window на каждый scrape.
+// Метки - только команда и статус, чтобы число рядов не зависело от количества пользователей.
+type FairnessCollector struct {
+	reporter  FairnessReporter
+	window    time.Duration
+	threshold float64
+	timeout   time.Duration
+
+	maxDeviation  *prometheus.Desc
+	members       *prometheus.Desc
+	totalAssigned *prometheus.Desc
+	scrapeErrors  prometheus.Counter
+}
+
+func NewFairnessCollector(reporter FairnessReporter, window time.Duration, threshold float64) *FairnessCollector {
+	return &FairnessCollector{
+		reporter:  reporter,
+		window:    window,
+		threshold: threshold,
+		timeout:   5 * time.Second,
+
+		maxDeviation: prometheus.NewDesc(
+			namespace+"_fairness_max_deviation",
+			"Maximum relative deviation of a team member's share of assigned reviews from an even split.",
+			[]string{"team"}, nil,
+		),
+		members: prometheus.NewDesc(
+			namespace+"_fairness_members",
+			"Number of team members by review workload status.",
+			[]string{"team", "status"}, nil,
+		),
+		totalAssigned: prometheus.NewDesc(
+			namespace+"_fairness_assigned_reviews",
+			"Number of review assignments in the team over the fairness window.",
+			[]string{"team"}, nil,
+		),
+		scrapeErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "fairness_scrape_errors_total",
+			Help:      "Number of failed fairness report computations.",
+		}),
+	}
+}
+
+func (c *FairnessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxDeviation
+	ch <- c.members
+	ch <- c.totalAssigned
+	c.scrapeErrors.Describe(ch)
+}
+
+func (c *FairnessCollector) Collect(ch chan<- prometheus.Metric) {
+	defer c.scrapeErrors.Collect(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	now := time.Now()
+	teams, err := c.reporter.GetFairness(ctx, domain.FairnessQuery{
+		From:      now.Add(-c.window),
+		To:        now,
+		Threshold: c.threshold,
+	})
+	if err != nil {
+		c.scrapeErrors.Inc()
+		return
+	}
+
+	for _, team := range teams {
+		ch <- prometheus.MustNewConstMetric(c.maxDeviation, prometheus.GaugeValue, team.MaxDeviation, team.TeamName)
+		ch <- prometheus.MustNewConstMetric(c.totalAssigned, prometheus.GaugeValue, float64(team.TotalAssigned), team.TeamName)
+		for status, count := range team.CountByStatus() {
+			ch <- prometheus.MustNewConstMetric(c.members, prometheus.GaugeValue, float64(count), team.TeamName, string(status))
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "pr_reviewer"
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of repository methods by repository and method.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method"})
+
+	assignmentsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "assignments_total",
+		Help:      "Reviewer assignment attempts by operation and outcome.",
+	}, []string{"operation", "outcome"})
+
+	rateLimitDecisionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_decisions_total",
+		Help:      "Rate limit decisions by route group and outcome.",
+	}, []string{"group", "outcome"})
+
+	outboxPublishTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_publish_total",
+		Help:      "Outbox event publish attempts by sink and outcome.",
+	}, []string{"sink", "outcome"})
+
+	outboxDeadTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_dead_total",
+		Help:      "Outbox events moved to dead letter by event type.",
+	}, []string{"event_type"})
+
+	webhookAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_attempts_total",
+		Help:      "Webhook delivery attempts by delivery status after the attempt.",
+	}, []string{"status"})
+)
+
+// Register регистрирует метрики сервиса и стандартные метрики рантайма в reg.
+func Register(reg prometheus.Registerer) {
+	reg.MustRegister(
+		httpRequestDuration,
+		dbQueryDuration,
+		assignmentsTotal,
+		rateLimitDecisionsTotal,
+		outboxPublishTotal,
+		outboxDeadTotal,
+		webhookAttemptsTotal,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// GinMiddleware замеряет длительность запросов. В метку route попадает шаблон маршрута,
+// а не путь запроса, поэтому число рядов ограничено числом маршрутов.
+func GinMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		httpRequestDuration.
+			WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+func ObserveQuery(repository, method string, duration time.Duration) {
+	dbQueryDuration.WithLabelValues(repository, method).Observe(duration.Seconds())
+}
+
+func ObserveAssignment(operation domain.AssignmentOperation, outcome domain.AssignmentOutcome) {
+	assignmentsTotal.WithLabelValues(string(operation), string(outcome)).Inc()
+}
+
+func ObserveRateLimit(group string, outcome domain.RateLimitOutcome) {
+	rateLimitDecisionsTotal.WithLabelValues(group, string(outcome)).Inc()
+}
+
+func ObserveOutboxPublish(sink string, outcome domain.EventPublishOutcome) {
+	outboxPublishTotal.WithLabelValues(sink, string(outcome)).Inc()
+}
+
+func ObserveOutboxDead(eventType domain.EventType) {
+	outboxDeadTotal.WithLabelValues(string(eventType)).Inc()
+}
+
+// ObserveWebhookAttempt считает попытку доставки по состоянию доставки после неё:
+// delivered - успех, pending - будет повтор, dead - попытки исчерпаны.
+func ObserveWebhookAttempt(status domain.WebhookDeliveryStatus) {
+	webhookAttemptsTotal.WithLabelValues(string(status)).Inc()
+}
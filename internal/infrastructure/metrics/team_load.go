@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type TeamLoadReporter interface {
+	GetTeamLoad(ctx context.Context) ([]domain.TeamLoad, error)
+}
+
+// TeamLoadCollector отдаёт число открытых PR и активных ревьюеров по командам на каждый scrape.
+type TeamLoadCollector struct {
+	reporter TeamLoadReporter
+	timeout  time.Duration
+
+	openPullRequests *prometheus.Desc
+	activeReviewers  *prometheus.Desc
+	scrapeErrors     prometheus.Counter
+}
+
+func NewTeamLoadCollector(reporter TeamLoadReporter) *TeamLoadCollector {
+	return &TeamLoadCollector{
+		reporter: reporter,
+		timeout:  5 * time.Second,
+
+		openPullRequests: prometheus.NewDesc(
+			namespace+"_open_pull_requests",
+			"Number of open pull requests by author's team.",
+			[]string{"team"}, nil,
+		),
+		activeReviewers: prometheus.NewDesc(
+			namespace+"_active_reviewers",
+			"Number of active users that can be assigned as reviewers by team.",
+			[]string{"team"}, nil,
+		),
+		scrapeErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "team_load_scrape_errors_total",
+			Help:      "Number of failed team load computations.",
+		}),
+	}
+}
+
+func (c *TeamLoadCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.openPullRequests
+	ch <- c.activeReviewers
+	c.scrapeErrors.Describe(ch)
+}
+
+func (c *TeamLoadCollector) Collect(ch chan<- prometheus.Metric) {
+	defer c.scrapeErrors.Collect(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	teams, err := c.reporter.GetTeamLoad(ctx)
+	if err != nil {
+		c.scrapeErrors.Inc()
+		return
+	}
+
+	for _, team := range teams {
+		ch <- prometheus.MustNewConstMetric(c.openPullRequests, prometheus.GaugeValue, float64(team.OpenPullRequests), team.TeamName)
+		ch <- prometheus.MustNewConstMetric(c.activeReviewers, prometheus.GaugeValue, float64(team.ActiveReviewers), team.TeamName)
+	}
+}
@@ -0,0 +1,143 @@
+package migrate
+
+import (
+	"avito-tech-go-task/migrations"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// Dialect - диалект БД, для которой применяются миграции.
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// Migrator применяет встроенные в бинарник миграции.
+// Для postgres каждая операция выполняется под advisory lock, поэтому несколько экземпляров сервиса,
+// запущенных одновременно с автомиграцией, применяют миграции по очереди.
+type Migrator struct {
+	provider *goose.Provider
+	logger   *slog.Logger
+}
+
+func New(dialect Dialect, db *sql.DB, logger *slog.Logger) (*Migrator, error) {
+	var provider *goose.Provider
+	switch dialect {
+	case DialectPostgres:
+		locker, err := lock.NewPostgresSessionLocker()
+		if err != nil {
+			return nil, fmt.Errorf("lock.NewPostgresSessionLocker: %w", err)
+		}
+		provider, err = goose.NewProvider(goose.DialectPostgres, db, migrations.Postgres(), goose.WithSessionLocker(locker))
+		if err != nil {
+			return nil, fmt.Errorf("goose.NewProvider: %w", err)
+		}
+	case DialectSQLite:
+		// SQLite сериализует запись в файл сам, отдельная блокировка не нужна
+		var err error
+		provider, err = goose.NewProvider(goose.DialectSQLite3, db, migrations.SQLite())
+		if err != nil {
+			return nil, fmt.Errorf("goose.NewProvider: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown dialect %q", dialect)
+	}
+
+	return &Migrator{provider: provider, logger: logger}, nil
+}
+
+// Up применяет все новые миграции.
+func (m *Migrator) Up(ctx context.Context) error {
+	results, err := m.provider.Up(ctx)
+	for _, result := range results {
+		m.logResult(ctx, result)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+
+	return nil
+}
+
+// Down откатывает последнюю применённую миграцию.
+func (m *Migrator) Down(ctx context.Context) error {
+	result, err := m.provider.Down(ctx)
+	if result != nil {
+		m.logResult(ctx, result)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate down: %w", err)
+	}
+
+	return nil
+}
+
+// Redo откатывает и заново применяет последнюю миграцию.
+func (m *Migrator) Redo(ctx context.Context) error {
+	if err := m.Down(ctx); err != nil {
+		return err
+	}
+
+	result, err := m.provider.UpByOne(ctx)
+	if result != nil {
+		m.logResult(ctx, result)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate redo: %w", err)
+	}
+
+	return nil
+}
+
+// Status возвращает все встроенные миграции и время их применения.
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("migrate status: %w", err)
+	}
+
+	return statuses, nil
+}
+
+// Versions возвращает версию схемы в БД и последнюю встроенную версию.
+func (m *Migrator) Versions(ctx context.Context) (current, target int64, err error) {
+	current, target, err = m.provider.GetVersions(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("migrate versions: %w", err)
+	}
+
+	return current, target, nil
+}
+
+// CheckVersion возвращает ошибку, если в БД применены не все встроенные миграции.
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	current, target, err := m.Versions(ctx)
+	if err != nil {
+		return err
+	}
+	if current < target {
+		return fmt.Errorf("schema version %d, expected %d: run migrate up", current, target)
+	}
+
+	return nil
+}
+
+func (m *Migrator) logResult(ctx context.Context, result *goose.MigrationResult) {
+	attrs := []any{
+		slog.String("migration", result.Source.Path),
+		slog.String("direction", result.Direction),
+		slog.Duration("duration", result.Duration),
+	}
+	if result.Error != nil {
+		m.logger.ErrorContext(ctx, "migration failed", append(attrs, slog.Any("error", result.Error))...)
+		return
+	}
+	m.logger.InfoContext(ctx, "migration applied", attrs...)
+}
@@ -0,0 +1,139 @@
+// Package outbox публикует доменные события из outbox во внешние системы.
+package outbox
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/metrics"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Store - outbox, из которого читает relay. События в него пишут репозитории в транзакциях изменений.
+type Store interface {
+	// AcquireLease берёт или продлевает аренду relay на ttl; false - аренду держит другой экземпляр.
+	AcquireLease(ctx context.Context, holder string, ttl time.Duration) (bool, error)
+	// Pending возвращает до limit неопубликованных событий в порядке ID.
+	Pending(ctx context.Context, limit uint64) ([]domain.Event, error)
+	MarkPublished(ctx context.Context, ids []int64, at time.Time) error
+	MarkFailed(ctx context.Context, id int64, reason string) error
+	// MarkDead переводит событие в dead letter: оно больше не возвращается из Pending.
+	MarkDead(ctx context.Context, id int64, reason string, at time.Time) error
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Sink - получатель событий. Publish должен быть идемпотентен по event.ID: при сбое relay
+// событие публикуется повторно.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event domain.Event) error
+}
+
+// Relay публикует события из outbox во все sinks с доставкой at-least-once.
+// События одного агрегата (Event.Key) публикуются в порядке ID: если событие не опубликовано,
+// следующие события агрегата ждут следующего запуска, события других агрегатов публикуются.
+// Событие, не опубликованное за maxAttempts попыток, переходит в dead letter и больше не задерживает агрегат.
+type Relay struct {
+	store       Store
+	sinks       []Sink
+	holder      string
+	batchSize   uint64
+	maxAttempts int
+	leaseTTL    time.Duration
+	logger      *slog.Logger
+}
+
+// NewRelay создаёт relay. leaseTTL должен быть больше интервала запуска Relay, иначе аренда
+// будет переходить между экземплярами сервиса.
+func NewRelay(store Store, sinks []Sink, batchSize uint64, maxAttempts int, leaseTTL time.Duration, logger *slog.Logger) *Relay {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return &Relay{
+		store:       store,
+		sinks:       sinks,
+		holder:      hex.EncodeToString(b),
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+		leaseTTL:    leaseTTL,
+		logger:      logger,
+	}
+}
+
+// Relay публикует одну пачку событий и возвращает число опубликованных.
+// Без аренды relay ничего не делает: события публикует другой экземпляр сервиса.
+func (r *Relay) Relay(ctx context.Context) (int, error) {
+	leased, err := r.store.AcquireLease(ctx, r.holder, r.leaseTTL)
+	if err != nil {
+		return 0, fmt.Errorf("acquire outbox lease: %w", err)
+	}
+	if !leased {
+		return 0, nil
+	}
+
+	events, err := r.store.Pending(ctx, r.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("read outbox: %w", err)
+	}
+
+	published := make([]int64, 0, len(events))
+	blocked := make(map[string]struct{})
+	for _, event := range events {
+		if _, ok := blocked[event.Key()]; ok {
+			continue
+		}
+
+		if err = r.publish(ctx, event); err != nil {
+			if event.Attempts+1 >= r.maxAttempts {
+				r.logger.ErrorContext(ctx, "outbox event moved to dead letter",
+					slog.Int64("event_id", event.ID),
+					slog.String("event_type", string(event.Type)),
+					slog.String("aggregate", event.Key()),
+					slog.Int("attempts", event.Attempts+1),
+					slog.Any("error", err),
+				)
+				metrics.ObserveOutboxDead(event.Type)
+				if err = r.store.MarkDead(ctx, event.ID, err.Error(), time.Now()); err != nil {
+					return 0, fmt.Errorf("mark outbox event dead: %w", err)
+				}
+				continue
+			}
+
+			blocked[event.Key()] = struct{}{}
+			r.logger.WarnContext(ctx, "outbox event not published",
+				slog.Int64("event_id", event.ID),
+				slog.String("event_type", string(event.Type)),
+				slog.String("aggregate", event.Key()),
+				slog.Int("attempts", event.Attempts+1),
+				slog.Any("error", err),
+			)
+			if err = r.store.MarkFailed(ctx, event.ID, err.Error()); err != nil {
+				return 0, fmt.Errorf("mark outbox event failed: %w", err)
+			}
+			continue
+		}
+		published = append(published, event.ID)
+	}
+
+	if err = r.store.MarkPublished(ctx, published, time.Now()); err != nil {
+		return 0, fmt.Errorf("mark outbox events published: %w", err)
+	}
+
+	return len(published), nil
+}
+
+// publish отправляет событие во все sinks по очереди. При ошибке событие публикуется повторно во все sinks,
+// включая те, что его уже получили.
+func (r *Relay) publish(ctx context.Context, event domain.Event) error {
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			metrics.ObserveOutboxPublish(sink.Name(), domain.EventFailed)
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+		metrics.ObserveOutboxPublish(sink.Name(), domain.EventPublished)
+	}
+	return nil
+}
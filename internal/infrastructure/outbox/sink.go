@@ -0,0 +1,31 @@
+package outbox
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"log/slog"
+)
+
+// LogSink пишет события в лог. Полезен для отладки и как пример sink.
+type LogSink struct {
+	logger *slog.Logger
+}
+
+func NewLogSink(logger *slog.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Publish(ctx context.Context, event domain.Event) error {
+	s.logger.InfoContext(ctx, "domain event",
+		slog.Int64("event_id", event.ID),
+		slog.String("event_type", string(event.Type)),
+		slog.String("aggregate", event.Key()),
+		slog.String("event_request_id", event.RequestID),
+		slog.String("payload", string(event.Payload)),
+	)
+	return nil
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval - как часто удалять из памяти bucket'ы, которые уже успели наполниться.
+const memorySweepInterval = time.Minute
+
+type memoryBucket struct {
+	bucket domain.RateBucket
+	limit  domain.RateLimit
+}
+
+// MemoryStore хранит bucket'ы в памяти процесса: у каждой реплики сервиса свои лимиты.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]memoryBucket
+	now     func() time.Time
+	sweptAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]memoryBucket), now: time.Now}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit domain.RateLimit) (domain.RateDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.sweptAt) > memorySweepInterval {
+		s.sweep(now)
+	}
+
+	stored, ok := s.buckets[key]
+	if !ok {
+		stored.bucket = domain.NewRateBucket(limit, now)
+	}
+	bucket, decision := stored.bucket.Take(limit, now)
+	s.buckets[key] = memoryBucket{bucket: bucket, limit: limit}
+
+	return decision, nil
+}
+
+// sweep удаляет наполнившиеся bucket'ы: для них Take создаст такой же полный bucket,
+// а память не растёт с числом когда-либо обращавшихся токенов и IP.
+func (s *MemoryStore) sweep(now time.Time) {
+	s.sweptAt = now
+	for key, stored := range s.buckets {
+		if stored.bucket.IsFull(stored.limit, now) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"avito-tech-go-task/internal/infrastructure/metrics"
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodeRateLimited = "RATE_LIMITED"
+
+	RemainingHeader = "X-RateLimit-Remaining"
+)
+
+// Store забирает токен из bucket с ключом key, создавая полный bucket для нового ключа.
+type Store interface {
+	Take(ctx context.Context, key string, limit domain.RateLimit) (domain.RateDecision, error)
+}
+
+// GinMiddleware ограничивает частоту запросов к группе маршрутов group для каждого автора запроса:
+// токена или JWT, а при выключенной аутентификации - IP клиента. Ставится после auth.GinMiddleware.
+// Запрос сверх лимита отклоняется с 429 и заголовком Retry-After.
+// Если хранилище лимитов недоступно, запрос пропускается.
+func GinMiddleware(store Store, group string, limit domain.RateLimit, logger *slog.Logger) gin.HandlerFunc {
+	return middleware(store, group, limit, identity, logger)
+}
+
+// IPMiddleware ограничивает частоту запросов с каждого IP клиента. Ставится до auth.GinMiddleware,
+// чтобы запросы с неверными токенами тоже расходовали лимит. IP берётся из X-Forwarded-For
+// только для доверенных прокси (gin.Engine.SetTrustedProxies), иначе - из соединения.
+func IPMiddleware(store Store, group string, limit domain.RateLimit, logger *slog.Logger) gin.HandlerFunc {
+	return middleware(store, group, limit, clientIP, logger)
+}
+
+func middleware(store Store, group string, limit domain.RateLimit, key func(*gin.Context) string, logger *slog.Logger) gin.HandlerFunc {
+	if limit.IsUnlimited() {
+		return func(ctx *gin.Context) { ctx.Next() }
+	}
+
+	return func(ctx *gin.Context) {
+		decision, err := store.Take(ctx.Request.Context(), group+":"+key(ctx), limit)
+		if err != nil {
+			logger.ErrorContext(ctx.Request.Context(), "take rate limit token", slog.String("group", group), slog.Any("error", err))
+			metrics.ObserveRateLimit(group, domain.RateLimitError)
+			ctx.Next()
+			return
+		}
+
+		if !decision.Allowed {
+			metrics.ObserveRateLimit(group, domain.RateLimitRejected)
+			logger.WarnContext(ctx.Request.Context(), "rate limit exceeded",
+				slog.String("group", group), slog.Duration("retry_after", decision.RetryAfter))
+
+			ctx.Header("Retry-After", strconv.Itoa(retryAfterSeconds(decision)))
+			ctx.Header(RemainingHeader, "0")
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, model.ErrorResponse{
+				Error: &model.ErrorDetail{
+					Code:    CodeRateLimited,
+					Message: "too many requests, retry later",
+				},
+			})
+			return
+		}
+
+		metrics.ObserveRateLimit(group, domain.RateLimitAllowed)
+		ctx.Header(RemainingHeader, strconv.Itoa(decision.Remaining))
+		ctx.Next()
+	}
+}
+
+// identity - автор запроса, для которого считается лимит.
+func identity(ctx *gin.Context) string {
+	if actor, ok := domain.ActorFromContext(ctx.Request.Context()); ok && actor.TokenID != "" {
+		return actor.TokenID
+	}
+	return clientIP(ctx)
+}
+
+func clientIP(ctx *gin.Context) string {
+	return "ip:" + ctx.ClientIP()
+}
+
+// retryAfterSeconds округляет ожидание вверх: Retry-After передаётся в целых секундах,
+// и клиент, выждавший их, должен получить токен.
+func retryAfterSeconds(decision domain.RateDecision) int {
+	return max(int(math.Ceil(decision.RetryAfter.Seconds())), 1)
+}
@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	retryInitialDelay = 200 * time.Millisecond
+	retryMaxDelay     = 5 * time.Second
+)
+
+// Retry вызывает fn, пока она не вернёт nil, с экспоненциальной задержкой между попытками.
+// Через timeout возвращается последняя ошибка; при timeout = 0 выполняется одна попытка.
+func Retry(ctx context.Context, timeout time.Duration, name string, fn func(ctx context.Context) error, logger *slog.Logger) error {
+	deadline := time.Now().Add(timeout)
+	delay := retryInitialDelay
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("%s: giving up after %d attempts: %w", name, attempt, err)
+		}
+		logger.WarnContext(ctx, "attempt failed, retrying",
+			slog.String("job", name), slog.Int("attempt", attempt), slog.Duration("delay", delay), slog.Any("error", err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s: %w", name, ctx.Err())
+		case <-timer.C:
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
@@ -0,0 +1,24 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Every вызывает fn раз в interval, пока ctx не отменён. Ошибки fn логируются, следующий запуск выполняется по расписанию.
+func Every(ctx context.Context, interval time.Duration, name string, fn func(ctx context.Context) error, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				logger.ErrorContext(ctx, "scheduled job failed", slog.String("job", name), slog.Any("error", err))
+			}
+		}
+	}
+}
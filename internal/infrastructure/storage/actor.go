@@ -0,0 +1,13 @@
+package storage
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"database/sql"
+)
+
+// UpdatedBy - значение колонки updated_by: токен автора запроса или NULL, если аутентификация выключена.
+func UpdatedBy(ctx context.Context) sql.NullString {
+	actor, ok := domain.ActorFromContext(ctx)
+	return sql.NullString{String: actor.TokenID, Valid: ok && actor.TokenID != ""}
+}
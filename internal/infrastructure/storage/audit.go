@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type AuditRepo struct {
+	db     DB
+	logger *slog.Logger
+}
+
+type AuditEntry struct {
+	ID          int64          `db:"id"`
+	CreatedAt   time.Time      `db:"created_at"`
+	ActorID     sql.NullString `db:"actor_id"`
+	ActorUserID sql.NullString `db:"actor_user_id"`
+	Action      string         `db:"action"`
+	EntityType  string         `db:"entity_type"`
+	EntityID    string         `db:"entity_id"`
+	Before      []byte         `db:"before"`
+	After       []byte         `db:"after"`
+	RequestID   sql.NullString `db:"request_id"`
+}
+
+const auditColumns = "id, created_at, actor_id, actor_user_id, action, entity_type, entity_id, before, after, request_id"
+
+func NewAuditRepo(db DB, logger *slog.Logger) *AuditRepo {
+	return &AuditRepo{db: db, logger: logger}
+}
+
+func (e AuditEntry) toDomain() domain.AuditEntry {
+	return domain.AuditEntry{
+		ID:          e.ID,
+		CreatedAt:   e.CreatedAt,
+		ActorID:     e.ActorID.String,
+		ActorUserID: e.ActorUserID.String,
+		Action:      domain.AuditAction(e.Action),
+		EntityType:  domain.AuditEntity(e.EntityType),
+		EntityID:    e.EntityID,
+		Before:      e.Before,
+		After:       e.After,
+		RequestID:   e.RequestID.String,
+	}
+}
+
+// AuditInsert возвращает INSERT записи журнала аудита. JSON передаётся строкой: и jsonb в postgres, и TEXT в SQLite
+// принимают его как текст.
+func AuditInsert(entry domain.AuditEntry) sq.InsertBuilder {
+	return sq.Insert("audit_log").
+		Columns("created_at", "actor_id", "actor_user_id", "action", "entity_type", "entity_id", "before", "after", "request_id").
+		Values(
+			entry.CreatedAt,
+			nullString(entry.ActorID),
+			nullString(entry.ActorUserID),
+			entry.Action,
+			entry.EntityType,
+			entry.EntityID,
+			nullString(string(entry.Before)),
+			nullString(string(entry.After)),
+			nullString(entry.RequestID),
+		)
+}
+
+// AuditSelect возвращает SELECT журнала аудита по фильтрам q от новых записей к старым,
+// на одну запись больше q.Limit, чтобы понять, есть ли следующая страница.
+func AuditSelect(q domain.AuditQuery) sq.SelectBuilder {
+	filters := sq.And{}
+	for _, filter := range []struct{ column, value string }{
+		{"actor_id", q.ActorID},
+		{"actor_user_id", q.ActorUserID},
+		{"action", string(q.Action)},
+		{"entity_type", string(q.EntityType)},
+		{"entity_id", q.EntityID},
+		{"request_id", q.RequestID},
+	} {
+		if filter.value != "" {
+			filters = append(filters, sq.Eq{filter.column: filter.value})
+		}
+	}
+	if !q.From.IsZero() {
+		filters = append(filters, sq.GtOrEq{"created_at": q.From})
+	}
+	if !q.To.IsZero() {
+		filters = append(filters, sq.Lt{"created_at": q.To})
+	}
+	if q.Cursor != nil {
+		filters = append(filters, sq.Lt{"id": q.Cursor.ID})
+	}
+
+	return sq.Select(auditColumns).
+		From("audit_log").
+		Where(filters).
+		OrderBy("id DESC").
+		Limit(q.Limit + 1)
+}
+
+func (r *AuditRepo) Append(ctx context.Context, entry domain.AuditEntry) error {
+	ctx, done := Observe(ctx, "AuditRepo", "Append")
+	defer done()
+
+	query, args, err := AuditInsert(entry).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("Append builder.ToSql: %w", err)
+	}
+	if _, err = Executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("Append db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AuditRepo) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	ctx, done := Observe(ctx, "AuditRepo", "Query")
+	defer done()
+
+	query, args, err := AuditSelect(q).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("Query builder.ToSql: %w", err)
+	}
+	rows, err := Executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Query db.Query: %w", err)
+	}
+
+	entries, err := ScanAuditEntries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("Query: %w", err)
+	}
+
+	return entries, nil
+}
+
+func ScanAuditEntries(rows *sql.Rows) ([]domain.AuditEntry, error) {
+	defer rows.Close()
+
+	entries := make([]domain.AuditEntry, 0)
+	for rows.Next() {
+		var entry AuditEntry
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.CreatedAt,
+			&entry.ActorID,
+			&entry.ActorUserID,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&entry.Before,
+			&entry.After,
+			&entry.RequestID,
+		); err != nil {
+			return nil, fmt.Errorf("rows.Next: %w", err)
+		}
+		entries = append(entries, entry.toDomain())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return entries, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -8,5 +8,19 @@ import (
 type DB interface {
 	Exec(ctx context.Context, query string, args ...any) (sql.Result, error)
 	Query(ctx context.Context, query string, args ...any) (*sql.Rows, error)
-	Begin(ctx context.Context) (*sql.Tx, error)
+	// Begin открывает транзакцию; opts = nil - уровень изоляции БД по умолчанию, чтение и запись.
+	Begin(ctx context.Context, opts *sql.TxOptions) (Tx, error)
+}
+
+type Tx interface {
+	Querier
+	Commit() error
+	Rollback() error
+}
+
+// SQLClient - клиент БД (postgres.Client или sqlite.Client), поверх которого строится DB.
+type SQLClient interface {
+	Exec(ctx context.Context, query string, args ...any) (sql.Result, error)
+	Query(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	Begin(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 }
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"log/slog"
+	"slices"
+)
+
+type AuditRepo struct {
+	store  *Store
+	logger *slog.Logger
+}
+
+func NewAuditRepo(store *Store, logger *slog.Logger) *AuditRepo {
+	return &AuditRepo{store: store, logger: logger}
+}
+
+func (r *AuditRepo) Append(ctx context.Context, entry domain.AuditEntry) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	entry.ID = int64(len(r.store.state.audit)) + 1
+	entry.Before = slices.Clone(entry.Before)
+	entry.After = slices.Clone(entry.After)
+	r.store.state.audit = append(r.store.state.audit, entry)
+
+	return nil
+}
+
+// Query возвращает записи от новых к старым, на одну больше q.Limit - так же, как SQL репозитории.
+func (r *AuditRepo) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	entries := make([]domain.AuditEntry, 0)
+	for _, entry := range slices.Backward(r.store.state.audit) {
+		if uint64(len(entries)) > q.Limit {
+			break
+		}
+		if q.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
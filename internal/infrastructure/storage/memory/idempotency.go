@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"log/slog"
+	"time"
+)
+
+type IdempotencyRepo struct {
+	store  *Store
+	logger *slog.Logger
+}
+
+func NewIdempotencyRepo(store *Store, logger *slog.Logger) *IdempotencyRepo {
+	return &IdempotencyRepo{store: store, logger: logger}
+}
+
+// Reserve занимает ключ под новый запрос. Истёкшая запись с тем же ключом перезаписывается.
+// Если ключ уже занят, возвращается существующая запись и false.
+func (r *IdempotencyRepo) Reserve(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	records := r.store.state.idempotency
+	k := idempotencyKey{key: record.Key, owner: record.Owner, endpoint: record.Endpoint}
+	if existing, ok := records[k]; ok && existing.ExpiresAt.After(time.Now()) {
+		return existing, false, nil
+	}
+
+	record.StatusCode = 0
+	record.ContentType = ""
+	record.ResponseBody = nil
+	records[k] = record
+
+	return record, true, nil
+}
+
+// Complete сохраняет ответ на запрос и продлевает жизнь ключа до expiresAt.
+func (r *IdempotencyRepo) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	records := r.store.state.idempotency
+	k := idempotencyKey{key: record.Key, owner: record.Owner, endpoint: record.Endpoint}
+	existing, ok := records[k]
+	if !ok || existing.RequestHash != record.RequestHash {
+		return nil
+	}
+
+	existing.StatusCode = record.StatusCode
+	existing.ContentType = record.ContentType
+	existing.ResponseBody = record.ResponseBody
+	existing.ExpiresAt = record.ExpiresAt
+	records[k] = existing
+
+	return nil
+}
+
+// Release освобождает ключ запроса, ответ на который не нужно сохранять.
+func (r *IdempotencyRepo) Release(ctx context.Context, record domain.IdempotencyRecord) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	records := r.store.state.idempotency
+	k := idempotencyKey{key: record.Key, owner: record.Owner, endpoint: record.Endpoint}
+	if existing, ok := records[k]; ok && existing.RequestHash == record.RequestHash && !existing.IsCompleted() {
+		delete(records, k)
+	}
+
+	return nil
+}
+
+// DeleteExpired удаляет истёкшие ключи и возвращает их количество.
+func (r *IdempotencyRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	var deleted int64
+	now := time.Now()
+	for k, record := range r.store.state.idempotency {
+		if !record.ExpiresAt.After(now) {
+			delete(r.store.state.idempotency, k)
+			deleted++
+		}
+	}
+
+	r.logger.InfoContext(ctx, "expired idempotency keys deleted", slog.Int64("deleted", deleted))
+
+	return deleted, nil
+}
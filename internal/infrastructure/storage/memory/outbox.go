@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/logging"
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+type outboxRecord struct {
+	event       domain.Event
+	publishedAt time.Time
+	deadAt      time.Time
+	lastError   string
+}
+
+type outboxLease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+type OutboxRepo struct {
+	store  *Store
+	logger *slog.Logger
+}
+
+func NewOutboxRepo(store *Store, logger *slog.Logger) *OutboxRepo {
+	return &OutboxRepo{store: store, logger: logger}
+}
+
+// appendOutbox пишет события в outbox; вызывается репозиториями под блокировкой Store.
+func (st *state) appendOutbox(ctx context.Context, events ...domain.Event) {
+	requestID := logging.RequestIDFromContext(ctx)
+	for _, event := range events {
+		st.outboxSeq++
+		event.ID = st.outboxSeq
+		event.RequestID = requestID
+		st.outbox = append(st.outbox, outboxRecord{event: event})
+	}
+}
+
+func (r *OutboxRepo) AcquireLease(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	now := time.Now()
+	if st.outboxLease.holder != holder && st.outboxLease.expiresAt.After(now) {
+		return false, nil
+	}
+	st.outboxLease = outboxLease{holder: holder, expiresAt: now.Add(ttl)}
+
+	return true, nil
+}
+
+func (r *OutboxRepo) Pending(ctx context.Context, limit uint64) ([]domain.Event, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	events := make([]domain.Event, 0)
+	for _, record := range r.store.state.outbox {
+		if uint64(len(events)) == limit {
+			break
+		}
+		if record.publishedAt.IsZero() && record.deadAt.IsZero() {
+			events = append(events, record.event)
+		}
+	}
+
+	return events, nil
+}
+
+func (r *OutboxRepo) MarkPublished(ctx context.Context, ids []int64, at time.Time) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	for _, id := range ids {
+		if record, ok := st.outboxRecord(id); ok {
+			record.publishedAt = at
+		}
+	}
+
+	return nil
+}
+
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id int64, reason string) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	if record, ok := r.store.state.outboxRecord(id); ok {
+		record.event.Attempts++
+		record.lastError = reason
+	}
+
+	return nil
+}
+
+func (r *OutboxRepo) MarkDead(ctx context.Context, id int64, reason string, at time.Time) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	if record, ok := r.store.state.outboxRecord(id); ok {
+		record.event.Attempts++
+		record.lastError = reason
+		record.deadAt = at
+	}
+
+	return nil
+}
+
+func (r *OutboxRepo) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	total := len(st.outbox)
+	st.outbox = slices.DeleteFunc(st.outbox, func(record outboxRecord) bool {
+		return !record.publishedAt.IsZero() && record.publishedAt.Before(before)
+	})
+
+	return int64(total - len(st.outbox)), nil
+}
+
+func (st *state) outboxRecord(id int64) (*outboxRecord, bool) {
+	i, ok := slices.BinarySearchFunc(st.outbox, id, func(record outboxRecord, id int64) int {
+		return cmp.Compare(record.event.ID, id)
+	})
+	if !ok {
+		return nil, false
+	}
+	return &st.outbox[i], true
+}
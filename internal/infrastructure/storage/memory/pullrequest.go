@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+type PRRepo struct {
+	store  *Store
+	logger *slog.Logger
+}
+
+func NewPRRepo(store *Store, logger *slog.Logger) *PRRepo {
+	return &PRRepo{store: store, logger: logger}
+}
+
+func (r *PRRepo) CreatePR(ctx context.Context, pr domain.PullRequest) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	if _, ok := st.prs[pr.ID]; ok {
+		return domain.ErrPRExists
+	}
+	st.prs[pr.ID] = clonePR(pr)
+
+	st.recordReviewEvents(domain.NewReviewEvents(pr.ID, domain.ReviewEventAssigned, pr.ReviewersIDs...)...)
+	st.appendOutbox(ctx, domain.NewPRCreatedEvents(pr)...)
+
+	return nil
+}
+
+func (r *PRRepo) ApprovePR(ctx context.Context, prID, reviewerID string) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	st.recordReviewEvents(*domain.NewReviewEvent(prID, reviewerID, domain.ReviewEventApproved))
+	st.appendOutbox(ctx, domain.NewPRApprovedEvent(prID, reviewerID))
+
+	return nil
+}
+
+func (r *PRRepo) MergePR(ctx context.Context, pr domain.PullRequest) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	stored, err := st.findPRVersion(pr.ID, pr.Version-1)
+	if err != nil {
+		return fmt.Errorf("MergePR: %w", err)
+	}
+	stored.Status = domain.PRStatusMerged
+	stored.MergedAt = pr.MergedAt
+	stored.Version = pr.Version
+	st.prs[pr.ID] = stored
+
+	st.recordReviewEvents(domain.NewReviewEvents(pr.ID, domain.ReviewEventMerged, pr.ReviewersIDs...)...)
+	st.appendOutbox(ctx, domain.NewPRMergedEvent(pr))
+
+	return nil
+}
+
+func (r *PRRepo) ReassignPR(ctx context.Context, pr domain.PullRequest, oldReviewer, newReviewer string) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	stored, err := st.findPRVersion(pr.ID, pr.Version-1)
+	if err != nil {
+		return fmt.Errorf("ReassignPR: %w", err)
+	}
+	stored.ReviewersIDs = slices.Clone(pr.ReviewersIDs)
+	stored.Version = pr.Version
+	st.prs[pr.ID] = stored
+
+	events := []domain.ReviewEvent{
+		*domain.NewUnassignedEvent(pr.ID, oldReviewer, domain.ReviewReasonReassigned),
+		*domain.NewReviewEvent(pr.ID, newReviewer, domain.ReviewEventAssigned),
+	}
+	st.recordReviewEvents(events...)
+	st.appendOutbox(ctx, domain.NewReviewerEvents(events...)...)
+
+	return nil
+}
+
+// findPRVersion возвращает PR, если он не менялся с версии version, иначе ErrPRVersionConflict.
+func (st *state) findPRVersion(prID string, version int64) (domain.PullRequest, error) {
+	pr, ok := st.prs[prID]
+	if !ok || pr.Version != version {
+		return domain.PullRequest{}, domain.ErrPRVersionConflict
+	}
+
+	return pr, nil
+}
+
+func (r *PRRepo) FindByID(ctx context.Context, prID string) (domain.PullRequest, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	pr, ok := r.store.state.prs[prID]
+	if !ok {
+		return domain.PullRequest{}, domain.ErrPRNotFound
+	}
+
+	return clonePR(pr), nil
+}
+
+// FindByIDForUpdate читает PR. Внутри TxManager.Do весь Store и так заблокирован до конца транзакции.
+func (r *PRRepo) FindByIDForUpdate(ctx context.Context, prID string) (domain.PullRequest, error) {
+	return r.FindByID(ctx, prID)
+}
+
+func (r *PRRepo) FindByReviewerID(ctx context.Context, reviewerID string) ([]domain.PullRequest, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	prs := make([]domain.PullRequest, 0, 10)
+	for _, id := range sortedPRIDs(st.prs) {
+		if pr := st.prs[id]; slices.Contains(pr.ReviewersIDs, reviewerID) {
+			prs = append(prs, clonePR(pr))
+		}
+	}
+
+	return prs, nil
+}
+
+func sortedPRIDs(prs map[string]domain.PullRequest) []string {
+	return slices.Sorted(maps.Keys(prs))
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// QueryStats возвращает статистику за период, отсортированную по q.SortBy и ключу группировки.
+// Выбирается на одну строку больше q.Limit, чтобы понять, есть ли следующая страница.
+func (r *UserRepo) QueryStats(ctx context.Context, q domain.StatsQuery) ([]domain.GroupStat, error) {
+	switch {
+	case q.GroupBy != domain.StatsGroupByUser && q.GroupBy != domain.StatsGroupByTeam:
+		return nil, domain.ErrInvalidStatsQuery
+	case q.SortBy != domain.StatsSortByKey && q.SortBy != domain.StatsSortByAssigned &&
+		q.SortBy != domain.StatsSortByMerged && q.SortBy != domain.StatsSortByReassigned &&
+		q.SortBy != domain.StatsSortByMedianTimeToMerge:
+		return nil, domain.ErrInvalidStatsQuery
+	}
+
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	groups := make(map[string]*domain.GroupStat)
+	mergeSeconds := make(map[string][]float64)
+	for _, event := range st.events {
+		if event.Type == domain.ReviewEventApproved || event.CreatedAt.Before(q.From) || !event.CreatedAt.Before(q.To) {
+			continue
+		}
+
+		// команда берётся из события, а не из текущего состава
+		key := event.UserID
+		if q.GroupBy == domain.StatsGroupByTeam {
+			key = event.TeamName
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &domain.GroupStat{Key: key}
+			groups[key] = group
+		}
+
+		switch event.Type {
+		case domain.ReviewEventAssigned:
+			group.ReviewsAssigned++
+		case domain.ReviewEventUnassigned:
+			// снятия при деактивации не считаются переназначением
+			if event.Reason == domain.ReviewReasonReassigned {
+				group.ReassignedAway++
+			}
+		case domain.ReviewEventMerged:
+			group.ReviewsMerged++
+			// время до мержа считается от последнего назначения ревьюера на PR
+			if assignedAt, ok := st.lastAssignedAt(event); ok {
+				mergeSeconds[key] = append(mergeSeconds[key], event.CreatedAt.Sub(assignedAt).Seconds())
+			}
+		}
+	}
+
+	stats := make([]domain.GroupStat, 0, len(groups))
+	for key, group := range groups {
+		if median := domain.PercentileCont(mergeSeconds[key], 0.5); median != nil {
+			group.MedianTimeToMerge = time.Duration(median[0] * float64(time.Second))
+		}
+		stats = append(stats, *group)
+	}
+
+	return domain.SelectGroupStats(stats, q), nil
+}
+
+// lastAssignedAt возвращает время последнего назначения ревьюера события MERGED на этот PR.
+func (st *state) lastAssignedAt(merged domain.ReviewEvent) (time.Time, bool) {
+	var (
+		assignedAt time.Time
+		found      bool
+	)
+	for _, event := range st.events {
+		if event.Type == domain.ReviewEventAssigned &&
+			event.PullRequestID == merged.PullRequestID &&
+			event.UserID == merged.UserID &&
+			!event.CreatedAt.After(merged.CreatedAt) &&
+			(!found || event.CreatedAt.After(assignedAt)) {
+			assignedAt, found = event.CreatedAt, true
+		}
+	}
+
+	return assignedAt, found
+}
+
+// QueryWorkload возвращает число назначений за период для активных участников команд
+// и для тех, кого назначали в этот период. Результат отсортирован по команде.
+func (r *UserRepo) QueryWorkload(ctx context.Context, q domain.FairnessQuery) ([]domain.MemberWorkload, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	assigned := make(map[string]int64)
+	for _, event := range st.events {
+		if event.Type == domain.ReviewEventAssigned && !event.CreatedAt.Before(q.From) && event.CreatedAt.Before(q.To) {
+			assigned[event.UserID]++
+		}
+	}
+
+	workloads := make([]domain.MemberWorkload, 0, 20)
+	for _, id := range slices.Sorted(maps.Keys(st.users)) {
+		user := st.users[id]
+		if q.TeamName != "" && user.TeamName != q.TeamName {
+			continue
+		}
+		if !user.IsActive && assigned[id] == 0 {
+			continue
+		}
+		workloads = append(workloads, domain.MemberWorkload{
+			UserID:   id,
+			TeamName: user.TeamName,
+			Assigned: assigned[id],
+		})
+	}
+	slices.SortStableFunc(workloads, func(a, b domain.MemberWorkload) int {
+		return strings.Compare(a.TeamName, b.TeamName)
+	})
+
+	return workloads, nil
+}
@@ -0,0 +1,178 @@
+// Package memory - реализация репозиториев в памяти процесса для локального запуска и тестов без postgres.
+// Поведение повторяет SQL-репозитории из пакета storage: события ревью, статистика,
+// побочные эффекты деактивации и ошибки "не найдено".
+package memory
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Store - общее состояние репозиториев. Все репозитории одного Store работают с одними данными.
+type Store struct {
+	mu    sync.Mutex
+	state state
+}
+
+type idempotencyKey struct {
+	key      string
+	owner    string
+	endpoint string
+}
+
+type state struct {
+	teams       map[string]struct{}
+	users       map[string]domain.User
+	prs         map[string]domain.PullRequest
+	events      []domain.ReviewEvent
+	stats       map[string]domain.UserStat
+	idempotency map[idempotencyKey]domain.IdempotencyRecord
+	tokens      map[string]domain.APIToken
+	// audit - журнал аудита, ID записи - её номер в срезе начиная с 1
+	audit []domain.AuditEntry
+	// outbox - доменные события в порядке ID, outboxSeq - ID последнего записанного события
+	outbox      []outboxRecord
+	outboxSeq   int64
+	outboxLease outboxLease
+	// webhooks - подписки по ID; deliveries и attempts - доставки и попытки в порядке ID
+	webhooks    map[string]domain.WebhookSubscription
+	deliveries  []domain.WebhookDelivery
+	deliverySeq int64
+	attempts    []domain.WebhookAttempt
+	attemptSeq  int64
+}
+
+func NewStore() *Store {
+	return &Store{
+		state: state{
+			teams:       make(map[string]struct{}),
+			users:       make(map[string]domain.User),
+			prs:         make(map[string]domain.PullRequest),
+			events:      make([]domain.ReviewEvent, 0),
+			stats:       make(map[string]domain.UserStat),
+			idempotency: make(map[idempotencyKey]domain.IdempotencyRecord),
+			tokens:      make(map[string]domain.APIToken),
+			audit:       make([]domain.AuditEntry, 0),
+			outbox:      make([]outboxRecord, 0),
+			webhooks:    make(map[string]domain.WebhookSubscription),
+			deliveries:  make([]domain.WebhookDelivery, 0),
+			attempts:    make([]domain.WebhookAttempt, 0),
+		},
+	}
+}
+
+type txKey struct{}
+
+// lock захватывает Store на время вызова репозитория.
+// Внутри TxManager.Do блокировка уже удерживается транзакцией, и повторно не захватывается.
+func (s *Store) lock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *Store) inTx(ctx context.Context) bool {
+	store, ok := ctx.Value(txKey{}).(*Store)
+	return ok && store == s
+}
+
+// snapshot копирует состояние для отката транзакции.
+// Срезы ревьюеров PR не изменяются на месте, поэтому копии карты PR достаточно.
+func (st *state) snapshot() state {
+	return state{
+		teams:       maps.Clone(st.teams),
+		users:       maps.Clone(st.users),
+		prs:         maps.Clone(st.prs),
+		events:      slices.Clone(st.events),
+		stats:       maps.Clone(st.stats),
+		idempotency: maps.Clone(st.idempotency),
+		tokens:      maps.Clone(st.tokens),
+		audit:       slices.Clone(st.audit),
+		outbox:      slices.Clone(st.outbox),
+		outboxSeq:   st.outboxSeq,
+		outboxLease: st.outboxLease,
+		webhooks:    maps.Clone(st.webhooks),
+		deliveries:  slices.Clone(st.deliveries),
+		deliverySeq: st.deliverySeq,
+		attempts:    slices.Clone(st.attempts),
+		attemptSeq:  st.attemptSeq,
+	}
+}
+
+// recordReviewEvents сохраняет события и пересчитывает статистику затронутых ревьюеров.
+func (st *state) recordReviewEvents(events ...domain.ReviewEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	userIDs := make([]string, 0, len(events))
+	for _, event := range events {
+		// команда запоминается на момент события, чтобы перевод ревьюера не переносил его историю
+		event.TeamName = st.users[event.UserID].TeamName
+		st.events = append(st.events, event)
+		userIDs = append(userIDs, event.UserID)
+	}
+	st.refreshReviewStats(userIDs...)
+}
+
+// refreshReviewStats пересчитывает статистику пользователей из истории событий.
+// Без userIDs пересчитываются все пользователи.
+func (st *state) refreshReviewStats(userIDs ...string) {
+	if len(userIDs) == 0 {
+		userIDs = slices.Collect(maps.Keys(st.users))
+	}
+
+	now := time.Now()
+	for _, userID := range userIDs {
+		if _, ok := st.users[userID]; !ok {
+			continue
+		}
+		stat := st.derivedReviewStat(userID)
+		stat.UpdatedAt = now
+		st.stats[userID] = stat
+	}
+}
+
+// derivedReviewStat восстанавливает статистику ревьюера из review_events:
+// total - PR, на которые его назначали, merged - его PR, которые смержили,
+// active - PR, последнее событие по которым для него - назначение (одобрение не в счёт).
+func (st *state) derivedReviewStat(userID string) domain.UserStat {
+	assigned := make(map[string]struct{})
+	merged := make(map[string]struct{})
+	last := make(map[string]domain.ReviewEventType)
+	for _, event := range st.events {
+		if event.UserID != userID {
+			continue
+		}
+		switch event.Type {
+		case domain.ReviewEventAssigned:
+			assigned[event.PullRequestID] = struct{}{}
+		case domain.ReviewEventMerged:
+			merged[event.PullRequestID] = struct{}{}
+		case domain.ReviewEventApproved:
+			continue
+		}
+		last[event.PullRequestID] = event.Type
+	}
+
+	var active int64
+	for _, eventType := range last {
+		if eventType == domain.ReviewEventAssigned {
+			active++
+		}
+	}
+
+	return *domain.NewUserStat(userID, int64(len(assigned)), active, int64(len(merged)), time.Time{})
+}
+
+func clonePR(pr domain.PullRequest) domain.PullRequest {
+	pr.ReviewersIDs = slices.Clone(pr.ReviewersIDs)
+	return pr
+}
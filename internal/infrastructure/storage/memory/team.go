@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+)
+
+type TeamRepo struct {
+	store  *Store
+	logger *slog.Logger
+}
+
+func NewTeamRepo(store *Store, logger *slog.Logger) *TeamRepo {
+	return &TeamRepo{store: store, logger: logger}
+}
+
+func (r *TeamRepo) Save(ctx context.Context, team domain.Team, teamMembers []domain.User) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	st.teams[team.Name] = struct{}{}
+	for _, member := range teamMembers {
+		user := domain.NewUser(member.ID, member.Name, team.Name, member.IsActive)
+		// При переходе в другую команду роль team_lead не переносится: лид остаётся лидом только своей команды
+		if existing, ok := st.users[member.ID]; ok && (existing.TeamName == team.Name || existing.Role == domain.RoleAdmin) {
+			user.Role = existing.Role
+		}
+		st.users[member.ID] = *user
+		if _, ok := st.stats[member.ID]; !ok {
+			st.stats[member.ID] = *domain.NewUserStat(member.ID, 0, 0, 0, time.Now())
+		}
+	}
+
+	return nil
+}
+
+func (r *TeamRepo) FindByName(ctx context.Context, teamName string) ([]domain.User, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	users := make([]domain.User, 0, 20)
+	for _, user := range r.store.state.users {
+		if user.TeamName == teamName {
+			users = append(users, user)
+		}
+	}
+	slices.SortFunc(users, func(a, b domain.User) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return users, nil
+}
+
+// DeactivateTeam деактивирует участников команды и переназначает их открытые PR
+// на одного случайного активного пользователя из другой команды.
+// Если такого пользователя нет, ревьюеры PR не меняются.
+func (r *TeamRepo) DeactivateTeam(ctx context.Context, teamName string) ([]domain.PullRequest, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	deactivated := make([]string, 0, 20)
+	for id, user := range st.users {
+		if user.TeamName == teamName && user.IsActive {
+			user.IsActive = false
+			st.users[id] = user
+			deactivated = append(deactivated, id)
+		}
+	}
+	slices.Sort(deactivated)
+	st.appendOutbox(ctx, domain.NewTeamDeactivatedEvents(teamName, deactivated)...)
+
+	candidates := make([]string, 0, 20)
+	for id, user := range st.users {
+		if user.TeamName != teamName && user.IsActive {
+			candidates = append(candidates, id)
+		}
+	}
+
+	prs := make([]domain.PullRequest, 0, 20)
+	if len(candidates) == 0 {
+		return prs, nil
+	}
+	newReviewers := []string{candidates[rand.IntN(len(candidates))]}
+
+	events := make([]domain.ReviewEvent, 0, 20)
+	for _, id := range sortedPRIDs(st.prs) {
+		pr := st.prs[id]
+		if !pr.IsOpen() || !slices.ContainsFunc(pr.ReviewersIDs, func(reviewerID string) bool {
+			return slices.Contains(deactivated, reviewerID)
+		}) {
+			continue
+		}
+
+		oldReviewers := pr.ReviewersIDs
+		pr.ReviewersIDs = slices.Clone(newReviewers)
+		pr.Version++
+		st.prs[id] = pr
+
+		prs = append(prs, clonePR(pr))
+		events = append(events, domain.ReviewersChangeEvents(pr.ID, oldReviewers, pr.ReviewersIDs, domain.ReviewReasonTeamDeactivated)...)
+	}
+	st.recordReviewEvents(events...)
+	st.appendOutbox(ctx, domain.NewReviewerEvents(events...)...)
+
+	r.logger.InfoContext(ctx, "team deactivated",
+		slog.String("team_name", teamName),
+		slog.Int("reassigned_pull_requests", len(prs)),
+	)
+
+	return prs, nil
+}
+
+func (r *TeamRepo) GetTeamLoad(ctx context.Context) ([]domain.TeamLoad, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	loads := make(map[string]*domain.TeamLoad, len(st.teams))
+	for name := range st.teams {
+		loads[name] = &domain.TeamLoad{TeamName: name}
+	}
+	for _, pr := range st.prs {
+		author, ok := st.users[pr.AuthorID]
+		if load, exist := loads[author.TeamName]; ok && exist && pr.IsOpen() {
+			load.OpenPullRequests++
+		}
+	}
+	for _, user := range st.users {
+		if load, ok := loads[user.TeamName]; ok && user.IsActive {
+			load.ActiveReviewers++
+		}
+	}
+
+	teams := make([]domain.TeamLoad, 0, len(loads))
+	for _, name := range slices.Sorted(maps.Keys(loads)) {
+		teams = append(teams, *loads[name])
+	}
+
+	return teams, nil
+}
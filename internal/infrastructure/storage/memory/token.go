@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+type TokenRepo struct {
+	store  *Store
+	logger *slog.Logger
+}
+
+func NewTokenRepo(store *Store, logger *slog.Logger) *TokenRepo {
+	return &TokenRepo{store: store, logger: logger}
+}
+
+// Save создаёт токен или обновляет имя, хеш, scopes и пользователя токена с тем же ID. Отзыв токена при этом сохраняется.
+func (r *TokenRepo) Save(ctx context.Context, token domain.APIToken) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	token.Scopes = slices.Clone(token.Scopes)
+	if existing, ok := r.store.state.tokens[token.ID]; ok {
+		token.CreatedAt = existing.CreatedAt
+		token.RevokedAt = existing.RevokedAt
+	}
+	r.store.state.tokens[token.ID] = token
+
+	return nil
+}
+
+func (r *TokenRepo) FindByHash(ctx context.Context, hash string) (domain.APIToken, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	for _, token := range r.store.state.tokens {
+		if token.Hash == hash {
+			return token, nil
+		}
+	}
+
+	return domain.APIToken{}, domain.ErrTokenNotFound
+}
+
+func (r *TokenRepo) List(ctx context.Context) ([]domain.APIToken, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	tokens := make([]domain.APIToken, 0, len(r.store.state.tokens))
+	for _, token := range r.store.state.tokens {
+		tokens = append(tokens, token)
+	}
+	slices.SortFunc(tokens, func(a, b domain.APIToken) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return tokens, nil
+}
+
+// Revoke отзывает токен. Повторный отзыв не меняет время первого.
+func (r *TokenRepo) Revoke(ctx context.Context, tokenID string, revokedAt time.Time) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	token, ok := r.store.state.tokens[tokenID]
+	if !ok {
+		return domain.ErrTokenNotFound
+	}
+	if token.RevokedAt == nil {
+		token.RevokedAt = &revokedAt
+		r.store.state.tokens[tokenID] = token
+	}
+
+	return nil
+}
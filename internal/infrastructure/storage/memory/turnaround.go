@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"maps"
+	"slices"
+	"time"
+)
+
+// turnaroundSample - PR в выборке группы: секунды до первого ревью и до мержа.
+// Время до ревью есть только у одобренных PR, время до мержа - только у PR в статусе MERGED.
+type turnaroundSample struct {
+	firstReview *float64
+	merge       *float64
+}
+
+func (r *PRRepo) QueryTurnaround(ctx context.Context, q domain.TurnaroundQuery) ([]domain.TurnaroundStat, error) {
+	switch q.GroupBy {
+	case domain.TurnaroundGroupByTeam, domain.TurnaroundGroupByWeek, domain.TurnaroundGroupByReviewer:
+	default:
+		return nil, domain.ErrInvalidTurnaroundQuery
+	}
+
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+
+	// время первого назначения и первого одобрения каждого ревьюера на PR
+	assignedAt := firstEventAt(st.events, domain.ReviewEventAssigned)
+	approvedAt := firstEventAt(st.events, domain.ReviewEventApproved)
+
+	samples := make(map[string][]turnaroundSample)
+	for _, pr := range st.prs {
+		if pr.CreatedAt.Before(q.From) || !pr.CreatedAt.Before(q.To) {
+			continue
+		}
+
+		var merge *float64
+		if pr.IsMerged() {
+			merge = secondsSince(pr.CreatedAt, pr.MergedAt)
+		}
+
+		switch q.GroupBy {
+		case domain.TurnaroundGroupByTeam, domain.TurnaroundGroupByWeek:
+			key := weekKey(pr.CreatedAt)
+			if q.GroupBy == domain.TurnaroundGroupByTeam {
+				author, ok := st.users[pr.AuthorID]
+				if !ok {
+					continue
+				}
+				key = author.TeamName
+			}
+
+			var firstReview *float64
+			for _, at := range approvedAt[pr.ID] {
+				if firstReview == nil || *secondsSince(pr.CreatedAt, at) < *firstReview {
+					firstReview = secondsSince(pr.CreatedAt, at)
+				}
+			}
+			samples[key] = append(samples[key], turnaroundSample{firstReview: firstReview, merge: merge})
+
+		case domain.TurnaroundGroupByReviewer:
+			for userID, assigned := range assignedAt[pr.ID] {
+				var firstReview *float64
+				if approved, ok := approvedAt[pr.ID][userID]; ok {
+					firstReview = secondsSince(assigned, approved)
+				}
+				samples[userID] = append(samples[userID], turnaroundSample{firstReview: firstReview, merge: merge})
+			}
+		}
+	}
+
+	stats := make([]domain.TurnaroundStat, 0, len(samples))
+	for _, key := range slices.Sorted(maps.Keys(samples)) {
+		firstReview := make([]float64, 0, len(samples[key]))
+		merge := make([]float64, 0, len(samples[key]))
+		for _, sample := range samples[key] {
+			if sample.firstReview != nil {
+				firstReview = append(firstReview, *sample.firstReview)
+			}
+			if sample.merge != nil {
+				merge = append(merge, *sample.merge)
+			}
+		}
+
+		stats = append(stats, domain.TurnaroundStat{
+			Key:                key,
+			PullRequests:       int64(len(samples[key])),
+			TimeToFirstReview:  domain.NewPercentiles(domain.PercentileCont(firstReview, 0.5, 0.9, 0.99)),
+			MergedPullRequests: int64(len(merge)),
+			TimeToMerge:        domain.NewPercentiles(domain.PercentileCont(merge, 0.5, 0.9, 0.99)),
+		})
+	}
+
+	return stats, nil
+}
+
+// firstEventAt возвращает время первого события eventType для каждой пары PR и пользователя.
+func firstEventAt(events []domain.ReviewEvent, eventType domain.ReviewEventType) map[string]map[string]time.Time {
+	firstAt := make(map[string]map[string]time.Time)
+	for _, event := range events {
+		if event.Type != eventType {
+			continue
+		}
+		byUser, ok := firstAt[event.PullRequestID]
+		if !ok {
+			byUser = make(map[string]time.Time)
+			firstAt[event.PullRequestID] = byUser
+		}
+		if first, ok := byUser[event.UserID]; !ok || event.CreatedAt.Before(first) {
+			byUser[event.UserID] = event.CreatedAt
+		}
+	}
+	return firstAt
+}
+
+func secondsSince(from, to time.Time) *float64 {
+	seconds := to.Sub(from).Seconds()
+	return &seconds
+}
+
+// weekKey - понедельник недели в UTC, как date_trunc('week') в postgres.
+func weekKey(t time.Time) string {
+	t = t.UTC()
+	monday := time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	return monday.Format(time.DateOnly)
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TxManager выполняет fn с монопольным доступом к Store и откатывает изменения, если fn вернула ошибку.
+// Конфликтов сериализации в памяти не бывает, поэтому fn выполняется ровно один раз.
+type TxManager struct {
+	store *Store
+}
+
+func NewTxManager(store *Store) *TxManager {
+	return &TxManager{store: store}
+}
+
+// Do выполняет fn в транзакции. Вызов внутри другой Do переиспользует внешнюю транзакцию.
+func (m *TxManager) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if m.store.inTx(ctx) {
+		return fn(ctx)
+	}
+
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	snapshot := m.store.state.snapshot()
+	defer func() {
+		if p := recover(); p != nil {
+			m.store.state = snapshot
+			panic(p)
+		}
+		if err != nil {
+			m.store.state = snapshot
+		}
+	}()
+
+	return fn(context.WithValue(ctx, txKey{}, m.store))
+}
+
+// DoWith работает как Do: транзакции в памяти и так выполняются по очереди, что строже любого уровня изоляции.
+func (m *TxManager) DoWith(ctx context.Context, _ *sql.TxOptions, fn func(ctx context.Context) error) error {
+	return m.Do(ctx, fn)
+}
@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+type UserRepo struct {
+	store  *Store
+	logger *slog.Logger
+}
+
+func NewUserRepo(store *Store, logger *slog.Logger) *UserRepo {
+	return &UserRepo{store: store, logger: logger}
+}
+
+func (r *UserRepo) SetIsActive(ctx context.Context, userID string, isActive bool) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	user, ok := st.users[userID]
+	wasActive := ok && user.IsActive
+	if ok {
+		user.IsActive = isActive
+		st.users[userID] = user
+	}
+
+	if isActive {
+		return nil
+	}
+	if wasActive {
+		st.appendOutbox(ctx, domain.NewUserDeactivatedEvent(userID, user.TeamName))
+	}
+
+	// Удаляем неактивного ревьюера со всех PR со статусом OPEN
+	events := make([]domain.ReviewEvent, 0, 10)
+	for _, id := range sortedPRIDs(st.prs) {
+		pr := st.prs[id]
+		if !pr.IsOpen() || !slices.Contains(pr.ReviewersIDs, userID) {
+			continue
+		}
+
+		pr.ReviewersIDs = slices.DeleteFunc(slices.Clone(pr.ReviewersIDs), func(reviewerID string) bool {
+			return reviewerID == userID
+		})
+		pr.Version++
+		st.prs[id] = pr
+
+		events = append(events, *domain.NewUnassignedEvent(pr.ID, userID, domain.ReviewReasonUserDeactivated))
+	}
+	st.recordReviewEvents(events...)
+	st.appendOutbox(ctx, domain.NewReviewerEvents(events...)...)
+
+	if len(events) > 0 {
+		r.logger.InfoContext(ctx, "inactive reviewer removed from open pull requests",
+			slog.String("user_id", userID),
+			slog.Int("pull_requests", len(events)),
+		)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) SetRole(ctx context.Context, userID string, role domain.Role) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	user, ok := st.users[userID]
+	if !ok {
+		return domain.ErrUserNotExist
+	}
+	user.Role = role
+	st.users[userID] = user
+
+	return nil
+}
+
+func (r *UserRepo) FindByID(ctx context.Context, userID string) (domain.User, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	user, ok := r.store.state.users[userID]
+	if !ok {
+		return domain.User{}, domain.ErrUserNotExist
+	}
+
+	return user, nil
+}
+
+func (r *UserRepo) FindTeamByUserID(ctx context.Context, userID string) (string, error) {
+	user, err := r.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	return user.TeamName, nil
+}
+
+func (r *UserRepo) FindActiveUserIDsByTeam(ctx context.Context, team string) ([]string, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	return r.store.state.activeUserIDs(team, ""), nil
+}
+
+func (r *UserRepo) FindActiveUserIDsByTeamExcludeAuthor(ctx context.Context, team, excludeAuthorID string, reviewersCount int64) ([]string, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	userIDs := r.store.state.activeUserIDs(team, excludeAuthorID)
+	if int64(len(userIDs)) > reviewersCount {
+		userIDs = userIDs[:reviewersCount]
+	}
+
+	return userIDs, nil
+}
+
+// activeUserIDs возвращает отсортированные id активных участников команды, кроме excludeID.
+func (st *state) activeUserIDs(team, excludeID string) []string {
+	userIDs := make([]string, 0, 15)
+	for _, id := range slices.Sorted(maps.Keys(st.users)) {
+		user := st.users[id]
+		if user.TeamName == team && user.IsActive && user.ID != excludeID {
+			userIDs = append(userIDs, id)
+		}
+	}
+
+	return userIDs
+}
+
+// GetStats возвращает сохранённую статистику ревьюеров. limit = 0 - без ограничения.
+func (r *UserRepo) GetStats(ctx context.Context, limit uint64) ([]domain.UserStat, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	stats := make([]domain.UserStat, 0, len(st.stats))
+	for _, userID := range slices.Sorted(maps.Keys(st.stats)) {
+		if limit > 0 && uint64(len(stats)) == limit {
+			break
+		}
+		stats = append(stats, st.stats[userID])
+	}
+
+	return stats, nil
+}
+
+// RebuildStats пересобирает статистику из истории событий
+// и возвращает пользователей, у которых сохранённая статистика расходилась с историей.
+func (r *UserRepo) RebuildStats(ctx context.Context) ([]domain.UserStatDrift, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	drifts := make([]domain.UserStatDrift, 0)
+	for _, userID := range slices.Sorted(maps.Keys(st.users)) {
+		stored := st.stats[userID]
+		actual := st.derivedReviewStat(userID)
+		if stored.CountersEqual(actual) {
+			continue
+		}
+
+		r.logger.WarnContext(ctx, "review stats drift",
+			slog.String("user_id", userID),
+			slog.Group("stored",
+				slog.Int64("total_reviews", stored.TotalReviews),
+				slog.Int64("active_reviews", stored.ActiveReviews),
+				slog.Int64("merged_reviews", stored.MergedReviews),
+			),
+			slog.Group("actual",
+				slog.Int64("total_reviews", actual.TotalReviews),
+				slog.Int64("active_reviews", actual.ActiveReviews),
+				slog.Int64("merged_reviews", actual.MergedReviews),
+			),
+		)
+		drifts = append(drifts, domain.UserStatDrift{
+			UserID: userID,
+			Stored: stored,
+			Actual: actual,
+		})
+	}
+
+	st.refreshReviewStats()
+
+	return drifts, nil
+}
@@ -0,0 +1,231 @@
+package memory
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+type WebhookRepo struct {
+	store  *Store
+	logger *slog.Logger
+}
+
+func NewWebhookRepo(store *Store, logger *slog.Logger) *WebhookRepo {
+	return &WebhookRepo{store: store, logger: logger}
+}
+
+func (r *WebhookRepo) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	subscription.Events = slices.Clone(subscription.Events)
+	r.store.state.webhooks[subscription.ID] = subscription
+
+	return nil
+}
+
+func (r *WebhookRepo) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	subscriptions := make([]domain.WebhookSubscription, 0, len(r.store.state.webhooks))
+	for _, subscription := range r.store.state.webhooks {
+		subscriptions = append(subscriptions, subscription)
+	}
+	slices.SortFunc(subscriptions, func(a, b domain.WebhookSubscription) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription удаляет подписку вместе с её доставками и их попытками.
+func (r *WebhookRepo) DeleteSubscription(ctx context.Context, subscriptionID string) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	if _, ok := st.webhooks[subscriptionID]; !ok {
+		return domain.ErrWebhookNotFound
+	}
+	delete(st.webhooks, subscriptionID)
+
+	deleted := make(map[int64]struct{})
+	st.deliveries = slices.DeleteFunc(st.deliveries, func(d domain.WebhookDelivery) bool {
+		if d.SubscriptionID != subscriptionID {
+			return false
+		}
+		deleted[d.ID] = struct{}{}
+		return true
+	})
+	st.attempts = slices.DeleteFunc(st.attempts, func(a domain.WebhookAttempt) bool {
+		_, ok := deleted[a.DeliveryID]
+		return ok
+	})
+
+	return nil
+}
+
+// Enqueue сохраняет доставки. Доставка события подписчику, которая уже есть, не создаётся повторно.
+func (r *WebhookRepo) Enqueue(ctx context.Context, deliveries ...domain.WebhookDelivery) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	for _, d := range deliveries {
+		if _, ok := st.webhooks[d.SubscriptionID]; !ok {
+			continue
+		}
+		exists := slices.ContainsFunc(st.deliveries, func(existing domain.WebhookDelivery) bool {
+			return existing.SubscriptionID == d.SubscriptionID && existing.EventID == d.EventID
+		})
+		if exists {
+			continue
+		}
+		st.deliverySeq++
+		d.ID = st.deliverySeq
+		st.deliveries = append(st.deliveries, d)
+	}
+
+	return nil
+}
+
+// Claim забирает до limit доставок, время попытки которых наступило к now, и откладывает их до until.
+func (r *WebhookRepo) Claim(ctx context.Context, now, until time.Time, limit uint64) ([]domain.WebhookDelivery, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	due := make([]int, 0)
+	for i, d := range st.deliveries {
+		if d.Status == domain.WebhookPending && !d.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	slices.SortFunc(due, func(a, b int) int {
+		if c := st.deliveries[a].NextAttemptAt.Compare(st.deliveries[b].NextAttemptAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(st.deliveries[a].ID, st.deliveries[b].ID)
+	})
+	if uint64(len(due)) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]domain.WebhookDelivery, 0, len(due))
+	for _, i := range due {
+		st.deliveries[i].NextAttemptAt = until
+		claimed = append(claimed, st.deliveries[i])
+	}
+	slices.SortFunc(claimed, func(a, b domain.WebhookDelivery) int { return cmp.Compare(a.ID, b.ID) })
+
+	return claimed, nil
+}
+
+// RecordAttempt сохраняет попытку и новое состояние доставки. Попытка доставки удалённой подписки не сохраняется.
+func (r *WebhookRepo) RecordAttempt(ctx context.Context, delivery domain.WebhookDelivery, attempt domain.WebhookAttempt) error {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	i := st.deliveryIndex(delivery.ID)
+	if i < 0 {
+		return nil
+	}
+	st.deliveries[i] = delivery
+
+	st.attemptSeq++
+	attempt.ID = st.attemptSeq
+	attempt.DeliveryID = delivery.ID
+	st.attempts = append(st.attempts, attempt)
+
+	return nil
+}
+
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, q domain.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for _, d := range slices.Backward(r.store.state.deliveries) {
+		if uint64(len(deliveries)) == q.Limit {
+			break
+		}
+		if q.SubscriptionID != "" && d.SubscriptionID != q.SubscriptionID {
+			continue
+		}
+		if q.Status != "" && d.Status != q.Status {
+			continue
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepo) FindDelivery(ctx context.Context, deliveryID int64) (domain.WebhookDelivery, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	i := r.store.state.deliveryIndex(deliveryID)
+	if i < 0 {
+		return domain.WebhookDelivery{}, domain.ErrWebhookDeliveryNotFound
+	}
+
+	return r.store.state.deliveries[i], nil
+}
+
+// ListAttempts возвращает попытки доставки в порядке выполнения.
+func (r *WebhookRepo) ListAttempts(ctx context.Context, deliveryID int64) ([]domain.WebhookAttempt, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	attempts := make([]domain.WebhookAttempt, 0)
+	for _, attempt := range r.store.state.attempts {
+		if attempt.DeliveryID == deliveryID {
+			attempts = append(attempts, attempt)
+		}
+	}
+
+	return attempts, nil
+}
+
+// ReplayDelivery возвращает доставку из dead letter в очередь с новым счётчиком попыток.
+func (r *WebhookRepo) ReplayDelivery(ctx context.Context, deliveryID int64, at time.Time) (domain.WebhookDelivery, error) {
+	unlock := r.store.lock(ctx)
+	defer unlock()
+
+	st := &r.store.state
+	i := st.deliveryIndex(deliveryID)
+	if i < 0 {
+		return domain.WebhookDelivery{}, domain.ErrWebhookDeliveryNotFound
+	}
+	if st.deliveries[i].Status != domain.WebhookDead {
+		return domain.WebhookDelivery{}, domain.ErrWebhookDeliveryNotFailed
+	}
+
+	st.deliveries[i].Status = domain.WebhookPending
+	st.deliveries[i].Attempts = 0
+	st.deliveries[i].NextAttemptAt = at
+
+	return st.deliveries[i], nil
+}
+
+// deliveryIndex ищет доставку по ID; доставки хранятся в порядке ID.
+func (st *state) deliveryIndex(id int64) int {
+	i, ok := slices.BinarySearchFunc(st.deliveries, id, func(d domain.WebhookDelivery, id int64) int {
+		return cmp.Compare(d.ID, id)
+	})
+	if !ok {
+		return -1
+	}
+	return i
+}
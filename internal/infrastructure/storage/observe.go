@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"avito-tech-go-task/internal/infrastructure/metrics"
+	"context"
+	"time"
+)
+
+// Observe замеряет длительность метода репозитория и кладёт его имя в контекст для трассировки запросов:
+//
+//	ctx, done := Observe(ctx, "PRRepo", "CreatePR")
+//	defer done()
+func Observe(ctx context.Context, repository, method string) (context.Context, func()) {
+	start := time.Now()
+	return withQueryName(ctx, repository+"."+method), func() {
+		metrics.ObserveQuery(repository, method, time.Since(start))
+	}
+}
@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/logging"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// outboxLeaseName - строка outbox_relay_lease, которой владеет работающий relay.
+const outboxLeaseName = "relay"
+
+const outboxColumns = "id, event_type, aggregate_type, aggregate_id, payload, occurred_at, request_id, attempts"
+
+// OutboxRepo читает outbox для relay. События в outbox пишут репозитории в транзакциях изменений.
+type OutboxRepo struct {
+	db     DB
+	logger *slog.Logger
+}
+
+func NewOutboxRepo(db DB, logger *slog.Logger) *OutboxRepo {
+	return &OutboxRepo{db: db, logger: logger}
+}
+
+// OutboxInsert возвращает INSERT событий в outbox с request ID текущего запроса.
+func OutboxInsert(ctx context.Context, events ...domain.Event) sq.InsertBuilder {
+	requestID := nullString(logging.RequestIDFromContext(ctx))
+	builder := sq.Insert("outbox_events").
+		Columns("event_type", "aggregate_type", "aggregate_id", "payload", "occurred_at", "request_id")
+	for _, event := range events {
+		builder = builder.Values(event.Type, event.AggregateType, event.AggregateID, string(event.Payload), event.OccurredAt, requestID)
+	}
+	return builder
+}
+
+// OutboxLease возвращает upsert аренды relay: holder получает аренду до expiresAt, если она свободна,
+// истекла к now или уже принадлежит ему. Запрос возвращает строку, только если аренда получена.
+func OutboxLease(holder string, now, expiresAt time.Time) sq.InsertBuilder {
+	return sq.Insert("outbox_relay_lease").
+		Columns("name", "holder", "expires_at").
+		Values(outboxLeaseName, holder, expiresAt).
+		Suffix(`ON CONFLICT (name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+			WHERE outbox_relay_lease.holder = excluded.holder OR outbox_relay_lease.expires_at < ?
+			RETURNING holder`, now)
+}
+
+// OutboxPending возвращает SELECT неопубликованных событий в порядке записи.
+func OutboxPending(limit uint64) sq.SelectBuilder {
+	return sq.Select(outboxColumns).
+		From("outbox_events").
+		Where(sq.Eq{"published_at": nil, "dead_at": nil}).
+		OrderBy("id").
+		Limit(limit)
+}
+
+func OutboxMarkPublished(ids []int64, at time.Time) sq.UpdateBuilder {
+	return sq.Update("outbox_events").
+		Set("published_at", at).
+		Where(sq.Eq{"id": ids})
+}
+
+func OutboxMarkFailed(id int64, reason string) sq.UpdateBuilder {
+	return sq.Update("outbox_events").
+		Set("attempts", sq.Expr("attempts + 1")).
+		Set("last_error", reason).
+		Where(sq.Eq{"id": id})
+}
+
+func OutboxMarkDead(id int64, reason string, at time.Time) sq.UpdateBuilder {
+	return OutboxMarkFailed(id, reason).Set("dead_at", at)
+}
+
+func OutboxDeletePublished(before time.Time) sq.DeleteBuilder {
+	return sq.Delete("outbox_events").
+		Where(sq.Lt{"published_at": before})
+}
+
+// appendOutbox пишет события в outbox в транзакции изменения.
+func appendOutbox(ctx context.Context, tx Tx, events ...domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	query, args, err := OutboxInsert(ctx, events...).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("appendOutbox builder.ToSql: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("appendOutbox tx.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepo) AcquireLease(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	ctx, done := Observe(ctx, "OutboxRepo", "AcquireLease")
+	defer done()
+
+	now := time.Now()
+	query, args, err := OutboxLease(holder, now, now.Add(ttl)).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return false, fmt.Errorf("AcquireLease builder.ToSql: %w", err)
+	}
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("AcquireLease db.Query: %w", err)
+	}
+
+	leased, err := ScanLeased(rows)
+	if err != nil {
+		return false, fmt.Errorf("AcquireLease: %w", err)
+	}
+
+	return leased, nil
+}
+
+// ScanLeased читает результат OutboxLease: строка есть, только если аренда получена.
+func ScanLeased(rows *sql.Rows) (bool, error) {
+	defer rows.Close()
+
+	leased := rows.Next()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return leased, nil
+}
+
+func (r *OutboxRepo) Pending(ctx context.Context, limit uint64) ([]domain.Event, error) {
+	ctx, done := Observe(ctx, "OutboxRepo", "Pending")
+	defer done()
+
+	query, args, err := OutboxPending(limit).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("Pending builder.ToSql: %w", err)
+	}
+	rows, err := Executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Pending db.Query: %w", err)
+	}
+
+	events, err := ScanOutboxEvents(rows)
+	if err != nil {
+		return nil, fmt.Errorf("Pending: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *OutboxRepo) MarkPublished(ctx context.Context, ids []int64, at time.Time) error {
+	ctx, done := Observe(ctx, "OutboxRepo", "MarkPublished")
+	defer done()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query, args, err := OutboxMarkPublished(ids, at).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("MarkPublished builder.ToSql: %w", err)
+	}
+	if _, err = Executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("MarkPublished db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id int64, reason string) error {
+	ctx, done := Observe(ctx, "OutboxRepo", "MarkFailed")
+	defer done()
+
+	query, args, err := OutboxMarkFailed(id, reason).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("MarkFailed builder.ToSql: %w", err)
+	}
+	if _, err = Executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("MarkFailed db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepo) MarkDead(ctx context.Context, id int64, reason string, at time.Time) error {
+	ctx, done := Observe(ctx, "OutboxRepo", "MarkDead")
+	defer done()
+
+	query, args, err := OutboxMarkDead(id, reason, at).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("MarkDead builder.ToSql: %w", err)
+	}
+	if _, err = Executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("MarkDead db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePublished удаляет события, опубликованные до before, и возвращает их число.
+func (r *OutboxRepo) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	ctx, done := Observe(ctx, "OutboxRepo", "DeletePublished")
+	defer done()
+
+	query, args, err := OutboxDeletePublished(before).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("DeletePublished builder.ToSql: %w", err)
+	}
+	res, err := Executor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("DeletePublished db.Exec: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+func ScanOutboxEvents(rows *sql.Rows) ([]domain.Event, error) {
+	defer rows.Close()
+
+	events := make([]domain.Event, 0)
+	for rows.Next() {
+		var (
+			event     domain.Event
+			payload   []byte
+			requestID sql.NullString
+		)
+		if err := rows.Scan(
+			&event.ID,
+			&event.Type,
+			&event.AggregateType,
+			&event.AggregateID,
+			&payload,
+			&event.OccurredAt,
+			&requestID,
+			&event.Attempts,
+		); err != nil {
+			return nil, fmt.Errorf("rows.Next: %w", err)
+		}
+		event.Payload = payload
+		event.RequestID = requestID.String
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return events, nil
+}
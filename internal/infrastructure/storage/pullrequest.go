@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -13,7 +14,8 @@ import (
 )
 
 type PRRepo struct {
-	db DB
+	db     DB
+	logger *slog.Logger
 }
 
 type PullRequest struct {
@@ -23,115 +25,89 @@ type PullRequest struct {
 	status       string         `db:"status"`
 	reviewersIDs pq.StringArray `db:"reviewers_ids"`
 	mergedAt     time.Time      `db:"merged_at"`
+	createdAt    sql.NullTime   `db:"created_at"` // NULL у PR, созданных до появления колонки
+	version      int64          `db:"version"`
 }
 
-func NewPRRepo(db DB) *PRRepo {
-	return &PRRepo{db: db}
+func NewPRRepo(db DB, logger *slog.Logger) *PRRepo {
+	return &PRRepo{db: db, logger: logger}
 }
 
 func (pr PullRequest) toDomain() domain.PullRequest {
-	return domain.NewPullRequestFromStorage(pr.id, pr.name, pr.authorID, domain.PRStatus(pr.status), pr.reviewersIDs, pr.mergedAt)
+	return domain.NewPullRequestFromStorage(pr.id, pr.name, pr.authorID, domain.PRStatus(pr.status), pr.reviewersIDs, pr.mergedAt, pr.createdAt.Time, pr.version)
 }
 
-func updateReviewStats(ctx context.Context, tx *sql.Tx, status domain.PRStatus, reviewerIDs ...string) error {
-	builder := sq.Update("user_review_stats").
-		Set("updated_at", time.Now())
+func (r *PRRepo) CreatePR(ctx context.Context, pr domain.PullRequest) (err error) {
+	ctx, done := Observe(ctx, "PRRepo", "CreatePR")
+	defer done()
 
-	switch status {
-	case domain.PRStatusOpen:
-		builder = builder.Set("total_reviews", sq.Expr("total_reviews + 1")).
-			Set("active_reviews", sq.Expr("active_reviews + 1"))
-
-	case domain.PRStatusMerged:
-		builder = builder.Set("merged_reviews", sq.Expr("merged_reviews + 1")).
-			Set("active_reviews", sq.Expr("active_reviews - 1"))
-
-	default:
-		return errors.New("invalid pull request status")
+	tx, finish, err := BeginTx(ctx, r.db)
+	if err != nil {
+		return err
 	}
+	defer func() { err = finish(err) }()
 
-	builder = builder.Where(sq.Eq{"user_id": reviewerIDs}).
+	builder := sq.Insert("pull_requests").
+		Columns("id", "name", "author_id", "status", "reviewers_ids", "merged_at", "created_at", "version", "updated_by").
+		Values(pr.ID, pr.Name, pr.AuthorID, pr.Status, pq.StringArray(pr.ReviewersIDs), pr.MergedAt, pr.CreatedAt, pr.Version, UpdatedBy(ctx)).
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := builder.ToSql()
 	if err != nil {
-		return fmt.Errorf("updateReviewStats builder.ToSql: %w", err)
+		return fmt.Errorf("CreatePR builder.ToSql: %w", err)
 	}
+
 	_, err = tx.ExecContext(ctx, query, args...)
+	if isUniqueViolation(err) {
+		return domain.ErrPRExists
+	}
 	if err != nil {
-		return fmt.Errorf("updateReviewStats tx.ExecContext: %w", err)
+		return fmt.Errorf("CreatePR db.Exec: %w", err)
 	}
 
-	return nil
-}
-
-func (r *PRRepo) CreatePR(ctx context.Context, pr domain.PullRequest) error {
-	tx, err := r.db.Begin(ctx)
+	err = recordReviewEvents(ctx, tx, domain.NewReviewEvents(pr.ID, domain.ReviewEventAssigned, pr.ReviewersIDs...)...)
 	if err != nil {
-		return fmt.Errorf("db.Begin: %w", err)
+		return fmt.Errorf("recordReviewEvents: %w", err)
 	}
-	defer func() {
-		if err == nil {
-			err = tx.Commit()
-			if err != nil {
-				err = fmt.Errorf("tx.Commit: %w", err)
-			}
-		}
-		if err != nil {
-			rbErr := tx.Rollback()
-			if rbErr != nil {
-				err = fmt.Errorf("%w tx.Rollback: %s", err, rbErr)
-			}
-		}
-	}()
 
-	builder := sq.Insert("pull_requests").
-		Columns("id", "name", "author_id", "status", "reviewers_ids", "merged_at").
-		Values(pr.ID, pr.Name, pr.AuthorID, pr.Status, pq.StringArray(pr.ReviewersIDs), pr.MergedAt).
-		PlaceholderFormat(sq.Dollar)
+	return appendOutbox(ctx, tx, domain.NewPRCreatedEvents(pr)...)
+}
 
-	query, args, err := builder.ToSql()
-	if err != nil {
-		return fmt.Errorf("CreatePR builder.ToSql: %w", err)
-	}
+// ApprovePR записывает одобрение PR ревьюером. Сам PR не меняется, поэтому версия не увеличивается.
+func (r *PRRepo) ApprovePR(ctx context.Context, prID, reviewerID string) (err error) {
+	ctx, done := Observe(ctx, "PRRepo", "ApprovePR")
+	defer done()
 
-	_, err = tx.ExecContext(ctx, query, args...)
+	tx, finish, err := BeginTx(ctx, r.db)
 	if err != nil {
-		return fmt.Errorf("CreatePR db.Exec: %w", err)
+		return err
 	}
+	defer func() { err = finish(err) }()
 
-	err = updateReviewStats(ctx, tx, domain.PRStatusOpen, pr.ReviewersIDs...)
+	err = recordReviewEvents(ctx, tx, *domain.NewReviewEvent(prID, reviewerID, domain.ReviewEventApproved))
 	if err != nil {
-		return fmt.Errorf("UpdateReviewStats: %w", err)
+		return fmt.Errorf("recordReviewEvents: %w", err)
 	}
 
-	return nil
+	return appendOutbox(ctx, tx, domain.NewPRApprovedEvent(prID, reviewerID))
 }
 
-func (r *PRRepo) MergePR(ctx context.Context, pr domain.PullRequest) error {
-	tx, err := r.db.Begin(ctx)
+func (r *PRRepo) MergePR(ctx context.Context, pr domain.PullRequest) (err error) {
+	ctx, done := Observe(ctx, "PRRepo", "MergePR")
+	defer done()
+
+	tx, finish, err := BeginTx(ctx, r.db)
 	if err != nil {
-		return fmt.Errorf("db.Begin: %w", err)
+		return err
 	}
-	defer func() {
-		if err == nil {
-			err = tx.Commit()
-			if err != nil {
-				err = fmt.Errorf("tx.Commit: %w", err)
-			}
-		}
-		if err != nil {
-			rbErr := tx.Rollback()
-			if rbErr != nil {
-				err = fmt.Errorf("%w tx.Rollback: %s", err, rbErr)
-			}
-		}
-	}()
+	defer func() { err = finish(err) }()
 
 	builder := sq.Update("pull_requests").
 		Set("status", domain.PRStatusMerged.String()).
 		Set("merged_at", pr.MergedAt).
-		Where(sq.Eq{"id": pr.ID}).
+		Set("version", pr.Version).
+		Set("updated_by", UpdatedBy(ctx)).
+		Where(sq.Eq{"id": pr.ID, "version": pr.Version - 1}).
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := builder.ToSql()
@@ -139,42 +115,38 @@ func (r *PRRepo) MergePR(ctx context.Context, pr domain.PullRequest) error {
 		return fmt.Errorf("MergePR builder.ToSql: %w", err)
 	}
 
-	_, err = r.db.Exec(ctx, query, args...)
+	res, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("MergePR db.Exec: %w", err)
 	}
+	err = checkUpdated(res)
+	if err != nil {
+		return fmt.Errorf("MergePR: %w", err)
+	}
 
-	err = updateReviewStats(ctx, tx, domain.PRStatusMerged, pr.ReviewersIDs...)
+	err = recordReviewEvents(ctx, tx, domain.NewReviewEvents(pr.ID, domain.ReviewEventMerged, pr.ReviewersIDs...)...)
 	if err != nil {
-		return fmt.Errorf("UpdateReviewStats: %w", err)
+		return fmt.Errorf("recordReviewEvents: %w", err)
 	}
 
-	return nil
+	return appendOutbox(ctx, tx, domain.NewPRMergedEvent(pr))
 }
 
-func (r *PRRepo) ReassignPR(ctx context.Context, pr domain.PullRequest, oldReviewer, newReviewer string) error {
-	tx, err := r.db.Begin(ctx)
+func (r *PRRepo) ReassignPR(ctx context.Context, pr domain.PullRequest, oldReviewer, newReviewer string) (err error) {
+	ctx, done := Observe(ctx, "PRRepo", "ReassignPR")
+	defer done()
+
+	tx, finish, err := BeginTx(ctx, r.db)
 	if err != nil {
-		return fmt.Errorf("db.Begin: %w", err)
+		return err
 	}
-	defer func() {
-		if err == nil {
-			err = tx.Commit()
-			if err != nil {
-				err = fmt.Errorf("tx.Commit: %w", err)
-			}
-		}
-		if err != nil {
-			rbErr := tx.Rollback()
-			if rbErr != nil {
-				err = fmt.Errorf("%w tx.Rollback: %s", err, rbErr)
-			}
-		}
-	}()
+	defer func() { err = finish(err) }()
 
 	builder := sq.Update("pull_requests").
 		Set("reviewers_ids", pq.StringArray(pr.ReviewersIDs)).
-		Where(sq.Eq{"id": pr.ID}).
+		Set("version", pr.Version).
+		Set("updated_by", UpdatedBy(ctx)).
+		Where(sq.Eq{"id": pr.ID, "version": pr.Version - 1}).
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := builder.ToSql()
@@ -182,31 +154,58 @@ func (r *PRRepo) ReassignPR(ctx context.Context, pr domain.PullRequest, oldRevie
 		return fmt.Errorf("ReassignPR builder.ToSql: %w", err)
 	}
 
-	_, err = tx.ExecContext(ctx, query, args...)
+	res, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("ReassignPR db.Exec: %w", err)
 	}
+	err = checkUpdated(res)
+	if err != nil {
+		return fmt.Errorf("ReassignPR: %w", err)
+	}
 
-	err = updateReviewStats(ctx, tx, domain.PRStatusOpen, oldReviewer, newReviewer)
+	events := []domain.ReviewEvent{
+		*domain.NewUnassignedEvent(pr.ID, oldReviewer, domain.ReviewReasonReassigned),
+		*domain.NewReviewEvent(pr.ID, newReviewer, domain.ReviewEventAssigned),
+	}
+	err = recordReviewEvents(ctx, tx, events...)
 	if err != nil {
-		return fmt.Errorf("UpdateReviewStats: %w", err)
+		return fmt.Errorf("recordReviewEvents: %w", err)
 	}
 
-	return nil
+	return appendOutbox(ctx, tx, domain.NewReviewerEvents(events...)...)
 }
 
 func (r *PRRepo) FindByID(ctx context.Context, prID string) (domain.PullRequest, error) {
-	builder := sq.Select("id", "name", "author_id", "status", "reviewers_ids", "merged_at").
+	ctx, done := Observe(ctx, "PRRepo", "FindByID")
+	defer done()
+
+	return r.findByID(ctx, prID, false)
+}
+
+// FindByIDForUpdate читает PR и блокирует его строку до конца транзакции.
+// Вне TxManager.Do блокировка снимается сразу после запроса.
+func (r *PRRepo) FindByIDForUpdate(ctx context.Context, prID string) (domain.PullRequest, error) {
+	ctx, done := Observe(ctx, "PRRepo", "FindByIDForUpdate")
+	defer done()
+
+	return r.findByID(ctx, prID, true)
+}
+
+func (r *PRRepo) findByID(ctx context.Context, prID string, forUpdate bool) (domain.PullRequest, error) {
+	builder := sq.Select("id", "name", "author_id", "status", "reviewers_ids", "merged_at", "created_at", "version").
 		From("pull_requests").
 		Where(sq.Eq{"id": prID}).
 		PlaceholderFormat(sq.Dollar)
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
 
 	query, args, err := builder.ToSql()
 	if err != nil {
 		return domain.PullRequest{}, fmt.Errorf("FindByID PR builder.ToSql: %w", err)
 	}
 
-	rows, err := r.db.Query(ctx, query, args...)
+	rows, err := Executor(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return domain.PullRequest{}, fmt.Errorf("FindByID PR db.Query: %w", err)
 	}
@@ -222,6 +221,8 @@ func (r *PRRepo) FindByID(ctx context.Context, prID string) (domain.PullRequest,
 			&pullRequest.status,
 			&pullRequest.reviewersIDs,
 			&pullRequest.mergedAt,
+			&pullRequest.createdAt,
+			&pullRequest.version,
 		); err != nil {
 			return domain.PullRequest{}, fmt.Errorf("FindByID PR rows.Next: %w", err)
 		}
@@ -236,11 +237,14 @@ func (r *PRRepo) FindByID(ctx context.Context, prID string) (domain.PullRequest,
 }
 
 func (r *PRRepo) FindByReviewerID(ctx context.Context, reviewerID string) ([]domain.PullRequest, error) {
-	queryString := `SELECT id, name, author_id, status, reviewers_ids, merged_at
+	ctx, done := Observe(ctx, "PRRepo", "FindByReviewerID")
+	defer done()
+
+	queryString := `SELECT id, name, author_id, status, reviewers_ids, merged_at, created_at, version
 		FROM pull_requests
 		WHERE $1 = ANY(reviewers_ids)`
 
-	rows, err := r.db.Query(ctx, queryString, reviewerID)
+	rows, err := ReplicaExecutor(ctx, r.db).QueryContext(ctx, queryString, reviewerID)
 	if err != nil {
 		return nil, fmt.Errorf("FindByReviewerID r.db.Query: %w", err)
 	}
@@ -256,6 +260,8 @@ func (r *PRRepo) FindByReviewerID(ctx context.Context, reviewerID string) ([]dom
 			&pullRequest.status,
 			&pullRequest.reviewersIDs,
 			&pullRequest.mergedAt,
+			&pullRequest.createdAt,
+			&pullRequest.version,
 		); err != nil {
 			return nil, fmt.Errorf("FindByReviewerID rows.Next: %w", err)
 		}
@@ -265,3 +271,22 @@ func (r *PRRepo) FindByReviewerID(ctx context.Context, reviewerID string) ([]dom
 
 	return prs, nil
 }
+
+// checkUpdated возвращает ErrPRVersionConflict, если условное обновление PR не затронуло ни одной строки:
+// PR изменили с момента чтения.
+func checkUpdated(res sql.Result) error {
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("res.RowsAffected: %w", err)
+	}
+	if updated == 0 {
+		return domain.ErrPRVersionConflict
+	}
+
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// RateLimitRepo хранит bucket'ы ограничения частоты запросов в postgres, чтобы лимит был общим для всех реплик сервиса.
+type RateLimitRepo struct {
+	db     DB
+	logger *slog.Logger
+}
+
+func NewRateLimitRepo(db DB, logger *slog.Logger) *RateLimitRepo {
+	return &RateLimitRepo{db: db, logger: logger}
+}
+
+// Take забирает токен из bucket с ключом key. Строка bucket'а блокируется до конца транзакции,
+// поэтому одновременные запросы с разных реплик не потратят один токен дважды.
+func (r *RateLimitRepo) Take(ctx context.Context, key string, limit domain.RateLimit) (decision domain.RateDecision, err error) {
+	ctx, done := Observe(ctx, "RateLimitRepo", "Take")
+	defer done()
+
+	tx, finish, err := BeginTx(ctx, r.db)
+	if err != nil {
+		return domain.RateDecision{}, err
+	}
+	defer func() { err = finish(err) }()
+
+	now := time.Now()
+	bucket := domain.NewRateBucket(limit, now)
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING`,
+		key,
+		bucket.Tokens,
+		bucket.UpdatedAt,
+	)
+	if err != nil {
+		return domain.RateDecision{}, fmt.Errorf("Take db.Exec: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = $1 FOR UPDATE", key)
+	if err != nil {
+		return domain.RateDecision{}, fmt.Errorf("Take db.Query: %w", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return domain.RateDecision{}, fmt.Errorf("Take rows.Err: %w", err)
+		}
+		return domain.RateDecision{}, fmt.Errorf("Take: bucket %q not found", key)
+	}
+	if err = rows.Scan(&bucket.Tokens, &bucket.UpdatedAt); err != nil {
+		return domain.RateDecision{}, fmt.Errorf("Take rows.Scan: %w", err)
+	}
+	if err = rows.Close(); err != nil {
+		return domain.RateDecision{}, fmt.Errorf("Take rows.Close: %w", err)
+	}
+
+	bucket, decision = bucket.Take(limit, now)
+	_, err = tx.ExecContext(ctx,
+		"UPDATE rate_limit_buckets SET tokens = $2, updated_at = $3 WHERE key = $1",
+		key,
+		bucket.Tokens,
+		bucket.UpdatedAt,
+	)
+	if err != nil {
+		return domain.RateDecision{}, fmt.Errorf("Take db.Exec: %w", err)
+	}
+
+	return decision, nil
+}
+
+// DeleteIdle удаляет bucket'ы, к которым не обращались с before, и возвращает их количество.
+// Такие bucket'ы уже наполнились, если before раньше времени наполнения самого медленного лимита.
+func (r *RateLimitRepo) DeleteIdle(ctx context.Context, before time.Time) (int64, error) {
+	ctx, done := Observe(ctx, "RateLimitRepo", "DeleteIdle")
+	defer done()
+
+	res, err := Executor(ctx, r.db).ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteIdle db.Exec: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("DeleteIdle res.RowsAffected: %w", err)
+	}
+
+	r.logger.InfoContext(ctx, "idle rate limit buckets deleted", slog.Int64("deleted", deleted))
+
+	return deleted, nil
+}
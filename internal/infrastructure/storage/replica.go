@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// replicationLagQuery возвращает отставание реплики postgres в секундах.
+// Реплика, которая применила весь полученный WAL, не отстаёт, даже если на primary давно не было записей.
+const replicationLagQuery = `
+SELECT CASE
+	WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// ReplicationLag измеряет отставание реплики от primary.
+type ReplicationLag func(ctx context.Context, replica DB) (time.Duration, error)
+
+// PostgresReplicationLag - отставание потоковой реплики postgres.
+func PostgresReplicationLag(ctx context.Context, replica DB) (time.Duration, error) {
+	rows, err := replica.Query(ctx, replicationLagQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var seconds float64
+	if rows.Next() {
+		if err = rows.Scan(&seconds); err != nil {
+			return 0, err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// ReplicatedDB - primary и реплика для чтения.
+// Запись и транзакции всегда идут в primary; на реплику попадают только запросы через ReplicaExecutor,
+// и только пока она доступна и отстаёт не больше чем на maxStaleness.
+type ReplicatedDB struct {
+	primary      DB
+	replica      DB
+	lag          ReplicationLag
+	maxStaleness time.Duration
+	healthy      atomic.Bool
+	logger       *slog.Logger
+}
+
+// NewReplicatedDB создаёт DB с репликой. До первой успешной проверки CheckReplica чтение идёт в primary.
+func NewReplicatedDB(primary, replica DB, lag ReplicationLag, maxStaleness time.Duration, logger *slog.Logger) *ReplicatedDB {
+	return &ReplicatedDB{
+		primary:      primary,
+		replica:      replica,
+		lag:          lag,
+		maxStaleness: maxStaleness,
+		logger:       logger,
+	}
+}
+
+func (db *ReplicatedDB) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.primary.Exec(ctx, query, args...)
+}
+
+func (db *ReplicatedDB) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return db.primary.Query(ctx, query, args...)
+}
+
+func (db *ReplicatedDB) Begin(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	return db.primary.Begin(ctx, opts)
+}
+
+// CheckReplica измеряет отставание реплики и решает, можно ли с неё читать.
+// Возвращает ошибку, если реплика недоступна или отстаёт больше допустимого.
+func (db *ReplicatedDB) CheckReplica(ctx context.Context) error {
+	lag, err := db.lag(ctx, db.replica)
+	if err == nil && lag > db.maxStaleness {
+		err = fmt.Errorf("replication lag %s exceeds %s", lag, db.maxStaleness)
+	}
+
+	healthy := err == nil
+	if db.healthy.Swap(healthy) != healthy {
+		if healthy {
+			db.logger.InfoContext(ctx, "replica is healthy, routing reads to replica", slog.Duration("lag", lag))
+		} else {
+			db.logger.WarnContext(ctx, "replica is unhealthy, routing reads to primary", slog.Any("error", err))
+		}
+	}
+
+	return err
+}
+
+// reader возвращает реплику, если с неё можно читать, иначе primary.
+func (db *ReplicatedDB) reader() DB {
+	if db.healthy.Load() {
+		return db.replica
+	}
+	return db.primary
+}
+
+// ReplicaExecutor работает как Executor, но вне транзакции читает с реплики, если db - ReplicatedDB.
+// Используется только в методах, которые ничего не пишут и допускают отставание данных на maxStaleness;
+// чтение внутри TxManager.Do идёт в транзакции на primary и видит собственные записи.
+func ReplicaExecutor(ctx context.Context, db DB) Querier {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	if replicated, ok := db.(*ReplicatedDB); ok {
+		return dbQuerier{db: replicated.reader()}
+	}
+	return dbQuerier{db: db}
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+)
+
+// derivedReviewStatsQuery восстанавливает статистику ревьюеров из review_events.
+// $1 - список user_id, NULL - все пользователи.
+const derivedReviewStatsQuery = `WITH events AS (
+		SELECT id, pull_request_id, user_id, type
+		FROM review_events
+		WHERE $1::text[] IS NULL OR user_id = ANY($1::text[])
+	),
+	last_events AS (
+		SELECT DISTINCT ON (user_id, pull_request_id) user_id, type
+		FROM events
+		WHERE type <> 'APPROVED'
+		ORDER BY user_id, pull_request_id, id DESC
+	),
+	counters AS (
+		SELECT user_id,
+			COUNT(DISTINCT pull_request_id) FILTER (WHERE type = 'ASSIGNED') AS total_reviews,
+			COUNT(DISTINCT pull_request_id) FILTER (WHERE type = 'MERGED') AS merged_reviews
+		FROM events
+		GROUP BY user_id
+	),
+	active AS (
+		SELECT user_id, COUNT(*) AS active_reviews
+		FROM last_events
+		WHERE type = 'ASSIGNED'
+		GROUP BY user_id
+	)
+	SELECT u.id,
+		COALESCE(c.total_reviews, 0),
+		COALESCE(a.active_reviews, 0),
+		COALESCE(c.merged_reviews, 0)
+	FROM users u
+	LEFT JOIN counters c ON c.user_id = u.id
+	LEFT JOIN active a ON a.user_id = u.id
+	WHERE $1::text[] IS NULL OR u.id = ANY($1::text[])`
+
+func saveReviewEvents(ctx context.Context, tx Tx, events ...domain.ReviewEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	builder := sq.Insert("review_events").
+		Columns("pull_request_id", "user_id", "type", "reason", "team_name", "created_at").
+		PlaceholderFormat(sq.Dollar)
+
+	// команда запоминается на момент события, чтобы перевод ревьюера не переносил его историю
+	for _, event := range events {
+		var reason any
+		if event.Reason != "" {
+			reason = event.Reason.String()
+		}
+		teamName := sq.Expr("COALESCE((SELECT team_name FROM users WHERE id = ?), '')", event.UserID)
+		builder = builder.Values(event.PullRequestID, event.UserID, event.Type.String(), reason, teamName, event.CreatedAt)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("saveReviewEvents builder.ToSql: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("saveReviewEvents tx.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+// refreshReviewStats пересчитывает user_review_stats для пользователей из истории событий.
+// Без userIDs пересчитываются все пользователи.
+func refreshReviewStats(ctx context.Context, tx Tx, userIDs ...string) error {
+	var filter pq.StringArray
+	if len(userIDs) > 0 {
+		filter = pq.StringArray(userIDs)
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO user_review_stats (user_id, total_reviews, active_reviews, merged_reviews, updated_at)
+		SELECT derived.*, NOW() FROM (`+derivedReviewStatsQuery+`) AS derived
+		ON CONFLICT (user_id) DO UPDATE SET
+			total_reviews = EXCLUDED.total_reviews,
+			active_reviews = EXCLUDED.active_reviews,
+			merged_reviews = EXCLUDED.merged_reviews,
+			updated_at = EXCLUDED.updated_at`,
+		filter,
+	)
+	if err != nil {
+		return fmt.Errorf("refreshReviewStats tx.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+// recordReviewEvents сохраняет события и пересчитывает статистику затронутых ревьюеров.
+func recordReviewEvents(ctx context.Context, tx Tx, events ...domain.ReviewEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	err := saveReviewEvents(ctx, tx, events...)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]string, 0, len(events))
+	for _, event := range events {
+		userIDs = append(userIDs, event.UserID)
+	}
+
+	return refreshReviewStats(ctx, tx, userIDs...)
+}
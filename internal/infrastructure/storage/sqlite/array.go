@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// stringArray хранит список строк в TEXT колонке как JSON массив - замена pq.StringArray.
+// Элементы массива доступны в запросах через json_each.
+type stringArray []string
+
+func (a stringArray) Value() (driver.Value, error) {
+	if a == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(a))
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
+	}
+	return string(data), nil
+}
+
+func (a *stringArray) Scan(src any) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		data = []byte(src)
+	case []byte:
+		data = src
+	default:
+		return fmt.Errorf("stringArray: unsupported type %T", src)
+	}
+
+	return json.Unmarshal(data, (*[]string)(a))
+}
@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"context"
+	"fmt"
+	"log/slog"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type AuditRepo struct {
+	db     storage.DB
+	logger *slog.Logger
+}
+
+func NewAuditRepo(db storage.DB, logger *slog.Logger) *AuditRepo {
+	return &AuditRepo{db: db, logger: logger}
+}
+
+func (r *AuditRepo) Append(ctx context.Context, entry domain.AuditEntry) error {
+	ctx, done := storage.Observe(ctx, "AuditRepo", "Append")
+	defer done()
+
+	entry.CreatedAt = entry.CreatedAt.UTC()
+	query, args, err := storage.AuditInsert(entry).PlaceholderFormat(sq.Question).ToSql()
+	if err != nil {
+		return fmt.Errorf("Append builder.ToSql: %w", err)
+	}
+	if _, err = storage.Executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("Append db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// Query фильтрует журнал аудита. Время хранится текстом в UTC, поэтому границы периода тоже переводятся в UTC.
+func (r *AuditRepo) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	ctx, done := storage.Observe(ctx, "AuditRepo", "Query")
+	defer done()
+
+	q.From, q.To = q.From.UTC(), q.To.UTC()
+	query, args, err := storage.AuditSelect(q).PlaceholderFormat(sq.Question).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("Query builder.ToSql: %w", err)
+	}
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Query db.Query: %w", err)
+	}
+
+	entries, err := storage.ScanAuditEntries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("Query: %w", err)
+	}
+
+	return entries, nil
+}
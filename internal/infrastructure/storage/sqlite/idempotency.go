@@ -0,0 +1,200 @@
+package sqlite
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type IdempotencyRepo struct {
+	db     storage.DB
+	logger *slog.Logger
+}
+
+type IdempotencyRecord struct {
+	Key          string         `db:"key"`
+	Owner        string         `db:"owner"`
+	Endpoint     string         `db:"endpoint"`
+	RequestHash  string         `db:"request_hash"`
+	StatusCode   sql.NullInt64  `db:"status_code"`
+	ContentType  sql.NullString `db:"content_type"`
+	ResponseBody []byte         `db:"response_body"`
+	CreatedAt    time.Time      `db:"created_at"`
+	ExpiresAt    time.Time      `db:"expires_at"`
+}
+
+const idempotencyColumns = "key, owner, endpoint, request_hash, status_code, content_type, response_body, created_at, expires_at"
+
+func NewIdempotencyRepo(db storage.DB, logger *slog.Logger) *IdempotencyRepo {
+	return &IdempotencyRepo{db: db, logger: logger}
+}
+
+func (r IdempotencyRecord) toDomain() domain.IdempotencyRecord {
+	return domain.IdempotencyRecord{
+		Key:          r.Key,
+		Owner:        r.Owner,
+		Endpoint:     r.Endpoint,
+		RequestHash:  r.RequestHash,
+		StatusCode:   int(r.StatusCode.Int64),
+		ContentType:  r.ContentType.String,
+		ResponseBody: r.ResponseBody,
+		CreatedAt:    r.CreatedAt,
+		ExpiresAt:    r.ExpiresAt,
+	}
+}
+
+// Reserve занимает ключ под новый запрос. Истёкшая запись с тем же ключом перезаписывается.
+// Если ключ уже занят, возвращается существующая запись и false. Если запись удаляют между вставкой и чтением
+// IdempotencyReserveAttempts раз подряд, возвращается ошибка.
+func (r *IdempotencyRepo) Reserve(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error) {
+	ctx, done := storage.Observe(ctx, "IdempotencyRepo", "Reserve")
+	defer done()
+
+	for range storage.IdempotencyReserveAttempts {
+		rows, err := storage.Executor(ctx, r.db).QueryContext(ctx,
+			`INSERT INTO idempotency_keys (key, owner, endpoint, request_hash, created_at, expires_at)
+			VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+			ON CONFLICT (key, owner, endpoint) DO UPDATE SET
+				request_hash = EXCLUDED.request_hash,
+				status_code = NULL,
+				content_type = NULL,
+				response_body = NULL,
+				created_at = EXCLUDED.created_at,
+				expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= ?7
+			RETURNING `+idempotencyColumns,
+			record.Key,
+			record.Owner,
+			record.Endpoint,
+			record.RequestHash,
+			record.CreatedAt,
+			record.ExpiresAt,
+			time.Now(),
+		)
+		if err != nil {
+			return domain.IdempotencyRecord{}, false, fmt.Errorf("Reserve db.Query: %w", err)
+		}
+		reserved, err := scanIdempotencyRecords(rows)
+		if err != nil {
+			return domain.IdempotencyRecord{}, false, fmt.Errorf("Reserve: %w", err)
+		}
+		if len(reserved) > 0 {
+			return reserved[0], true, nil
+		}
+
+		rows, err = storage.Executor(ctx, r.db).QueryContext(ctx,
+			"SELECT "+idempotencyColumns+" FROM idempotency_keys WHERE key = ? AND owner = ? AND endpoint = ?",
+			record.Key,
+			record.Owner,
+			record.Endpoint,
+		)
+		if err != nil {
+			return domain.IdempotencyRecord{}, false, fmt.Errorf("Reserve db.Query: %w", err)
+		}
+		existing, err := scanIdempotencyRecords(rows)
+		if err != nil {
+			return domain.IdempotencyRecord{}, false, fmt.Errorf("Reserve: %w", err)
+		}
+		if len(existing) > 0 {
+			return existing[0], false, nil
+		}
+		// запись удалили между запросами - ключ снова свободен
+	}
+
+	return domain.IdempotencyRecord{}, false, fmt.Errorf("Reserve: key deleted concurrently %d times in a row", storage.IdempotencyReserveAttempts)
+}
+
+// Complete сохраняет ответ на запрос и продлевает жизнь ключа до expiresAt.
+func (r *IdempotencyRepo) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	ctx, done := storage.Observe(ctx, "IdempotencyRepo", "Complete")
+	defer done()
+
+	_, err := storage.Executor(ctx, r.db).ExecContext(ctx,
+		`UPDATE idempotency_keys
+		SET status_code = ?4, content_type = ?5, response_body = ?6, expires_at = ?7
+		WHERE key = ?1 AND owner = ?2 AND endpoint = ?3 AND request_hash = ?8`,
+		record.Key,
+		record.Owner,
+		record.Endpoint,
+		record.StatusCode,
+		record.ContentType,
+		record.ResponseBody,
+		record.ExpiresAt,
+		record.RequestHash,
+	)
+	if err != nil {
+		return fmt.Errorf("Complete db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// Release освобождает ключ запроса, ответ на который не нужно сохранять.
+func (r *IdempotencyRepo) Release(ctx context.Context, record domain.IdempotencyRecord) error {
+	ctx, done := storage.Observe(ctx, "IdempotencyRepo", "Release")
+	defer done()
+
+	_, err := storage.Executor(ctx, r.db).ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE key = ? AND owner = ? AND endpoint = ? AND request_hash = ? AND status_code IS NULL",
+		record.Key,
+		record.Owner,
+		record.Endpoint,
+		record.RequestHash,
+	)
+	if err != nil {
+		return fmt.Errorf("Release db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired удаляет истёкшие ключи и возвращает их количество.
+func (r *IdempotencyRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	ctx, done := storage.Observe(ctx, "IdempotencyRepo", "DeleteExpired")
+	defer done()
+
+	res, err := storage.Executor(ctx, r.db).ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= ?", time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("DeleteExpired db.Exec: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("DeleteExpired res.RowsAffected: %w", err)
+	}
+
+	r.logger.InfoContext(ctx, "expired idempotency keys deleted", slog.Int64("deleted", deleted))
+
+	return deleted, nil
+}
+
+func scanIdempotencyRecords(rows *sql.Rows) ([]domain.IdempotencyRecord, error) {
+	defer rows.Close()
+
+	records := make([]domain.IdempotencyRecord, 0, 1)
+	for rows.Next() {
+		var record IdempotencyRecord
+		if err := rows.Scan(
+			&record.Key,
+			&record.Owner,
+			&record.Endpoint,
+			&record.RequestHash,
+			&record.StatusCode,
+			&record.ContentType,
+			&record.ResponseBody,
+			&record.CreatedAt,
+			&record.ExpiresAt,
+		); err != nil {
+			return nil, fmt.Errorf("rows.Next: %w", err)
+		}
+		records = append(records, record.toDomain())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return records, nil
+}
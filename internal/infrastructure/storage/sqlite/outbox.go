@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// OutboxRepo читает outbox для relay. Время хранится текстом в UTC, поэтому все метки времени переводятся в UTC.
+type OutboxRepo struct {
+	db     storage.DB
+	logger *slog.Logger
+}
+
+func NewOutboxRepo(db storage.DB, logger *slog.Logger) *OutboxRepo {
+	return &OutboxRepo{db: db, logger: logger}
+}
+
+// appendOutbox пишет события в outbox в транзакции изменения.
+func appendOutbox(ctx context.Context, tx storage.Tx, events ...domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	query, args, err := storage.OutboxInsert(ctx, events...).PlaceholderFormat(sq.Question).ToSql()
+	if err != nil {
+		return fmt.Errorf("appendOutbox builder.ToSql: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("appendOutbox tx.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepo) AcquireLease(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	ctx, done := storage.Observe(ctx, "OutboxRepo", "AcquireLease")
+	defer done()
+
+	now := time.Now().UTC()
+	query, args, err := storage.OutboxLease(holder, now, now.Add(ttl)).PlaceholderFormat(sq.Question).ToSql()
+	if err != nil {
+		return false, fmt.Errorf("AcquireLease builder.ToSql: %w", err)
+	}
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("AcquireLease db.Query: %w", err)
+	}
+
+	leased, err := storage.ScanLeased(rows)
+	if err != nil {
+		return false, fmt.Errorf("AcquireLease: %w", err)
+	}
+
+	return leased, nil
+}
+
+func (r *OutboxRepo) Pending(ctx context.Context, limit uint64) ([]domain.Event, error) {
+	ctx, done := storage.Observe(ctx, "OutboxRepo", "Pending")
+	defer done()
+
+	query, args, err := storage.OutboxPending(limit).PlaceholderFormat(sq.Question).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("Pending builder.ToSql: %w", err)
+	}
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Pending db.Query: %w", err)
+	}
+
+	events, err := storage.ScanOutboxEvents(rows)
+	if err != nil {
+		return nil, fmt.Errorf("Pending: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *OutboxRepo) MarkPublished(ctx context.Context, ids []int64, at time.Time) error {
+	ctx, done := storage.Observe(ctx, "OutboxRepo", "MarkPublished")
+	defer done()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query, args, err := storage.OutboxMarkPublished(ids, at.UTC()).PlaceholderFormat(sq.Question).ToSql()
+	if err != nil {
+		return fmt.Errorf("MarkPublished builder.ToSql: %w", err)
+	}
+	if _, err = storage.Executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("MarkPublished db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id int64, reason string) error {
+	ctx, done := storage.Observe(ctx, "OutboxRepo", "MarkFailed")
+	defer done()
+
+	query, args, err := storage.OutboxMarkFailed(id, reason).PlaceholderFormat(sq.Question).ToSql()
+	if err != nil {
+		return fmt.Errorf("MarkFailed builder.ToSql: %w", err)
+	}
+	if _, err = storage.Executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("MarkFailed db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepo) MarkDead(ctx context.Context, id int64, reason string, at time.Time) error {
+	ctx, done := storage.Observe(ctx, "OutboxRepo", "MarkDead")
+	defer done()
+
+	query, args, err := storage.OutboxMarkDead(id, reason, at.UTC()).PlaceholderFormat(sq.Question).ToSql()
+	if err != nil {
+		return fmt.Errorf("MarkDead builder.ToSql: %w", err)
+	}
+	if _, err = storage.Executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("MarkDead db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepo) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	ctx, done := storage.Observe(ctx, "OutboxRepo", "DeletePublished")
+	defer done()
+
+	query, args, err := storage.OutboxDeletePublished(before.UTC()).PlaceholderFormat(sq.Question).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("DeletePublished builder.ToSql: %w", err)
+	}
+	res, err := storage.Executor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("DeletePublished db.Exec: %w", err)
+	}
+
+	return res.RowsAffected()
+}
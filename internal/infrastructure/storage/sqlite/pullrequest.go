@@ -0,0 +1,276 @@
+package sqlite
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const prColumns = "id, name, author_id, status, reviewers_ids, merged_at, created_at, version"
+
+type PRRepo struct {
+	db     storage.DB
+	logger *slog.Logger
+}
+
+type PullRequest struct {
+	id           string      `db:"id"`
+	name         string      `db:"name"`
+	authorID     string      `db:"author_id"`
+	status       string      `db:"status"`
+	reviewersIDs stringArray `db:"reviewers_ids"`
+	mergedAt     time.Time   `db:"merged_at"`
+	createdAt    time.Time   `db:"created_at"`
+	version      int64       `db:"version"`
+}
+
+func NewPRRepo(db storage.DB, logger *slog.Logger) *PRRepo {
+	return &PRRepo{db: db, logger: logger}
+}
+
+func (pr PullRequest) toDomain() domain.PullRequest {
+	return domain.NewPullRequestFromStorage(pr.id, pr.name, pr.authorID, domain.PRStatus(pr.status), pr.reviewersIDs, pr.mergedAt, pr.createdAt, pr.version)
+}
+
+func (r *PRRepo) CreatePR(ctx context.Context, pr domain.PullRequest) (err error) {
+	ctx, done := storage.Observe(ctx, "PRRepo", "CreatePR")
+	defer done()
+
+	tx, finish, err := storage.BeginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func() { err = finish(err) }()
+
+	builder := sq.Insert("pull_requests").
+		Columns("id", "name", "author_id", "status", "reviewers_ids", "merged_at", "created_at", "version", "updated_by").
+		Values(pr.ID, pr.Name, pr.AuthorID, pr.Status.String(), stringArray(pr.ReviewersIDs), pr.MergedAt, pr.CreatedAt, pr.Version, storage.UpdatedBy(ctx)).
+		PlaceholderFormat(sq.Question)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("CreatePR builder.ToSql: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if isUniqueViolation(err) {
+		return domain.ErrPRExists
+	}
+	if err != nil {
+		return fmt.Errorf("CreatePR db.Exec: %w", err)
+	}
+
+	err = recordReviewEvents(ctx, tx, domain.NewReviewEvents(pr.ID, domain.ReviewEventAssigned, pr.ReviewersIDs...)...)
+	if err != nil {
+		return fmt.Errorf("recordReviewEvents: %w", err)
+	}
+
+	return appendOutbox(ctx, tx, domain.NewPRCreatedEvents(pr)...)
+}
+
+// ApprovePR записывает одобрение PR ревьюером. Сам PR не меняется, поэтому версия не увеличивается.
+func (r *PRRepo) ApprovePR(ctx context.Context, prID, reviewerID string) (err error) {
+	ctx, done := storage.Observe(ctx, "PRRepo", "ApprovePR")
+	defer done()
+
+	tx, finish, err := storage.BeginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func() { err = finish(err) }()
+
+	err = recordReviewEvents(ctx, tx, *domain.NewReviewEvent(prID, reviewerID, domain.ReviewEventApproved))
+	if err != nil {
+		return fmt.Errorf("recordReviewEvents: %w", err)
+	}
+
+	return appendOutbox(ctx, tx, domain.NewPRApprovedEvent(prID, reviewerID))
+}
+
+func (r *PRRepo) MergePR(ctx context.Context, pr domain.PullRequest) (err error) {
+	ctx, done := storage.Observe(ctx, "PRRepo", "MergePR")
+	defer done()
+
+	tx, finish, err := storage.BeginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func() { err = finish(err) }()
+
+	builder := sq.Update("pull_requests").
+		Set("status", domain.PRStatusMerged.String()).
+		Set("merged_at", pr.MergedAt).
+		Set("version", pr.Version).
+		Set("updated_by", storage.UpdatedBy(ctx)).
+		Where(sq.Eq{"id": pr.ID, "version": pr.Version - 1}).
+		PlaceholderFormat(sq.Question)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("MergePR builder.ToSql: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("MergePR db.Exec: %w", err)
+	}
+	err = checkUpdated(res)
+	if err != nil {
+		return fmt.Errorf("MergePR: %w", err)
+	}
+
+	err = recordReviewEvents(ctx, tx, domain.NewReviewEvents(pr.ID, domain.ReviewEventMerged, pr.ReviewersIDs...)...)
+	if err != nil {
+		return fmt.Errorf("recordReviewEvents: %w", err)
+	}
+
+	return appendOutbox(ctx, tx, domain.NewPRMergedEvent(pr))
+}
+
+func (r *PRRepo) ReassignPR(ctx context.Context, pr domain.PullRequest, oldReviewer, newReviewer string) (err error) {
+	ctx, done := storage.Observe(ctx, "PRRepo", "ReassignPR")
+	defer done()
+
+	tx, finish, err := storage.BeginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func() { err = finish(err) }()
+
+	builder := sq.Update("pull_requests").
+		Set("reviewers_ids", stringArray(pr.ReviewersIDs)).
+		Set("version", pr.Version).
+		Set("updated_by", storage.UpdatedBy(ctx)).
+		Where(sq.Eq{"id": pr.ID, "version": pr.Version - 1}).
+		PlaceholderFormat(sq.Question)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("ReassignPR builder.ToSql: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("ReassignPR db.Exec: %w", err)
+	}
+	err = checkUpdated(res)
+	if err != nil {
+		return fmt.Errorf("ReassignPR: %w", err)
+	}
+
+	events := []domain.ReviewEvent{
+		*domain.NewUnassignedEvent(pr.ID, oldReviewer, domain.ReviewReasonReassigned),
+		*domain.NewReviewEvent(pr.ID, newReviewer, domain.ReviewEventAssigned),
+	}
+	err = recordReviewEvents(ctx, tx, events...)
+	if err != nil {
+		return fmt.Errorf("recordReviewEvents: %w", err)
+	}
+
+	return appendOutbox(ctx, tx, domain.NewReviewerEvents(events...)...)
+}
+
+func (r *PRRepo) FindByID(ctx context.Context, prID string) (domain.PullRequest, error) {
+	ctx, done := storage.Observe(ctx, "PRRepo", "FindByID")
+	defer done()
+
+	return r.findByID(ctx, prID)
+}
+
+// FindByIDForUpdate читает PR внутри транзакции. Блокировать строку не нужно:
+// транзакция SQLite с _txlock=immediate держит блокировку на запись всей базы до конца.
+func (r *PRRepo) FindByIDForUpdate(ctx context.Context, prID string) (domain.PullRequest, error) {
+	ctx, done := storage.Observe(ctx, "PRRepo", "FindByIDForUpdate")
+	defer done()
+
+	return r.findByID(ctx, prID)
+}
+
+func (r *PRRepo) findByID(ctx context.Context, prID string) (domain.PullRequest, error) {
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx,
+		"SELECT "+prColumns+" FROM pull_requests WHERE id = ?",
+		prID,
+	)
+	if err != nil {
+		return domain.PullRequest{}, fmt.Errorf("FindByID PR db.Query: %w", err)
+	}
+	prs, err := scanPullRequests(rows)
+	if err != nil {
+		return domain.PullRequest{}, fmt.Errorf("FindByID PR: %w", err)
+	}
+
+	if len(prs) == 0 {
+		return domain.PullRequest{}, domain.ErrPRNotFound
+	}
+
+	return prs[0], nil
+}
+
+func (r *PRRepo) FindByReviewerID(ctx context.Context, reviewerID string) ([]domain.PullRequest, error) {
+	ctx, done := storage.Observe(ctx, "PRRepo", "FindByReviewerID")
+	defer done()
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx,
+		`SELECT `+prColumns+`
+		FROM pull_requests
+		WHERE EXISTS (SELECT 1 FROM json_each(reviewers_ids) WHERE value = ?)
+		ORDER BY id`,
+		reviewerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FindByReviewerID r.db.Query: %w", err)
+	}
+	prs, err := scanPullRequests(rows)
+	if err != nil {
+		return nil, fmt.Errorf("FindByReviewerID: %w", err)
+	}
+
+	return prs, nil
+}
+
+func scanPullRequests(rows *sql.Rows) ([]domain.PullRequest, error) {
+	defer rows.Close()
+
+	prs := make([]domain.PullRequest, 0, 10)
+	for rows.Next() {
+		var pullRequest PullRequest
+		if err := rows.Scan(
+			&pullRequest.id,
+			&pullRequest.name,
+			&pullRequest.authorID,
+			&pullRequest.status,
+			&pullRequest.reviewersIDs,
+			&pullRequest.mergedAt,
+			&pullRequest.createdAt,
+			&pullRequest.version,
+		); err != nil {
+			return nil, fmt.Errorf("rows.Next: %w", err)
+		}
+		prs = append(prs, pullRequest.toDomain())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return prs, nil
+}
+
+// checkUpdated возвращает ErrPRVersionConflict, если условное обновление PR не затронуло ни одной строки:
+// PR изменили с момента чтения.
+func checkUpdated(res sql.Result) error {
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("res.RowsAffected: %w", err)
+	}
+	if updated == 0 {
+		return domain.ErrPRVersionConflict
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"context"
+	"fmt"
+	"time"
+)
+
+// groupStatsEvents - события review_events за период [?1, ?2) с ключом группировки.
+// Команда берётся из события, а не из текущего состава. Одобрения в статистику назначений не входят.
+const groupStatsEvents = `WITH events AS (
+		SELECT e.id, e.pull_request_id, e.user_id, e.type, e.reason, e.created_at, %[1]s AS key
+		FROM review_events e
+		WHERE e.created_at >= ?1 AND e.created_at < ?2 AND e.type <> 'APPROVED'
+	)`
+
+// groupStatsCounters считает события каждого типа по ключу группировки.
+// Из снятий считаются только переназначения, снятия при деактивации не входят.
+const groupStatsCounters = groupStatsEvents + `
+	SELECT key,
+		COUNT(*) FILTER (WHERE type = 'ASSIGNED'),
+		COUNT(*) FILTER (WHERE type = 'MERGED'),
+		COUNT(*) FILTER (WHERE type = 'UNASSIGNED' AND reason = 'REASSIGNED')
+	FROM events
+	GROUP BY key`
+
+// groupStatsMergeTimes - время до мержа в секундах: от последнего назначения ревьюера на PR до события MERGED.
+const groupStatsMergeTimes = groupStatsEvents + `
+	SELECT m.key, (julianday(m.created_at) - julianday(MAX(ra.created_at))) * 86400.0
+	FROM events m
+	JOIN review_events ra
+		ON ra.pull_request_id = m.pull_request_id
+		AND ra.user_id = m.user_id
+		AND ra.type = 'ASSIGNED'
+		AND ra.created_at <= m.created_at
+	WHERE m.type = 'MERGED'
+	GROUP BY m.id, m.key, m.created_at`
+
+var statsGroupColumns = map[domain.StatsGroupBy]string{
+	domain.StatsGroupByUser: "e.user_id",
+	domain.StatsGroupByTeam: "e.team_name",
+}
+
+// QueryStats возвращает статистику за период, отсортированную по q.SortBy и ключу группировки.
+// Выбирается на одну строку больше q.Limit, чтобы понять, есть ли следующая страница.
+// В SQLite нет percentile_cont, поэтому медиана, сортировка и курсор считаются в Go.
+func (r *UserRepo) QueryStats(ctx context.Context, q domain.StatsQuery) ([]domain.GroupStat, error) {
+	ctx, done := storage.Observe(ctx, "UserRepo", "QueryStats")
+	defer done()
+
+	groupColumn, ok := statsGroupColumns[q.GroupBy]
+	if !ok {
+		return nil, domain.ErrInvalidStatsQuery
+	}
+	switch q.SortBy {
+	case domain.StatsSortByKey, domain.StatsSortByAssigned, domain.StatsSortByMerged,
+		domain.StatsSortByReassigned, domain.StatsSortByMedianTimeToMerge:
+	default:
+		return nil, domain.ErrInvalidStatsQuery
+	}
+
+	mergeSeconds, err := r.queryMergeTimes(ctx, groupColumn, q)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx, fmt.Sprintf(groupStatsCounters, groupColumn), q.From, q.To)
+	if err != nil {
+		return nil, fmt.Errorf("QueryStats db.Query: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]domain.GroupStat, 0, 20)
+	for rows.Next() {
+		var stat domain.GroupStat
+		if err := rows.Scan(
+			&stat.Key,
+			&stat.ReviewsAssigned,
+			&stat.ReviewsMerged,
+			&stat.ReassignedAway,
+		); err != nil {
+			return nil, fmt.Errorf("QueryStats rows.Next: %w", err)
+		}
+		if median := domain.PercentileCont(mergeSeconds[stat.Key], 0.5); median != nil {
+			stat.MedianTimeToMerge = time.Duration(median[0] * float64(time.Second))
+		}
+		stats = append(stats, stat)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("QueryStats rows.Err: %w", err)
+	}
+
+	return domain.SelectGroupStats(stats, q), nil
+}
+
+func (r *UserRepo) queryMergeTimes(ctx context.Context, groupColumn string, q domain.StatsQuery) (map[string][]float64, error) {
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx, fmt.Sprintf(groupStatsMergeTimes, groupColumn), q.From, q.To)
+	if err != nil {
+		return nil, fmt.Errorf("queryMergeTimes db.Query: %w", err)
+	}
+	defer rows.Close()
+
+	seconds := make(map[string][]float64)
+	for rows.Next() {
+		var (
+			key   string
+			value float64
+		)
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("queryMergeTimes rows.Next: %w", err)
+		}
+		seconds[key] = append(seconds[key], value)
+	}
+
+	return seconds, rows.Err()
+}
+
+// QueryWorkload возвращает число назначений за период для активных участников команд
+// и для тех, кого назначали в этот период. Результат отсортирован по команде.
+func (r *UserRepo) QueryWorkload(ctx context.Context, q domain.FairnessQuery) ([]domain.MemberWorkload, error) {
+	ctx, done := storage.Observe(ctx, "UserRepo", "QueryWorkload")
+	defer done()
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx,
+		`SELECT u.id, u.team_name, COUNT(e.id)
+		FROM users u
+		LEFT JOIN review_events e
+			ON e.user_id = u.id
+			AND e.type = 'ASSIGNED'
+			AND e.created_at >= ?1
+			AND e.created_at < ?2
+		WHERE ?3 = '' OR u.team_name = ?3
+		GROUP BY u.id, u.team_name, u.is_active
+		HAVING u.is_active OR COUNT(e.id) > 0
+		ORDER BY u.team_name, u.id`,
+		q.From,
+		q.To,
+		q.TeamName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("QueryWorkload db.Query: %w", err)
+	}
+	defer rows.Close()
+
+	workloads := make([]domain.MemberWorkload, 0, 20)
+	for rows.Next() {
+		var workload domain.MemberWorkload
+		if err := rows.Scan(
+			&workload.UserID,
+			&workload.TeamName,
+			&workload.Assigned,
+		); err != nil {
+			return nil, fmt.Errorf("QueryWorkload rows.Next: %w", err)
+		}
+		workloads = append(workloads, workload)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("QueryWorkload rows.Err: %w", err)
+	}
+
+	return workloads, nil
+}
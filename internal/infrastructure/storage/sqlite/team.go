@@ -0,0 +1,287 @@
+package sqlite
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type TeamRepo struct {
+	db     storage.DB
+	logger *slog.Logger
+}
+
+func NewTeamRepo(db storage.DB, logger *slog.Logger) *TeamRepo {
+	return &TeamRepo{db: db, logger: logger}
+}
+
+func (r *TeamRepo) Save(ctx context.Context, team domain.Team, teamMembers []domain.User) (err error) {
+	ctx, done := storage.Observe(ctx, "TeamRepo", "Save")
+	defer done()
+
+	tx, finish, err := storage.BeginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func() { err = finish(err) }()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO teams (name, updated_by) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET updated_by = excluded.updated_by",
+		team.Name,
+		storage.UpdatedBy(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("save team tx.ExecContext: %w", err)
+	}
+
+	// При переходе в другую команду роль team_lead не переносится: лид остаётся лидом только своей команды
+	builder := sq.Insert("users").
+		Columns("id", "name", "team_name", "is_active", "updated_by").
+		PlaceholderFormat(sq.Question).
+		Suffix(`ON CONFLICT (id) DO UPDATE SET
+            name = excluded.name,
+            role = CASE WHEN users.team_name = excluded.team_name OR users.role = 'admin' THEN users.role ELSE 'member' END,
+            team_name = excluded.team_name,
+            is_active = excluded.is_active,
+            updated_by = excluded.updated_by`)
+
+	for _, member := range teamMembers {
+		builder = builder.Values(member.ID, member.Name, team.Name, member.IsActive, storage.UpdatedBy(ctx))
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("save team builder.ToSql: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("insert users tx.ExecContext: %w", err)
+	}
+
+	err = createReviewStats(ctx, tx, teamMembers)
+	if err != nil {
+		return fmt.Errorf("createStats: %w", err)
+	}
+
+	return nil
+}
+
+func createReviewStats(ctx context.Context, tx storage.Tx, users []domain.User) error {
+	builder := sq.Insert("user_review_stats").
+		Columns("user_id", "updated_at").
+		PlaceholderFormat(sq.Question).
+		Suffix(`ON CONFLICT (user_id) DO NOTHING`)
+
+	for _, user := range users {
+		builder = builder.Values(user.ID, time.Now())
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("create stats builder.ToSql: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("create stats users tx.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TeamRepo) FindByName(ctx context.Context, teamName string) ([]domain.User, error) {
+	ctx, done := storage.Observe(ctx, "TeamRepo", "FindByName")
+	defer done()
+
+	builder := sq.Select("id", "name", "team_name", "is_active", "role").
+		From("users").
+		Where(sq.Eq{"team_name": teamName}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Question)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("FindByName team builder.ToSql: %w", err)
+	}
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("FindByName team db.Query: %w", err)
+	}
+
+	return scanUsers(rows)
+}
+
+// DeactivateTeam выполняет по шагам то, что в postgres делает один запрос с CTE:
+// SQLite не поддерживает UPDATE внутри WITH, а RETURNING не отдаёт старые значения строки.
+func (r *TeamRepo) DeactivateTeam(ctx context.Context, teamName string) (prs []domain.PullRequest, err error) {
+	ctx, done := storage.Observe(ctx, "TeamRepo", "DeactivateTeam")
+	defer done()
+
+	tx, finish, err := storage.BeginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = finish(err) }()
+
+	deactivated, err := queryStrings(ctx, tx,
+		`UPDATE users
+		SET is_active = FALSE, updated_by = ?
+		WHERE team_name = ?
+		  AND is_active = TRUE
+		RETURNING id`,
+		storage.UpdatedBy(ctx),
+		teamName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("deactivate team users: %w", err)
+	}
+
+	if len(deactivated) == 0 {
+		return []domain.PullRequest{}, nil
+	}
+	slices.Sort(deactivated)
+
+	err = appendOutbox(ctx, tx, domain.NewTeamDeactivatedEvents(teamName, deactivated)...)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := queryStrings(ctx, tx,
+		`SELECT id
+		FROM users
+		WHERE team_name != ?
+		  AND is_active = TRUE
+		ORDER BY RANDOM()
+		LIMIT 1`,
+		teamName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("deactivate team new reviewer: %w", err)
+	}
+	if len(candidates) == 0 {
+		return []domain.PullRequest{}, nil
+	}
+
+	oldReviewers, err := selectOpenPRReviewers(ctx, tx, deactivated)
+	if err != nil {
+		return nil, fmt.Errorf("deactivate team open pull requests: %w", err)
+	}
+	prIDs := make([]string, 0, len(oldReviewers))
+	for prID := range oldReviewers {
+		prIDs = append(prIDs, prID)
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`UPDATE pull_requests
+		SET reviewers_ids = json_array(?), version = version + 1, updated_by = ?
+		WHERE id IN (SELECT value FROM json_each(?))
+		RETURNING `+prColumns,
+		candidates[0],
+		storage.UpdatedBy(ctx),
+		stringArray(prIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("deactivate team db.Exec: %w", err)
+	}
+	prs, err = scanPullRequests(rows)
+	if err != nil {
+		return nil, fmt.Errorf("DeactivateTeam: %w", err)
+	}
+
+	events := make([]domain.ReviewEvent, 0, 20)
+	for _, pr := range prs {
+		events = append(events, domain.ReviewersChangeEvents(pr.ID, oldReviewers[pr.ID], pr.ReviewersIDs, domain.ReviewReasonTeamDeactivated)...)
+	}
+
+	err = recordReviewEvents(ctx, tx, events...)
+	if err != nil {
+		return nil, fmt.Errorf("recordReviewEvents: %w", err)
+	}
+
+	err = appendOutbox(ctx, tx, domain.NewReviewerEvents(events...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.InfoContext(ctx, "team deactivated",
+		slog.String("team_name", teamName),
+		slog.Int("reassigned_pull_requests", len(prs)),
+	)
+
+	return prs, nil
+}
+
+// selectOpenPRReviewers возвращает ревьюеров открытых PR, где ревьюером назначен кто-то из userIDs.
+func selectOpenPRReviewers(ctx context.Context, tx storage.Tx, userIDs []string) (map[string][]string, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT pr.id, pr.reviewers_ids
+		FROM pull_requests pr
+		WHERE pr.status = 'OPEN'
+		  AND EXISTS (
+			  SELECT 1
+			  FROM json_each(pr.reviewers_ids) r
+			  WHERE r.value IN (SELECT value FROM json_each(?))
+		  )`,
+		stringArray(userIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tx.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	reviewers := make(map[string][]string)
+	for rows.Next() {
+		var (
+			prID        string
+			reviewerIDs stringArray
+		)
+		if err := rows.Scan(&prID, &reviewerIDs); err != nil {
+			return nil, fmt.Errorf("rows.Next: %w", err)
+		}
+		reviewers[prID] = reviewerIDs
+	}
+
+	return reviewers, rows.Err()
+}
+
+func (r *TeamRepo) GetTeamLoad(ctx context.Context) ([]domain.TeamLoad, error) {
+	ctx, done := storage.Observe(ctx, "TeamRepo", "GetTeamLoad")
+	defer done()
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx,
+		`SELECT t.name,
+			(SELECT COUNT(*)
+			 FROM pull_requests pr
+			 JOIN users a ON a.id = pr.author_id
+			 WHERE a.team_name = t.name AND pr.status = 'OPEN'),
+			(SELECT COUNT(*)
+			 FROM users u
+			 WHERE u.team_name = t.name AND u.is_active = TRUE)
+		FROM teams t
+		ORDER BY t.name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetTeamLoad db.Query: %w", err)
+	}
+	defer rows.Close()
+
+	teams := make([]domain.TeamLoad, 0, 20)
+	for rows.Next() {
+		var team domain.TeamLoad
+		if err := rows.Scan(
+			&team.TeamName,
+			&team.OpenPullRequests,
+			&team.ActiveReviewers,
+		); err != nil {
+			return nil, fmt.Errorf("GetTeamLoad rows.Next: %w", err)
+		}
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
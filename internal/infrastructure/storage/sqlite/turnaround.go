@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Выборки (ключ группировки, секунды до первого ревью, секунды до мержа) для каждой группировки,
+// те же, что и в postgres.
+var turnaroundSamples = map[domain.TurnaroundGroupBy]string{
+	domain.TurnaroundGroupByTeam: `SELECT u.team_name AS key,
+			(julianday(fr.first_review_at) - julianday(pr.created_at)) * 86400.0 AS first_review_seconds,
+			CASE WHEN pr.status = 'MERGED' THEN (julianday(pr.merged_at) - julianday(pr.created_at)) * 86400.0 END AS merge_seconds
+		FROM pull_requests pr
+		JOIN users u ON u.id = pr.author_id
+		LEFT JOIN (
+			SELECT pull_request_id, MIN(created_at) AS first_review_at
+			FROM review_events
+			WHERE type = 'APPROVED'
+			GROUP BY pull_request_id
+		) fr ON fr.pull_request_id = pr.id
+		WHERE pr.created_at >= ?1 AND pr.created_at < ?2`,
+
+	// неделя начинается с понедельника, как date_trunc('week') в postgres
+	domain.TurnaroundGroupByWeek: `SELECT date(pr.created_at, '-6 days', 'weekday 1') AS key,
+			(julianday(fr.first_review_at) - julianday(pr.created_at)) * 86400.0 AS first_review_seconds,
+			CASE WHEN pr.status = 'MERGED' THEN (julianday(pr.merged_at) - julianday(pr.created_at)) * 86400.0 END AS merge_seconds
+		FROM pull_requests pr
+		LEFT JOIN (
+			SELECT pull_request_id, MIN(created_at) AS first_review_at
+			FROM review_events
+			WHERE type = 'APPROVED'
+			GROUP BY pull_request_id
+		) fr ON fr.pull_request_id = pr.id
+		WHERE pr.created_at >= ?1 AND pr.created_at < ?2`,
+
+	domain.TurnaroundGroupByReviewer: `SELECT a.user_id AS key,
+			(julianday(a.approved_at) - julianday(a.assigned_at)) * 86400.0 AS first_review_seconds,
+			CASE WHEN pr.status = 'MERGED' THEN (julianday(pr.merged_at) - julianday(pr.created_at)) * 86400.0 END AS merge_seconds
+		FROM pull_requests pr
+		JOIN (
+			SELECT pull_request_id, user_id,
+				MIN(created_at) FILTER (WHERE type = 'ASSIGNED') AS assigned_at,
+				MIN(created_at) FILTER (WHERE type = 'APPROVED') AS approved_at
+			FROM review_events
+			WHERE type IN ('ASSIGNED', 'APPROVED')
+			GROUP BY pull_request_id, user_id
+		) a ON a.pull_request_id = pr.id AND a.assigned_at IS NOT NULL
+		WHERE pr.created_at >= ?1 AND pr.created_at < ?2`,
+}
+
+// QueryTurnaround выбирает времена по каждому PR, а перцентили считает в Go: в SQLite нет percentile_cont.
+func (r *PRRepo) QueryTurnaround(ctx context.Context, q domain.TurnaroundQuery) ([]domain.TurnaroundStat, error) {
+	ctx, done := storage.Observe(ctx, "PRRepo", "QueryTurnaround")
+	defer done()
+
+	samples, ok := turnaroundSamples[q.GroupBy]
+	if !ok {
+		return nil, domain.ErrInvalidTurnaroundQuery
+	}
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx, samples+` ORDER BY key`, q.From, q.To)
+	if err != nil {
+		return nil, fmt.Errorf("QueryTurnaround db.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		stats              = make([]domain.TurnaroundStat, 0, 20)
+		firstReview, merge []float64
+	)
+	flush := func() {
+		if len(stats) == 0 {
+			return
+		}
+		stat := &stats[len(stats)-1]
+		stat.TimeToFirstReview = domain.NewPercentiles(domain.PercentileCont(firstReview, 0.5, 0.9, 0.99))
+		stat.TimeToMerge = domain.NewPercentiles(domain.PercentileCont(merge, 0.5, 0.9, 0.99))
+		firstReview, merge = firstReview[:0], merge[:0]
+	}
+	for rows.Next() {
+		var (
+			key                string
+			firstReviewSeconds sql.NullFloat64
+			mergeSeconds       sql.NullFloat64
+		)
+		if err := rows.Scan(&key, &firstReviewSeconds, &mergeSeconds); err != nil {
+			return nil, fmt.Errorf("QueryTurnaround rows.Next: %w", err)
+		}
+
+		if len(stats) == 0 || stats[len(stats)-1].Key != key {
+			flush()
+			stats = append(stats, domain.TurnaroundStat{Key: key})
+		}
+		stat := &stats[len(stats)-1]
+		stat.PullRequests++
+		if firstReviewSeconds.Valid {
+			firstReview = append(firstReview, firstReviewSeconds.Float64)
+		}
+		if mergeSeconds.Valid {
+			stat.MergedPullRequests++
+			merge = append(merge, mergeSeconds.Float64)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("QueryTurnaround rows.Err: %w", err)
+	}
+	flush()
+
+	return stats, nil
+}
@@ -0,0 +1,406 @@
+package sqlite
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type UserRepo struct {
+	db     storage.DB
+	logger *slog.Logger
+}
+
+type User struct {
+	id       string `db:"id"`
+	name     string `db:"name"`
+	teamName string `db:"team_name"`
+	isActive bool   `db:"is_active"`
+	role     string `db:"role"`
+}
+
+type UserStat struct {
+	UserID        string    `db:"user_id"`
+	TotalReviews  int64     `db:"total_reviews" `
+	ActiveReviews int64     `db:"active_reviews"`
+	MergedReviews int64     `db:"merged_reviews"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+func NewUserRepo(db storage.DB, logger *slog.Logger) *UserRepo {
+	return &UserRepo{db: db, logger: logger}
+}
+
+func (u User) toDomain() domain.User {
+	user := domain.NewUser(u.id, u.name, u.teamName, u.isActive)
+	user.Role = domain.Role(u.role)
+	return *user
+}
+
+func (u UserStat) toDomain() domain.UserStat {
+	return *domain.NewUserStat(u.UserID, u.TotalReviews, u.ActiveReviews, u.MergedReviews, u.UpdatedAt)
+}
+
+func (r *UserRepo) SetIsActive(ctx context.Context, userID string, isActive bool) (err error) {
+	ctx, done := storage.Observe(ctx, "UserRepo", "SetIsActive")
+	defer done()
+
+	tx, finish, err := storage.BeginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func() { err = finish(err) }()
+
+	// о деактивации сообщается, только если пользователь был активен
+	activeTeam, err := queryStrings(ctx, tx, "SELECT team_name FROM users WHERE id = ? AND is_active = TRUE", userID)
+	if err != nil {
+		return fmt.Errorf("select active user: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users
+		SET is_active = ?, updated_by = ?
+		WHERE id = ?`,
+		isActive,
+		storage.UpdatedBy(ctx),
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("update is_active user tx.ExecContext: %w", err)
+	}
+
+	if isActive {
+		return nil
+	}
+
+	// Удаляем неактивного ревьюера со всех PR со статусом OPEN
+	prIDs, err := queryStrings(ctx, tx,
+		`UPDATE pull_requests
+		SET reviewers_ids = (
+			SELECT json_group_array(value)
+			FROM (SELECT value FROM json_each(reviewers_ids) WHERE value != ?1 ORDER BY key)
+		), version = version + 1, updated_by = ?3
+		WHERE EXISTS (SELECT 1 FROM json_each(reviewers_ids) WHERE value = ?1) AND status = ?2
+		RETURNING id`,
+		userID,
+		domain.PRStatusOpen.String(),
+		storage.UpdatedBy(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("remove not active reviewer: %w", err)
+	}
+
+	events := make([]domain.ReviewEvent, 0, len(prIDs))
+	for _, prID := range prIDs {
+		events = append(events, *domain.NewUnassignedEvent(prID, userID, domain.ReviewReasonUserDeactivated))
+	}
+
+	err = recordReviewEvents(ctx, tx, events...)
+	if err != nil {
+		return fmt.Errorf("recordReviewEvents: %w", err)
+	}
+
+	outbox := domain.NewReviewerEvents(events...)
+	if len(activeTeam) > 0 {
+		outbox = append([]domain.Event{domain.NewUserDeactivatedEvent(userID, activeTeam[0])}, outbox...)
+	}
+	err = appendOutbox(ctx, tx, outbox...)
+	if err != nil {
+		return err
+	}
+
+	if len(events) > 0 {
+		r.logger.InfoContext(ctx, "inactive reviewer removed from open pull requests",
+			slog.String("user_id", userID),
+			slog.Int("pull_requests", len(events)),
+		)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) SetRole(ctx context.Context, userID string, role domain.Role) error {
+	ctx, done := storage.Observe(ctx, "UserRepo", "SetRole")
+	defer done()
+
+	res, err := storage.Executor(ctx, r.db).ExecContext(ctx,
+		"UPDATE users SET role = ?, updated_by = ? WHERE id = ?",
+		string(role),
+		storage.UpdatedBy(ctx),
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("SetRole db.Exec: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("SetRole RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return domain.ErrUserNotExist
+	}
+
+	return nil
+}
+
+func (r *UserRepo) FindByID(ctx context.Context, userID string) (domain.User, error) {
+	ctx, done := storage.Observe(ctx, "UserRepo", "FindByID")
+	defer done()
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx, "SELECT id, name, team_name, is_active, role FROM users WHERE id = ?", userID)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("FindByID db.Query: %w", err)
+	}
+	users, err := scanUsers(rows)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("FindByID: %w", err)
+	}
+
+	if len(users) == 0 {
+		return domain.User{}, domain.ErrUserNotExist
+	}
+
+	return users[0], nil
+}
+
+func (r *UserRepo) FindTeamByUserID(ctx context.Context, userID string) (string, error) {
+	ctx, done := storage.Observe(ctx, "UserRepo", "FindTeamByUserID")
+	defer done()
+
+	teams, err := queryStrings(ctx, storage.Executor(ctx, r.db), "SELECT team_name FROM users WHERE id = ?", userID)
+	if err != nil {
+		return "", fmt.Errorf("FindTeamByUserID: %w", err)
+	}
+
+	if len(teams) == 0 || teams[0] == "" {
+		return "", domain.ErrUserNotExist
+	}
+
+	return teams[0], nil
+}
+
+func (r *UserRepo) FindActiveUserIDsByTeam(ctx context.Context, team string) ([]string, error) {
+	ctx, done := storage.Observe(ctx, "UserRepo", "FindActiveUserIDsByTeam")
+	defer done()
+
+	userIDs, err := queryStrings(ctx, storage.Executor(ctx, r.db),
+		"SELECT id FROM users WHERE team_name = ? AND is_active = TRUE ORDER BY id",
+		team,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FindActiveUserIDsByTeam: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+func (r *UserRepo) FindActiveUserIDsByTeamExcludeAuthor(ctx context.Context, team, excludeAuthorID string, reviewersCount int64) ([]string, error) {
+	ctx, done := storage.Observe(ctx, "UserRepo", "FindActiveUserIDsByTeamExcludeAuthor")
+	defer done()
+
+	userIDs, err := queryStrings(ctx, storage.Executor(ctx, r.db),
+		"SELECT id FROM users WHERE team_name = ? AND id != ? AND is_active = TRUE ORDER BY id LIMIT ?",
+		team,
+		excludeAuthorID,
+		reviewersCount,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FindActiveUserIDsByTeamExcludeAuthor: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+func (r *UserRepo) GetStats(ctx context.Context, limit uint64) ([]domain.UserStat, error) {
+	ctx, done := storage.Observe(ctx, "UserRepo", "GetStats")
+	defer done()
+
+	builder := sq.Select("user_id", "total_reviews", "active_reviews", "merged_reviews", "updated_at").
+		From("user_review_stats").
+		OrderBy("user_id").
+		PlaceholderFormat(sq.Question)
+	if limit > 0 {
+		builder = builder.Limit(limit)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("GetStats team builder.ToSql: %w", err)
+	}
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetStats team db.Query: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]domain.UserStat, 0, 20)
+	for rows.Next() {
+		var userStat UserStat
+		if err := rows.Scan(
+			&userStat.UserID,
+			&userStat.TotalReviews,
+			&userStat.ActiveReviews,
+			&userStat.MergedReviews,
+			&userStat.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("GetStats team rows.Next: %w", err)
+		}
+		users = append(users, userStat.toDomain())
+	}
+
+	return users, nil
+}
+
+// RebuildStats пересобирает user_review_stats из истории review_events
+// и возвращает пользователей, у которых сохранённая статистика расходилась с историей.
+func (r *UserRepo) RebuildStats(ctx context.Context) (drifts []domain.UserStatDrift, err error) {
+	ctx, done := storage.Observe(ctx, "UserRepo", "RebuildStats")
+	defer done()
+
+	tx, finish, err := storage.BeginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = finish(err) }()
+
+	stored, err := selectStoredStats(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, derivedReviewStatsQuery, nil)
+	if err != nil {
+		return nil, fmt.Errorf("RebuildStats tx.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	drifts = make([]domain.UserStatDrift, 0)
+	for rows.Next() {
+		var actual UserStat
+		if err = rows.Scan(
+			&actual.UserID,
+			&actual.TotalReviews,
+			&actual.ActiveReviews,
+			&actual.MergedReviews,
+		); err != nil {
+			return nil, fmt.Errorf("RebuildStats rows.Next: %w", err)
+		}
+
+		storedStat := stored[actual.UserID]
+		if !storedStat.CountersEqual(actual.toDomain()) {
+			r.logger.WarnContext(ctx, "review stats drift",
+				slog.String("user_id", actual.UserID),
+				slog.Group("stored",
+					slog.Int64("total_reviews", storedStat.TotalReviews),
+					slog.Int64("active_reviews", storedStat.ActiveReviews),
+					slog.Int64("merged_reviews", storedStat.MergedReviews),
+				),
+				slog.Group("actual",
+					slog.Int64("total_reviews", actual.TotalReviews),
+					slog.Int64("active_reviews", actual.ActiveReviews),
+					slog.Int64("merged_reviews", actual.MergedReviews),
+				),
+			)
+			drifts = append(drifts, domain.UserStatDrift{
+				UserID: actual.UserID,
+				Stored: storedStat,
+				Actual: actual.toDomain(),
+			})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("RebuildStats rows.Err: %w", err)
+	}
+	rows.Close()
+
+	err = refreshReviewStats(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("refreshReviewStats: %w", err)
+	}
+
+	return drifts, nil
+}
+
+// selectStoredStats читает сохранённую статистику. FOR UPDATE не нужен:
+// транзакция SQLite с _txlock=immediate уже держит блокировку на запись.
+func selectStoredStats(ctx context.Context, tx storage.Tx) (map[string]domain.UserStat, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT user_id, total_reviews, active_reviews, merged_reviews, updated_at
+		FROM user_review_stats`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("selectStoredStats tx.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[string]domain.UserStat)
+	for rows.Next() {
+		var userStat UserStat
+		if err := rows.Scan(
+			&userStat.UserID,
+			&userStat.TotalReviews,
+			&userStat.ActiveReviews,
+			&userStat.MergedReviews,
+			&userStat.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("selectStoredStats rows.Next: %w", err)
+		}
+		stats[userStat.UserID] = userStat.toDomain()
+	}
+
+	return stats, rows.Err()
+}
+
+func scanUsers(rows *sql.Rows) ([]domain.User, error) {
+	defer rows.Close()
+
+	users := make([]domain.User, 0, 20)
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(
+			&user.id,
+			&user.name,
+			&user.teamName,
+			&user.isActive,
+			&user.role,
+		); err != nil {
+			return nil, fmt.Errorf("rows.Next: %w", err)
+		}
+		users = append(users, user.toDomain())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return users, nil
+}
+
+// queryStrings выполняет запрос, возвращающий одну текстовую колонку.
+func queryStrings(ctx context.Context, q storage.Querier, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	values := make([]string, 0, 15)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("rows.Next: %w", err)
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,299 @@
+package sqlite
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// WebhookRepo хранит подписки на события, их доставки и попытки доставок.
+// Время хранится текстом в UTC, поэтому все метки времени переводятся в UTC.
+type WebhookRepo struct {
+	db     storage.DB
+	logger *slog.Logger
+}
+
+func NewWebhookRepo(db storage.DB, logger *slog.Logger) *WebhookRepo {
+	return &WebhookRepo{db: db, logger: logger}
+}
+
+func (r *WebhookRepo) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) error {
+	ctx, done := storage.Observe(ctx, "WebhookRepo", "CreateSubscription")
+	defer done()
+
+	_, err := storage.Executor(ctx, r.db).ExecContext(ctx,
+		"INSERT INTO webhook_subscriptions ("+storage.WebhookColumns+") VALUES (?, ?, ?, ?, ?)",
+		subscription.ID,
+		subscription.URL,
+		stringArray(domain.EventTypesToStrings(subscription.Events)),
+		subscription.Secret,
+		subscription.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("CreateSubscription db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepo) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	ctx, done := storage.Observe(ctx, "WebhookRepo", "ListSubscriptions")
+	defer done()
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx,
+		"SELECT "+storage.WebhookColumns+" FROM webhook_subscriptions ORDER BY created_at, id",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListSubscriptions db.Query: %w", err)
+	}
+	subscriptions, err := scanWebhooks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("ListSubscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription удаляет подписку; доставки и попытки удаляются внешними ключами ON DELETE CASCADE.
+func (r *WebhookRepo) DeleteSubscription(ctx context.Context, subscriptionID string) error {
+	ctx, done := storage.Observe(ctx, "WebhookRepo", "DeleteSubscription")
+	defer done()
+
+	res, err := storage.Executor(ctx, r.db).ExecContext(ctx,
+		"DELETE FROM webhook_subscriptions WHERE id = ?",
+		subscriptionID,
+	)
+	if err != nil {
+		return fmt.Errorf("DeleteSubscription db.Exec: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("DeleteSubscription RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return domain.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// Enqueue сохраняет доставки. Доставка события подписчику, которая уже есть, не создаётся повторно:
+// relay outbox может опубликовать событие ещё раз.
+func (r *WebhookRepo) Enqueue(ctx context.Context, deliveries ...domain.WebhookDelivery) (err error) {
+	ctx, done := storage.Observe(ctx, "WebhookRepo", "Enqueue")
+	defer done()
+
+	tx, finish, err := storage.BeginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("Enqueue db.BeginTx: %w", err)
+	}
+	defer func() { err = finish(err) }()
+
+	for _, d := range deliveries {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (subscription_id, event_id) DO NOTHING`,
+			d.SubscriptionID, d.EventID, d.EventType, string(d.Payload), d.Status, d.Attempts, d.NextAttemptAt.UTC(), d.CreatedAt.UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("Enqueue tx.Exec: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Claim забирает до limit доставок, время попытки которых наступило к now, и откладывает их до until.
+// SQLite допускает одного писателя, поэтому отбор и обновление не пересекаются с другими Claim.
+func (r *WebhookRepo) Claim(ctx context.Context, now, until time.Time, limit uint64) ([]domain.WebhookDelivery, error) {
+	ctx, done := storage.Observe(ctx, "WebhookRepo", "Claim")
+	defer done()
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx,
+		`UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+		)
+		RETURNING `+storage.DeliveryColumns,
+		until.UTC(), domain.WebhookPending, now.UTC(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Claim db.Query: %w", err)
+	}
+	deliveries, err := storage.ScanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("Claim: %w", err)
+	}
+	slices.SortFunc(deliveries, func(a, b domain.WebhookDelivery) int { return cmp.Compare(a.ID, b.ID) })
+
+	return deliveries, nil
+}
+
+// RecordAttempt сохраняет попытку и новое состояние доставки. Попытка доставки удалённой подписки не сохраняется.
+func (r *WebhookRepo) RecordAttempt(ctx context.Context, delivery domain.WebhookDelivery, attempt domain.WebhookAttempt) (err error) {
+	ctx, done := storage.Observe(ctx, "WebhookRepo", "RecordAttempt")
+	defer done()
+
+	tx, finish, err := storage.BeginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("RecordAttempt db.BeginTx: %w", err)
+	}
+	defer func() { err = finish(err) }()
+
+	var deliveredAt sql.NullTime
+	if delivery.DeliveredAt != nil {
+		deliveredAt = sql.NullTime{Time: delivery.DeliveredAt.UTC(), Valid: true}
+	}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, delivered_at = ?
+		WHERE id = ?`,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt.UTC(), sql.NullString{String: delivery.LastError, Valid: delivery.LastError != ""}, deliveredAt, delivery.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("RecordAttempt tx.Exec: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("RecordAttempt RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO webhook_attempts (delivery_id, attempted_at, status_code, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?)`,
+		delivery.ID, attempt.AttemptedAt.UTC(), attempt.StatusCode, sql.NullString{String: attempt.Error, Valid: attempt.Error != ""}, attempt.Duration.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("RecordAttempt tx.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, q domain.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error) {
+	ctx, done := storage.Observe(ctx, "WebhookRepo", "ListDeliveries")
+	defer done()
+
+	query, args, err := storage.WebhookDeliveriesSelect(q).PlaceholderFormat(sq.Question).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("ListDeliveries builder.ToSql: %w", err)
+	}
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ListDeliveries db.Query: %w", err)
+	}
+	deliveries, err := storage.ScanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("ListDeliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepo) FindDelivery(ctx context.Context, deliveryID int64) (domain.WebhookDelivery, error) {
+	ctx, done := storage.Observe(ctx, "WebhookRepo", "FindDelivery")
+	defer done()
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx,
+		"SELECT "+storage.DeliveryColumns+" FROM webhook_deliveries WHERE id = ?",
+		deliveryID,
+	)
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("FindDelivery db.Query: %w", err)
+	}
+	deliveries, err := storage.ScanWebhookDeliveries(rows)
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("FindDelivery: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return domain.WebhookDelivery{}, domain.ErrWebhookDeliveryNotFound
+	}
+
+	return deliveries[0], nil
+}
+
+// ListAttempts возвращает попытки доставки в порядке выполнения.
+func (r *WebhookRepo) ListAttempts(ctx context.Context, deliveryID int64) ([]domain.WebhookAttempt, error) {
+	ctx, done := storage.Observe(ctx, "WebhookRepo", "ListAttempts")
+	defer done()
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx,
+		`SELECT id, delivery_id, attempted_at, status_code, error, duration_ms
+		FROM webhook_attempts WHERE delivery_id = ? ORDER BY id`,
+		deliveryID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListAttempts db.Query: %w", err)
+	}
+	attempts, err := storage.ScanWebhookAttempts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("ListAttempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// ReplayDelivery возвращает доставку из dead letter в очередь с новым счётчиком попыток.
+func (r *WebhookRepo) ReplayDelivery(ctx context.Context, deliveryID int64, at time.Time) (domain.WebhookDelivery, error) {
+	ctx, done := storage.Observe(ctx, "WebhookRepo", "ReplayDelivery")
+	defer done()
+
+	rows, err := storage.Executor(ctx, r.db).QueryContext(ctx,
+		`UPDATE webhook_deliveries SET status = ?, attempts = 0, next_attempt_at = ?
+		WHERE id = ? AND status = ?
+		RETURNING `+storage.DeliveryColumns,
+		domain.WebhookPending, at.UTC(), deliveryID, domain.WebhookDead,
+	)
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("ReplayDelivery db.Query: %w", err)
+	}
+	deliveries, err := storage.ScanWebhookDeliveries(rows)
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("ReplayDelivery: %w", err)
+	}
+	if len(deliveries) == 0 {
+		if _, err = r.FindDelivery(ctx, deliveryID); err != nil {
+			return domain.WebhookDelivery{}, err
+		}
+		return domain.WebhookDelivery{}, domain.ErrWebhookDeliveryNotFailed
+	}
+
+	return deliveries[0], nil
+}
+
+func scanWebhooks(rows *sql.Rows) ([]domain.WebhookSubscription, error) {
+	defer rows.Close()
+
+	subscriptions := make([]domain.WebhookSubscription, 0)
+	for rows.Next() {
+		var (
+			subscription domain.WebhookSubscription
+			events       stringArray
+		)
+		if err := rows.Scan(&subscription.ID, &subscription.URL, &events, &subscription.Secret, &subscription.CreatedAt); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		subscription.Events = domain.EventTypesFromStrings(events)
+		subscriptions = append(subscriptions, subscription)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return subscriptions, nil
+}
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type GroupStat struct {
+	Key               string  `db:"key"`
+	Assigned          int64   `db:"assigned"`
+	Merged            int64   `db:"merged"`
+	Reassigned        int64   `db:"reassigned"`
+	MedianTimeToMerge float64 `db:"median_time_to_merge"`
+}
+
+// groupStatsCTE агрегирует review_events за период [$from, $to) по ключу группировки.
+// Команда берётся из события, а не из текущего состава: история ревьюера остаётся за прежней командой.
+// В reassigned входят только переназначения, снятия при деактивации не считаются.
+// Время до мержа считается от последнего назначения ревьюера на PR до события MERGED. Одобрения не учитываются.
+const groupStatsCTE = `WITH events AS (
+		SELECT e.pull_request_id, e.user_id, e.type, e.reason, e.created_at, e.team_name
+		FROM review_events e
+		WHERE e.created_at >= ? AND e.created_at < ? AND e.type <> 'APPROVED'
+	),
+	merge_times AS (
+		SELECT m.user_id, m.team_name,
+			EXTRACT(EPOCH FROM m.created_at - a.assigned_at)::float8 AS seconds
+		FROM events m
+		JOIN LATERAL (
+			SELECT MAX(ra.created_at) AS assigned_at
+			FROM review_events ra
+			WHERE ra.pull_request_id = m.pull_request_id
+			  AND ra.user_id = m.user_id
+			  AND ra.type = 'ASSIGNED'
+			  AND ra.created_at <= m.created_at
+		) a ON a.assigned_at IS NOT NULL
+		WHERE m.type = 'MERGED'
+	),
+	counters AS (
+		SELECT %[1]s AS key,
+			COUNT(*) FILTER (WHERE type = 'ASSIGNED') AS assigned,
+			COUNT(*) FILTER (WHERE type = 'MERGED') AS merged,
+			COUNT(*) FILTER (WHERE type = 'UNASSIGNED' AND reason = 'REASSIGNED') AS reassigned
+		FROM events
+		GROUP BY %[1]s
+	),
+	medians AS (
+		SELECT %[1]s AS key,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY seconds) AS median_time_to_merge
+		FROM merge_times
+		GROUP BY %[1]s
+	),
+	stats AS (
+		SELECT c.key, c.assigned, c.merged, c.reassigned,
+			COALESCE(m.median_time_to_merge, 0) AS median_time_to_merge
+		FROM counters c
+		LEFT JOIN medians m ON m.key = c.key
+	)`
+
+func (s GroupStat) toDomain() domain.GroupStat {
+	return domain.GroupStat{
+		Key:               s.Key,
+		ReviewsAssigned:   s.Assigned,
+		ReviewsMerged:     s.Merged,
+		ReassignedAway:    s.Reassigned,
+		MedianTimeToMerge: time.Duration(s.MedianTimeToMerge * float64(time.Second)),
+	}
+}
+
+var (
+	statsGroupColumns = map[domain.StatsGroupBy]string{
+		domain.StatsGroupByUser: "user_id",
+		domain.StatsGroupByTeam: "team_name",
+	}
+	statsSortColumns = map[domain.StatsSortBy]string{
+		domain.StatsSortByKey:               "key",
+		domain.StatsSortByAssigned:          "assigned",
+		domain.StatsSortByMerged:            "merged",
+		domain.StatsSortByReassigned:        "reassigned",
+		domain.StatsSortByMedianTimeToMerge: "median_time_to_merge",
+	}
+)
+
+// QueryStats возвращает статистику за период, отсортированную по q.SortBy и ключу группировки.
+// Выбирается на одну строку больше q.Limit, чтобы понять, есть ли следующая страница.
+func (r *UserRepo) QueryStats(ctx context.Context, q domain.StatsQuery) ([]domain.GroupStat, error) {
+	ctx, done := Observe(ctx, "UserRepo", "QueryStats")
+	defer done()
+
+	groupColumn, ok := statsGroupColumns[q.GroupBy]
+	if !ok {
+		return nil, domain.ErrInvalidStatsQuery
+	}
+	sortColumn, ok := statsSortColumns[q.SortBy]
+	if !ok {
+		return nil, domain.ErrInvalidStatsQuery
+	}
+
+	direction, cmp := "ASC", ">"
+	if q.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	builder := sq.Select("key", "assigned", "merged", "reassigned", "median_time_to_merge").
+		Prefix(fmt.Sprintf(groupStatsCTE, groupColumn), q.From, q.To).
+		From("stats").
+		OrderBy(sortColumn+" "+direction, "key "+direction).
+		Limit(q.Limit + 1).
+		PlaceholderFormat(sq.Dollar)
+
+	if q.Cursor != nil {
+		if q.SortBy == domain.StatsSortByKey {
+			builder = builder.Where(sq.Expr("key "+cmp+" ?", q.Cursor.Key))
+		} else {
+			builder = builder.Where(sq.Expr("("+sortColumn+", key) "+cmp+" (?::float8, ?)", q.Cursor.SortValue, q.Cursor.Key))
+		}
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("QueryStats builder.ToSql: %w", err)
+	}
+
+	rows, err := ReplicaExecutor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("QueryStats db.Query: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]domain.GroupStat, 0, q.Limit+1)
+	for rows.Next() {
+		var stat GroupStat
+		if err := rows.Scan(
+			&stat.Key,
+			&stat.Assigned,
+			&stat.Merged,
+			&stat.Reassigned,
+			&stat.MedianTimeToMerge,
+		); err != nil {
+			return nil, fmt.Errorf("QueryStats rows.Next: %w", err)
+		}
+		stats = append(stats, stat.toDomain())
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("QueryStats rows.Err: %w", err)
+	}
+
+	return stats, nil
+}
+
+// QueryWorkload возвращает число назначений за период для активных участников команд
+// и для тех, кого назначали в этот период. Результат отсортирован по команде.
+func (r *UserRepo) QueryWorkload(ctx context.Context, q domain.FairnessQuery) ([]domain.MemberWorkload, error) {
+	ctx, done := Observe(ctx, "UserRepo", "QueryWorkload")
+	defer done()
+
+	rows, err := ReplicaExecutor(ctx, r.db).QueryContext(ctx,
+		`SELECT u.id, u.team_name, COUNT(e.id)
+		FROM users u
+		LEFT JOIN review_events e
+			ON e.user_id = u.id
+			AND e.type = 'ASSIGNED'
+			AND e.created_at >= $1
+			AND e.created_at < $2
+		WHERE $3 = '' OR u.team_name = $3
+		GROUP BY u.id, u.team_name, u.is_active
+		HAVING u.is_active OR COUNT(e.id) > 0
+		ORDER BY u.team_name, u.id`,
+		q.From,
+		q.To,
+		q.TeamName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("QueryWorkload db.Query: %w", err)
+	}
+	defer rows.Close()
+
+	workloads := make([]domain.MemberWorkload, 0, 20)
+	for rows.Next() {
+		var workload domain.MemberWorkload
+		if err := rows.Scan(
+			&workload.UserID,
+			&workload.TeamName,
+			&workload.Assigned,
+		); err != nil {
+			return nil, fmt.Errorf("QueryWorkload rows.Next: %w", err)
+		}
+		workloads = append(workloads, workload)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("QueryWorkload rows.Err: %w", err)
+	}
+
+	return workloads, nil
+}
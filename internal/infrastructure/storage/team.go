@@ -3,23 +3,25 @@ package storage
 import (
 	"avito-tech-go-task/internal/domain"
 	"context"
-	"database/sql"
 	"fmt"
+	"log/slog"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 )
 
 type TeamRepo struct {
-	db DB
+	db     DB
+	logger *slog.Logger
 }
 
 type Team struct {
 	name string `db:"name"`
 }
 
-func NewTeamRepo(db DB) *TeamRepo {
-	return &TeamRepo{db: db}
+func NewTeamRepo(db DB, logger *slog.Logger) *TeamRepo {
+	return &TeamRepo{db: db, logger: logger}
 }
 
 func (t Team) toDomain() domain.Team {
@@ -27,44 +29,38 @@ func (t Team) toDomain() domain.Team {
 }
 
 func (r *TeamRepo) Save(ctx context.Context, team domain.Team, teamMembers []domain.User) (err error) {
-	tx, err := r.db.Begin(ctx)
+	ctx, done := Observe(ctx, "TeamRepo", "Save")
+	defer done()
+
+	tx, finish, err := BeginTx(ctx, r.db)
 	if err != nil {
-		return fmt.Errorf("db.Begin: %w", err)
+		return err
 	}
-	defer func() {
-		if err == nil {
-			err = tx.Commit()
-			if err != nil {
-				err = fmt.Errorf("tx.Commit: %w", err)
-			}
-		}
-		if err != nil {
-			rbErr := tx.Rollback()
-			if rbErr != nil {
-				err = fmt.Errorf("%w tx.Rollback: %s", err, rbErr)
-			}
-		}
-	}()
+	defer func() { err = finish(err) }()
 
 	_, err = tx.ExecContext(
 		ctx,
-		"INSERT INTO teams (name) VALUES ($1) ON CONFLICT (name) DO NOTHING",
+		"INSERT INTO teams (name, updated_by) VALUES ($1, $2) ON CONFLICT (name) DO UPDATE SET updated_by = EXCLUDED.updated_by",
 		team.Name,
+		UpdatedBy(ctx),
 	)
 	if err != nil {
 		return fmt.Errorf("save team tx.ExecContext: %w", err)
 	}
 
+	// При переходе в другую команду роль team_lead не переносится: лид остаётся лидом только своей команды
 	builder := sq.Insert("users").
-		Columns("id", "name", "team_name", "is_active").
+		Columns("id", "name", "team_name", "is_active", "updated_by").
 		PlaceholderFormat(sq.Dollar).
 		Suffix(`ON CONFLICT (id) DO UPDATE SET 
             name = EXCLUDED.name,
+            role = CASE WHEN users.team_name = EXCLUDED.team_name OR users.role = 'admin' THEN users.role ELSE 'member' END,
             team_name = EXCLUDED.team_name,
-            is_active = EXCLUDED.is_active`)
+            is_active = EXCLUDED.is_active,
+            updated_by = EXCLUDED.updated_by`)
 
 	for _, member := range teamMembers {
-		builder = builder.Values(member.ID, member.Name, team.Name, member.IsActive)
+		builder = builder.Values(member.ID, member.Name, team.Name, member.IsActive, UpdatedBy(ctx))
 	}
 	query, args, err := builder.ToSql()
 	if err != nil {
@@ -83,7 +79,7 @@ func (r *TeamRepo) Save(ctx context.Context, team domain.Team, teamMembers []dom
 	return nil
 }
 
-func createReviewStats(ctx context.Context, tx *sql.Tx, users []domain.User) error {
+func createReviewStats(ctx context.Context, tx Tx, users []domain.User) error {
 	builder := sq.Insert("user_review_stats").
 		Columns("user_id", "updated_at").
 		PlaceholderFormat(sq.Dollar).
@@ -105,7 +101,10 @@ func createReviewStats(ctx context.Context, tx *sql.Tx, users []domain.User) err
 }
 
 func (r *TeamRepo) FindByName(ctx context.Context, teamName string) ([]domain.User, error) {
-	builder := sq.Select("id", "name", "team_name", "is_active").
+	ctx, done := Observe(ctx, "TeamRepo", "FindByName")
+	defer done()
+
+	builder := sq.Select("id", "name", "team_name", "is_active", "role").
 		From("users").
 		Where(sq.Eq{"team_name": teamName}).
 		PlaceholderFormat(sq.Dollar)
@@ -115,7 +114,7 @@ func (r *TeamRepo) FindByName(ctx context.Context, teamName string) ([]domain.Us
 		return nil, fmt.Errorf("FindByName team builder.ToSql: %w", err)
 	}
 
-	rows, err := r.db.Query(ctx, query, args...)
+	rows, err := ReplicaExecutor(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("FindByName team db.Query: %w", err)
 	}
@@ -129,6 +128,7 @@ func (r *TeamRepo) FindByName(ctx context.Context, teamName string) ([]domain.Us
 			&user.name,
 			&user.teamName,
 			&user.isActive,
+			&user.role,
 		); err != nil {
 			return nil, fmt.Errorf("FindByName team rows.Next: %w", err)
 		}
@@ -138,11 +138,26 @@ func (r *TeamRepo) FindByName(ctx context.Context, teamName string) ([]domain.Us
 	return users, nil
 }
 
-func (r *TeamRepo) DeactivateTeam(ctx context.Context, teamName string) ([]domain.PullRequest, error) {
-	rows, err := r.db.Query(ctx,
+func (r *TeamRepo) DeactivateTeam(ctx context.Context, teamName string) (prs []domain.PullRequest, err error) {
+	ctx, done := Observe(ctx, "TeamRepo", "DeactivateTeam")
+	defer done()
+
+	tx, finish, err := BeginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = finish(err) }()
+
+	// активные участники блокируются до деактивации: запрос ниже деактивирует ровно их
+	deactivated, err := selectActiveTeamMembers(ctx, tx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx,
 		`WITH updated_users AS (
 			UPDATE users
-			SET is_active = FALSE
+			SET is_active = FALSE, updated_by = $2
 			WHERE team_name = $1
 			  AND is_active = TRUE
 			RETURNING id
@@ -166,12 +181,13 @@ func (r *TeamRepo) DeactivateTeam(ctx context.Context, teamName string) ([]domai
 			LIMIT 1
 		)
 		UPDATE pull_requests pr
-		SET reviewers_ids = ARRAY(SELECT id FROM new_reviewers)
+		SET reviewers_ids = ARRAY(SELECT id FROM new_reviewers), version = pr.version + 1, updated_by = $2
 		FROM open_prs op
 		WHERE pr.id = op.id
 		  AND EXISTS (SELECT 1 FROM new_reviewers)
-		RETURNING pr.id, pr.name, pr.author_id, pr.status, pr.reviewers_ids, pr.merged_at;`,
+		RETURNING pr.id, pr.name, pr.author_id, pr.status, pr.reviewers_ids, pr.merged_at, pr.created_at, pr.version, op.reviewers_ids;`,
 		teamName,
+		UpdatedBy(ctx),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("deactivate team db.Exec: %w", err)
@@ -179,22 +195,109 @@ func (r *TeamRepo) DeactivateTeam(ctx context.Context, teamName string) ([]domai
 
 	defer rows.Close()
 
-	prs := make([]domain.PullRequest, 0, 20)
+	prs = make([]domain.PullRequest, 0, 20)
+	events := make([]domain.ReviewEvent, 0, 20)
 	for rows.Next() {
 		var pullRequest PullRequest
-		if err := rows.Scan(
+		var oldReviewersIDs pq.StringArray
+		if err = rows.Scan(
 			&pullRequest.id,
 			&pullRequest.name,
 			&pullRequest.authorID,
 			&pullRequest.status,
 			&pullRequest.reviewersIDs,
 			&pullRequest.mergedAt,
+			&pullRequest.createdAt,
+			&pullRequest.version,
+			&oldReviewersIDs,
 		); err != nil {
 			return nil, fmt.Errorf("DeactivateTeam rows.Next: %w", err)
 		}
 
 		prs = append(prs, pullRequest.toDomain())
+		events = append(events, domain.ReviewersChangeEvents(pullRequest.id, oldReviewersIDs, pullRequest.reviewersIDs, domain.ReviewReasonTeamDeactivated)...)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("DeactivateTeam rows.Err: %w", err)
+	}
+	rows.Close()
+
+	err = recordReviewEvents(ctx, tx, events...)
+	if err != nil {
+		return nil, fmt.Errorf("recordReviewEvents: %w", err)
+	}
+
+	err = appendOutbox(ctx, tx, append(domain.NewTeamDeactivatedEvents(teamName, deactivated), domain.NewReviewerEvents(events...)...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.InfoContext(ctx, "team deactivated",
+		slog.String("team_name", teamName),
+		slog.Int("reassigned_pull_requests", len(prs)),
+	)
 
 	return prs, nil
 }
+
+func selectActiveTeamMembers(ctx context.Context, tx Tx, teamName string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id FROM users WHERE team_name = $1 AND is_active = TRUE ORDER BY id FOR UPDATE",
+		teamName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("select active team members tx.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0, 20)
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("select active team members rows.Next: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("select active team members rows.Err: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *TeamRepo) GetTeamLoad(ctx context.Context) ([]domain.TeamLoad, error) {
+	ctx, done := Observe(ctx, "TeamRepo", "GetTeamLoad")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx,
+		`SELECT t.name,
+			(SELECT COUNT(*)
+			 FROM pull_requests pr
+			 JOIN users a ON a.id = pr.author_id
+			 WHERE a.team_name = t.name AND pr.status = 'OPEN'::pr_status),
+			(SELECT COUNT(*)
+			 FROM users u
+			 WHERE u.team_name = t.name AND u.is_active = TRUE)
+		FROM teams t
+		ORDER BY t.name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetTeamLoad db.Query: %w", err)
+	}
+	defer rows.Close()
+
+	teams := make([]domain.TeamLoad, 0, 20)
+	for rows.Next() {
+		var team domain.TeamLoad
+		if err := rows.Scan(
+			&team.TeamName,
+			&team.OpenPullRequests,
+			&team.ActiveReviewers,
+		); err != nil {
+			return nil, fmt.Errorf("GetTeamLoad rows.Next: %w", err)
+		}
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type TokenRepo struct {
+	db     DB
+	logger *slog.Logger
+}
+
+const tokenColumns = "id, name, token_hash, scopes, user_id, created_at, revoked_at"
+
+func NewTokenRepo(db DB, logger *slog.Logger) *TokenRepo {
+	return &TokenRepo{db: db, logger: logger}
+}
+
+// Save создаёт токен или обновляет имя, хеш, scopes и пользователя токена с тем же ID. Отзыв токена при этом сохраняется.
+func (r *TokenRepo) Save(ctx context.Context, token domain.APIToken) error {
+	ctx, done := Observe(ctx, "TokenRepo", "Save")
+	defer done()
+
+	_, err := Executor(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO api_tokens (id, name, token_hash, scopes, user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			token_hash = EXCLUDED.token_hash,
+			scopes = EXCLUDED.scopes,
+			user_id = EXCLUDED.user_id`,
+		token.ID,
+		token.Name,
+		token.Hash,
+		pq.Array(domain.ScopesToStrings(token.Scopes)),
+		sql.NullString{String: token.UserID, Valid: token.UserID != ""},
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Save db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepo) FindByHash(ctx context.Context, hash string) (domain.APIToken, error) {
+	ctx, done := Observe(ctx, "TokenRepo", "FindByHash")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx,
+		"SELECT "+tokenColumns+" FROM api_tokens WHERE token_hash = $1",
+		hash,
+	)
+	if err != nil {
+		return domain.APIToken{}, fmt.Errorf("FindByHash db.Query: %w", err)
+	}
+	tokens, err := scanTokens(rows)
+	if err != nil {
+		return domain.APIToken{}, fmt.Errorf("FindByHash: %w", err)
+	}
+	if len(tokens) == 0 {
+		return domain.APIToken{}, domain.ErrTokenNotFound
+	}
+
+	return tokens[0], nil
+}
+
+func (r *TokenRepo) List(ctx context.Context) ([]domain.APIToken, error) {
+	ctx, done := Observe(ctx, "TokenRepo", "List")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx,
+		"SELECT "+tokenColumns+" FROM api_tokens ORDER BY created_at, id",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("List db.Query: %w", err)
+	}
+	tokens, err := scanTokens(rows)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Revoke отзывает токен. Повторный отзыв не меняет время первого.
+func (r *TokenRepo) Revoke(ctx context.Context, tokenID string, revokedAt time.Time) error {
+	ctx, done := Observe(ctx, "TokenRepo", "Revoke")
+	defer done()
+
+	res, err := Executor(ctx, r.db).ExecContext(ctx,
+		"UPDATE api_tokens SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1",
+		tokenID,
+		revokedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("Revoke db.Exec: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Revoke RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return domain.ErrTokenNotFound
+	}
+
+	return nil
+}
+
+func scanTokens(rows *sql.Rows) ([]domain.APIToken, error) {
+	defer rows.Close()
+
+	var tokens []domain.APIToken
+	for rows.Next() {
+		var (
+			token     domain.APIToken
+			scopes    []string
+			userID    sql.NullString
+			revokedAt sql.NullTime
+		)
+		if err := rows.Scan(&token.ID, &token.Name, &token.Hash, pq.Array(&scopes), &userID, &token.CreatedAt, &revokedAt); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		token.Scopes = domain.ScopesFromStrings(scopes)
+		token.UserID = userID.String
+		if revokedAt.Valid {
+			token.RevokedAt = &revokedAt.Time
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "avito-tech-go-task/internal/infrastructure/storage"
+
+type queryNameKey struct{}
+
+// TracedDB создаёт span на каждый SQL запрос, в том числе выполненный внутри транзакции.
+// Имя span - имя метода репозитория из контекста и тип запроса, например "PRRepo.CreatePR INSERT".
+type TracedDB struct {
+	client SQLClient
+	tracer trace.Tracer
+}
+
+type tracedTx struct {
+	tx     *sql.Tx
+	tracer trace.Tracer
+}
+
+func NewTracedDB(client SQLClient) *TracedDB {
+	return &TracedDB{
+		client: client,
+		tracer: otel.Tracer(tracerName),
+	}
+}
+
+func withQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+// QueryName возвращает имя метода репозитория, выполняющего запрос.
+func QueryName(ctx context.Context) string {
+	name, _ := ctx.Value(queryNameKey{}).(string)
+	if name == "" {
+		return "unnamed"
+	}
+	return name
+}
+
+func (db *TracedDB) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, db.tracer, query)
+	defer span.End()
+
+	res, err := db.client.Exec(ctx, query, args...)
+	recordError(span, err)
+	return res, err
+}
+
+func (db *TracedDB) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, db.tracer, query)
+	defer span.End()
+
+	rows, err := db.client.Query(ctx, query, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+func (db *TracedDB) Begin(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := db.client.Begin(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tracedTx{tx: tx, tracer: db.tracer}, nil
+}
+
+func (t *tracedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, t.tracer, query)
+	defer span.End()
+
+	res, err := t.tx.ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return res, err
+}
+
+func (t *tracedTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, t.tracer, query)
+	defer span.End()
+
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+func (t *tracedTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *tracedTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+func startQuerySpan(ctx context.Context, tracer trace.Tracer, query string) (context.Context, trace.Span) {
+	operation := queryOperation(query)
+
+	return tracer.Start(ctx, QueryName(ctx)+" "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.query.name", QueryName(ctx)),
+			attribute.String("db.statement", query),
+		),
+	)
+}
+
+// queryOperation возвращает первое ключевое слово запроса (SELECT, INSERT, WITH...).
+func queryOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+func recordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
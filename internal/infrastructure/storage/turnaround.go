@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Выборки (ключ группировки, секунды до первого ревью, секунды до мержа) для каждой группировки.
+// Первое ревью - первое одобрение PR, при группировке по ревьюеру - его одобрение, отсчитанное от его назначения.
+// Время до ревью есть только у одобренных PR, время до мержа - только у PR в статусе MERGED.
+var turnaroundSamples = map[domain.TurnaroundGroupBy]string{
+	domain.TurnaroundGroupByTeam: `SELECT u.team_name AS key,
+			EXTRACT(EPOCH FROM fr.first_review_at - pr.created_at)::float8 AS first_review_seconds,
+			CASE WHEN pr.status = 'MERGED' THEN EXTRACT(EPOCH FROM pr.merged_at - pr.created_at)::float8 END AS merge_seconds
+		FROM pull_requests pr
+		JOIN users u ON u.id = pr.author_id
+		LEFT JOIN LATERAL (
+			SELECT MIN(e.created_at) AS first_review_at
+			FROM review_events e
+			WHERE e.pull_request_id = pr.id AND e.type = 'APPROVED'
+		) fr ON TRUE
+		WHERE pr.created_at >= $1 AND pr.created_at < $2`,
+
+	domain.TurnaroundGroupByWeek: `SELECT to_char(date_trunc('week', pr.created_at AT TIME ZONE 'UTC'), 'YYYY-MM-DD') AS key,
+			EXTRACT(EPOCH FROM fr.first_review_at - pr.created_at)::float8 AS first_review_seconds,
+			CASE WHEN pr.status = 'MERGED' THEN EXTRACT(EPOCH FROM pr.merged_at - pr.created_at)::float8 END AS merge_seconds
+		FROM pull_requests pr
+		LEFT JOIN LATERAL (
+			SELECT MIN(e.created_at) AS first_review_at
+			FROM review_events e
+			WHERE e.pull_request_id = pr.id AND e.type = 'APPROVED'
+		) fr ON TRUE
+		WHERE pr.created_at >= $1 AND pr.created_at < $2`,
+
+	domain.TurnaroundGroupByReviewer: `SELECT a.user_id AS key,
+			EXTRACT(EPOCH FROM a.approved_at - a.assigned_at)::float8 AS first_review_seconds,
+			CASE WHEN pr.status = 'MERGED' THEN EXTRACT(EPOCH FROM pr.merged_at - pr.created_at)::float8 END AS merge_seconds
+		FROM pull_requests pr
+		JOIN (
+			SELECT pull_request_id, user_id,
+				MIN(created_at) FILTER (WHERE type = 'ASSIGNED') AS assigned_at,
+				MIN(created_at) FILTER (WHERE type = 'APPROVED') AS approved_at
+			FROM review_events
+			WHERE type IN ('ASSIGNED', 'APPROVED')
+			GROUP BY pull_request_id, user_id
+		) a ON a.pull_request_id = pr.id AND a.assigned_at IS NOT NULL
+		WHERE pr.created_at >= $1 AND pr.created_at < $2`,
+}
+
+func (r *PRRepo) QueryTurnaround(ctx context.Context, q domain.TurnaroundQuery) ([]domain.TurnaroundStat, error) {
+	ctx, done := Observe(ctx, "PRRepo", "QueryTurnaround")
+	defer done()
+
+	samples, ok := turnaroundSamples[q.GroupBy]
+	if !ok {
+		return nil, domain.ErrInvalidTurnaroundQuery
+	}
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx,
+		`WITH samples AS (`+samples+`)
+		SELECT key,
+			COUNT(*),
+			percentile_cont(ARRAY[0.5, 0.9, 0.99]) WITHIN GROUP (ORDER BY first_review_seconds),
+			COUNT(merge_seconds),
+			percentile_cont(ARRAY[0.5, 0.9, 0.99]) WITHIN GROUP (ORDER BY merge_seconds)
+		FROM samples
+		GROUP BY key
+		ORDER BY key`,
+		q.From,
+		q.To,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("QueryTurnaround db.Query: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]domain.TurnaroundStat, 0, 20)
+	for rows.Next() {
+		var (
+			stat        domain.TurnaroundStat
+			firstReview pq.Float64Array
+			merge       pq.Float64Array
+		)
+		if err := rows.Scan(
+			&stat.Key,
+			&stat.PullRequests,
+			&firstReview,
+			&stat.MergedPullRequests,
+			&merge,
+		); err != nil {
+			return nil, fmt.Errorf("QueryTurnaround rows.Next: %w", err)
+		}
+		stat.TimeToFirstReview = domain.NewPercentiles(firstReview)
+		stat.TimeToMerge = domain.NewPercentiles(merge)
+
+		stats = append(stats, stat)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("QueryTurnaround rows.Err: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const (
+	txMaxAttempts  = 3
+	txRetryBackoff = 20 * time.Millisecond
+)
+
+// retryableCodes - ошибки postgres, после которых транзакцию можно повторить целиком:
+// serialization_failure (в транзакциях SERIALIZABLE, см. DoWith) и deadlock_detected.
+var retryableCodes = map[pq.ErrorCode]struct{}{
+	"40001": {},
+	"40P01": {},
+}
+
+type txKey struct{}
+
+// Querier выполняет запросы в транзакции или через пул соединений.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// TxManager - unit of work: выполняет несколько вызовов репозиториев в одной транзакции.
+// Репозитории берут транзакцию из контекста, поэтому сервису достаточно передать им ctx из Do.
+type TxManager struct {
+	db     DB
+	logger *slog.Logger
+}
+
+func NewTxManager(db DB, logger *slog.Logger) *TxManager {
+	return &TxManager{db: db, logger: logger}
+}
+
+// Do выполняет fn в транзакции и коммитит её, если fn не вернула ошибку.
+// Вызов внутри другой Do переиспользует внешнюю транзакцию.
+// Транзакция открывается с уровнем изоляции БД по умолчанию (в postgres - READ COMMITTED):
+// от гонок защищают блокировки строк (FindByIDForUpdate), а где их недостаточно, нужен DoWith с SERIALIZABLE.
+// Если транзакцию можно повторить (см. isRetryable), fn выполняется заново в новой транзакции,
+// поэтому fn не должна иметь побочных эффектов вне БД.
+func (m *TxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.DoWith(ctx, nil, fn)
+}
+
+// DoWith работает как Do, но открывает транзакцию с opts (уровень изоляции, только чтение).
+// Вложенный вызов переиспользует внешнюю транзакцию с её параметрами.
+func (m *TxManager) DoWith(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := txFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := m.run(ctx, opts, fn)
+		if err == nil || !isRetryable(err) || attempt == txMaxAttempts {
+			return err
+		}
+
+		m.logger.WarnContext(ctx, "retry transaction",
+			slog.Int("attempt", attempt),
+			slog.Any("error", err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * txRetryBackoff):
+		}
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) (err error) {
+	tx, err := m.db.Begin(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("db.Begin: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		err = finishTx(tx, err)
+	}()
+
+	return fn(withTx(ctx, tx))
+}
+
+// BeginTx возвращает транзакцию из контекста или открывает новую.
+// finish коммитит или откатывает только транзакцию, открытую здесь же:
+//
+//	tx, finish, err := BeginTx(ctx, r.db)
+//	if err != nil {
+//		return err
+//	}
+//	defer func() { err = finish(err) }()
+func BeginTx(ctx context.Context, db DB) (Tx, func(err error) error, error) {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx, func(err error) error { return err }, nil
+	}
+
+	tx, err := db.Begin(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("db.Begin: %w", err)
+	}
+
+	return tx, func(err error) error { return finishTx(tx, err) }, nil
+}
+
+func finishTx(tx Tx, err error) error {
+	if err == nil {
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("tx.Commit: %w", err)
+		}
+		return nil
+	}
+
+	if rbErr := tx.Rollback(); rbErr != nil {
+		return fmt.Errorf("%w tx.Rollback: %s", err, rbErr)
+	}
+	return err
+}
+
+// Executor возвращает транзакцию из контекста, если репозиторий вызван внутри TxManager.Do, иначе пул.
+func Executor(ctx context.Context, db DB) Querier {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	return dbQuerier{db: db}
+}
+
+func withTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+func txFromContext(ctx context.Context) (Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(Tx)
+	return tx, ok
+}
+
+// isRetryable сообщает, что транзакция откатилась из-за конкурентной транзакции и её можно повторить:
+// в postgres - serialization failure или deadlock, в SQLite - база занята другим писателем дольше busy_timeout.
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		_, ok := retryableCodes[pqErr.Code]
+		return ok
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		// расширенные коды (например, SQLITE_BUSY_SNAPSHOT) в младшем байте содержат основной
+		code := sqliteErr.Code() & 0xff
+		return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+	}
+
+	return false
+}
+
+// dbQuerier приводит DB к интерфейсу Querier.
+type dbQuerier struct {
+	db DB
+}
+
+func (q dbQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return q.db.Exec(ctx, query, args...)
+}
+
+func (q dbQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return q.db.Query(ctx, query, args...)
+}
@@ -4,13 +4,15 @@ import (
 	"avito-tech-go-task/internal/domain"
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 )
 
 type UserRepo struct {
-	db DB
+	db     DB
+	logger *slog.Logger
 }
 
 type User struct {
@@ -18,6 +20,7 @@ type User struct {
 	name     string `db:"name"`
 	teamName string `db:"team_name"`
 	isActive bool   `db:"is_active"`
+	role     string `db:"role"`
 }
 
 type UserStat struct {
@@ -28,12 +31,14 @@ type UserStat struct {
 	UpdatedAt     time.Time `db:"updated_at"`
 }
 
-func NewUserRepo(db DB) *UserRepo {
-	return &UserRepo{db: db}
+func NewUserRepo(db DB, logger *slog.Logger) *UserRepo {
+	return &UserRepo{db: db, logger: logger}
 }
 
 func (u User) toDomain() domain.User {
-	return *domain.NewUser(u.id, u.name, u.teamName, u.isActive)
+	user := domain.NewUser(u.id, u.name, u.teamName, u.isActive)
+	user.Role = domain.Role(u.role)
+	return *user
 }
 
 func (u UserStat) toDomain() domain.UserStat {
@@ -41,31 +46,28 @@ func (u UserStat) toDomain() domain.UserStat {
 }
 
 func (r *UserRepo) SetIsActive(ctx context.Context, userID string, isActive bool) (err error) {
-	tx, err := r.db.Begin(ctx)
+	ctx, done := Observe(ctx, "UserRepo", "SetIsActive")
+	defer done()
+
+	tx, finish, err := BeginTx(ctx, r.db)
 	if err != nil {
-		return fmt.Errorf("db.Begin: %w", err)
+		return err
+	}
+	defer func() { err = finish(err) }()
+
+	// о деактивации сообщается, только если пользователь был активен
+	teamName, wasActive, err := selectActiveUserTeam(ctx, tx, userID)
+	if err != nil {
+		return err
 	}
-	defer func() {
-		if err == nil {
-			err = tx.Commit()
-			if err != nil {
-				err = fmt.Errorf("tx.Commit: %w", err)
-			}
-		}
-		if err != nil {
-			rbErr := tx.Rollback()
-			if rbErr != nil {
-				err = fmt.Errorf("%w tx.Rollback: %s", err, rbErr)
-			}
-		}
-	}()
 
 	_, err = tx.ExecContext(ctx,
 		`UPDATE users
-		SET is_active = $1
+		SET is_active = $1, updated_by = $3
 		WHERE id = $2`,
 		isActive,
 		userID,
+		UpdatedBy(ctx),
 	)
 	if err != nil {
 		return fmt.Errorf("update is_active user tx.ExecContext: %w", err)
@@ -76,23 +78,109 @@ func (r *UserRepo) SetIsActive(ctx context.Context, userID string, isActive bool
 	}
 
 	// Удаляем неактивного ревьюера со всех PR со статусом OPEN
-	_, err = tx.ExecContext(
+	rows, err := tx.QueryContext(
 		ctx,
 		`UPDATE pull_requests
-		SET reviewers_ids = array_remove(reviewers_ids, $1)
-		WHERE $1 = ANY(reviewers_ids) AND status = $2`,
+		SET reviewers_ids = array_remove(reviewers_ids, $1), version = version + 1, updated_by = $3
+		WHERE $1 = ANY(reviewers_ids) AND status = $2
+		RETURNING id`,
 		userID,
 		domain.PRStatusOpen,
+		UpdatedBy(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("remove not active reviewer tx.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]domain.ReviewEvent, 0, 10)
+	for rows.Next() {
+		var prID string
+		if err = rows.Scan(&prID); err != nil {
+			return fmt.Errorf("remove not active reviewer rows.Next: %w", err)
+		}
+		events = append(events, *domain.NewUnassignedEvent(prID, userID, domain.ReviewReasonUserDeactivated))
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("remove not active reviewer rows.Err: %w", err)
+	}
+	rows.Close()
+
+	err = recordReviewEvents(ctx, tx, events...)
+	if err != nil {
+		return fmt.Errorf("recordReviewEvents: %w", err)
+	}
+
+	outbox := domain.NewReviewerEvents(events...)
+	if wasActive {
+		outbox = append([]domain.Event{domain.NewUserDeactivatedEvent(userID, teamName)}, outbox...)
+	}
+	err = appendOutbox(ctx, tx, outbox...)
+	if err != nil {
+		return err
+	}
+
+	if len(events) > 0 {
+		r.logger.InfoContext(ctx, "inactive reviewer removed from open pull requests",
+			slog.String("user_id", userID),
+			slog.Int("pull_requests", len(events)),
+		)
+	}
+
+	return nil
+}
+
+// selectActiveUserTeam блокирует пользователя и возвращает его команду; active = false, если пользователь
+// не активен или не существует.
+func selectActiveUserTeam(ctx context.Context, tx Tx, userID string) (teamName string, active bool, err error) {
+	rows, err := tx.QueryContext(ctx, "SELECT team_name FROM users WHERE id = $1 AND is_active = TRUE FOR UPDATE", userID)
+	if err != nil {
+		return "", false, fmt.Errorf("select active user tx.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err = rows.Scan(&teamName); err != nil {
+			return "", false, fmt.Errorf("select active user rows.Next: %w", err)
+		}
+		active = true
+	}
+	if err = rows.Err(); err != nil {
+		return "", false, fmt.Errorf("select active user rows.Err: %w", err)
+	}
+
+	return teamName, active, nil
+}
+
+func (r *UserRepo) SetRole(ctx context.Context, userID string, role domain.Role) error {
+	ctx, done := Observe(ctx, "UserRepo", "SetRole")
+	defer done()
+
+	res, err := Executor(ctx, r.db).ExecContext(ctx,
+		"UPDATE users SET role = $1, updated_by = $3 WHERE id = $2",
+		role,
+		userID,
+		UpdatedBy(ctx),
 	)
 	if err != nil {
-		return fmt.Errorf("remove not active reviewer tx.ExecContext: %w", err)
+		return fmt.Errorf("SetRole db.Exec: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("SetRole RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return domain.ErrUserNotExist
 	}
 
 	return nil
 }
 
 func (r *UserRepo) FindByID(ctx context.Context, userID string) (domain.User, error) {
-	rows, err := r.db.Query(ctx, "SELECT id, name, team_name, is_active FROM users WHERE id = $1", userID)
+	ctx, done := Observe(ctx, "UserRepo", "FindByID")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx, "SELECT id, name, team_name, is_active, role FROM users WHERE id = $1", userID)
 	if err != nil {
 		return domain.User{}, fmt.Errorf("FindByID db.Query: %w", err)
 	}
@@ -106,6 +194,7 @@ func (r *UserRepo) FindByID(ctx context.Context, userID string) (domain.User, er
 			&user.name,
 			&user.teamName,
 			&user.isActive,
+			&user.role,
 		); err != nil {
 			return domain.User{}, fmt.Errorf("FindByID rows.Next: %w", err)
 		}
@@ -120,7 +209,10 @@ func (r *UserRepo) FindByID(ctx context.Context, userID string) (domain.User, er
 }
 
 func (r *UserRepo) FindTeamByUserID(ctx context.Context, userID string) (string, error) {
-	rows, err := r.db.Query(ctx, "SELECT team_name FROM users WHERE id = $1", userID)
+	ctx, done := Observe(ctx, "UserRepo", "FindTeamByUserID")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx, "SELECT team_name FROM users WHERE id = $1", userID)
 	if err != nil {
 		return "", fmt.Errorf("FindTeamByUserID db.Query: %w", err)
 	}
@@ -143,7 +235,10 @@ func (r *UserRepo) FindTeamByUserID(ctx context.Context, userID string) (string,
 }
 
 func (r *UserRepo) FindActiveUserIDsByTeam(ctx context.Context, team string) ([]string, error) {
-	rows, err := r.db.Query(ctx, "SELECT id FROM users WHERE team_name = $1", team)
+	ctx, done := Observe(ctx, "UserRepo", "FindActiveUserIDsByTeam")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx, "SELECT id FROM users WHERE team_name = $1 AND is_active = TRUE ORDER BY id", team)
 	if err != nil {
 		return nil, fmt.Errorf("FindActiveUserIDsByTeam db.Query: %w", err)
 	}
@@ -164,7 +259,10 @@ func (r *UserRepo) FindActiveUserIDsByTeam(ctx context.Context, team string) ([]
 }
 
 func (r *UserRepo) FindActiveUserIDsByTeamExcludeAuthor(ctx context.Context, team, excludeAuthorID string, reviewersCount int64) ([]string, error) {
-	rows, err := r.db.Query(ctx, "SELECT id FROM users WHERE team_name = $1 AND id != $2 LIMIT $3", team, excludeAuthorID, reviewersCount)
+	ctx, done := Observe(ctx, "UserRepo", "FindActiveUserIDsByTeamExcludeAuthor")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx, "SELECT id FROM users WHERE team_name = $1 AND id != $2 AND is_active = TRUE ORDER BY id LIMIT $3", team, excludeAuthorID, reviewersCount)
 	if err != nil {
 		return nil, fmt.Errorf("FindActiveUserIDsByTeamExcludeAuthor db.Query: %w", err)
 	}
@@ -185,17 +283,23 @@ func (r *UserRepo) FindActiveUserIDsByTeamExcludeAuthor(ctx context.Context, tea
 }
 
 func (r *UserRepo) GetStats(ctx context.Context, limit uint64) ([]domain.UserStat, error) {
+	ctx, done := Observe(ctx, "UserRepo", "GetStats")
+	defer done()
+
 	builder := sq.Select("user_id", "total_reviews", "active_reviews", "merged_reviews", "updated_at ").
 		From("user_review_stats").
-		Limit(limit).
+		OrderBy("user_id").
 		PlaceholderFormat(sq.Dollar)
+	if limit > 0 {
+		builder = builder.Limit(limit)
+	}
 
 	query, args, err := builder.ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("GetStats team builder.ToSql: %w", err)
 	}
 
-	rows, err := r.db.Query(ctx, query, args...)
+	rows, err := ReplicaExecutor(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("GetStats team db.Query: %w", err)
 	}
@@ -218,3 +322,102 @@ func (r *UserRepo) GetStats(ctx context.Context, limit uint64) ([]domain.UserSta
 
 	return users, nil
 }
+
+// RebuildStats пересобирает user_review_stats из истории review_events
+// и возвращает пользователей, у которых сохранённая статистика расходилась с историей.
+func (r *UserRepo) RebuildStats(ctx context.Context) (drifts []domain.UserStatDrift, err error) {
+	ctx, done := Observe(ctx, "UserRepo", "RebuildStats")
+	defer done()
+
+	tx, finish, err := BeginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = finish(err) }()
+
+	stored, err := selectStoredStats(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, derivedReviewStatsQuery, nil)
+	if err != nil {
+		return nil, fmt.Errorf("RebuildStats tx.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	drifts = make([]domain.UserStatDrift, 0)
+	for rows.Next() {
+		var actual UserStat
+		if err = rows.Scan(
+			&actual.UserID,
+			&actual.TotalReviews,
+			&actual.ActiveReviews,
+			&actual.MergedReviews,
+		); err != nil {
+			return nil, fmt.Errorf("RebuildStats rows.Next: %w", err)
+		}
+
+		storedStat := stored[actual.UserID]
+		if !storedStat.CountersEqual(actual.toDomain()) {
+			r.logger.WarnContext(ctx, "review stats drift",
+				slog.String("user_id", actual.UserID),
+				slog.Group("stored",
+					slog.Int64("total_reviews", storedStat.TotalReviews),
+					slog.Int64("active_reviews", storedStat.ActiveReviews),
+					slog.Int64("merged_reviews", storedStat.MergedReviews),
+				),
+				slog.Group("actual",
+					slog.Int64("total_reviews", actual.TotalReviews),
+					slog.Int64("active_reviews", actual.ActiveReviews),
+					slog.Int64("merged_reviews", actual.MergedReviews),
+				),
+			)
+			drifts = append(drifts, domain.UserStatDrift{
+				UserID: actual.UserID,
+				Stored: storedStat,
+				Actual: actual.toDomain(),
+			})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("RebuildStats rows.Err: %w", err)
+	}
+	rows.Close()
+
+	err = refreshReviewStats(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("refreshReviewStats: %w", err)
+	}
+
+	return drifts, nil
+}
+
+func selectStoredStats(ctx context.Context, tx Tx) (map[string]domain.UserStat, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT user_id, total_reviews, active_reviews, merged_reviews, updated_at
+		FROM user_review_stats
+		FOR UPDATE`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("selectStoredStats tx.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[string]domain.UserStat)
+	for rows.Next() {
+		var userStat UserStat
+		if err := rows.Scan(
+			&userStat.UserID,
+			&userStat.TotalReviews,
+			&userStat.ActiveReviews,
+			&userStat.MergedReviews,
+			&userStat.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("selectStoredStats rows.Next: %w", err)
+		}
+		stats[userStat.UserID] = userStat.toDomain()
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,383 @@
+package storage
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+)
+
+// WebhookRepo хранит подписки на события, их доставки и попытки доставок.
+type WebhookRepo struct {
+	db     DB
+	logger *slog.Logger
+}
+
+const (
+	WebhookColumns  = "id, url, events, secret, created_at"
+	DeliveryColumns = "id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at"
+)
+
+func NewWebhookRepo(db DB, logger *slog.Logger) *WebhookRepo {
+	return &WebhookRepo{db: db, logger: logger}
+}
+
+func (r *WebhookRepo) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) error {
+	ctx, done := Observe(ctx, "WebhookRepo", "CreateSubscription")
+	defer done()
+
+	_, err := Executor(ctx, r.db).ExecContext(ctx,
+		"INSERT INTO webhook_subscriptions ("+WebhookColumns+") VALUES ($1, $2, $3, $4, $5)",
+		subscription.ID,
+		subscription.URL,
+		pq.Array(domain.EventTypesToStrings(subscription.Events)),
+		subscription.Secret,
+		subscription.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("CreateSubscription db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepo) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	ctx, done := Observe(ctx, "WebhookRepo", "ListSubscriptions")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx,
+		"SELECT "+WebhookColumns+" FROM webhook_subscriptions ORDER BY created_at, id",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListSubscriptions db.Query: %w", err)
+	}
+	subscriptions, err := scanWebhooks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("ListSubscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription удаляет подписку вместе с её доставками.
+func (r *WebhookRepo) DeleteSubscription(ctx context.Context, subscriptionID string) error {
+	ctx, done := Observe(ctx, "WebhookRepo", "DeleteSubscription")
+	defer done()
+
+	res, err := Executor(ctx, r.db).ExecContext(ctx,
+		"DELETE FROM webhook_subscriptions WHERE id = $1",
+		subscriptionID,
+	)
+	if err != nil {
+		return fmt.Errorf("DeleteSubscription db.Exec: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("DeleteSubscription RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return domain.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// Enqueue сохраняет доставки. Доставка события подписчику, которая уже есть, не создаётся повторно:
+// relay outbox может опубликовать событие ещё раз.
+func (r *WebhookRepo) Enqueue(ctx context.Context, deliveries ...domain.WebhookDelivery) (err error) {
+	ctx, done := Observe(ctx, "WebhookRepo", "Enqueue")
+	defer done()
+
+	tx, finish, err := BeginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("Enqueue db.BeginTx: %w", err)
+	}
+	defer func() { err = finish(err) }()
+
+	for _, d := range deliveries {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (subscription_id, event_id) DO NOTHING`,
+			d.SubscriptionID, d.EventID, d.EventType, string(d.Payload), d.Status, d.Attempts, d.NextAttemptAt, d.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("Enqueue tx.Exec: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Claim забирает до limit доставок, время попытки которых наступило к now, и откладывает их до until,
+// чтобы другие экземпляры сервиса не отправили их одновременно. Если экземпляр упадёт, не записав попытку,
+// доставки снова станут доступны после until.
+func (r *WebhookRepo) Claim(ctx context.Context, now, until time.Time, limit uint64) ([]domain.WebhookDelivery, error) {
+	ctx, done := Observe(ctx, "WebhookRepo", "Claim")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx,
+		`UPDATE webhook_deliveries SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY next_attempt_at, id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+DeliveryColumns,
+		until, domain.WebhookPending, now, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Claim db.Query: %w", err)
+	}
+	deliveries, err := ScanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("Claim: %w", err)
+	}
+	slices.SortFunc(deliveries, func(a, b domain.WebhookDelivery) int { return cmp.Compare(a.ID, b.ID) })
+
+	return deliveries, nil
+}
+
+// RecordAttempt сохраняет попытку и новое состояние доставки. Попытка доставки удалённой подписки не сохраняется.
+func (r *WebhookRepo) RecordAttempt(ctx context.Context, delivery domain.WebhookDelivery, attempt domain.WebhookAttempt) (err error) {
+	ctx, done := Observe(ctx, "WebhookRepo", "RecordAttempt")
+	defer done()
+
+	tx, finish, err := BeginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("RecordAttempt db.BeginTx: %w", err)
+	}
+	defer func() { err = finish(err) }()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, delivered_at = $6
+		WHERE id = $1`,
+		delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, nullString(delivery.LastError), delivery.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("RecordAttempt tx.Exec: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("RecordAttempt RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO webhook_attempts (delivery_id, attempted_at, status_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5)`,
+		delivery.ID, attempt.AttemptedAt, attempt.StatusCode, nullString(attempt.Error), attempt.Duration.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("RecordAttempt tx.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// WebhookDeliveriesSelect возвращает SELECT доставок по фильтру от новых к старым.
+func WebhookDeliveriesSelect(q domain.WebhookDeliveryQuery) sq.SelectBuilder {
+	builder := sq.Select(DeliveryColumns).
+		From("webhook_deliveries").
+		OrderBy("id DESC").
+		Limit(q.Limit)
+	if q.SubscriptionID != "" {
+		builder = builder.Where(sq.Eq{"subscription_id": q.SubscriptionID})
+	}
+	if q.Status != "" {
+		builder = builder.Where(sq.Eq{"status": q.Status})
+	}
+	return builder
+}
+
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, q domain.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error) {
+	ctx, done := Observe(ctx, "WebhookRepo", "ListDeliveries")
+	defer done()
+
+	query, args, err := WebhookDeliveriesSelect(q).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("ListDeliveries builder.ToSql: %w", err)
+	}
+	rows, err := Executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ListDeliveries db.Query: %w", err)
+	}
+	deliveries, err := ScanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("ListDeliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepo) FindDelivery(ctx context.Context, deliveryID int64) (domain.WebhookDelivery, error) {
+	ctx, done := Observe(ctx, "WebhookRepo", "FindDelivery")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx,
+		"SELECT "+DeliveryColumns+" FROM webhook_deliveries WHERE id = $1",
+		deliveryID,
+	)
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("FindDelivery db.Query: %w", err)
+	}
+	deliveries, err := ScanWebhookDeliveries(rows)
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("FindDelivery: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return domain.WebhookDelivery{}, domain.ErrWebhookDeliveryNotFound
+	}
+
+	return deliveries[0], nil
+}
+
+// ListAttempts возвращает попытки доставки в порядке выполнения.
+func (r *WebhookRepo) ListAttempts(ctx context.Context, deliveryID int64) ([]domain.WebhookAttempt, error) {
+	ctx, done := Observe(ctx, "WebhookRepo", "ListAttempts")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx,
+		`SELECT id, delivery_id, attempted_at, status_code, error, duration_ms
+		FROM webhook_attempts WHERE delivery_id = $1 ORDER BY id`,
+		deliveryID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ListAttempts db.Query: %w", err)
+	}
+	attempts, err := ScanWebhookAttempts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("ListAttempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// ReplayDelivery возвращает доставку из dead letter в очередь: она отправляется при ближайшем проходе
+// с новым счётчиком попыток. История попыток сохраняется.
+func (r *WebhookRepo) ReplayDelivery(ctx context.Context, deliveryID int64, at time.Time) (domain.WebhookDelivery, error) {
+	ctx, done := Observe(ctx, "WebhookRepo", "ReplayDelivery")
+	defer done()
+
+	rows, err := Executor(ctx, r.db).QueryContext(ctx,
+		`UPDATE webhook_deliveries SET status = $2, attempts = 0, next_attempt_at = $3
+		WHERE id = $1 AND status = $4
+		RETURNING `+DeliveryColumns,
+		deliveryID, domain.WebhookPending, at, domain.WebhookDead,
+	)
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("ReplayDelivery db.Query: %w", err)
+	}
+	deliveries, err := ScanWebhookDeliveries(rows)
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("ReplayDelivery: %w", err)
+	}
+	if len(deliveries) == 0 {
+		if _, err = r.FindDelivery(ctx, deliveryID); err != nil {
+			return domain.WebhookDelivery{}, err
+		}
+		return domain.WebhookDelivery{}, domain.ErrWebhookDeliveryNotFailed
+	}
+
+	return deliveries[0], nil
+}
+
+func scanWebhooks(rows *sql.Rows) ([]domain.WebhookSubscription, error) {
+	defer rows.Close()
+
+	subscriptions := make([]domain.WebhookSubscription, 0)
+	for rows.Next() {
+		var (
+			subscription domain.WebhookSubscription
+			events       []string
+		)
+		if err := rows.Scan(&subscription.ID, &subscription.URL, pq.Array(&events), &subscription.Secret, &subscription.CreatedAt); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		subscription.Events = domain.EventTypesFromStrings(events)
+		subscriptions = append(subscriptions, subscription)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// ScanWebhookDeliveries читает строки DeliveryColumns; общий для postgres и SQLite.
+func ScanWebhookDeliveries(rows *sql.Rows) ([]domain.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for rows.Next() {
+		var (
+			delivery    domain.WebhookDelivery
+			payload     []byte
+			lastError   sql.NullString
+			deliveredAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventID,
+			&delivery.EventType,
+			&payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&lastError,
+			&delivery.CreatedAt,
+			&deliveredAt,
+		); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		delivery.Payload = payload
+		delivery.LastError = lastError.String
+		if deliveredAt.Valid {
+			delivery.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ScanWebhookAttempts читает попытки доставок; общий для postgres и SQLite.
+func ScanWebhookAttempts(rows *sql.Rows) ([]domain.WebhookAttempt, error) {
+	defer rows.Close()
+
+	attempts := make([]domain.WebhookAttempt, 0)
+	for rows.Next() {
+		var (
+			attempt    domain.WebhookAttempt
+			errMessage sql.NullString
+			durationMs int64
+		)
+		if err := rows.Scan(&attempt.ID, &attempt.DeliveryID, &attempt.AttemptedAt, &attempt.StatusCode, &errMessage, &durationMs); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		attempt.Error = errMessage.String
+		attempt.Duration = time.Duration(durationMs) * time.Millisecond
+		attempts = append(attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,203 @@
+// Package webhook доставляет доменные события подписчикам HTTP запросами с HMAC подписью.
+package webhook
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/metrics"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// responseBodyLimit - сколько байт ответа подписчика дочитывается, чтобы соединение вернулось в пул.
+const responseBodyLimit = 64 << 10
+
+// Store - подписки и очередь доставок.
+type Store interface {
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	// Enqueue сохраняет доставки; уже существующая доставка события подписке не дублируется.
+	Enqueue(ctx context.Context, deliveries ...domain.WebhookDelivery) error
+	// Claim забирает доставки, время попытки которых наступило к now, и откладывает их до until.
+	Claim(ctx context.Context, now, until time.Time, limit uint64) ([]domain.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, delivery domain.WebhookDelivery, attempt domain.WebhookAttempt) error
+}
+
+// Dispatcher отправляет доставки подписчикам. Успешной считается попытка с ответом 2xx; после неудачи
+// доставка повторяется с экспоненциальной задержкой, а после policy.MaxAttempts попыток переходит в dead.
+// Доставка at-least-once: если экземпляр упал между запросом и записью попытки, запрос будет отправлен снова.
+type Dispatcher struct {
+	store     Store
+	client    *http.Client
+	policy    domain.WebhookRetryPolicy
+	batchSize uint64
+	claimTTL  time.Duration
+	logger    *slog.Logger
+}
+
+// NewDispatcher создаёт dispatcher. timeout - время ожидания ответа подписчика; доставки забираются на два таймаута,
+// чтобы их не забрал другой экземпляр, пока идёт запрос.
+// Без allowPrivateNetworks подписчики в loopback, link-local и частных сетях недоступны (см. newClient).
+func NewDispatcher(store Store, policy domain.WebhookRetryPolicy, batchSize uint64, timeout time.Duration, allowPrivateNetworks bool, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:     store,
+		client:    newClient(timeout, allowPrivateNetworks),
+		policy:    policy,
+		batchSize: batchSize,
+		claimTTL:  2 * timeout,
+		logger:    logger,
+	}
+}
+
+// newClient создаёт клиент, который не следует редиректам: ответ 3xx - неудачная попытка.
+// URL подписки задаёт администратор, но без ограничений через него можно обращаться ко внутренним сервисам (SSRF),
+// поэтому без allowPrivateNetworks адрес проверяется при соединении - уже после DNS, так что подмена
+// записи DNS после создания подписки не помогает. По той же причине не используется HTTP_PROXY:
+// соединение с прокси проверялось бы вместо соединения с подписчиком.
+func newClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivateNetworks {
+		dialer := &net.Dialer{
+			Timeout: timeout,
+			Control: func(_, address string, _ syscall.RawConn) error {
+				addrPort, err := netip.ParseAddrPort(address)
+				if err != nil {
+					return err
+				}
+				if !isPublic(addrPort.Addr()) {
+					return fmt.Errorf("%w: %s", errPrivateAddress, addrPort.Addr())
+				}
+				return nil
+			},
+		}
+		transport.Proxy = nil
+		transport.DialContext = dialer.DialContext
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+var errPrivateAddress = errors.New("webhook address is not public")
+
+// isPublic сообщает, что адрес не loopback, не link-local (в том числе metadata облака 169.254.169.254),
+// не из частных сетей и не специальный.
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace - адреса carrier-grade NAT (RFC 6598), которые тоже не маршрутизируются из интернета.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Dispatch отправляет одну пачку доставок параллельно и возвращает число успешных.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	now := time.Now()
+	deliveries, err := d.store.Claim(ctx, now, now.Add(d.claimTTL), d.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	list, err := d.store.ListSubscriptions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list webhooks: %w", err)
+	}
+	subscriptions := make(map[string]domain.WebhookSubscription, len(list))
+	for _, subscription := range list {
+		subscriptions[subscription.ID] = subscription
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		errs      []error
+		delivered atomic.Int64
+	)
+	for _, delivery := range deliveries {
+		// подписку удалили после Claim: её доставки удалены вместе с ней
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			continue
+		}
+
+		wg.Go(func() {
+			attempt := d.send(ctx, subscription, delivery)
+			delivery.Record(attempt, d.policy)
+			metrics.ObserveWebhookAttempt(delivery.Status)
+			if delivery.Status == domain.WebhookDelivered {
+				delivered.Add(1)
+			} else {
+				d.logger.WarnContext(ctx, "webhook delivery failed",
+					slog.Int64("delivery_id", delivery.ID),
+					slog.String("webhook_id", delivery.SubscriptionID),
+					slog.Int("attempts", delivery.Attempts),
+					slog.String("status", string(delivery.Status)),
+					slog.String("error", attempt.Error),
+				)
+			}
+
+			if err := d.store.RecordAttempt(ctx, delivery, attempt); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("record webhook attempt %d: %w", delivery.ID, err))
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	return int(delivered.Load()), errors.Join(errs...)
+}
+
+// send выполняет одну попытку доставки.
+func (d *Dispatcher) send(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) (attempt domain.WebhookAttempt) {
+	start := time.Now()
+	attempt = domain.WebhookAttempt{
+		DeliveryID:  delivery.ID,
+		AttemptedAt: start.UTC(),
+	}
+	defer func() { attempt.Duration = time.Since(start) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, responseBodyLimit))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return attempt
+}
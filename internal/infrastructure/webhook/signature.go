@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Заголовки запроса доставки.
+const (
+	// SignatureHeader - подпись "sha256=<hex>": HMAC-SHA256 секретом подписки от "<timestamp>.<тело запроса>".
+	SignatureHeader = "X-Webhook-Signature"
+	// TimestampHeader - unix-время отправки в секундах; входит в подпись, чтобы подписчик мог отбросить старые запросы.
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	// DeliveryHeader - ID доставки; повторы одной доставки приходят с тем же ID.
+	DeliveryHeader = "X-Webhook-Delivery"
+
+	signaturePrefix = "sha256="
+)
+
+// Sign возвращает значение SignatureHeader для тела запроса.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись доставки на стороне подписчика.
+func Verify(secret, signature string, timestamp int64, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
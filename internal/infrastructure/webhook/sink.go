@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Sink подключает подписки к relay outbox: на каждое событие создаётся доставка каждой подписке с подходящим фильтром.
+// Сами запросы к подписчикам отправляет Dispatcher, поэтому медленный подписчик не задерживает relay.
+type Sink struct {
+	store  Store
+	logger *slog.Logger
+}
+
+func NewSink(store Store, logger *slog.Logger) *Sink {
+	return &Sink{store: store, logger: logger}
+}
+
+func (s *Sink) Name() string {
+	return "webhook"
+}
+
+func (s *Sink) Publish(ctx context.Context, event domain.Event) error {
+	subscriptions, err := s.store.ListSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("list webhooks: %w", err)
+	}
+
+	deliveries := make([]domain.WebhookDelivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		if subscription.Matches(event.Type) {
+			deliveries = append(deliveries, domain.NewWebhookDelivery(subscription, event))
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err = s.store.Enqueue(ctx, deliveries...); err != nil {
+		return fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TYPE "review_event_type" AS ENUM ('ASSIGNED', 'UNASSIGNED', 'MERGED');
+
+CREATE TABLE review_events (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    type review_event_type NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX review_events_user_id_idx ON review_events (user_id, pull_request_id);
+
+-- история для уже существующих PR восстанавливается по их текущему состоянию
+INSERT INTO review_events (pull_request_id, user_id, type)
+SELECT pr.id, r.user_id, 'ASSIGNED'
+FROM pull_requests pr, unnest(pr.reviewers_ids) AS r(user_id);
+
+INSERT INTO review_events (pull_request_id, user_id, type, created_at)
+SELECT pr.id, r.user_id, 'MERGED', COALESCE(pr.merged_at, NOW())
+FROM pull_requests pr, unnest(pr.reviewers_ids) AS r(user_id)
+WHERE pr.status = 'MERGED';
+
+-- +goose Down
+DROP TABLE IF EXISTS review_events;
+DROP TYPE IF EXISTS "review_event_type";
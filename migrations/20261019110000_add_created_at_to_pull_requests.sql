@@ -0,0 +1,12 @@
+-- +goose Up
+-- Для существующих PR время создания неизвестно: события в review_events восстановлены по их текущему состоянию
+-- и датированы временем миграции или мержа. Такие PR остаются с NULL и не попадают в аналитику времени ревью,
+-- новые PR получают время создания по умолчанию.
+ALTER TABLE pull_requests ADD COLUMN created_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE pull_requests ALTER COLUMN created_at SET DEFAULT NOW();
+
+CREATE INDEX pull_requests_created_at_idx ON pull_requests (created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS pull_requests_created_at_idx;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS created_at;
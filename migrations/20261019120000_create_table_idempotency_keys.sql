@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE idempotency_keys (
+    key           TEXT NOT NULL,
+    endpoint      TEXT NOT NULL,
+    request_hash  TEXT NOT NULL,
+    status_code   INT,
+    content_type  TEXT,
+    response_body BYTEA,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (key, endpoint)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
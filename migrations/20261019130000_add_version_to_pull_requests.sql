@@ -0,0 +1,6 @@
+-- +goose Up
+-- версия PR увеличивается при каждом изменении, обновления выполняются только при совпадении версии
+ALTER TABLE pull_requests ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS version;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE api_tokens (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    scopes     TEXT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- токен, которым в последний раз изменена строка; NULL, если аутентификация выключена
+ALTER TABLE teams ADD COLUMN updated_by TEXT;
+ALTER TABLE users ADD COLUMN updated_by TEXT;
+ALTER TABLE pull_requests ADD COLUMN updated_by TEXT;
+
+-- +goose Down
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS updated_by;
+ALTER TABLE users DROP COLUMN IF EXISTS updated_by;
+ALTER TABLE teams DROP COLUMN IF EXISTS updated_by;
+DROP TABLE IF EXISTS api_tokens;
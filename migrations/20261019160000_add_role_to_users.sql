@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'member'
+    CHECK (role IN ('admin', 'team_lead', 'member'));
+
+-- пользователь, от имени которого действует токен; NULL у сервисных токенов
+ALTER TABLE api_tokens ADD COLUMN user_id TEXT;
+
+-- +goose Down
+ALTER TABLE api_tokens DROP COLUMN IF EXISTS user_id;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- bucket'ы ограничения частоты запросов для режима RATE_LIMIT_STORE=postgres
+CREATE TABLE rate_limit_buckets (
+    key        TEXT PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS rate_limit_buckets;
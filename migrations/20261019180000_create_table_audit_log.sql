@@ -0,0 +1,35 @@
+-- +goose Up
+-- журнал аудита: запись добавляется в той же транзакции, что и изменение, и больше не меняется
+CREATE TABLE audit_log (
+    id            BIGSERIAL PRIMARY KEY,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    actor_id      TEXT,
+    actor_user_id TEXT,
+    action        TEXT NOT NULL,
+    entity_type   TEXT NOT NULL,
+    entity_id     TEXT NOT NULL,
+    before        JSONB,
+    after         JSONB,
+    request_id    TEXT
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id, id);
+CREATE INDEX audit_log_actor_id_idx ON audit_log (actor_id, id);
+CREATE INDEX audit_log_request_id_idx ON audit_log (request_id);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
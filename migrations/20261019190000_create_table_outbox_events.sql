@@ -0,0 +1,28 @@
+-- +goose Up
+-- transactional outbox: события пишутся в транзакции изменения, relay публикует их и отмечает published_at
+CREATE TABLE outbox_events (
+    id             BIGSERIAL PRIMARY KEY,
+    event_type     TEXT NOT NULL,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id   TEXT NOT NULL,
+    payload        JSONB NOT NULL,
+    occurred_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    request_id     TEXT,
+    published_at   TIMESTAMP WITH TIME ZONE,
+    attempts       INTEGER NOT NULL DEFAULT 0,
+    last_error     TEXT
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events (id) WHERE published_at IS NULL;
+CREATE INDEX outbox_events_published_at_idx ON outbox_events (published_at) WHERE published_at IS NOT NULL;
+
+-- аренда relay: публикует только экземпляр сервиса, который держит аренду
+CREATE TABLE outbox_relay_lease (
+    name       TEXT PRIMARY KEY,
+    holder     TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox_relay_lease;
+DROP TABLE IF EXISTS outbox_events;
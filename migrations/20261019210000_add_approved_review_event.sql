@@ -0,0 +1,8 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- одобрение PR ревьюером: от него считается время до первого ревью
+ALTER TYPE "review_event_type" ADD VALUE IF NOT EXISTS 'APPROVED';
+
+-- +goose Down
+-- значение из перечисления не удалить, поэтому удаляются только сами события
+DELETE FROM review_events WHERE type = 'APPROVED';
@@ -0,0 +1,12 @@
+-- +goose Up
+-- ключ действует в пределах токена: другой клиент с тем же ключом не получит чужой сохранённый ответ
+ALTER TABLE idempotency_keys ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (key, owner, endpoint);
+
+-- +goose Down
+-- без owner ключи разных токенов совпали бы, поэтому остаются только ключи без токена
+DELETE FROM idempotency_keys WHERE owner <> '';
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (key, endpoint);
+ALTER TABLE idempotency_keys DROP COLUMN owner;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- команда ревьюера на момент события: перевод пользователя в другую команду не переносит его историю
+ALTER TABLE review_events ADD COLUMN team_name VARCHAR(36) NOT NULL DEFAULT '';
+
+-- для уже записанных событий известна только текущая команда
+UPDATE review_events e SET team_name = u.team_name FROM users u WHERE u.id = e.user_id;
+
+-- +goose Down
+ALTER TABLE review_events DROP COLUMN team_name;
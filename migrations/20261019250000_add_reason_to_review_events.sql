@@ -0,0 +1,31 @@
+-- +goose Up
+-- причина снятия ревьюера: в reassigned_away статистики входят только переназначения
+ALTER TABLE review_events ADD COLUMN reason VARCHAR(32)
+    CHECK (reason IN ('REASSIGNED', 'USER_DEACTIVATED', 'TEAM_DEACTIVATED'));
+
+-- для уже записанных снятий причина восстанавливается по назначению на тот же PR в той же транзакции:
+-- переназначение берёт ревьюера из команды снятого, деактивация команды - из другой команды,
+-- деактивация пользователя никого не назначает
+UPDATE review_events e
+SET reason = CASE
+    WHEN EXISTS (
+        SELECT 1 FROM review_events a
+        WHERE a.pull_request_id = e.pull_request_id
+          AND a.type = 'ASSIGNED'
+          AND a.id > e.id
+          AND a.created_at - e.created_at < INTERVAL '1 second'
+          AND a.team_name = e.team_name
+    ) THEN 'REASSIGNED'
+    WHEN EXISTS (
+        SELECT 1 FROM review_events a
+        WHERE a.pull_request_id = e.pull_request_id
+          AND a.type = 'ASSIGNED'
+          AND a.id > e.id
+          AND a.created_at - e.created_at < INTERVAL '1 second'
+    ) THEN 'TEAM_DEACTIVATED'
+    ELSE 'USER_DEACTIVATED'
+END
+WHERE e.type = 'UNASSIGNED';
+
+-- +goose Down
+ALTER TABLE review_events DROP COLUMN reason;
@@ -0,0 +1,28 @@
+// Package migrations встраивает SQL миграции в бинарник сервиса.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqlite embed.FS
+
+// Postgres возвращает миграции postgres.
+func Postgres() fs.FS {
+	return postgres
+}
+
+// SQLite возвращает миграции SQLite.
+func SQLite() fs.FS {
+	sub, err := fs.Sub(sqlite, "sqlite")
+	if err != nil {
+		// каталог встроен при сборке, ошибки здесь быть не может
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,66 @@
+-- +goose Up
+-- Схема SQLite повторяет схему postgres из ./migrations.
+-- Массив reviewers_ids хранится как JSON массив строк, перечисления pr_status и review_event_type - через CHECK.
+CREATE TABLE users (
+    id        TEXT PRIMARY KEY,
+    name      TEXT NOT NULL,
+    team_name TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE teams (
+    name TEXT PRIMARY KEY
+);
+
+CREATE TABLE pull_requests (
+    id            TEXT PRIMARY KEY,
+    name          TEXT NOT NULL,
+    author_id     TEXT NOT NULL,
+    status        TEXT NOT NULL CHECK (status IN ('OPEN', 'MERGED')),
+    reviewers_ids TEXT NOT NULL DEFAULT '[]' CHECK (json_valid(reviewers_ids) AND json_type(reviewers_ids) = 'array'),
+    merged_at     DATETIME,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version       INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX pull_requests_created_at_idx ON pull_requests (created_at);
+
+CREATE TABLE user_review_stats (
+    user_id        TEXT PRIMARY KEY,
+    total_reviews  INTEGER NOT NULL DEFAULT 0,
+    active_reviews INTEGER NOT NULL DEFAULT 0,
+    merged_reviews INTEGER NOT NULL DEFAULT 0,
+    updated_at     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE review_events (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    pull_request_id TEXT NOT NULL,
+    user_id         TEXT NOT NULL,
+    type            TEXT NOT NULL CHECK (type IN ('ASSIGNED', 'UNASSIGNED', 'MERGED')),
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX review_events_user_id_idx ON review_events (user_id, pull_request_id);
+
+CREATE TABLE idempotency_keys (
+    key           TEXT NOT NULL,
+    endpoint      TEXT NOT NULL,
+    request_hash  TEXT NOT NULL,
+    status_code   INTEGER,
+    content_type  TEXT,
+    response_body BLOB,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at    DATETIME NOT NULL,
+    PRIMARY KEY (key, endpoint)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS review_events;
+DROP TABLE IF EXISTS user_review_stats;
+DROP TABLE IF EXISTS pull_requests;
+DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS users;
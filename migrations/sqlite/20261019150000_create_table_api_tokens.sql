@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE api_tokens (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    scopes     TEXT NOT NULL CHECK (json_valid(scopes) AND json_type(scopes) = 'array'),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at DATETIME
+);
+
+-- токен, которым в последний раз изменена строка; NULL, если аутентификация выключена
+ALTER TABLE teams ADD COLUMN updated_by TEXT;
+ALTER TABLE users ADD COLUMN updated_by TEXT;
+ALTER TABLE pull_requests ADD COLUMN updated_by TEXT;
+
+-- +goose Down
+ALTER TABLE pull_requests DROP COLUMN updated_by;
+ALTER TABLE users DROP COLUMN updated_by;
+ALTER TABLE teams DROP COLUMN updated_by;
+DROP TABLE IF EXISTS api_tokens;
@@ -0,0 +1,36 @@
+-- +goose Up
+-- журнал аудита: запись добавляется в той же транзакции, что и изменение, и больше не меняется
+CREATE TABLE audit_log (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor_id      TEXT,
+    actor_user_id TEXT,
+    action        TEXT NOT NULL,
+    entity_type   TEXT NOT NULL,
+    entity_id     TEXT NOT NULL,
+    before        TEXT CHECK (before IS NULL OR json_valid(before)),
+    after         TEXT CHECK (after IS NULL OR json_valid(after)),
+    request_id    TEXT
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id, id);
+CREATE INDEX audit_log_actor_id_idx ON audit_log (actor_id, id);
+CREATE INDEX audit_log_request_id_idx ON audit_log (request_id);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- одобрение PR ревьюером: от него считается время до первого ревью.
+-- CHECK в SQLite не изменить, поэтому таблица пересоздаётся.
+CREATE TABLE review_events_new (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    pull_request_id TEXT NOT NULL,
+    user_id         TEXT NOT NULL,
+    type            TEXT NOT NULL CHECK (type IN ('ASSIGNED', 'UNASSIGNED', 'MERGED', 'APPROVED')),
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO review_events_new (id, pull_request_id, user_id, type, created_at)
+SELECT id, pull_request_id, user_id, type, created_at FROM review_events;
+
+DROP TABLE review_events;
+ALTER TABLE review_events_new RENAME TO review_events;
+
+CREATE INDEX review_events_user_id_idx ON review_events (user_id, pull_request_id);
+
+-- +goose Down
+CREATE TABLE review_events_old (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    pull_request_id TEXT NOT NULL,
+    user_id         TEXT NOT NULL,
+    type            TEXT NOT NULL CHECK (type IN ('ASSIGNED', 'UNASSIGNED', 'MERGED')),
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO review_events_old (id, pull_request_id, user_id, type, created_at)
+SELECT id, pull_request_id, user_id, type, created_at FROM review_events WHERE type <> 'APPROVED';
+
+DROP TABLE review_events;
+ALTER TABLE review_events_old RENAME TO review_events;
+
+CREATE INDEX review_events_user_id_idx ON review_events (user_id, pull_request_id);
@@ -0,0 +1,13 @@
+-- +goose Up
+-- dead letter: событие, не опубликованное за outbox.max_attempts попыток, больше не публикуется
+-- и не задерживает следующие события своего агрегата
+ALTER TABLE outbox_events ADD COLUMN dead_at DATETIME;
+
+DROP INDEX outbox_events_pending_idx;
+CREATE INDEX outbox_events_pending_idx ON outbox_events (id) WHERE published_at IS NULL AND dead_at IS NULL;
+
+-- +goose Down
+DROP INDEX outbox_events_pending_idx;
+CREATE INDEX outbox_events_pending_idx ON outbox_events (id) WHERE published_at IS NULL;
+
+ALTER TABLE outbox_events DROP COLUMN dead_at;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- ключ действует в пределах токена: другой клиент с тем же ключом не получит чужой сохранённый ответ.
+-- SQLite не меняет первичный ключ, поэтому таблица пересоздаётся
+CREATE TABLE idempotency_keys_new (
+    key           TEXT NOT NULL,
+    owner         TEXT NOT NULL DEFAULT '',
+    endpoint      TEXT NOT NULL,
+    request_hash  TEXT NOT NULL,
+    status_code   INTEGER,
+    content_type  TEXT,
+    response_body BLOB,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at    DATETIME NOT NULL,
+    PRIMARY KEY (key, owner, endpoint)
+);
+INSERT INTO idempotency_keys_new (key, endpoint, request_hash, status_code, content_type, response_body, created_at, expires_at)
+SELECT key, endpoint, request_hash, status_code, content_type, response_body, created_at, expires_at FROM idempotency_keys;
+DROP TABLE idempotency_keys;
+ALTER TABLE idempotency_keys_new RENAME TO idempotency_keys;
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+-- +goose Down
+CREATE TABLE idempotency_keys_old (
+    key           TEXT NOT NULL,
+    endpoint      TEXT NOT NULL,
+    request_hash  TEXT NOT NULL,
+    status_code   INTEGER,
+    content_type  TEXT,
+    response_body BLOB,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at    DATETIME NOT NULL,
+    PRIMARY KEY (key, endpoint)
+);
+-- без owner ключи разных токенов совпали бы, поэтому остаются только ключи без токена
+INSERT INTO idempotency_keys_old (key, endpoint, request_hash, status_code, content_type, response_body, created_at, expires_at)
+SELECT key, endpoint, request_hash, status_code, content_type, response_body, created_at, expires_at FROM idempotency_keys WHERE owner = '';
+DROP TABLE idempotency_keys;
+ALTER TABLE idempotency_keys_old RENAME TO idempotency_keys;
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,10 @@
+-- +goose Up
+-- команда ревьюера на момент события: перевод пользователя в другую команду не переносит его историю
+ALTER TABLE review_events ADD COLUMN team_name TEXT NOT NULL DEFAULT '';
+
+-- для уже записанных событий известна только текущая команда
+UPDATE review_events SET team_name = (SELECT u.team_name FROM users u WHERE u.id = review_events.user_id)
+WHERE EXISTS (SELECT 1 FROM users u WHERE u.id = review_events.user_id);
+
+-- +goose Down
+ALTER TABLE review_events DROP COLUMN team_name;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- причина снятия ревьюера: в reassigned_away статистики входят только переназначения
+ALTER TABLE review_events ADD COLUMN reason TEXT
+    CHECK (reason IN ('REASSIGNED', 'USER_DEACTIVATED', 'TEAM_DEACTIVATED'));
+
+-- для уже записанных снятий причина восстанавливается по назначению на тот же PR в той же транзакции:
+-- переназначение берёт ревьюера из команды снятого, деактивация команды - из другой команды,
+-- деактивация пользователя никого не назначает
+UPDATE review_events
+SET reason = CASE
+    WHEN EXISTS (
+        SELECT 1 FROM review_events a
+        WHERE a.pull_request_id = review_events.pull_request_id
+          AND a.type = 'ASSIGNED'
+          AND a.id > review_events.id
+          AND (julianday(a.created_at) - julianday(review_events.created_at)) * 86400.0 < 1
+          AND a.team_name = review_events.team_name
+    ) THEN 'REASSIGNED'
+    WHEN EXISTS (
+        SELECT 1 FROM review_events a
+        WHERE a.pull_request_id = review_events.pull_request_id
+          AND a.type = 'ASSIGNED'
+          AND a.id > review_events.id
+          AND (julianday(a.created_at) - julianday(review_events.created_at)) * 86400.0 < 1
+    ) THEN 'TEAM_DEACTIVATED'
+    ELSE 'USER_DEACTIVATED'
+END
+WHERE type = 'UNASSIGNED';
+
+-- +goose Down
+ALTER TABLE review_events DROP COLUMN reason;
@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/application/service"
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/http/controller"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getAuditLog(t *testing.T, r http.Handler, query string) model.GetAuditLogResponse {
+	t.Helper()
+	w := doJSON(r, http.MethodGet, "/admin/getAuditLog?"+query, nil, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var res model.GetAuditLogResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	return res
+}
+
+func TestAuditLog(t *testing.T) {
+	r, client := authRouter(t, nil)
+
+	w := doJSON(r, http.MethodPost, "/teams/add", model.AddTeamRequest{
+		TeamName: "payments",
+		Members: []model.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+		},
+	}, withToken(bootstrapSecret), withRequestID("add-payments"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, http.MethodPost, "/teams/add", model.AddTeamRequest{
+		TeamName: "backend",
+		Members:  []model.TeamMember{{UserID: "u3", Username: "Paul", IsActive: true}},
+	}, withToken(bootstrapSecret), withRequestID("add-backend"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, http.MethodPost, "/pullRequests/create", model.CreatePullRequestRequest{
+		PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1",
+	}, withToken(bootstrapSecret), withRequestID("create-pr"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(r, http.MethodPatch, "/teams/deactivate?team_name=payments", nil, withToken(bootstrapSecret), withRequestID("deactivate-payments"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// кто деактивировал payments и какие PR переназначены
+	res := getAuditLog(t, r, "request_id=deactivate-payments")
+	require.Len(t, res.Entries, 2)
+	require.Empty(t, res.NextCursor)
+
+	reassign, deactivate := res.Entries[0], res.Entries[1]
+	require.Equal(t, string(domain.AuditTeamDeactivate), deactivate.Action)
+	require.Equal(t, "payments", deactivate.EntityID)
+	require.Equal(t, domain.BootstrapTokenID, deactivate.ActorID)
+	require.Equal(t, "deactivate-payments", deactivate.RequestID)
+	var before, after []model.User
+	require.NoError(t, json.Unmarshal(deactivate.Before, &before))
+	require.NoError(t, json.Unmarshal(deactivate.After, &after))
+	require.Len(t, before, 2)
+	require.True(t, before[1].IsActive)
+	require.Len(t, after, 2)
+	require.False(t, after[1].IsActive)
+
+	require.Equal(t, string(domain.AuditPRReassign), reassign.Action)
+	require.Equal(t, string(domain.AuditEntityPullRequest), reassign.EntityType)
+	require.Equal(t, "pr-1", reassign.EntityID)
+	var prBefore, prAfter model.PullRequest
+	require.NoError(t, json.Unmarshal(reassign.Before, &prBefore))
+	require.NoError(t, json.Unmarshal(reassign.After, &prAfter))
+	require.Equal(t, []string{"u2"}, prBefore.AssignedReviewers)
+	require.Equal(t, []string{"u3"}, prAfter.AssignedReviewers)
+
+	// история PR целиком: создание и переназначение
+	res = getAuditLog(t, r, "entity_type=pull_request&entity_id=pr-1")
+	require.Len(t, res.Entries, 2)
+	require.Equal(t, string(domain.AuditPRCreate), res.Entries[1].Action)
+	require.JSONEq(t, "null", string(res.Entries[1].Before))
+
+	res = getAuditLog(t, r, "action=team.save&limit=1")
+	require.Len(t, res.Entries, 1)
+	require.Equal(t, "backend", res.Entries[0].EntityID)
+	require.NotEmpty(t, res.NextCursor)
+	res = getAuditLog(t, r, "action=team.save&limit=1&cursor="+res.NextCursor)
+	require.Len(t, res.Entries, 1)
+	require.Equal(t, "payments", res.Entries[0].EntityID)
+	require.JSONEq(t, "null", string(res.Entries[0].Before))
+	require.Empty(t, res.NextCursor)
+
+	for _, query := range []string{"action=team.delete", "limit=1000", "cursor=broken", "from=yesterday"} {
+		w = doJSON(r, http.MethodGet, "/admin/getAuditLog?"+query, nil, withToken(bootstrapSecret))
+		require.Equal(t, http.StatusBadRequest, w.Code, query)
+		require.Equal(t, controller.CodeInvalidRequest, errorCode(t, w), query)
+	}
+
+	// записи журнала нельзя изменить или удалить
+	ctx := asSystem()
+	_, err := client.Exec(ctx, "UPDATE audit_log SET actor_id = 'someone'")
+	require.Error(t, err)
+	_, err = client.Exec(ctx, "DELETE FROM audit_log")
+	require.Error(t, err)
+}
+
+func TestAuditUserDeactivation(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	ctx := asSystem()
+
+	b := newMemoryBackend(logger)
+	audit := b.audit
+	s := service.NewPRService(b.prs, b.users, b.teams, b.audit, b.tx, domain.ReviewersMaxCount, logger)
+
+	require.NoError(t, s.AddTeam(ctx, "payments", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Kate", IsActive: true},
+	}))
+	for _, id := range []string{"pr-1", "pr-2", "pr-3"} {
+		_, err := s.CreatePR(ctx, id, "Add search", "u1")
+		require.NoError(t, err)
+	}
+	_, err := s.MergePR(ctx, "pr-3", 0)
+	require.NoError(t, err)
+
+	_, err = s.SetIsActiveUser(ctx, "u2", false)
+	require.NoError(t, err)
+
+	// снятие с каждого открытого PR записано отдельно, смерженный PR не изменился
+	entries, err := audit.Query(ctx, domain.AuditQuery{Action: domain.AuditPRReassign, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.EntityID)
+		require.Equal(t, domain.AuditEntityPullRequest, entry.EntityType)
+
+		var prBefore, prAfter model.PullRequest
+		require.NoError(t, json.Unmarshal(entry.Before, &prBefore))
+		require.NoError(t, json.Unmarshal(entry.After, &prAfter))
+		require.ElementsMatch(t, []string{"u2", "u3"}, prBefore.AssignedReviewers)
+		require.Equal(t, []string{"u3"}, prAfter.AssignedReviewers)
+	}
+	require.ElementsMatch(t, []string{"pr-1", "pr-2"}, ids)
+
+	entries, err = audit.Query(ctx, domain.AuditQuery{Action: domain.AuditUserSetIsActive, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestAuditAdminChanges(t *testing.T) {
+	r, _ := authRouter(t, nil)
+
+	w := doJSON(r, http.MethodPost, "/admin/issueToken", model.IssueTokenRequest{
+		Name: "ci-pipeline", Scopes: []string{"prs:write"},
+	}, withToken(bootstrapSecret), withRequestID("issue-token"))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var issued model.IssueTokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+	w = doJSON(r, http.MethodPost, "/admin/revokeToken", model.RevokeTokenRequest{TokenID: issued.Token.TokenID},
+		withToken(bootstrapSecret), withRequestID("revoke-token"))
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	// выпуск и отзыв токена записаны от имени админа, секрет в журнал не попадает
+	res := getAuditLog(t, r, "entity_type=token&entity_id="+issued.Token.TokenID)
+	require.Len(t, res.Entries, 2)
+	revoke, issue := res.Entries[0], res.Entries[1]
+	require.Equal(t, string(domain.AuditTokenIssue), issue.Action)
+	require.Equal(t, domain.BootstrapTokenID, issue.ActorID)
+	require.Equal(t, "issue-token", issue.RequestID)
+	require.JSONEq(t, "null", string(issue.Before))
+	require.NotContains(t, string(issue.After), issued.Secret)
+
+	require.Equal(t, string(domain.AuditTokenRevoke), revoke.Action)
+	require.Equal(t, "revoke-token", revoke.RequestID)
+	var tokenBefore, tokenAfter model.APIToken
+	require.NoError(t, json.Unmarshal(revoke.Before, &tokenBefore))
+	require.NoError(t, json.Unmarshal(revoke.After, &tokenAfter))
+	require.Nil(t, tokenBefore.RevokedAt)
+	require.NotNil(t, tokenAfter.RevokedAt)
+
+	w = doJSON(r, http.MethodPost, "/admin/createWebhook", model.CreateWebhookRequest{
+		URL: "https://chat.example.com/hooks/reviews", Secret: webhookSecret,
+	}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created model.CreateWebhookResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	w = doJSON(r, http.MethodPost, "/admin/deleteWebhook", model.DeleteWebhookRequest{WebhookID: created.Webhook.WebhookID}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	// подписка в журнале без секрета: после удаления её состояние видно только в записи webhook.delete
+	res = getAuditLog(t, r, "entity_type=webhook")
+	require.Len(t, res.Entries, 2)
+	remove, create := res.Entries[0], res.Entries[1]
+	require.Equal(t, string(domain.AuditWebhookCreate), create.Action)
+	require.Equal(t, created.Webhook.WebhookID, create.EntityID)
+	require.NotContains(t, string(create.After), webhookSecret)
+	require.Equal(t, string(domain.AuditWebhookDelete), remove.Action)
+	require.JSONEq(t, "null", string(remove.After))
+	var webhookBefore model.Webhook
+	require.NoError(t, json.Unmarshal(remove.Before, &webhookBefore))
+	require.Equal(t, "https://chat.example.com/hooks/reviews", webhookBefore.URL)
+
+	// неизвестный токен не отзывается и в журнал не попадает
+	w = doJSON(r, http.MethodPost, "/admin/revokeToken", model.RevokeTokenRequest{TokenID: "missing"}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	res = getAuditLog(t, r, "action=token.revoke")
+	require.Len(t, res.Entries, 1)
+}
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/auth"
+	"avito-tech-go-task/internal/infrastructure/http/controller"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthTokens(t *testing.T) {
+	r, client := authRouter(t, nil)
+	ctx := context.Background()
+
+	w := doJSON(r, http.MethodGet, "/teams/get?team_name=payments", nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	require.Equal(t, auth.CodeUnauthorized, errorCode(t, w))
+
+	w = doJSON(r, http.MethodGet, "/teams/get?team_name=payments", nil, withToken("prs_unknown"))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doJSON(r, http.MethodPost, "/admin/issueToken", model.IssueTokenRequest{
+		Name:   "team-sync",
+		Scopes: []string{"teams:write", "unknown"},
+	}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(r, http.MethodPost, "/admin/issueToken", model.IssueTokenRequest{
+		Name:   "team-sync",
+		Scopes: []string{"teams:write"},
+	}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var issued model.IssueTokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+	require.Contains(t, issued.Secret, domain.TokenPrefix)
+	require.Equal(t, []string{"teams:write"}, issued.Token.Scopes)
+
+	// сервисный токен без пользователя и без scope admin не проходит проверку ролей
+	addTeam := model.AddTeamRequest{
+		TeamName: "payments",
+		Members:  []model.TeamMember{{UserID: "u1", Username: "Alice", IsActive: true}},
+	}
+	w = doJSON(r, http.MethodPost, "/teams/add", addTeam, withToken(issued.Secret))
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	require.Equal(t, controller.CodeForbidden, errorCode(t, w))
+
+	// изменение помечается токеном, которым оно выполнено
+	w = doJSON(r, http.MethodPost, "/admin/issueToken", model.IssueTokenRequest{
+		Name:   "team-admin",
+		Scopes: []string{"admin"},
+	}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var admin model.IssueTokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &admin))
+	w = doJSON(r, http.MethodPost, "/teams/add", addTeam, withToken(admin.Secret))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	for _, table := range []string{"teams", "users"} {
+		rows, err := client.Query(ctx, "SELECT updated_by FROM "+table)
+		require.NoError(t, err)
+		var updatedBy []string
+		for rows.Next() {
+			var value string
+			require.NoError(t, rows.Scan(&value))
+			updatedBy = append(updatedBy, value)
+		}
+		rows.Close()
+		require.Equal(t, []string{admin.Token.TokenID}, updatedBy, table)
+	}
+
+	w = doJSON(r, http.MethodGet, "/teams/get?team_name=payments", nil, withToken(issued.Secret))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(r, http.MethodPost, "/pullRequests/create", model.CreatePullRequestRequest{
+		PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1",
+	}, withToken(issued.Secret))
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, auth.CodeForbidden, errorCode(t, w))
+
+	w = doJSON(r, http.MethodGet, "/admin/listTokens", nil, withToken(issued.Secret))
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON(r, http.MethodGet, "/admin/listTokens", nil, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	var list model.ListTokensResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Tokens, 3)
+	require.NotContains(t, w.Body.String(), issued.Secret)
+
+	w = doJSON(r, http.MethodPost, "/admin/revokeToken", model.RevokeTokenRequest{TokenID: issued.Token.TokenID}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w = doJSON(r, http.MethodPost, "/admin/revokeToken", model.RevokeTokenRequest{TokenID: "missing"}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(r, http.MethodGet, "/teams/get?team_name=payments", nil, withToken(issued.Secret))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthRoles(t *testing.T) {
+	r, _ := authRouter(t, nil)
+
+	w := doJSON(r, http.MethodPost, "/teams/add", model.AddTeamRequest{
+		TeamName: "payments",
+		Members: []model.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+		},
+	}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(r, http.MethodPost, "/admin/issueToken", model.IssueTokenRequest{
+		Name: "alice", Scopes: []string{"teams:write"}, UserID: "u404",
+	}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(r, http.MethodPost, "/admin/issueToken", model.IssueTokenRequest{
+		Name: "alice", Scopes: []string{"teams:write"}, UserID: "u1",
+	}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var issued model.IssueTokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+	require.Equal(t, "u1", issued.Token.UserID)
+
+	// scope teams:write есть, но роль member не позволяет менять чужой флаг и команду
+	w = doJSON(r, http.MethodPost, "/users/setIsActive", model.SetIsActiveUserRequest{UserID: "u2", IsActive: true}, withToken(issued.Secret))
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, controller.CodeForbidden, errorCode(t, w))
+	w = doJSON(r, http.MethodPatch, "/teams/deactivate?team_name=payments", nil, withToken(issued.Secret))
+	require.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(r, http.MethodPost, "/users/setRole", model.SetUserRoleRequest{UserID: "u1", Role: "admin"}, withToken(issued.Secret))
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON(r, http.MethodPost, "/users/setIsActive", model.SetIsActiveUserRequest{UserID: "u1", IsActive: true}, withToken(issued.Secret))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// участнику для своего флага не нужен teams:write, чужой флаг ему не даёт поменять роль
+	w = doJSON(r, http.MethodPost, "/admin/issueToken", model.IssueTokenRequest{
+		Name: "bob", Scopes: []string{"prs:write"}, UserID: "u2",
+	}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var bob model.IssueTokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bob))
+	w = doJSON(r, http.MethodPost, "/users/setIsActive", model.SetIsActiveUserRequest{UserID: "u2", IsActive: true}, withToken(bob.Secret))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, http.MethodPost, "/users/setIsActive", model.SetIsActiveUserRequest{UserID: "u1", IsActive: true}, withToken(bob.Secret))
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, controller.CodeForbidden, errorCode(t, w))
+
+	w = doJSON(r, http.MethodPost, "/users/setRole", model.SetUserRoleRequest{UserID: "u1", Role: "team_lead"}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var res model.SetUserRoleResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, "team_lead", res.User.Role)
+
+	w = doJSON(r, http.MethodPost, "/users/setIsActive", model.SetIsActiveUserRequest{UserID: "u2", IsActive: true}, withToken(issued.Secret))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, http.MethodPatch, "/teams/deactivate?team_name=payments", nil, withToken(issued.Secret))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
@@ -0,0 +1,1033 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/application/service"
+	"avito-tech-go-task/internal/clients/postgres"
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/idempotency"
+	"avito-tech-go-task/internal/infrastructure/outbox"
+	"avito-tech-go-task/internal/infrastructure/webhook"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// backend - набор репозиториев одного хранилища.
+type backend struct {
+	teams       service.TeamRepository
+	prs         service.PullRequestRepository
+	users       service.UserRepository
+	tx          service.TxManager
+	idempotency idempotency.Store
+	tokens      service.TokenRepository
+	audit       service.AuditRepository
+	outbox      outbox.Store
+	webhooks    webhookRepository
+}
+
+// webhookRepository - подписки для админских ручек и очередь доставок для dispatcher.
+type webhookRepository interface {
+	service.WebhookRepository
+	webhook.Store
+}
+
+// ContractSuite проверяет, что все реализации репозиториев ведут себя одинаково.
+// Каждый тест получает пустое хранилище с командами payments и backend.
+type ContractSuite struct {
+	suite.Suite
+	backend
+	// newBackend возвращает репозитории поверх пустого хранилища
+	newBackend func() backend
+	teardown   func()
+}
+
+func TestMemoryContract(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	suite.Run(t, &ContractSuite{
+		newBackend: func() backend { return newMemoryBackend(logger) },
+	})
+}
+
+func TestPostgresContract(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	pg, err := postgres.Connect(dbDSN)
+	if err != nil {
+		t.Fatal("failed to connect", err)
+	}
+	suite.Run(t, &ContractSuite{
+		newBackend: func() backend {
+			cleanDB(pg)
+			return newPostgresBackend(pg)
+		},
+		teardown: func() {
+			cleanDB(pg)
+			pg.Close()
+		},
+	})
+}
+
+func TestSQLiteContract(t *testing.T) {
+	client, _, err := connectSQLite(t)
+	if err != nil {
+		t.Fatal("failed to connect", err)
+	}
+	suite.Run(t, &ContractSuite{
+		newBackend: func() backend {
+			cleanDB(client)
+			return newSQLiteBackend(client)
+		},
+		teardown: func() {
+			client.Close()
+		},
+	})
+}
+
+func (s *ContractSuite) SetupTest() {
+	s.backend = s.newBackend()
+
+	ctx := context.Background()
+	s.Require().NoError(s.teams.Save(ctx, *domain.NewTeam("payments"), []domain.User{
+		*domain.NewUser("u1", "Alice", "payments", true),
+		*domain.NewUser("u2", "Bob", "payments", true),
+	}))
+	s.Require().NoError(s.teams.Save(ctx, *domain.NewTeam("backend"), []domain.User{
+		*domain.NewUser("u3", "Paul", "backend", true),
+		*domain.NewUser("u4", "Anna", "backend", true),
+		*domain.NewUser("u5", "Nina", "backend", true),
+		*domain.NewUser("u6", "Jack", "backend", false),
+	}))
+}
+
+func (s *ContractSuite) TearDownSuite() {
+	if s.teardown != nil {
+		s.teardown()
+	}
+}
+
+// createPR сохраняет открытый PR и возвращает его.
+func (s *ContractSuite) createPR(id, authorID string, reviewersIDs ...string) domain.PullRequest {
+	pr, err := domain.NewPullRequest(id, "pull request "+id, authorID, reviewersIDs)
+	s.Require().NoError(err)
+	s.Require().NoError(s.prs.CreatePR(context.Background(), *pr))
+	return *pr
+}
+
+// userStat возвращает сохранённую статистику ревьюера.
+func (s *ContractSuite) userStat(userID string) domain.UserStat {
+	stats, err := s.users.GetStats(context.Background(), 0)
+	s.Require().NoError(err)
+	for _, stat := range stats {
+		if stat.UserID == userID {
+			return stat
+		}
+	}
+	s.FailNow("no stats for user", userID)
+	return domain.UserStat{}
+}
+
+func (s *ContractSuite) requireCounters(userID string, total, active, merged int64) {
+	stat := s.userStat(userID)
+	s.Equal(total, stat.TotalReviews, "total reviews of %s", userID)
+	s.Equal(active, stat.ActiveReviews, "active reviews of %s", userID)
+	s.Equal(merged, stat.MergedReviews, "merged reviews of %s", userID)
+}
+
+func (s *ContractSuite) TestTeamSaveAndFind() {
+	ctx := context.Background()
+
+	users, err := s.teams.FindByName(ctx, "payments")
+	s.Require().NoError(err)
+	s.ElementsMatch([]domain.User{
+		*domain.NewUser("u1", "Alice", "payments", true),
+		*domain.NewUser("u2", "Bob", "payments", true),
+	}, users)
+
+	users, err = s.teams.FindByName(ctx, "unknown")
+	s.NoError(err)
+	s.Empty(users)
+
+	// повторное сохранение обновляет участников и переносит их между командами
+	s.Require().NoError(s.teams.Save(ctx, *domain.NewTeam("payments"), []domain.User{
+		*domain.NewUser("u2", "Lili", "payments", true),
+		*domain.NewUser("u6", "Jack", "payments", true),
+	}))
+
+	user, err := s.users.FindByID(ctx, "u2")
+	s.Require().NoError(err)
+	s.Equal("Lili", user.Name)
+
+	team, err := s.users.FindTeamByUserID(ctx, "u6")
+	s.Require().NoError(err)
+	s.Equal("payments", team)
+
+	_, err = s.users.FindByID(ctx, "u404")
+	s.ErrorIs(err, domain.ErrUserNotExist)
+	_, err = s.users.FindTeamByUserID(ctx, "u404")
+	s.ErrorIs(err, domain.ErrUserNotExist)
+
+	stats, err := s.users.GetStats(ctx, 0)
+	s.Require().NoError(err)
+	s.Len(stats, 6)
+
+	stats, err = s.users.GetStats(ctx, 2)
+	s.Require().NoError(err)
+	s.Len(stats, 2)
+}
+
+func (s *ContractSuite) TestActiveUsers() {
+	ctx := context.Background()
+
+	ids, err := s.users.FindActiveUserIDsByTeam(ctx, "backend")
+	s.Require().NoError(err)
+	s.ElementsMatch([]string{"u3", "u4", "u5"}, ids)
+
+	ids, err = s.users.FindActiveUserIDsByTeamExcludeAuthor(ctx, "backend", "u3", 2)
+	s.Require().NoError(err)
+	s.ElementsMatch([]string{"u4", "u5"}, ids)
+
+	ids, err = s.users.FindActiveUserIDsByTeamExcludeAuthor(ctx, "payments", "u1", 2)
+	s.Require().NoError(err)
+	s.Equal([]string{"u2"}, ids)
+}
+
+func (s *ContractSuite) TestCreatePR() {
+	ctx := context.Background()
+	created := s.createPR("pr-1", "u3", "u4", "u5")
+
+	s.ErrorIs(s.prs.CreatePR(ctx, created), domain.ErrPRExists)
+
+	found, err := s.prs.FindByID(ctx, "pr-1")
+	s.Require().NoError(err)
+	s.Equal(created.ID, found.ID)
+	s.Equal(created.Name, found.Name)
+	s.Equal(created.AuthorID, found.AuthorID)
+	s.Equal(domain.PRStatusOpen, found.Status)
+	s.Equal([]string{"u4", "u5"}, found.ReviewersIDs)
+	s.Equal(int64(1), found.Version)
+
+	_, err = s.prs.FindByID(ctx, "pr-404")
+	s.ErrorIs(err, domain.ErrPRNotFound)
+	_, err = s.prs.FindByIDForUpdate(ctx, "pr-404")
+	s.ErrorIs(err, domain.ErrPRNotFound)
+
+	prs, err := s.prs.FindByReviewerID(ctx, "u4")
+	s.Require().NoError(err)
+	s.Require().Len(prs, 1)
+	s.Equal("pr-1", prs[0].ID)
+
+	prs, err = s.prs.FindByReviewerID(ctx, "u3")
+	s.NoError(err)
+	s.Empty(prs)
+
+	s.requireCounters("u4", 1, 1, 0)
+	s.requireCounters("u5", 1, 1, 0)
+	s.requireCounters("u3", 0, 0, 0)
+}
+
+func (s *ContractSuite) TestMergePR() {
+	ctx := context.Background()
+	pr := s.createPR("pr-1", "u3", "u4", "u5")
+
+	pr.SetMergedStatus()
+	s.Require().NoError(s.prs.MergePR(ctx, pr))
+
+	merged, err := s.prs.FindByID(ctx, "pr-1")
+	s.Require().NoError(err)
+	s.Equal(domain.PRStatusMerged, merged.Status)
+	s.Equal(int64(2), merged.Version)
+	s.False(merged.MergedAt.IsZero())
+
+	// PR уже изменён с версии, от которой считает клиент
+	s.ErrorIs(s.prs.MergePR(ctx, pr), domain.ErrPRVersionConflict)
+
+	s.requireCounters("u4", 1, 0, 1)
+	s.requireCounters("u5", 1, 0, 1)
+}
+
+func (s *ContractSuite) TestReassignPR() {
+	ctx := context.Background()
+	pr := s.createPR("pr-1", "u3", "u4")
+
+	newReviewer, err := pr.ReassignReviewer(0, []string{"u5"})
+	s.Require().NoError(err)
+	s.Require().NoError(s.prs.ReassignPR(ctx, pr, "u4", newReviewer))
+
+	found, err := s.prs.FindByID(ctx, "pr-1")
+	s.Require().NoError(err)
+	s.Equal([]string{"u5"}, found.ReviewersIDs)
+	s.Equal(int64(2), found.Version)
+
+	stale := pr
+	stale.Version = 2
+	s.ErrorIs(s.prs.ReassignPR(ctx, stale, "u5", "u4"), domain.ErrPRVersionConflict)
+
+	s.requireCounters("u4", 1, 0, 0)
+	s.requireCounters("u5", 1, 1, 0)
+}
+
+func (s *ContractSuite) TestSetIsActive() {
+	ctx := context.Background()
+	s.createPR("pr-1", "u3", "u4", "u5")
+	merged := s.createPR("pr-2", "u3", "u4")
+	merged.SetMergedStatus()
+	s.Require().NoError(s.prs.MergePR(ctx, merged))
+
+	s.Require().NoError(s.users.SetIsActive(ctx, "u4", false))
+
+	user, err := s.users.FindByID(ctx, "u4")
+	s.Require().NoError(err)
+	s.False(user.IsActive)
+
+	// неактивный ревьюер снимается только с открытых PR
+	open, err := s.prs.FindByID(ctx, "pr-1")
+	s.Require().NoError(err)
+	s.Equal([]string{"u5"}, open.ReviewersIDs)
+	s.Equal(int64(2), open.Version)
+
+	closed, err := s.prs.FindByID(ctx, "pr-2")
+	s.Require().NoError(err)
+	s.Equal([]string{"u4"}, closed.ReviewersIDs)
+	s.Equal(int64(2), closed.Version)
+
+	s.requireCounters("u4", 2, 0, 1)
+
+	s.NoError(s.users.SetIsActive(ctx, "u404", true))
+
+	s.Require().NoError(s.users.SetIsActive(ctx, "u6", true))
+	ids, err := s.users.FindActiveUserIDsByTeam(ctx, "backend")
+	s.Require().NoError(err)
+	s.ElementsMatch([]string{"u3", "u5", "u6"}, ids)
+}
+
+func (s *ContractSuite) TestDeactivateTeam() {
+	ctx := context.Background()
+	s.createPR("pr-1", "u3", "u4", "u5")
+	s.createPR("pr-2", "u1", "u2")
+
+	prs, err := s.teams.DeactivateTeam(ctx, "backend")
+	s.Require().NoError(err)
+	s.Require().Len(prs, 1)
+	s.Equal("pr-1", prs[0].ID)
+	s.Equal(int64(2), prs[0].Version)
+	s.Require().Len(prs[0].ReviewersIDs, 1)
+	s.Contains([]string{"u1", "u2"}, prs[0].ReviewersIDs[0])
+
+	ids, err := s.users.FindActiveUserIDsByTeam(ctx, "backend")
+	s.Require().NoError(err)
+	s.Empty(ids)
+
+	s.requireCounters("u4", 1, 0, 0)
+	s.requireCounters("u5", 1, 0, 0)
+	newReviewer := prs[0].ReviewersIDs[0]
+	reviewing, err := s.prs.FindByReviewerID(ctx, newReviewer)
+	s.Require().NoError(err)
+	s.requireCounters(newReviewer, int64(len(reviewing)), int64(len(reviewing)), 0)
+
+	// активных пользователей вне команды не осталось - ревьюеры не меняются
+	prs, err = s.teams.DeactivateTeam(ctx, "payments")
+	s.Require().NoError(err)
+	s.Empty(prs)
+
+	found, err := s.prs.FindByID(ctx, "pr-2")
+	s.Require().NoError(err)
+	s.Equal([]string{"u2"}, found.ReviewersIDs)
+}
+
+func (s *ContractSuite) TestRebuildStats() {
+	ctx := context.Background()
+	pr := s.createPR("pr-1", "u3", "u4", "u5")
+	_, err := pr.ReassignReviewer(0, []string{"u6"})
+	s.Require().NoError(err)
+	s.Require().NoError(s.prs.ReassignPR(ctx, pr, "u4", "u6"))
+	s.Require().NoError(s.users.SetIsActive(ctx, "u5", false))
+
+	drifts, err := s.users.RebuildStats(ctx)
+	s.Require().NoError(err)
+	s.Empty(drifts)
+}
+
+// Регрессия: переназначение переносит активное ревью на нового ревьюера,
+// а мерж засчитывается только текущему ревьюеру, снятому - только reassigned_away.
+func (s *ContractSuite) TestReassignStats() {
+	ctx := asSystem()
+	now := time.Now()
+	prService := service.NewPRService(s.prs, s.users, s.teams, s.audit, s.tx, 1, slog.New(slog.DiscardHandler))
+
+	pr, err := prService.CreatePR(ctx, "pr-1", "Add search", "u3")
+	s.Require().NoError(err)
+	s.Require().Len(pr.ReviewersIDs, 1)
+	oldReviewer := pr.ReviewersIDs[0]
+
+	pr, newReviewer, err := prService.ReassignPR(ctx, "pr-1", oldReviewer, pr.Version)
+	s.Require().NoError(err)
+	s.NotEqual(oldReviewer, newReviewer)
+	s.requireCounters(oldReviewer, 1, 0, 0)
+	s.requireCounters(newReviewer, 1, 1, 0)
+
+	_, err = prService.MergePR(ctx, "pr-1", pr.Version)
+	s.Require().NoError(err)
+	s.requireCounters(oldReviewer, 1, 0, 0)
+	s.requireCounters(newReviewer, 1, 0, 1)
+
+	stats, err := s.users.QueryStats(ctx, domain.StatsQuery{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: domain.StatsGroupByUser,
+		SortBy:  domain.StatsSortByKey,
+		Limit:   domain.StatsDefaultLimit,
+	})
+	s.Require().NoError(err)
+	byUser := make(map[string]domain.GroupStat, len(stats))
+	for _, stat := range stats {
+		byUser[stat.Key] = stat
+	}
+	s.Equal(domain.GroupStat{Key: oldReviewer, ReviewsAssigned: 1, ReassignedAway: 1}, byUser[oldReviewer])
+	s.Equal(int64(1), byUser[newReviewer].ReviewsAssigned)
+	s.Equal(int64(1), byUser[newReviewer].ReviewsMerged)
+	s.Equal(int64(0), byUser[newReviewer].ReassignedAway)
+
+	drifts, err := s.users.RebuildStats(ctx)
+	s.Require().NoError(err)
+	s.Empty(drifts)
+}
+
+// Регрессия: статистика по командам берёт команду из события,
+// поэтому перевод ревьюера в другую команду не переносит его историю.
+func (s *ContractSuite) TestTeamStatsKeepHistory() {
+	ctx := context.Background()
+	now := time.Now()
+	s.createPR("pr-1", "u3", "u4")
+	s.Require().NoError(s.teams.Save(ctx, *domain.NewTeam("payments"), []domain.User{
+		*domain.NewUser("u4", "Anna", "payments", true),
+	}))
+	s.createPR("pr-2", "u1", "u4")
+
+	stats, err := s.users.QueryStats(ctx, domain.StatsQuery{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: domain.StatsGroupByTeam,
+		SortBy:  domain.StatsSortByKey,
+		Limit:   domain.StatsDefaultLimit,
+	})
+	s.Require().NoError(err)
+	s.Equal([]domain.GroupStat{
+		{Key: "backend", ReviewsAssigned: 1},
+		{Key: "payments", ReviewsAssigned: 1},
+	}, stats)
+}
+
+// Регрессия: снятия при деактивации пользователя или команды не считаются переназначением.
+func (s *ContractSuite) TestDeactivationIsNotReassignment() {
+	ctx := context.Background()
+	now := time.Now()
+	s.createPR("pr-1", "u3", "u4")
+	s.createPR("pr-2", "u3", "u1")
+	s.Require().NoError(s.users.SetIsActive(ctx, "u4", false))
+	_, err := s.teams.DeactivateTeam(ctx, "payments")
+	s.Require().NoError(err)
+
+	stats, err := s.users.QueryStats(ctx, domain.StatsQuery{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: domain.StatsGroupByUser,
+		SortBy:  domain.StatsSortByKey,
+		Limit:   domain.StatsDefaultLimit,
+	})
+	s.Require().NoError(err)
+	byUser := make(map[string]domain.GroupStat, len(stats))
+	for _, stat := range stats {
+		byUser[stat.Key] = stat
+	}
+	s.Equal(domain.GroupStat{Key: "u4", ReviewsAssigned: 1}, byUser["u4"])
+	s.Equal(domain.GroupStat{Key: "u1", ReviewsAssigned: 1}, byUser["u1"])
+}
+
+func (s *ContractSuite) TestGetTeamLoad() {
+	ctx := context.Background()
+	s.createPR("pr-1", "u3", "u4", "u5")
+	merged := s.createPR("pr-2", "u1", "u2")
+	merged.SetMergedStatus()
+	s.Require().NoError(s.prs.MergePR(ctx, merged))
+
+	load, err := s.teams.GetTeamLoad(ctx)
+	s.Require().NoError(err)
+	s.Equal([]domain.TeamLoad{
+		{TeamName: "backend", OpenPullRequests: 1, ActiveReviewers: 3},
+		{TeamName: "payments", OpenPullRequests: 0, ActiveReviewers: 2},
+	}, load)
+}
+
+func (s *ContractSuite) TestTxManager() {
+	ctx := context.Background()
+
+	errAbort := errors.New("abort")
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		pr, err := domain.NewPullRequest("pr-tx", "rolled back", "u3", []string{"u4"})
+		s.Require().NoError(err)
+		s.Require().NoError(s.prs.CreatePR(ctx, *pr))
+		s.Require().NoError(s.users.SetIsActive(ctx, "u5", false))
+
+		_, err = s.prs.FindByIDForUpdate(ctx, pr.ID)
+		s.Require().NoError(err)
+
+		return errAbort
+	})
+	s.ErrorIs(err, errAbort)
+
+	_, err = s.prs.FindByID(ctx, "pr-tx")
+	s.ErrorIs(err, domain.ErrPRNotFound)
+	user, err := s.users.FindByID(ctx, "u5")
+	s.Require().NoError(err)
+	s.True(user.IsActive)
+	s.requireCounters("u4", 0, 0, 0)
+
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		return s.tx.Do(ctx, func(ctx context.Context) error {
+			pr, err := domain.NewPullRequest("pr-tx", "committed", "u3", []string{"u4"})
+			s.Require().NoError(err)
+			return s.prs.CreatePR(ctx, *pr)
+		})
+	})
+	s.Require().NoError(err)
+
+	_, err = s.prs.FindByID(ctx, "pr-tx")
+	s.NoError(err)
+	s.requireCounters("u4", 1, 1, 0)
+}
+
+func (s *ContractSuite) TestAnalytics() {
+	ctx := context.Background()
+	now := time.Now()
+	s.createPR("pr-1", "u3", "u4", "u5")
+	merged := s.createPR("pr-2", "u1", "u2")
+	merged.SetMergedStatus()
+	s.Require().NoError(s.prs.MergePR(ctx, merged))
+
+	q := domain.StatsQuery{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: domain.StatsGroupByTeam,
+		SortBy:  domain.StatsSortByAssigned,
+		Desc:    true,
+		Limit:   1,
+	}
+	stats, err := s.users.QueryStats(ctx, q)
+	s.Require().NoError(err)
+	s.Require().Len(stats, 2)
+	s.Equal("backend", stats[0].Key)
+	s.Equal(int64(2), stats[0].ReviewsAssigned)
+	s.Equal("payments", stats[1].Key)
+	s.Equal(int64(1), stats[1].ReviewsMerged)
+	s.GreaterOrEqual(stats[1].MedianTimeToMerge, time.Duration(0))
+
+	q.Cursor = &domain.StatsCursor{SortValue: stats[0].SortValue(q.SortBy), Key: stats[0].Key}
+	stats, err = s.users.QueryStats(ctx, q)
+	s.Require().NoError(err)
+	s.Require().Len(stats, 1)
+	s.Equal("payments", stats[0].Key)
+
+	workload, err := s.users.QueryWorkload(ctx, domain.FairnessQuery{From: now.Add(-time.Hour), To: now.Add(time.Hour)})
+	s.Require().NoError(err)
+	s.Equal([]domain.MemberWorkload{
+		{UserID: "u3", TeamName: "backend", Assigned: 0},
+		{UserID: "u4", TeamName: "backend", Assigned: 1},
+		{UserID: "u5", TeamName: "backend", Assigned: 1},
+		{UserID: "u1", TeamName: "payments", Assigned: 0},
+		{UserID: "u2", TeamName: "payments", Assigned: 1},
+	}, workload)
+
+	turnaround, err := s.prs.QueryTurnaround(ctx, domain.TurnaroundQuery{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: domain.TurnaroundGroupByTeam,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(turnaround, 2)
+	s.Equal("backend", turnaround[0].Key)
+	s.Equal(int64(1), turnaround[0].PullRequests)
+	s.Equal(int64(0), turnaround[0].MergedPullRequests)
+	s.Equal("payments", turnaround[1].Key)
+	s.Equal(int64(1), turnaround[1].MergedPullRequests)
+	s.GreaterOrEqual(turnaround[1].TimeToMerge.P99, turnaround[1].TimeToMerge.P50)
+
+	turnaround, err = s.prs.QueryTurnaround(ctx, domain.TurnaroundQuery{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: domain.TurnaroundGroupByReviewer,
+	})
+	s.Require().NoError(err)
+	keys := make([]string, 0, len(turnaround))
+	for _, stat := range turnaround {
+		keys = append(keys, stat.Key)
+	}
+	s.Equal([]string{"u2", "u4", "u5"}, keys)
+
+	_, err = s.prs.QueryTurnaround(ctx, domain.TurnaroundQuery{From: now.Add(-time.Hour), To: now, GroupBy: "month"})
+	s.ErrorIs(err, domain.ErrInvalidTurnaroundQuery)
+}
+
+func (s *ContractSuite) TestTurnaroundFirstReview() {
+	ctx := context.Background()
+	now := time.Now()
+
+	// PR создан 10 минут назад, ревьюеры назначены сразу: назначение ревью не считается
+	pr, err := domain.NewPullRequest("pr-1", "Add search", "u3", []string{"u4", "u5"})
+	s.Require().NoError(err)
+	pr.CreatedAt = now.Add(-10 * time.Minute)
+	s.Require().NoError(s.prs.CreatePR(ctx, *pr))
+	s.createPR("pr-2", "u1", "u2")
+
+	s.Require().NoError(s.prs.ApprovePR(ctx, "pr-1", "u4"))
+	s.Require().NoError(s.prs.ApprovePR(ctx, "pr-1", "u5"))
+	s.requireCounters("u4", 1, 1, 0)
+
+	turnaround, err := s.prs.QueryTurnaround(ctx, domain.TurnaroundQuery{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: domain.TurnaroundGroupByTeam,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(turnaround, 2)
+	s.Equal("backend", turnaround[0].Key)
+	s.InDelta(10*time.Minute, turnaround[0].TimeToFirstReview.P50, float64(time.Minute))
+	s.Equal(turnaround[0].TimeToFirstReview.P50, turnaround[0].TimeToFirstReview.P99)
+	// PR без одобрений в перцентили не входит
+	s.Equal("payments", turnaround[1].Key)
+	s.Equal(int64(1), turnaround[1].PullRequests)
+	s.Zero(turnaround[1].TimeToFirstReview)
+
+	// у ревьюера время считается от его назначения, а не от создания PR
+	turnaround, err = s.prs.QueryTurnaround(ctx, domain.TurnaroundQuery{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: domain.TurnaroundGroupByReviewer,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(turnaround, 3)
+	s.Equal("u4", turnaround[1].Key)
+	s.Less(turnaround[1].TimeToFirstReview.P50, time.Minute)
+}
+
+func (s *ContractSuite) TestTokens() {
+	ctx := context.Background()
+
+	ci, _, err := domain.NewAPIToken("ci", []domain.Scope{domain.ScopePRsWrite, domain.ScopeStatsRead})
+	s.Require().NoError(err)
+	ci.UserID = "u1"
+	s.Require().NoError(s.tokens.Save(ctx, *ci))
+	bootstrap := domain.NewBootstrapToken("first-secret")
+	bootstrap.CreatedAt = ci.CreatedAt.Add(time.Second)
+	s.Require().NoError(s.tokens.Save(ctx, *bootstrap))
+
+	found, err := s.tokens.FindByHash(ctx, ci.Hash)
+	s.Require().NoError(err)
+	s.Equal(ci.ID, found.ID)
+	s.Equal("ci", found.Name)
+	s.Equal([]domain.Scope{domain.ScopePRsWrite, domain.ScopeStatsRead}, found.Scopes)
+	s.Equal("u1", found.UserID)
+	s.False(found.IsRevoked())
+
+	_, err = s.tokens.FindByHash(ctx, domain.HashToken("unknown"))
+	s.ErrorIs(err, domain.ErrTokenNotFound)
+
+	revokedAt := time.Now().UTC().Truncate(time.Second)
+	s.Require().NoError(s.tokens.Revoke(ctx, ci.ID, revokedAt))
+	s.Require().NoError(s.tokens.Revoke(ctx, ci.ID, revokedAt.Add(time.Hour)))
+	s.ErrorIs(s.tokens.Revoke(ctx, "missing", revokedAt), domain.ErrTokenNotFound)
+
+	// смена секрета bootstrap токена заменяет хеш, отзыв сохраняется
+	s.Require().NoError(s.tokens.Revoke(ctx, bootstrap.ID, revokedAt))
+	s.Require().NoError(s.tokens.Save(ctx, *domain.NewBootstrapToken("second-secret")))
+	_, err = s.tokens.FindByHash(ctx, bootstrap.Hash)
+	s.ErrorIs(err, domain.ErrTokenNotFound)
+	rotated, err := s.tokens.FindByHash(ctx, domain.HashToken("second-secret"))
+	s.Require().NoError(err)
+	s.True(rotated.IsRevoked())
+
+	tokens, err := s.tokens.List(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(tokens, 2)
+	s.Equal(ci.ID, tokens[0].ID)
+	s.Require().NotNil(tokens[0].RevokedAt)
+	s.True(revokedAt.Equal(*tokens[0].RevokedAt))
+	s.Equal(domain.BootstrapTokenID, tokens[1].ID)
+	s.Empty(tokens[1].UserID)
+}
+
+func (s *ContractSuite) TestUserRoles() {
+	ctx := context.Background()
+
+	user, err := s.users.FindByID(ctx, "u1")
+	s.Require().NoError(err)
+	s.Equal(domain.RoleMember, user.Role)
+
+	s.Require().NoError(s.users.SetRole(ctx, "u1", domain.RoleTeamLead))
+	s.Require().NoError(s.users.SetRole(ctx, "u3", domain.RoleAdmin))
+	s.ErrorIs(s.users.SetRole(ctx, "missing", domain.RoleAdmin), domain.ErrUserNotExist)
+
+	members, err := s.teams.FindByName(ctx, "payments")
+	s.Require().NoError(err)
+	s.Equal(map[string]domain.Role{"u1": domain.RoleTeamLead, "u2": domain.RoleMember}, roles(members))
+
+	// повторное сохранение команды не сбрасывает роли
+	s.Require().NoError(s.teams.Save(ctx, *domain.NewTeam("payments"), []domain.User{
+		*domain.NewUser("u1", "Alice", "payments", true),
+	}))
+	user, err = s.users.FindByID(ctx, "u1")
+	s.Require().NoError(err)
+	s.Equal(domain.RoleTeamLead, user.Role)
+
+	// лид, перешедший в другую команду, становится в ней участником; admin остаётся admin
+	s.Require().NoError(s.teams.Save(ctx, *domain.NewTeam("platform"), []domain.User{
+		*domain.NewUser("u1", "Alice", "platform", true),
+		*domain.NewUser("u3", "Paul", "platform", true),
+	}))
+	members, err = s.teams.FindByName(ctx, "platform")
+	s.Require().NoError(err)
+	s.Equal(map[string]domain.Role{"u1": domain.RoleMember, "u3": domain.RoleAdmin}, roles(members))
+}
+
+// roles возвращает роли участников по ID: порядок участников команды не определён.
+func roles(users []domain.User) map[string]domain.Role {
+	byID := make(map[string]domain.Role, len(users))
+	for _, user := range users {
+		byID[user.ID] = user.Role
+	}
+	return byID
+}
+
+func (s *ContractSuite) TestAuditLog() {
+	ctx := context.Background()
+	// журнал append-only и не очищается между тестами, записи теста отбираются по request ID
+	requestID := fmt.Sprintf("audit-%d", time.Now().UnixNano())
+
+	appendEntry := func(ctx context.Context, action domain.AuditAction, entityType domain.AuditEntity, entityID string, before, after any) {
+		entry, err := domain.NewAuditEntry(action, entityType, entityID, before, after)
+		s.Require().NoError(err)
+		entry.ActorID = "token-u1"
+		entry.ActorUserID = "u1"
+		entry.RequestID = requestID
+		s.Require().NoError(s.audit.Append(ctx, entry))
+	}
+
+	appendEntry(ctx, domain.AuditTeamSave, domain.AuditEntityTeam, "payments", nil, []string{"u1", "u2"})
+	appendEntry(ctx, domain.AuditUserSetIsActive, domain.AuditEntityUser, "u2",
+		map[string]bool{"is_active": true}, map[string]bool{"is_active": false})
+	appendEntry(ctx, domain.AuditTeamDeactivate, domain.AuditEntityTeam, "payments", []string{"u1", "u2"}, []string{})
+
+	// запись откатывается вместе с транзакцией изменения
+	errAbort := errors.New("abort")
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		appendEntry(ctx, domain.AuditPRCreate, domain.AuditEntityPullRequest, "pr-tx", nil, map[string]string{"id": "pr-tx"})
+		return errAbort
+	})
+	s.ErrorIs(err, errAbort)
+
+	q, err := domain.NewAuditQuery(domain.AuditQuery{RequestID: requestID}, "")
+	s.Require().NoError(err)
+	entries, err := s.audit.Query(ctx, *q)
+	s.Require().NoError(err)
+	s.Require().Len(entries, 3)
+	s.Equal(domain.AuditTeamDeactivate, entries[0].Action)
+	s.Equal(domain.AuditUserSetIsActive, entries[1].Action)
+	s.Equal(domain.AuditTeamSave, entries[2].Action)
+	s.Greater(entries[0].ID, entries[1].ID)
+	s.Equal("token-u1", entries[1].ActorID)
+	s.Equal("u1", entries[1].ActorUserID)
+	s.Equal("u2", entries[1].EntityID)
+	s.Equal(domain.AuditEntityUser, entries[1].EntityType)
+	s.JSONEq(`{"is_active": true}`, string(entries[1].Before))
+	s.JSONEq(`{"is_active": false}`, string(entries[1].After))
+	s.Nil(entries[2].Before)
+	s.JSONEq(`["u1", "u2"]`, string(entries[2].After))
+	s.WithinDuration(time.Now(), entries[0].CreatedAt, time.Minute)
+
+	q, err = domain.NewAuditQuery(domain.AuditQuery{RequestID: requestID, EntityType: domain.AuditEntityTeam, EntityID: "payments"}, "")
+	s.Require().NoError(err)
+	entries, err = s.audit.Query(ctx, *q)
+	s.Require().NoError(err)
+	s.Len(entries, 2)
+
+	q, err = domain.NewAuditQuery(domain.AuditQuery{RequestID: requestID, Action: domain.AuditUserSetIsActive, ActorUserID: "u1"}, "")
+	s.Require().NoError(err)
+	entries, err = s.audit.Query(ctx, *q)
+	s.Require().NoError(err)
+	s.Len(entries, 1)
+
+	q, err = domain.NewAuditQuery(domain.AuditQuery{RequestID: requestID, From: time.Now().Add(time.Hour)}, "")
+	s.Require().NoError(err)
+	entries, err = s.audit.Query(ctx, *q)
+	s.Require().NoError(err)
+	s.Empty(entries)
+
+	// постраничный обход от новых записей к старым
+	var actions []domain.AuditAction
+	cursor := ""
+	for {
+		q, err = domain.NewAuditQuery(domain.AuditQuery{RequestID: requestID, Limit: 2}, cursor)
+		s.Require().NoError(err)
+		entries, err = s.audit.Query(ctx, *q)
+		s.Require().NoError(err)
+		page := domain.NewAuditPage(entries, *q)
+		for _, entry := range page.Items {
+			actions = append(actions, entry.Action)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	s.Equal([]domain.AuditAction{domain.AuditTeamDeactivate, domain.AuditUserSetIsActive, domain.AuditTeamSave}, actions)
+}
+
+// pendingEvents возвращает неопубликованные события в виде "тип агрегат".
+func (s *ContractSuite) pendingEvents() []string {
+	events, err := s.outbox.Pending(context.Background(), 100)
+	s.Require().NoError(err)
+	res := make([]string, 0, len(events))
+	for _, event := range events {
+		res = append(res, string(event.Type)+" "+event.Key())
+	}
+	return res
+}
+
+func (s *ContractSuite) TestOutbox() {
+	ctx := context.Background()
+	pr := s.createPR("pr-1", "u3", "u4", "u5")
+	s.Equal([]string{
+		"pr.created pull_request:pr-1",
+		"pr.reviewer_assigned pull_request:pr-1",
+		"pr.reviewer_assigned pull_request:pr-1",
+	}, s.pendingEvents())
+
+	newReviewer, err := pr.ReassignReviewer(0, []string{"u6"})
+	s.Require().NoError(err)
+	s.Require().NoError(s.prs.ReassignPR(ctx, pr, "u4", newReviewer))
+	pr.SetMergedStatus()
+	s.Require().NoError(s.prs.MergePR(ctx, pr))
+	s.Require().NoError(s.users.SetIsActive(ctx, "u2", false))
+	// повторная деактивация ничего не меняет и событий не пишет
+	s.Require().NoError(s.users.SetIsActive(ctx, "u2", false))
+	_, err = s.teams.DeactivateTeam(ctx, "payments")
+	s.Require().NoError(err)
+
+	// события откатываются вместе с транзакцией изменения
+	errAbort := errors.New("abort")
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		pr, err := domain.NewPullRequest("pr-tx", "rolled back", "u3", []string{"u4"})
+		s.Require().NoError(err)
+		s.Require().NoError(s.prs.CreatePR(ctx, *pr))
+		return errAbort
+	})
+	s.ErrorIs(err, errAbort)
+
+	events, err := s.outbox.Pending(ctx, 100)
+	s.Require().NoError(err)
+	s.Require().Len(events, 9)
+	types := make([]domain.EventType, 0, len(events))
+	for i, event := range events {
+		types = append(types, event.Type)
+		if i > 0 {
+			s.Greater(event.ID, events[i-1].ID)
+		}
+		s.Zero(event.Attempts)
+		s.WithinDuration(time.Now(), event.OccurredAt, time.Minute)
+	}
+	s.ElementsMatch([]domain.EventType{domain.EventReviewerRemoved, domain.EventReviewerAssigned}, types[3:5])
+	s.Equal([]domain.EventType{
+		domain.EventPRMerged, domain.EventUserDeactivated, domain.EventUserDeactivated, domain.EventTeamDeactivated,
+	}, types[5:])
+	s.Equal("user:u2", events[6].Key())
+	s.JSONEq(`{"user_id": "u2", "team_name": "payments"}`, string(events[6].Payload))
+	s.Equal("user:u1", events[7].Key())
+	s.Equal("team:payments", events[8].Key())
+	s.JSONEq(`{"team_name": "payments", "user_ids": ["u1"]}`, string(events[8].Payload))
+
+	s.Require().NoError(s.outbox.MarkFailed(ctx, events[0].ID, "sink unavailable"))
+	s.Require().NoError(s.outbox.MarkPublished(ctx, []int64{events[1].ID, events[2].ID}, time.Now().Add(-time.Hour)))
+	pending, err := s.outbox.Pending(ctx, 2)
+	s.Require().NoError(err)
+	s.Require().Len(pending, 2)
+	s.Equal(events[0].ID, pending[0].ID)
+	s.Equal(1, pending[0].Attempts)
+	s.Equal(events[3].ID, pending[1].ID)
+
+	deleted, err := s.outbox.DeletePublished(ctx, time.Now().Add(-time.Minute))
+	s.Require().NoError(err)
+	s.Equal(int64(2), deleted)
+	s.Len(s.pendingEvents(), 7)
+
+	// событие в dead letter больше не публикуется и не удаляется вместе с опубликованными
+	s.Require().NoError(s.outbox.MarkDead(ctx, events[0].ID, "malformed payload", time.Now().Add(-time.Hour)))
+	pending, err = s.outbox.Pending(ctx, 100)
+	s.Require().NoError(err)
+	s.Require().Len(pending, 6)
+	s.Equal(events[3].ID, pending[0].ID)
+	deleted, err = s.outbox.DeletePublished(ctx, time.Now())
+	s.Require().NoError(err)
+	s.Zero(deleted)
+
+	// аренду держит один relay, пока она не истечёт
+	leased, err := s.outbox.AcquireLease(ctx, "relay-1", time.Minute)
+	s.Require().NoError(err)
+	s.True(leased)
+	leased, err = s.outbox.AcquireLease(ctx, "relay-2", time.Minute)
+	s.Require().NoError(err)
+	s.False(leased)
+	leased, err = s.outbox.AcquireLease(ctx, "relay-1", -time.Minute)
+	s.Require().NoError(err)
+	s.True(leased)
+	leased, err = s.outbox.AcquireLease(ctx, "relay-2", time.Minute)
+	s.Require().NoError(err)
+	s.True(leased)
+}
+
+// createWebhook сохраняет подписку на events.
+func (s *ContractSuite) createWebhook(events ...domain.EventType) domain.WebhookSubscription {
+	subscription, err := domain.NewWebhookSubscription("http://127.0.0.1:9/hooks", events, "contract-secret-0123")
+	s.Require().NoError(err)
+	s.Require().NoError(s.webhooks.CreateSubscription(context.Background(), *subscription))
+	return *subscription
+}
+
+func (s *ContractSuite) TestWebhooks() {
+	ctx := context.Background()
+	merged := s.createWebhook(domain.EventPRMerged, domain.EventReviewerAssigned)
+	all := s.createWebhook()
+
+	subscriptions, err := s.webhooks.ListSubscriptions(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(subscriptions, 2)
+	for _, subscription := range subscriptions {
+		s.Equal("contract-secret-0123", subscription.Secret)
+		if subscription.ID == merged.ID {
+			s.Equal([]domain.EventType{domain.EventPRMerged, domain.EventReviewerAssigned}, subscription.Events)
+		} else {
+			s.Equal(all.ID, subscription.ID)
+			s.Empty(subscription.Events)
+		}
+	}
+
+	event := domain.Event{ID: 1, Type: domain.EventPRMerged, AggregateType: domain.AggregatePullRequest, AggregateID: "pr-1", Payload: []byte(`{}`)}
+	other := domain.Event{ID: 2, Type: domain.EventPRCreated, AggregateType: domain.AggregatePullRequest, AggregateID: "pr-2", Payload: []byte(`{}`)}
+	s.Require().NoError(s.webhooks.Enqueue(ctx,
+		domain.NewWebhookDelivery(merged, event),
+		domain.NewWebhookDelivery(all, event),
+		domain.NewWebhookDelivery(all, other),
+	))
+	// повторная публикация события relay доставки не дублирует
+	s.Require().NoError(s.webhooks.Enqueue(ctx, domain.NewWebhookDelivery(merged, event)))
+
+	q, err := domain.NewWebhookDeliveryQuery(domain.WebhookDeliveryQuery{})
+	s.Require().NoError(err)
+	deliveries, err := s.webhooks.ListDeliveries(ctx, *q)
+	s.Require().NoError(err)
+	s.Require().Len(deliveries, 3)
+	s.Equal(all.ID, deliveries[0].SubscriptionID)
+	s.Equal(int64(2), deliveries[0].EventID)
+	s.Equal(domain.EventPRMerged, deliveries[2].EventType)
+	s.Equal(domain.WebhookPending, deliveries[2].Status)
+	s.JSONEq(string(domain.NewWebhookDelivery(merged, event).Payload), string(deliveries[2].Payload))
+
+	// доставки ещё не подошли по времени
+	now := time.Now()
+	claimed, err := s.webhooks.Claim(ctx, now.Add(-time.Minute), now, 10)
+	s.Require().NoError(err)
+	s.Empty(claimed)
+
+	claimed, err = s.webhooks.Claim(ctx, now.Add(time.Second), now.Add(time.Minute), 2)
+	s.Require().NoError(err)
+	s.Require().Len(claimed, 2)
+	s.Less(claimed[0].ID, claimed[1].ID)
+	s.WithinDuration(now.Add(time.Minute), claimed[0].NextAttemptAt, time.Second)
+	// забранные доставки не отдаются повторно до until
+	claimed2, err := s.webhooks.Claim(ctx, now.Add(time.Second), now.Add(time.Minute), 10)
+	s.Require().NoError(err)
+	s.Require().Len(claimed2, 1)
+	s.Equal(deliveries[0].ID, claimed2[0].ID)
+
+	policy := domain.WebhookRetryPolicy{MaxAttempts: 2, BaseDelay: time.Second, MaxDelay: time.Second}
+	failed := domain.WebhookAttempt{AttemptedAt: now.UTC(), StatusCode: 503, Error: "unexpected status 503", Duration: 120 * time.Millisecond}
+	dead := claimed[0]
+	dead.Record(failed, policy)
+	s.Require().NoError(s.webhooks.RecordAttempt(ctx, dead, failed))
+	dead.Record(failed, policy)
+	s.Require().NoError(s.webhooks.RecordAttempt(ctx, dead, failed))
+	delivered := claimed[1]
+	ok := domain.WebhookAttempt{AttemptedAt: now.UTC(), StatusCode: 204}
+	delivered.Record(ok, policy)
+	s.Require().NoError(s.webhooks.RecordAttempt(ctx, delivered, ok))
+
+	found, err := s.webhooks.FindDelivery(ctx, dead.ID)
+	s.Require().NoError(err)
+	s.Equal(domain.WebhookDead, found.Status)
+	s.Equal(2, found.Attempts)
+	s.Equal("unexpected status 503", found.LastError)
+	s.Nil(found.DeliveredAt)
+	found, err = s.webhooks.FindDelivery(ctx, delivered.ID)
+	s.Require().NoError(err)
+	s.Equal(domain.WebhookDelivered, found.Status)
+	s.Require().NotNil(found.DeliveredAt)
+	s.WithinDuration(now, *found.DeliveredAt, time.Second)
+
+	attempts, err := s.webhooks.ListAttempts(ctx, dead.ID)
+	s.Require().NoError(err)
+	s.Require().Len(attempts, 2)
+	s.Less(attempts[0].ID, attempts[1].ID)
+	s.Equal(dead.ID, attempts[0].DeliveryID)
+	s.Equal(503, attempts[0].StatusCode)
+	s.Equal(120*time.Millisecond, attempts[0].Duration)
+
+	q, err = domain.NewWebhookDeliveryQuery(domain.WebhookDeliveryQuery{Status: domain.WebhookDead})
+	s.Require().NoError(err)
+	deliveries, err = s.webhooks.ListDeliveries(ctx, *q)
+	s.Require().NoError(err)
+	s.Require().Len(deliveries, 1)
+	s.Equal(dead.ID, deliveries[0].ID)
+	q, err = domain.NewWebhookDeliveryQuery(domain.WebhookDeliveryQuery{SubscriptionID: all.ID, Limit: 1})
+	s.Require().NoError(err)
+	deliveries, err = s.webhooks.ListDeliveries(ctx, *q)
+	s.Require().NoError(err)
+	s.Require().Len(deliveries, 1)
+	s.Equal(int64(2), deliveries[0].EventID)
+
+	_, err = s.webhooks.ReplayDelivery(ctx, delivered.ID, now)
+	s.ErrorIs(err, domain.ErrWebhookDeliveryNotFailed)
+	_, err = s.webhooks.ReplayDelivery(ctx, 1_000_000, now)
+	s.ErrorIs(err, domain.ErrWebhookDeliveryNotFound)
+	replayed, err := s.webhooks.ReplayDelivery(ctx, dead.ID, now)
+	s.Require().NoError(err)
+	s.Equal(domain.WebhookPending, replayed.Status)
+	s.Zero(replayed.Attempts)
+	claimed, err = s.webhooks.Claim(ctx, now.Add(time.Second), now.Add(time.Minute), 10)
+	s.Require().NoError(err)
+	s.Require().Len(claimed, 1)
+	s.Equal(dead.ID, claimed[0].ID)
+	// история попыток после повтора сохраняется
+	attempts, err = s.webhooks.ListAttempts(ctx, dead.ID)
+	s.Require().NoError(err)
+	s.Len(attempts, 2)
+
+	// подписка удаляется вместе с доставками и попытками
+	s.Require().NoError(s.webhooks.DeleteSubscription(ctx, merged.ID))
+	s.ErrorIs(s.webhooks.DeleteSubscription(ctx, merged.ID), domain.ErrWebhookNotFound)
+	_, err = s.webhooks.FindDelivery(ctx, dead.ID)
+	s.ErrorIs(err, domain.ErrWebhookDeliveryNotFound)
+	attempts, err = s.webhooks.ListAttempts(ctx, dead.ID)
+	s.Require().NoError(err)
+	s.Empty(attempts)
+	// попытка доставки удалённой подписки молча отбрасывается
+	s.Require().NoError(s.webhooks.RecordAttempt(ctx, dead, failed))
+	subscriptions, err = s.webhooks.ListSubscriptions(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(subscriptions, 1)
+	s.Equal(all.ID, subscriptions[0].ID)
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/clients/sqlite"
+	"avito-tech-go-task/internal/infrastructure/health"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"avito-tech-go-task/internal/infrastructure/migrate"
+	"avito-tech-go-task/internal/infrastructure/scheduler"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, checker *health.Checker, path string) (int, model.HealthResponse) {
+	t.Helper()
+	r := gin.New()
+	r.GET("/healthz", checker.LivenessHandler)
+	r.GET("/readyz", checker.ReadinessHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var resp model.HealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestHealthSQLite(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.DiscardHandler)
+
+	client, err := sqlite.Connect(sqlite.Scheme + filepath.Join(t.TempDir(), "pr_reviewer.db"))
+	require.NoError(t, err)
+	defer client.Close()
+
+	migrator, err := migrate.New(migrate.DialectSQLite, client.DB(), logger)
+	require.NoError(t, err)
+
+	checker := health.NewChecker(logger,
+		health.Check{Name: "database", Check: client.DB().PingContext},
+		health.Check{Name: "migrations", Check: migrator.CheckVersion},
+	)
+
+	code, resp := probe(t, checker, "/healthz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, health.StatusOK, resp.Status)
+
+	// миграции не применены
+	code, resp = probe(t, checker, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, health.StatusUnavailable, resp.Status)
+	require.Equal(t, health.StatusOK, resp.Checks["database"])
+	require.Contains(t, resp.Checks["migrations"], "migrate up")
+
+	require.NoError(t, migrator.Up(ctx))
+	code, resp = probe(t, checker, "/readyz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, map[string]string{"database": health.StatusOK, "migrations": health.StatusOK}, resp.Checks)
+
+	// при остановке сервис жив, но не принимает трафик
+	checker.Drain()
+	code, _ = probe(t, checker, "/healthz")
+	require.Equal(t, http.StatusOK, code)
+	code, resp = probe(t, checker, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Contains(t, resp.Checks, "server")
+
+	require.NoError(t, client.Close())
+	checker = health.NewChecker(logger, health.Check{Name: "database", Check: client.DB().PingContext})
+	code, resp = probe(t, checker, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.NotEqual(t, health.StatusOK, resp.Checks["database"])
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.DiscardHandler)
+	errDown := errors.New("connection refused")
+
+	attempts := 0
+	err := scheduler.Retry(ctx, 5*time.Second, "connect", func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errDown
+		}
+		return nil
+	}, logger)
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+
+	// без таймаута выполняется одна попытка
+	attempts = 0
+	err = scheduler.Retry(ctx, 0, "connect", func(context.Context) error {
+		attempts++
+		return errDown
+	}, logger)
+	require.ErrorIs(t, err, errDown)
+	require.Equal(t, 1, attempts)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = scheduler.Retry(canceled, time.Minute, "connect", func(context.Context) error {
+		return errDown
+	}, logger)
+	require.ErrorIs(t, err, context.Canceled)
+}
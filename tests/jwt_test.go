@@ -0,0 +1,287 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/auth"
+	"avito-tech-go-task/internal/infrastructure/http/controller"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ssoKeys - ключи заглушки SSO: RSA с kid rsa-1 и EC P-256 с kid ec-1.
+type ssoKeys struct {
+	rsa  *rsa.PrivateKey
+	ec   *ecdsa.PrivateKey
+	jwks []byte
+}
+
+func newSSOKeys(t *testing.T) ssoKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	point, err := ecKey.PublicKey.Bytes()
+	require.NoError(t, err)
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(point[1:33]), "y": b64(point[33:])},
+	}})
+	require.NoError(t, err)
+
+	return ssoKeys{rsa: rsaKey, ec: ecKey, jwks: jwks}
+}
+
+// sign подписывает claims ключом SSO: RS256 для rsa-1, ES256 для ec-1.
+func (k ssoKeys) sign(t *testing.T, kid string, claims map[string]any) string {
+	t.Helper()
+	alg := "RS256"
+	if kid == "ec-1" {
+		alg = "ES256"
+	}
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	if alg == "RS256" {
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	} else {
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func jwtConfig() auth.JWTConfig {
+	return auth.JWTConfig{
+		Issuer:        "https://sso.local",
+		Audience:      "pr-service",
+		UserClaim:     "sub",
+		RoleClaim:     "role",
+		ScopeClaim:    "scope",
+		DefaultScopes: []domain.Scope{domain.ScopeStatsRead},
+		Leeway:        time.Second,
+	}
+}
+
+func claims(userID string, extra map[string]any) map[string]any {
+	c := map[string]any{
+		"iss": "https://sso.local",
+		"aud": []string{"pr-service", "dashboards"},
+		"sub": userID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for key, value := range extra {
+		c[key] = value
+	}
+	return c
+}
+
+func TestJWTVerify(t *testing.T) {
+	keys := newSSOKeys(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, keys.jwks, 0o600))
+	verifier := auth.NewJWTVerifier(auth.NewKeySet(path, time.Hour, slog.New(slog.DiscardHandler)), jwtConfig())
+
+	other := newSSOKeys(t)
+	expired := keys.sign(t, "rsa-1", claims("u1", map[string]any{"exp": time.Now().Add(-time.Minute).Unix()}))
+	parts := strings.Split(keys.sign(t, "rsa-1", claims("u1", nil)), ".")
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+
+	tests := []struct {
+		name    string
+		token   string
+		want    domain.Actor
+		wantErr string
+	}{
+		{
+			name:  "rsa with scopes and role",
+			token: keys.sign(t, "rsa-1", claims("u1", map[string]any{"scope": "openid prs:write teams:write", "role": "team_lead"})),
+			want:  domain.Actor{TokenID: "jwt:u1", UserID: "u1", Role: domain.RoleTeamLead, Scopes: []domain.Scope{domain.ScopePRsWrite, domain.ScopeTeamsWrite}},
+		},
+		{
+			name:  "ec with default scopes",
+			token: keys.sign(t, "ec-1", claims("u2", nil)),
+			want:  domain.Actor{TokenID: "jwt:u2", UserID: "u2", Scopes: []domain.Scope{domain.ScopeStatsRead}},
+		},
+		{name: "expired", token: expired, wantErr: "token expired"},
+		{name: "not valid yet", token: keys.sign(t, "rsa-1", claims("u1", map[string]any{"nbf": time.Now().Add(time.Hour).Unix()})), wantErr: "not valid yet"},
+		{name: "other issuer", token: keys.sign(t, "rsa-1", claims("u1", map[string]any{"iss": "https://evil.local"})), wantErr: "issuer"},
+		{name: "other audience", token: keys.sign(t, "rsa-1", claims("u1", map[string]any{"aud": "billing"})), wantErr: "audience"},
+		{name: "signed by other key", token: other.sign(t, "rsa-1", claims("u1", nil)), wantErr: "invalid signature"},
+		{name: "alg none", token: unsigned, wantErr: "unsupported alg"},
+		{name: "unknown kid", token: other.sign(t, "rsa-2", claims("u1", nil)), wantErr: "kid"},
+		{name: "no user", token: keys.sign(t, "rsa-1", claims("", nil)), wantErr: "sub is required"},
+		{name: "unknown role", token: keys.sign(t, "rsa-1", claims("u1", map[string]any{"role": "owner"})), wantErr: "role"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actor, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, domain.ErrTokenInvalid)
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, actor)
+		})
+	}
+}
+
+func TestJWTIdentity(t *testing.T) {
+	keys := newSSOKeys(t)
+	sso := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(keys.jwks)
+	}))
+	t.Cleanup(sso.Close)
+	verifier := auth.NewJWTVerifier(auth.NewKeySet(sso.URL, time.Hour, slog.New(slog.DiscardHandler)), jwtConfig())
+	r, client := authRouter(t, verifier)
+
+	w := doJSON(r, http.MethodPost, "/teams/add", model.AddTeamRequest{
+		TeamName: "payments",
+		Members: []model.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+		},
+	}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	bob := keys.sign(t, "ec-1", claims("u2", map[string]any{"scope": "prs:write teams:write"}))
+
+	// автор PR берётся из JWT, а не из тела запроса
+	w = doJSON(r, http.MethodPost, "/pullRequests/create", model.CreatePullRequestRequest{
+		PullRequestID: "pr-1", PullRequestName: "Add search",
+	}, withToken(bob))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var created model.CreatePullRequestResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.Equal(t, "u2", created.PR.AuthorID)
+
+	w = doJSON(r, http.MethodPost, "/pullRequests/create", model.CreatePullRequestRequest{
+		PullRequestID: "pr-2", PullRequestName: "Add filters", AuthorID: "u1",
+	}, withToken(bob))
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, controller.CodeForbidden, errorCode(t, w))
+
+	// изменение помечается пользователем из JWT
+	var updatedBy string
+	rows, err := client.Query(context.Background(), "SELECT updated_by FROM pull_requests WHERE id = 'pr-1'")
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&updatedBy))
+	rows.Close()
+	require.Equal(t, "jwt:u2", updatedBy)
+
+	// роль member из хранилища не даёт менять чужой флаг, team_lead из JWT - даёт
+	w = doJSON(r, http.MethodPost, "/users/setIsActive", model.SetIsActiveUserRequest{UserID: "u1", IsActive: true}, withToken(bob))
+	require.Equal(t, http.StatusForbidden, w.Code)
+	lead := keys.sign(t, "ec-1", claims("u2", map[string]any{"scope": "teams:write", "role": "team_lead"}))
+	w = doJSON(r, http.MethodPost, "/users/setIsActive", model.SetIsActiveUserRequest{UserID: "u1", IsActive: true}, withToken(lead))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(r, http.MethodPost, "/pullRequests/create", model.CreatePullRequestRequest{
+		PullRequestID: "pr-3", PullRequestName: "Add sorting",
+	}, withToken(keys.sign(t, "ec-1", claims("u2", nil))))
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, auth.CodeForbidden, errorCode(t, w))
+
+	expired := keys.sign(t, "rsa-1", claims("u2", map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}))
+	w = doJSON(r, http.MethodGet, "/teams/get?team_name=payments", nil, withToken(expired))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Header().Get("WWW-Authenticate"), "invalid_token")
+}
+
+func TestKeySetConcurrentRefresh(t *testing.T) {
+	keys := newSSOKeys(t)
+	var fetches atomic.Int32
+	// пока gate не закрыт, заглушка SSO отвечает, только получив значение из gate
+	gate := make(chan struct{})
+	started := make(chan struct{}, 16)
+	sso := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		started <- struct{}{}
+		<-gate
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(keys.jwks)
+	}))
+	t.Cleanup(sso.Close)
+	release := sync.OnceFunc(func() { close(gate) })
+	t.Cleanup(release)
+	set := auth.NewKeySet(sso.URL, time.Hour, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+
+	// одновременные запросы до первой загрузки ждут одну загрузку
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Go(func() {
+			_, err := set.Key(ctx, "rsa-1")
+			errs <- err
+		})
+	}
+	<-started
+	gate <- struct{}{}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(1), fetches.Load())
+
+	// во время загрузки известные ключи отдаются без ожидания SSO
+	refreshed := make(chan error, 1)
+	go func() { refreshed <- set.Refresh(ctx) }()
+	<-started
+	found := make(chan error, 1)
+	go func() {
+		_, err := set.Key(ctx, "ec-1")
+		found <- err
+	}()
+	select {
+	case err := <-found:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Key waits for the JWKS fetch")
+	}
+
+	// отменённое ожидание не прерывает загрузку, которую ждут другие
+	waitCtx, cancelWait := context.WithCancel(ctx)
+	cancelWait()
+	require.ErrorIs(t, set.Refresh(waitCtx), context.Canceled)
+
+	release()
+	require.NoError(t, <-refreshed)
+	require.Equal(t, int32(2), fetches.Load())
+}
@@ -3,13 +3,20 @@ package tests
 import (
 	"avito-tech-go-task/internal/application/service"
 	"avito-tech-go-task/internal/clients/postgres"
+	"avito-tech-go-task/internal/clients/sqlite"
+	"avito-tech-go-task/internal/domain"
 	"avito-tech-go-task/internal/infrastructure/http/controller"
+	"avito-tech-go-task/internal/infrastructure/migrate"
 	"avito-tech-go-task/internal/infrastructure/storage"
+	sqliterepo "avito-tech-go-task/internal/infrastructure/storage/sqlite"
 	"context"
 	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -17,9 +24,19 @@ var (
 	dbDSN string
 )
 
+// sqlClient - клиент БД (postgres.Client или sqlite.Client), на котором запускаются интеграционные тесты.
+type sqlClient interface {
+	storage.SQLClient
+	Close() error
+}
+
 type TestSuite struct {
 	suite.Suite
-	db *postgres.Client
+	db sqlClient
+	backend
+	// connect подключается к БД с применёнными миграциями и собирает репозитории поверх неё
+	connect   func() (sqlClient, backend, error)
+	prService *service.PRService
 	*controller.ApiService
 }
 
@@ -31,15 +48,22 @@ func TestSuiteFunc(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
 	}
-	suite.Run(t, new(TestSuite))
+	suite.Run(t, &TestSuite{connect: connectPostgres})
+}
+
+func TestSQLiteSuite(t *testing.T) {
+	suite.Run(t, &TestSuite{connect: func() (sqlClient, backend, error) {
+		return connectSQLite(t)
+	}})
 }
 
 func (s *TestSuite) SetupSuite() {
-	db, err := postgres.Connect(dbDSN)
+	db, backend, err := s.connect()
 	if err != nil {
 		s.FailNow("failed to connect", err)
 	}
 	s.db = db
+	s.backend = backend
 	s.initDeps()
 	if err = populateDB(s.db); err != nil {
 		s.FailNow("failed to populate db", err)
@@ -51,21 +75,79 @@ func (s *TestSuite) TearDownSuite() {
 }
 
 func (s *TestSuite) initDeps() {
-	team := storage.NewTeamRepo(s.db)
-	user := storage.NewUserRepo(s.db)
-	pr := storage.NewPRRepo(s.db)
-	prService := service.NewPRService(pr, user, team)
-	s.ApiService = controller.NewApiService(prService)
+	logger := slog.New(slog.DiscardHandler)
+	s.prService = service.NewPRService(s.prs, s.users, s.teams, s.audit, s.tx, domain.ReviewersMaxCount, logger)
+	s.ApiService = controller.NewApiService(s.prService, logger)
+}
+
+func connectPostgres() (sqlClient, backend, error) {
+	pg, err := postgres.Connect(dbDSN)
+	if err != nil {
+		return nil, backend{}, err
+	}
+
+	return pg, newPostgresBackend(pg), nil
+}
+
+// connectSQLite создаёт базу SQLite во временном каталоге теста и применяет к ней встроенные миграции.
+func connectSQLite(t *testing.T) (*sqlite.Client, backend, error) {
+	client, err := sqlite.Connect(sqlite.Scheme + filepath.Join(t.TempDir(), "pr_reviewer.db"))
+	if err != nil {
+		return nil, backend{}, err
+	}
+
+	migrator, err := migrate.New(migrate.DialectSQLite, client.DB(), slog.New(slog.DiscardHandler))
+	if err != nil {
+		return nil, backend{}, err
+	}
+	if err = migrator.Up(context.Background()); err != nil {
+		return nil, backend{}, err
+	}
+
+	return client, newSQLiteBackend(client), nil
+}
+
+func newPostgresBackend(client storage.SQLClient) backend {
+	logger := slog.New(slog.DiscardHandler)
+	db := storage.NewTracedDB(client)
+	return backend{
+		teams:       storage.NewTeamRepo(db, logger),
+		prs:         storage.NewPRRepo(db, logger),
+		users:       storage.NewUserRepo(db, logger),
+		tx:          storage.NewTxManager(db, logger),
+		idempotency: storage.NewIdempotencyRepo(db, logger),
+		tokens:      storage.NewTokenRepo(db, logger),
+		audit:       storage.NewAuditRepo(db, logger),
+		outbox:      storage.NewOutboxRepo(db, logger),
+		webhooks:    storage.NewWebhookRepo(db, logger),
+	}
+}
+
+func newSQLiteBackend(client storage.SQLClient) backend {
+	logger := slog.New(slog.DiscardHandler)
+	db := storage.NewTracedDB(client)
+	return backend{
+		teams:       sqliterepo.NewTeamRepo(db, logger),
+		prs:         sqliterepo.NewPRRepo(db, logger),
+		users:       sqliterepo.NewUserRepo(db, logger),
+		tx:          storage.NewTxManager(db, logger),
+		idempotency: sqliterepo.NewIdempotencyRepo(db, logger),
+		tokens:      sqliterepo.NewTokenRepo(db, logger),
+		audit:       sqliterepo.NewAuditRepo(db, logger),
+		outbox:      sqliterepo.NewOutboxRepo(db, logger),
+		webhooks:    sqliterepo.NewWebhookRepo(db, logger),
+	}
 }
 
 func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
 	rc := m.Run()
 	os.Exit(rc)
 }
 
 // очистить таблицу
-func truncateTable(db *postgres.Client, tableName string) error {
-	sqlStatement := `TRUNCATE TABLE ` + tableName
+func truncateTable(db sqlClient, tableName string) error {
+	sqlStatement := `DELETE FROM ` + tableName
 	_, err := db.Exec(context.Background(), sqlStatement)
 	if err != nil {
 		return err
@@ -74,7 +156,7 @@ func truncateTable(db *postgres.Client, tableName string) error {
 }
 
 // заполение бд тестовыми данными
-func populateDB(db *postgres.Client) (err error) {
+func populateDB(db sqlClient) (err error) {
 	cleanDB(db)
 	//teamRepo := storage.NewTeamRepo(db)
 	//userRepo := storage.NewUserRepo(db)
@@ -90,7 +172,7 @@ func populateDB(db *postgres.Client) (err error) {
 }
 
 // очистить бд
-func cleanDB(db *postgres.Client) {
+func cleanDB(db sqlClient) {
 	err := truncateTable(db, "users")
 	if err != nil {
 		log.Print("failed to truncate users", err)
@@ -110,4 +192,44 @@ func cleanDB(db *postgres.Client) {
 	if err != nil {
 		log.Print("failed to truncate teams", err)
 	}
+
+	err = truncateTable(db, "review_events")
+	if err != nil {
+		log.Print("failed to truncate review_events", err)
+	}
+
+	err = truncateTable(db, "idempotency_keys")
+	if err != nil {
+		log.Print("failed to truncate idempotency_keys", err)
+	}
+
+	err = truncateTable(db, "api_tokens")
+	if err != nil {
+		log.Print("failed to truncate api_tokens", err)
+	}
+
+	err = truncateTable(db, "outbox_events")
+	if err != nil {
+		log.Print("failed to truncate outbox_events", err)
+	}
+
+	err = truncateTable(db, "outbox_relay_lease")
+	if err != nil {
+		log.Print("failed to truncate outbox_relay_lease", err)
+	}
+
+	err = truncateTable(db, "webhook_attempts")
+	if err != nil {
+		log.Print("failed to truncate webhook_attempts", err)
+	}
+
+	err = truncateTable(db, "webhook_deliveries")
+	if err != nil {
+		log.Print("failed to truncate webhook_deliveries", err)
+	}
+
+	err = truncateTable(db, "webhook_subscriptions")
+	if err != nil {
+		log.Print("failed to truncate webhook_subscriptions", err)
+	}
 }
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/clients/sqlite"
+	"avito-tech-go-task/internal/infrastructure/migrate"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateSQLite(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := sqlite.Connect(sqlite.Scheme + filepath.Join(t.TempDir(), "pr_reviewer.db"))
+	require.NoError(t, err)
+	defer client.Close()
+
+	migrator, err := migrate.New(migrate.DialectSQLite, client.DB(), slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	current, target, err := migrator.Versions(ctx)
+	require.NoError(t, err)
+	require.Zero(t, current)
+	require.NotZero(t, target)
+
+	require.NoError(t, migrator.Up(ctx))
+	current, _, err = migrator.Versions(ctx)
+	require.NoError(t, err)
+	require.Equal(t, target, current)
+
+	// повторный запуск ничего не применяет
+	require.NoError(t, migrator.Up(ctx))
+
+	require.NoError(t, migrator.Redo(ctx))
+	_, err = client.Exec(ctx, "INSERT INTO teams (name) VALUES ('after-redo')")
+	require.NoError(t, err)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, statuses)
+	for _, status := range statuses {
+		require.False(t, status.AppliedAt.IsZero(), status.Source.Path)
+	}
+
+	for current > 0 {
+		require.NoError(t, migrator.Down(ctx))
+		current, _, err = migrator.Versions(ctx)
+		require.NoError(t, err)
+	}
+	_, err = client.Exec(ctx, "SELECT 1 FROM teams")
+	require.Error(t, err)
+}
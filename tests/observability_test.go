@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/application/service"
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/auth"
+	"avito-tech-go-task/internal/infrastructure/http/controller"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"avito-tech-go-task/internal/infrastructure/logging"
+	"avito-tech-go-task/internal/infrastructure/metrics"
+	"avito-tech-go-task/internal/infrastructure/tracing"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanExporter - глобальный TracerProvider пакета тестов. Tracer'ы сервиса и репозиториев берутся из otel
+// один раз и после первой установки provider'а не переключаются, поэтому он устанавливается только здесь.
+var spanExporter = sync.OnceValue(func() *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return exporter
+})
+
+// observedRouter собирает цепочку middleware и /metrics так же, как cmd/main.go с выключенной аутентификацией.
+func observedRouter(t *testing.T, logger *slog.Logger) *gin.Engine {
+	t.Helper()
+	client, b, err := connectSQLite(t)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	prService := service.NewPRService(b.prs, b.users, b.teams, b.audit, b.tx, domain.ReviewersMaxCount, logger)
+	c := controller.NewApiService(prService, logger)
+
+	registry := prometheus.NewRegistry()
+	metrics.Register(registry)
+
+	r := gin.New()
+	r.Use(gin.Recovery(), metrics.GinMiddleware(), tracing.GinMiddleware(), logging.GinMiddleware(logger), auth.System())
+	r.POST("/teams/add", c.AddTeamHandler)
+	r.POST("/pullRequests/create", c.CreatePullRequestHandler)
+	r.GET("/metrics", gin.WrapH(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+	return r
+}
+
+// scrape возвращает значения рядов со страницы /metrics по строке ряда с метками.
+func scrape(t *testing.T, r http.Handler) map[string]float64 {
+	t.Helper()
+	w := doJSON(r, http.MethodGet, "/metrics", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	series := map[string]float64{}
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		require.NoError(t, err, line)
+		series[line[:i]] = value
+	}
+	require.NoError(t, scanner.Err())
+	return series
+}
+
+func TestMetricsScrape(t *testing.T) {
+	r := observedRouter(t, slog.New(slog.DiscardHandler))
+
+	const (
+		created  = `pr_reviewer_assignments_total{operation="create",outcome="partial"}`
+		requests = `pr_reviewer_http_request_duration_seconds_count{method="POST",route="/pullRequests/create",status="200"}`
+		conflict = `pr_reviewer_http_request_duration_seconds_count{method="POST",route="/pullRequests/create",status="409"}`
+		notFound = `pr_reviewer_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"}`
+		query    = `pr_reviewer_db_query_duration_seconds_count{method="CreatePR",repository="PRRepo"}`
+	)
+	// счётчики глобальные, поэтому сравниваются приращения
+	before := scrape(t, r)
+
+	w := doJSON(r, http.MethodPost, "/teams/add", model.AddTeamRequest{
+		TeamName: "payments",
+		Members: []model.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	pr := model.CreatePullRequestRequest{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1"}
+	w = doJSON(r, http.MethodPost, "/pullRequests/create", pr)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, http.MethodPost, "/pullRequests/create", pr)
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	// путь запроса не попадает в метку route
+	w = doJSON(r, http.MethodGet, "/pullRequests/pr-1", nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	after := scrape(t, r)
+	// у автора один товарищ по команде из двух нужных ревьюеров
+	require.Equal(t, 1.0, after[created]-before[created])
+	require.Equal(t, 1.0, after[requests]-before[requests])
+	require.Equal(t, 1.0, after[conflict]-before[conflict])
+	require.Equal(t, 1.0, after[notFound]-before[notFound])
+	require.Equal(t, 1.0, after[query]-before[query])
+	for line := range after {
+		require.NotContains(t, line, "pr-1")
+	}
+}
+
+func TestTracingSpanTree(t *testing.T) {
+	exporter := spanExporter()
+	r := observedRouter(t, slog.New(slog.DiscardHandler))
+
+	w := doJSON(r, http.MethodPost, "/teams/add", model.AddTeamRequest{
+		TeamName: "payments",
+		Members: []model.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// запрос продолжает trace вызывающего сервиса из заголовка traceparent
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		caller  = "00f067aa0ba902b7"
+	)
+	w = doJSON(r, http.MethodPost, "/pullRequests/create",
+		model.CreatePullRequestRequest{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1"},
+		withHeader("traceparent", "00-"+traceID+"-"+caller+"-01"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	spans := map[string][]tracetest.SpanStub{}
+	byID := map[trace.SpanID]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		if span.SpanContext.TraceID().String() != traceID {
+			continue
+		}
+		spans[span.Name] = append(spans[span.Name], span)
+		byID[span.SpanContext.SpanID()] = span
+	}
+
+	require.Len(t, spans["POST /pullRequests/create"], 1)
+	server := spans["POST /pullRequests/create"][0]
+	require.Equal(t, trace.SpanKindServer, server.SpanKind)
+	require.Equal(t, caller, server.Parent.SpanID().String())
+	require.True(t, server.Parent.IsRemote())
+
+	require.Len(t, spans["PRService.CreatePR"], 1)
+	svc := spans["PRService.CreatePR"][0]
+	require.Equal(t, server.SpanContext.SpanID(), svc.Parent.SpanID())
+
+	// каждый SQL запрос - дочерний span сервиса, в том числе выполненный внутри транзакции
+	require.NotEmpty(t, spans["PRRepo.CreatePR INSERT"])
+	require.NotEmpty(t, spans["UserRepo.FindActiveUserIDsByTeamExcludeAuthor SELECT"])
+	require.NotEmpty(t, spans["AuditRepo.Append INSERT"])
+	for _, span := range byID {
+		if span.SpanContext.SpanID() == server.SpanContext.SpanID() || span.SpanContext.SpanID() == svc.SpanContext.SpanID() {
+			continue
+		}
+		require.Equal(t, trace.SpanKindClient, span.SpanKind, span.Name)
+		require.Equal(t, svc.SpanContext.SpanID(), span.Parent.SpanID(), span.Name)
+	}
+}
+
+// logRecords разбирает JSON записи лога, по одной на строку.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record), line)
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info")
+	require.NoError(t, err)
+	r := observedRouter(t, logger)
+
+	// входящий request ID возвращается в ответе и попадает в каждую запись лога запроса
+	w := doJSON(r, http.MethodPost, "/teams/add", model.AddTeamRequest{
+		TeamName: "payments",
+		Members:  []model.TeamMember{{UserID: "u1", Username: "Alice", IsActive: true}},
+	}, withRequestID("req-42"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "req-42", w.Header().Get(logging.RequestIDHeader))
+
+	records := logRecords(t, &buf)
+	require.NotEmpty(t, records)
+	var logged []string
+	for _, record := range records {
+		require.Equal(t, "req-42", record["request_id"], record)
+		require.Equal(t, "/teams/add", record["route"], record)
+		logged = append(logged, record["msg"].(string))
+	}
+	require.Contains(t, logged, "http request")
+	last := records[len(records)-1]
+	require.Equal(t, "http request", last["msg"])
+	require.Equal(t, float64(http.StatusOK), last["status"])
+
+	// без заголовка и со слишком длинным ID генерируется новый
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	for _, requestID := range []string{"", strings.Repeat("x", 129)} {
+		buf.Reset()
+		w = doJSON(r, http.MethodPost, "/teams/add", model.AddTeamRequest{TeamName: "payments"}, withRequestID(requestID))
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		got := w.Header().Get(logging.RequestIDHeader)
+		require.Regexp(t, generated, got)
+		records = logRecords(t, &buf)
+		require.Len(t, records, 1)
+		require.Equal(t, got, records[0]["request_id"])
+		require.Equal(t, "WARN", records[0]["level"])
+	}
+}
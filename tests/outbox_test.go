@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/application/service"
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"avito-tech-go-task/internal/infrastructure/metrics"
+	"avito-tech-go-task/internal/infrastructure/outbox"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink запоминает опубликованные события и отказывает в публикации событий агрегатов из failing
+// и событий из poisoned ("тип агрегат").
+type recordingSink struct {
+	name      string
+	failing   map[string]bool
+	poisoned  map[string]bool
+	published []string
+}
+
+func (s *recordingSink) Name() string {
+	return s.name
+}
+
+func (s *recordingSink) Publish(_ context.Context, event domain.Event) error {
+	if s.failing[event.Key()] {
+		return errors.New("sink unavailable")
+	}
+	if s.poisoned[string(event.Type)+" "+event.Key()] {
+		return errors.New("malformed payload")
+	}
+	s.published = append(s.published, string(event.Type)+" "+event.Key())
+	return nil
+}
+
+// outboxPublishes возвращает значение outbox_publish_total для sink и результата.
+func outboxPublishes(t *testing.T, sink string, outcome domain.EventPublishOutcome) float64 {
+	return counterValue(t, "pr_reviewer_outbox_publish_total", map[string]string{"sink": sink, "outcome": string(outcome)})
+}
+
+// counterValue возвращает значение счётчика name с метками want.
+func counterValue(t *testing.T, name string, want map[string]string) float64 {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	metrics.Register(reg)
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			for k, v := range want {
+				if labels[k] != v {
+					continue metrics
+				}
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestOutboxRelay(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	ctx := asSystem()
+
+	b := newMemoryBackend(logger)
+	repo := b.outbox
+	s := service.NewPRService(b.prs, b.users, b.teams, b.audit, b.tx, 1, logger)
+
+	require.NoError(t, s.AddTeam(ctx, "payments", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	}))
+	_, err := s.CreatePR(ctx, "pr-1", "Add search", "u1")
+	require.NoError(t, err)
+	_, err = s.CreatePR(ctx, "pr-2", "Fix login", "u2")
+	require.NoError(t, err)
+	_, err = s.MergePR(ctx, "pr-1", 0)
+	require.NoError(t, err)
+
+	audit := &recordingSink{name: "test_audit"}
+	chat := &recordingSink{name: "test_chat", failing: map[string]bool{"pull_request:pr-1": true}}
+	relay := outbox.NewRelay(repo, []outbox.Sink{audit, chat}, 100, 10, time.Minute, logger)
+
+	// pr-1 не доставлен в chat: его события ждут, события pr-2 публикуются
+	n, err := relay.Relay(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []string{
+		"pr.created pull_request:pr-2",
+		"pr.reviewer_assigned pull_request:pr-2",
+	}, chat.published)
+	require.Equal(t, float64(1), outboxPublishes(t, "test_chat", domain.EventFailed))
+	require.Equal(t, float64(3), outboxPublishes(t, "test_audit", domain.EventPublished))
+
+	pending, err := repo.Pending(ctx, 100)
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+	require.Equal(t, 1, pending[0].Attempts)
+	require.Zero(t, pending[1].Attempts)
+
+	// другой экземпляр не публикует, пока аренда у первого
+	other := outbox.NewRelay(repo, []outbox.Sink{audit}, 100, 10, time.Minute, logger)
+	n, err = other.Relay(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	// после восстановления sink события pr-1 доставляются по порядку, audit получает первое повторно
+	chat.failing = nil
+	n, err = relay.Relay(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []string{
+		"pr.created pull_request:pr-2",
+		"pr.reviewer_assigned pull_request:pr-2",
+		"pr.created pull_request:pr-1",
+		"pr.reviewer_assigned pull_request:pr-1",
+		"pr.merged pull_request:pr-1",
+	}, chat.published)
+	require.Len(t, audit.published, 6)
+	require.Equal(t, audit.published[0], audit.published[3])
+
+	n, err = relay.Relay(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestOutboxRelayDeadLetter(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	ctx := asSystem()
+
+	b := newMemoryBackend(logger)
+	repo := b.outbox
+	s := service.NewPRService(b.prs, b.users, b.teams, b.audit, b.tx, 1, logger)
+
+	require.NoError(t, s.AddTeam(ctx, "payments", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	}))
+	_, err := s.CreatePR(ctx, "pr-1", "Add search", "u1")
+	require.NoError(t, err)
+	_, err = s.MergePR(ctx, "pr-1", 0)
+	require.NoError(t, err)
+
+	// sink никогда не примет создание pr-1: без dead letter остальные события pr-1 ждали бы вечно
+	chat := &recordingSink{name: "test_poisoned", poisoned: map[string]bool{"pr.created pull_request:pr-1": true}}
+	relay := outbox.NewRelay(repo, []outbox.Sink{chat}, 100, 3, time.Minute, logger)
+	deadBefore := counterValue(t, "pr_reviewer_outbox_dead_total", map[string]string{"event_type": string(domain.EventPRCreated)})
+
+	for range 2 {
+		n, err := relay.Relay(ctx)
+		require.NoError(t, err)
+		require.Zero(t, n)
+	}
+	pending, err := repo.Pending(ctx, 100)
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+	require.Equal(t, 2, pending[0].Attempts)
+
+	// третья неудача переводит событие в dead letter, следующие события агрегата публикуются в том же проходе
+	n, err := relay.Relay(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []string{"pr.reviewer_assigned pull_request:pr-1", "pr.merged pull_request:pr-1"}, chat.published)
+	require.Equal(t, deadBefore+1, counterValue(t, "pr_reviewer_outbox_dead_total", map[string]string{"event_type": string(domain.EventPRCreated)}))
+
+	pending, err = repo.Pending(ctx, 100)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+	n, err = relay.Relay(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
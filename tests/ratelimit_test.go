@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/clients/postgres"
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/metrics"
+	"avito-tech-go-task/internal/infrastructure/ratelimit"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateBucket(t *testing.T) {
+	limit := domain.RateLimit{Rate: 2, Burst: 3}
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		bucket domain.RateBucket
+		at     time.Time
+		want   domain.RateDecision
+	}{
+		{
+			name:   "new bucket allows burst",
+			bucket: domain.NewRateBucket(limit, now),
+			at:     now,
+			want:   domain.RateDecision{Allowed: true, Remaining: 2},
+		},
+		{
+			name:   "empty bucket",
+			bucket: domain.RateBucket{Tokens: 0, UpdatedAt: now},
+			at:     now,
+			want:   domain.RateDecision{RetryAfter: 500 * time.Millisecond},
+		},
+		{
+			name:   "partly refilled",
+			bucket: domain.RateBucket{Tokens: 0.5, UpdatedAt: now},
+			at:     now.Add(100 * time.Millisecond),
+			want:   domain.RateDecision{RetryAfter: 150 * time.Millisecond},
+		},
+		{
+			name:   "refill is capped by burst",
+			bucket: domain.RateBucket{Tokens: 0, UpdatedAt: now},
+			at:     now.Add(time.Hour),
+			want:   domain.RateDecision{Allowed: true, Remaining: 2},
+		},
+		{
+			name:   "bucket from the future is not refilled",
+			bucket: domain.RateBucket{Tokens: 0.5, UpdatedAt: now.Add(time.Second)},
+			at:     now,
+			want:   domain.RateDecision{RetryAfter: 250 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, decision := tt.bucket.Take(limit, tt.at)
+			require.Equal(t, tt.want.Allowed, decision.Allowed)
+			require.Equal(t, tt.want.Remaining, decision.Remaining)
+			require.InDelta(t, tt.want.RetryAfter, decision.RetryAfter, float64(time.Millisecond))
+			require.Equal(t, tt.at, bucket.UpdatedAt)
+		})
+	}
+
+	require.True(t, domain.RateBucket{Tokens: 1, UpdatedAt: now}.IsFull(limit, now.Add(time.Second)))
+	require.False(t, domain.RateBucket{Tokens: 1, UpdatedAt: now}.IsFull(limit, now.Add(time.Millisecond)))
+}
+
+// failingStore - хранилище лимитов, которое недоступно.
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, domain.RateLimit) (domain.RateDecision, error) {
+	return domain.RateDecision{}, errors.New("connection refused")
+}
+
+// rateLimitRouter ограничивает /limited/* для автора из токена в заголовке Authorization; без заголовка лимит считается по IP.
+func rateLimitRouter(store ratelimit.Store, group string, limit domain.RateLimit) *gin.Engine {
+	r := gin.New()
+	actor := func(ctx *gin.Context) {
+		if token := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer "); token != "" {
+			ctx.Request = ctx.Request.WithContext(domain.WithActor(ctx.Request.Context(), domain.Actor{TokenID: token}))
+		}
+		ctx.Next()
+	}
+	limited := r.Group("/limited", actor, ratelimit.GinMiddleware(store, group, limit, slog.New(slog.DiscardHandler)))
+	limited.GET("ping", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+	return r
+}
+
+// rateLimitDecisions возвращает значение rate_limit_decisions_total для группы и решения.
+func rateLimitDecisions(t *testing.T, group string, outcome domain.RateLimitOutcome) float64 {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	metrics.Register(reg)
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "pr_reviewer_rate_limit_decisions_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["group"] == group && labels["outcome"] == string(outcome) {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	r := rateLimitRouter(ratelimit.NewMemoryStore(), "test_memory", domain.RateLimit{Rate: 0.01, Burst: 2})
+
+	for i := range 2 {
+		w := doJSON(r, http.MethodGet, "/limited/ping", nil, withToken("ci"))
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, []string{"1", "0"}[i], w.Header().Get(ratelimit.RemainingHeader))
+	}
+
+	w := doJSON(r, http.MethodGet, "/limited/ping", nil, withToken("ci"))
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, ratelimit.CodeRateLimited, errorCode(t, w))
+	// следующий токен появится через 100 секунд
+	require.Equal(t, "100", w.Header().Get("Retry-After"))
+
+	// у другого токена и у запросов без токена свои лимиты
+	require.Equal(t, http.StatusNoContent, doJSON(r, http.MethodGet, "/limited/ping", nil, withToken("dashboards")).Code)
+	require.Equal(t, http.StatusNoContent, doJSON(r, http.MethodGet, "/limited/ping", nil).Code)
+
+	require.Equal(t, float64(4), rateLimitDecisions(t, "test_memory", domain.RateLimitAllowed))
+	require.Equal(t, float64(1), rateLimitDecisions(t, "test_memory", domain.RateLimitRejected))
+
+	// недоступное хранилище лимитов не останавливает API
+	r = rateLimitRouter(failingStore{}, "test_failing", domain.RateLimit{Rate: 1, Burst: 1})
+	require.Equal(t, http.StatusNoContent, doJSON(r, http.MethodGet, "/limited/ping", nil, withToken("ci")).Code)
+	require.Equal(t, float64(1), rateLimitDecisions(t, "test_failing", domain.RateLimitError))
+
+	// группа с нулевым RPS не ограничивается
+	r = rateLimitRouter(failingStore{}, "test_unlimited", domain.RateLimit{})
+	require.Equal(t, http.StatusNoContent, doJSON(r, http.MethodGet, "/limited/ping", nil, withToken("ci")).Code)
+	require.Zero(t, rateLimitDecisions(t, "test_unlimited", domain.RateLimitError))
+}
+
+// ipRateLimitRouter ограничивает /limited/* по IP до проверки токена: любой токен, кроме "valid", отклоняется с 401.
+func ipRateLimitRouter(t *testing.T, group string, limit domain.RateLimit, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	r := gin.New()
+	require.NoError(t, r.SetTrustedProxies(trustedProxies))
+	authenticate := func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") != "Bearer valid" {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		ctx.Next()
+	}
+	limited := r.Group("/limited", ratelimit.IPMiddleware(ratelimit.NewMemoryStore(), group, limit, slog.New(slog.DiscardHandler)), authenticate)
+	limited.GET("ping", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+	return r
+}
+
+func TestRateLimitByIP(t *testing.T) {
+	limit := domain.RateLimit{Rate: 0.01, Burst: 2}
+
+	// неверные токены расходуют лимит IP: аутентификация не спасает от перебора
+	r := ipRateLimitRouter(t, "test_ip_flood", limit, nil)
+	require.Equal(t, http.StatusUnauthorized, doJSON(r, http.MethodGet, "/limited/ping", nil, from("203.0.113.7:4000", ""), withToken("guess-1")).Code)
+	require.Equal(t, http.StatusUnauthorized, doJSON(r, http.MethodGet, "/limited/ping", nil, from("203.0.113.7:4001", ""), withToken("guess-2")).Code)
+	w := doJSON(r, http.MethodGet, "/limited/ping", nil, from("203.0.113.7:4002", ""), withToken("valid"))
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, ratelimit.CodeRateLimited, errorCode(t, w))
+	require.Equal(t, http.StatusNoContent, doJSON(r, http.MethodGet, "/limited/ping", nil, from("198.51.100.1:4000", ""), withToken("valid")).Code)
+
+	// без доверенных прокси X-Forwarded-For игнорируется: подмена заголовка не даёт новый bucket
+	r = ipRateLimitRouter(t, "test_ip_spoof", limit, nil)
+	for i, forwardedFor := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		w := doJSON(r, http.MethodGet, "/limited/ping", nil, from("203.0.113.7:4000", forwardedFor), withToken("valid"))
+		require.Equal(t, []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests}[i], w.Code)
+	}
+
+	// за доверенным прокси лимит считается для клиента из X-Forwarded-For
+	r = ipRateLimitRouter(t, "test_ip_proxy", limit, []string{"192.0.2.0/24"})
+	for range 2 {
+		require.Equal(t, http.StatusNoContent, doJSON(r, http.MethodGet, "/limited/ping", nil, from("192.0.2.10:4000", "203.0.113.7"), withToken("valid")).Code)
+	}
+	require.Equal(t, http.StatusTooManyRequests, doJSON(r, http.MethodGet, "/limited/ping", nil, from("192.0.2.11:4000", "203.0.113.7"), withToken("valid")).Code)
+	require.Equal(t, http.StatusNoContent, doJSON(r, http.MethodGet, "/limited/ping", nil, from("192.0.2.10:4000", "198.51.100.1"), withToken("valid")).Code)
+}
+
+func TestPostgresRateLimit(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	pg, err := postgres.Connect(dbDSN)
+	require.NoError(t, err)
+	defer pg.Close()
+
+	ctx := context.Background()
+	repo := storage.NewRateLimitRepo(storage.NewTracedDB(pg), slog.New(slog.DiscardHandler))
+	_, err = pg.Exec(ctx, "DELETE FROM rate_limit_buckets")
+	require.NoError(t, err)
+
+	// реплики делят один bucket: из 20 одновременных запросов проходят ровно Burst
+	limit := domain.RateLimit{Rate: 0.01, Burst: 5}
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int64
+	)
+	for range 20 {
+		wg.Go(func() {
+			decision, err := repo.Take(ctx, "pull_requests:ci", limit)
+			require.NoError(t, err)
+			if decision.Allowed {
+				allowed.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	require.Equal(t, int64(5), allowed.Load())
+
+	decision, err := repo.Take(ctx, "pull_requests:ci", limit)
+	require.NoError(t, err)
+	require.False(t, decision.Allowed)
+	require.Greater(t, decision.RetryAfter, 90*time.Second)
+
+	deleted, err := repo.DeleteIdle(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+}
@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// as возвращает контекст запроса с токеном, привязанным к пользователю.
+func as(userID string) context.Context {
+	return domain.WithActor(context.Background(), domain.Actor{
+		TokenID: "token-" + userID,
+		UserID:  userID,
+		Scopes:  []domain.Scope{domain.ScopeTeamsWrite, domain.ScopePRsWrite},
+	})
+}
+
+// serviceToken возвращает контекст запроса с сервисным токеном без пользователя.
+func serviceToken(scopes ...domain.Scope) context.Context {
+	return domain.WithActor(context.Background(), domain.Actor{TokenID: "ci", Scopes: scopes})
+}
+
+// asSystem возвращает контекст действий от имени сервиса: при выключенной аутентификации, из CLI или фоновой задачи.
+func asSystem() context.Context {
+	return domain.WithActor(context.Background(), domain.SystemActor)
+}
+
+func TestRBACSetIsActive(t *testing.T) {
+	s := rbacService(t)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		userID  string
+		wantErr error
+	}{
+		{name: "member changes own flag", ctx: as("u2"), userID: "u2"},
+		{name: "member can't change teammate", ctx: as("u2"), userID: "u7", wantErr: domain.ErrForbidden},
+		{name: "lead changes own team member", ctx: as("u1"), userID: "u7"},
+		{name: "lead can't change other team", ctx: as("u1"), userID: "u4", wantErr: domain.ErrForbidden},
+		{name: "token of deleted user", ctx: as("u404"), userID: "u2", wantErr: domain.ErrForbidden},
+		{name: "service token without user", ctx: serviceToken(domain.ScopeTeamsWrite), userID: "u4", wantErr: domain.ErrForbidden},
+		{name: "admin service token", ctx: serviceToken(domain.ScopeAdmin), userID: "u4"},
+		{name: "auth disabled", ctx: asSystem(), userID: "u5"},
+		{name: "no actor", ctx: context.Background(), userID: "u5", wantErr: domain.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := s.SetIsActiveUser(tt.ctx, tt.userID, true)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.userID, user.ID)
+		})
+	}
+}
+
+func TestRBACTeams(t *testing.T) {
+	s := rbacService(t)
+
+	_, err := s.DeactivateTeam(as("u2"), "payments")
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = s.DeactivateTeam(as("u1"), "backend")
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = s.DeactivateTeam(serviceToken(domain.ScopeTeamsWrite), "backend")
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	err = s.AddTeam(serviceToken(domain.ScopeTeamsWrite), "payments", []model.TeamMember{{UserID: "u8", Username: "Mike", IsActive: true}})
+	require.ErrorIs(t, err, domain.ErrForbidden)
+
+	// лид управляет составом своей команды, но не создаёт другие команды и не забирает чужих участников
+	err = s.AddTeam(as("u1"), "payments", []model.TeamMember{{UserID: "u8", Username: "Mike", IsActive: true}})
+	require.NoError(t, err)
+	err = s.AddTeam(as("u1"), "payments", []model.TeamMember{{UserID: "u4", Username: "Anna", IsActive: true}})
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	err = s.AddTeam(as("u1"), "frontend", []model.TeamMember{{UserID: "u9", Username: "Leo", IsActive: true}})
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	err = s.AddTeam(as("u8"), "payments", []model.TeamMember{{UserID: "u8", Username: "Mike", IsActive: false}})
+	require.ErrorIs(t, err, domain.ErrForbidden)
+
+	team, err := s.GetTeam(asSystem(), "backend")
+	require.NoError(t, err)
+	require.Len(t, team, 4)
+
+	// менять роли может только admin
+	_, err = s.SetUserRole(as("u1"), "u2", string(domain.RoleTeamLead))
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = s.SetUserRole(asSystem(), "u2", "owner")
+	require.ErrorIs(t, err, domain.ErrInvalidRole)
+	user, err := s.SetUserRole(asSystem(), "u2", string(domain.RoleAdmin))
+	require.NoError(t, err)
+	require.Equal(t, domain.RoleAdmin, user.Role)
+
+	_, err = s.DeactivateTeam(as("u2"), "backend")
+	require.NoError(t, err)
+	_, err = s.DeactivateTeam(as("u1"), "payments")
+	require.NoError(t, err)
+}
+
+func TestRBACReassign(t *testing.T) {
+	s := rbacService(t)
+
+	pr, err := s.CreatePR(asSystem(), "pr-1", "Add search", "u4")
+	require.NoError(t, err)
+	require.Len(t, pr.ReviewersIDs, 2)
+	// ревьюеры - двое из u3, u5, u6; хотя бы один из них не лид
+	member, other := pr.ReviewersIDs[0], pr.ReviewersIDs[1]
+	if member == "u3" {
+		member, other = other, member
+	}
+
+	// участник снимает с ревью только себя, лид другой команды - никого
+	_, _, err = s.ReassignPR(as(member), "pr-1", other, 0)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, _, err = s.ReassignPR(as("u1"), "pr-1", member, 0)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+
+	pr, newReviewerID, err := s.ReassignPR(as(member), "pr-1", member, 0)
+	require.NoError(t, err)
+	require.NotContains(t, pr.ReviewersIDs, member)
+
+	// лид переназначает любого ревьюера своей команды
+	pr, _, err = s.ReassignPR(as("u3"), "pr-1", newReviewerID, 0)
+	require.NoError(t, err)
+	require.NotContains(t, pr.ReviewersIDs, newReviewerID)
+}
+
+func TestRBACReassignChecksRoleFirst(t *testing.T) {
+	s := rbacService(t)
+
+	pr, err := s.CreatePR(asSystem(), "pr-1", "Add search", "u4")
+	require.NoError(t, err)
+
+	// без прав нельзя узнать, есть ли PR, его версия и ревьюеры: 403 раньше 404 и 409
+	_, _, err = s.ReassignPR(as("u2"), "pr-404", pr.ReviewersIDs[0], 0)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, _, err = s.ReassignPR(as("u2"), "pr-1", pr.ReviewersIDs[0], pr.Version+1)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, _, err = s.ReassignPR(as("u1"), "pr-1", "u404", 0)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, _, err = s.ReassignPR(serviceToken(domain.ScopePRsWrite), "pr-1", pr.ReviewersIDs[0], 0)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+
+	// с правами ошибки те же, что и раньше
+	_, _, err = s.ReassignPR(as("u3"), "pr-404", "u5", 0)
+	require.ErrorIs(t, err, domain.ErrPRNotFound)
+	_, _, err = s.ReassignPR(as(pr.ReviewersIDs[0]), "pr-1", pr.ReviewersIDs[0], pr.Version+1)
+	var conflict *domain.PRVersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	_, _, err = s.ReassignPR(serviceToken(domain.ScopeAdmin), "pr-1", "u404", 0)
+	require.ErrorIs(t, err, domain.ErrReviewerNotAssigned)
+}
+
+func TestRBACMerge(t *testing.T) {
+	s := rbacService(t)
+
+	for _, id := range []string{"pr-1", "pr-2", "pr-3"} {
+		_, err := s.CreatePR(asSystem(), id, "Add search", "u4")
+		require.NoError(t, err)
+	}
+
+	// мержит автор, лид его команды или admin
+	_, err := s.MergePR(as("u5"), "pr-1", 0)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = s.MergePR(as("u1"), "pr-1", 0)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = s.MergePR(serviceToken(domain.ScopePRsWrite), "pr-1", 0)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	// без прав версия PR не проверяется
+	_, err = s.MergePR(as("u5"), "pr-1", 100)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+
+	pr, err := s.MergePR(as("u4"), "pr-1", 0)
+	require.NoError(t, err)
+	require.True(t, pr.IsMerged())
+	pr, err = s.MergePR(as("u3"), "pr-2", 0)
+	require.NoError(t, err)
+	require.True(t, pr.IsMerged())
+	pr, err = s.MergePR(serviceToken(domain.ScopeAdmin), "pr-3", 0)
+	require.NoError(t, err)
+	require.True(t, pr.IsMerged())
+}
+
+func TestRBACApprove(t *testing.T) {
+	s := rbacService(t)
+
+	pr, err := s.CreatePR(asSystem(), "pr-1", "Add search", "u4")
+	require.NoError(t, err)
+	reviewer := pr.ReviewersIDs[0]
+
+	// одобряет только сам ревьюер: ни автор, ни лид команды, ни сервисный токен
+	_, err = s.ApprovePR(as("u4"), "pr-1", reviewer)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	if reviewer != "u3" {
+		_, err = s.ApprovePR(as("u3"), "pr-1", reviewer)
+		require.ErrorIs(t, err, domain.ErrForbidden)
+	}
+	_, err = s.ApprovePR(serviceToken(domain.ScopePRsWrite), "pr-1", reviewer)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = s.ApprovePR(as("u4"), "pr-1", "u4")
+	require.ErrorIs(t, err, domain.ErrReviewerNotAssigned)
+
+	_, err = s.ApprovePR(as(reviewer), "pr-1", reviewer)
+	require.NoError(t, err)
+	_, err = s.ApprovePR(serviceToken(domain.ScopeAdmin), "pr-1", pr.ReviewersIDs[1])
+	require.NoError(t, err)
+
+	_, err = s.MergePR(as("u4"), "pr-1", 0)
+	require.NoError(t, err)
+	_, err = s.ApprovePR(as(reviewer), "pr-1", reviewer)
+	require.ErrorIs(t, err, domain.ErrPRMerged)
+}
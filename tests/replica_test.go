@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/clients/postgres"
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaRouting(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.DiscardHandler)
+
+	primaryClient, _, err := connectSQLite(t)
+	require.NoError(t, err)
+	defer primaryClient.Close()
+	replicaClient, _, err := connectSQLite(t)
+	require.NoError(t, err)
+	defer replicaClient.Close()
+
+	_, err = primaryClient.Exec(ctx, "INSERT INTO teams (name) VALUES ('primary')")
+	require.NoError(t, err)
+	_, err = replicaClient.Exec(ctx, "INSERT INTO teams (name) VALUES ('replica')")
+	require.NoError(t, err)
+
+	var (
+		lag    time.Duration
+		lagErr error
+	)
+	db := storage.NewReplicatedDB(storage.NewTracedDB(primaryClient), storage.NewTracedDB(replicaClient),
+		func(context.Context, storage.DB) (time.Duration, error) { return lag, lagErr },
+		time.Second, logger)
+
+	readFrom := func(ctx context.Context) string {
+		t.Helper()
+		names, err := queryStrings(ctx, storage.ReplicaExecutor(ctx, db), "SELECT name FROM teams")
+		require.NoError(t, err)
+		require.Len(t, names, 1)
+		return names[0]
+	}
+
+	// до первой проверки реплика считается недоступной
+	require.Equal(t, "primary", readFrom(ctx))
+
+	require.NoError(t, db.CheckReplica(ctx))
+	require.Equal(t, "replica", readFrom(ctx))
+	// обычный Executor и запись не ходят на реплику
+	names, err := queryStrings(ctx, storage.Executor(ctx, db), "SELECT name FROM teams")
+	require.NoError(t, err)
+	require.Equal(t, []string{"primary"}, names)
+
+	// чтение в транзакции видит собственные записи в primary
+	tx := storage.NewTxManager(db, logger)
+	err = tx.Do(ctx, func(ctx context.Context) error {
+		_, err := storage.Executor(ctx, db).ExecContext(ctx, "UPDATE teams SET name = 'primary-updated'")
+		require.NoError(t, err)
+		require.Equal(t, "primary-updated", readFrom(ctx))
+		return nil
+	})
+	require.NoError(t, err)
+
+	lag = 2 * time.Second
+	require.Error(t, db.CheckReplica(ctx))
+	require.Equal(t, "primary-updated", readFrom(ctx))
+
+	lag = 0
+	require.NoError(t, db.CheckReplica(ctx))
+	require.Equal(t, "replica", readFrom(ctx))
+
+	lagErr = errors.New("connection refused")
+	require.ErrorIs(t, db.CheckReplica(ctx), lagErr)
+	require.Equal(t, "primary-updated", readFrom(ctx))
+}
+
+func TestPostgresReplica(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	pg, err := postgres.Connect(dbDSN)
+	require.NoError(t, err)
+	defer pg.Close()
+	cleanDB(pg)
+	defer cleanDB(pg)
+
+	ctx := context.Background()
+	logger := slog.New(slog.DiscardHandler)
+	traced := storage.NewTracedDB(pg)
+	// primary в роли реплики: не в recovery, отставание 0
+	db := storage.NewReplicatedDB(traced, traced, storage.PostgresReplicationLag, time.Second, logger)
+	require.NoError(t, db.CheckReplica(ctx))
+
+	teams := storage.NewTeamRepo(db, logger)
+	require.NoError(t, teams.Save(ctx, *domain.NewTeam("payments"), []domain.User{
+		*domain.NewUser("u1", "Alice", "payments", true),
+	}))
+	members, err := teams.FindByName(ctx, "payments")
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+}
@@ -1,9 +1,34 @@
 package tests
 
 import (
+	"avito-tech-go-task/internal/application/service"
+	"avito-tech-go-task/internal/clients/sqlite"
 	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/auth"
+	"avito-tech-go-task/internal/infrastructure/http/controller"
 	"avito-tech-go-task/internal/infrastructure/http/model"
+	"avito-tech-go-task/internal/infrastructure/idempotency"
+	"avito-tech-go-task/internal/infrastructure/logging"
+	"avito-tech-go-task/internal/infrastructure/storage/memory"
+	"avito-tech-go-task/internal/infrastructure/webhook"
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
 )
 
 func (s *TestSuite) TestAddTeam() {
@@ -93,7 +118,7 @@ func (s *TestSuite) TestAddTeam() {
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			ctx := context.Background()
+			ctx := asSystem()
 
 			result, err := s.ApiService.AddTeam(ctx, tt.request)
 
@@ -160,7 +185,7 @@ func (s *TestSuite) TestCreatePullRequest() {
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			ctx := context.Background()
+			ctx := asSystem()
 
 			result, err := s.ApiService.CreatePullRequest(ctx, tt.request)
 
@@ -211,7 +236,7 @@ func (s *TestSuite) TestMergePullRequest() {
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			ctx := context.Background()
+			ctx := asSystem()
 
 			result, err := s.ApiService.MergePullRequest(ctx, tt.request)
 
@@ -263,7 +288,7 @@ func (s *TestSuite) TestSetIsActiveUser() {
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			ctx := context.Background()
+			ctx := asSystem()
 
 			result, err := s.ApiService.SetIsActiveUser(ctx, tt.request)
 
@@ -279,3 +304,715 @@ func (s *TestSuite) TestSetIsActiveUser() {
 		})
 	}
 }
+
+func (s *TestSuite) TestRebuildStats() {
+	ctx := asSystem()
+
+	drifts, err := s.prService.RebuildStats(ctx)
+	s.NoError(err)
+	s.Empty(drifts)
+
+	_, err = s.db.Exec(ctx, "UPDATE user_review_stats SET active_reviews = active_reviews + 5 WHERE user_id = 'u4'")
+	s.NoError(err)
+
+	drifts, err = s.prService.RebuildStats(ctx)
+	s.NoError(err)
+	s.Require().Len(drifts, 1)
+	s.Equal("u4", drifts[0].UserID)
+	s.Equal(drifts[0].Actual.ActiveReviews+5, drifts[0].Stored.ActiveReviews)
+
+	drifts, err = s.prService.RebuildStats(ctx)
+	s.NoError(err)
+	s.Empty(drifts)
+}
+
+func (s *TestSuite) TestReviewStats() {
+	ctx := asSystem()
+	now := time.Now()
+
+	result, err := s.ApiService.GetReviewStats(ctx, &model.GetReviewStatsRequest{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: "team",
+		SortBy:  "assigned",
+		Order:   "desc",
+		Limit:   1,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(result.Stats, 1)
+	s.Equal("backend", result.Stats[0].Key)
+	s.Equal(int64(2), result.Stats[0].ReviewsAssigned)
+	s.NotEmpty(result.NextCursor)
+
+	// курсор выдан для sort_by=assigned&order=desc и в другом порядке не принимается
+	for _, req := range []model.GetReviewStatsRequest{
+		{GroupBy: "team", SortBy: "assigned", Order: "asc"},
+		{GroupBy: "team", SortBy: "merged", Order: "desc"},
+		{GroupBy: "user", SortBy: "assigned", Order: "desc"},
+	} {
+		req.From, req.To, req.Limit, req.Cursor = now.Add(-time.Hour), now.Add(time.Hour), 1, result.NextCursor
+		_, err = s.ApiService.GetReviewStats(ctx, &req)
+		s.ErrorIs(err, domain.ErrStatsCursorMismatch)
+	}
+
+	result, err = s.ApiService.GetReviewStats(ctx, &model.GetReviewStatsRequest{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: "team",
+		SortBy:  "assigned",
+		Order:   "desc",
+		Limit:   1,
+		Cursor:  result.NextCursor,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(result.Stats, 1)
+	s.Equal("payments", result.Stats[0].Key)
+	s.Equal(int64(1), result.Stats[0].ReviewsMerged)
+	s.Empty(result.NextCursor)
+
+	_, err = s.ApiService.GetReviewStats(ctx, &model.GetReviewStatsRequest{
+		From:    now,
+		To:      now.Add(-time.Hour),
+		GroupBy: "team",
+	})
+	s.ErrorIs(err, domain.ErrInvalidStatsPeriod)
+}
+
+func (s *TestSuite) TestTurnaround() {
+	ctx := asSystem()
+	now := time.Now()
+
+	result, err := s.ApiService.GetTurnaround(ctx, &model.GetTurnaroundRequest{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: "team",
+	})
+	s.Require().NoError(err)
+	s.Require().Len(result.Stats, 2)
+
+	s.Equal("backend", result.Stats[0].Key)
+	s.Equal(int64(1), result.Stats[0].PullRequests)
+	s.Equal(int64(0), result.Stats[0].MergedPullRequests)
+
+	s.Equal("payments", result.Stats[1].Key)
+	s.Equal(int64(1), result.Stats[1].PullRequests)
+	s.Equal(int64(1), result.Stats[1].MergedPullRequests)
+	s.GreaterOrEqual(result.Stats[1].TimeToMerge.P99Seconds, result.Stats[1].TimeToMerge.P50Seconds)
+
+	_, err = s.ApiService.GetTurnaround(ctx, &model.GetTurnaroundRequest{
+		From:    now.Add(-time.Hour),
+		To:      now.Add(time.Hour),
+		GroupBy: "month",
+	})
+	s.ErrorIs(err, domain.ErrInvalidTurnaroundQuery)
+}
+
+func (s *TestSuite) TestFairness() {
+	ctx := asSystem()
+	now := time.Now()
+
+	result, err := s.ApiService.GetFairness(ctx, &model.GetFairnessRequest{
+		From:     now.Add(-time.Hour),
+		To:       now.Add(time.Hour),
+		TeamName: "payments",
+	})
+	s.Require().NoError(err)
+	s.Require().Len(result.Teams, 1)
+
+	team := result.Teams[0]
+	s.Equal("payments", team.TeamName)
+	s.Equal(int64(1), team.TotalAssigned)
+	s.InDelta(1.0, team.MaxDeviation, 0.001)
+
+	statuses := make(map[string]string, len(team.Members))
+	for _, m := range team.Members {
+		statuses[m.UserID] = m.Status
+	}
+	s.Equal(map[string]string{
+		"u1": string(domain.WorkloadUnderused),
+		"u2": string(domain.WorkloadOverloaded),
+	}, statuses)
+
+	_, err = s.ApiService.GetFairness(ctx, &model.GetFairnessRequest{
+		From:      now.Add(-time.Hour),
+		To:        now.Add(time.Hour),
+		Threshold: -1,
+	})
+	s.ErrorIs(err, domain.ErrInvalidFairnessThreshold)
+}
+
+func (s *TestSuite) TestErrorResponses() {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(auth.System())
+	r.POST("/teams/add", s.ApiService.AddTeamHandler)
+	r.GET("/teams/get", s.ApiService.GetTeamHandler)
+	r.POST("/pullRequests/create", s.ApiService.CreatePullRequestHandler)
+	r.POST("/pullRequests/merge", s.ApiService.MergePullRequestHandler)
+	r.POST("/pullRequests/reassign", s.ApiService.ReassignPullRequestHandler)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "PR with this ID already exists",
+			method:     http.MethodPost,
+			path:       "/pullRequests/create",
+			body:       `{"pull_request_id":"pr-100","pull_request_name":"add some features","author_id":"u1"}`,
+			wantStatus: http.StatusConflict,
+			wantCode:   controller.CodePRExists,
+		},
+		{
+			name:       "author not exist",
+			method:     http.MethodPost,
+			path:       "/pullRequests/create",
+			body:       `{"pull_request_id":"pr-159","pull_request_name":"add some validations","author_id":"u404"}`,
+			wantStatus: http.StatusNotFound,
+			wantCode:   controller.CodeNotFound,
+		},
+		{
+			name:       "merge not existing PR",
+			method:     http.MethodPost,
+			path:       "/pullRequests/merge",
+			body:       `{"pull_request_id":"pr-404"}`,
+			wantStatus: http.StatusNotFound,
+			wantCode:   controller.CodeNotFound,
+		},
+		{
+			name:       "reassign user who is not a reviewer",
+			method:     http.MethodPost,
+			path:       "/pullRequests/reassign",
+			body:       `{"pull_request_id":"pr-100","old_reviewer_id":"u1"}`,
+			wantStatus: http.StatusConflict,
+			wantCode:   controller.CodeNotAssigned,
+		},
+		{
+			name:       "reassign without candidates in team",
+			method:     http.MethodPost,
+			path:       "/pullRequests/reassign",
+			body:       `{"pull_request_id":"pr-100","old_reviewer_id":"u2"}`,
+			wantStatus: http.StatusConflict,
+			wantCode:   controller.CodeNoCandidate,
+		},
+		{
+			name:       "not valid team members",
+			method:     http.MethodPost,
+			path:       "/teams/add",
+			body:       `{"team_name":"payments","members":[{"user_id":"u2","username":"","is_active":true}]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   controller.CodeInvalidMember,
+		},
+		{
+			name:       "team not exist",
+			method:     http.MethodGet,
+			path:       "/teams/get?team_name=unknown",
+			wantStatus: http.StatusNotFound,
+			wantCode:   controller.CodeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			r.ServeHTTP(w, req)
+
+			var res model.ErrorResponse
+			s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &res))
+			s.Equal(tt.wantStatus, w.Code)
+			s.Require().NotNil(res.Error)
+			s.Equal(tt.wantCode, res.Error.Code)
+		})
+	}
+}
+
+// Регрессия: кандидатами в ревьюеры были и неактивные участники, выбор с LIMIT зависел от порядка строк,
+// а limit = 0 в GetStats возвращал пустой список вместо всех записей.
+func (s *TestSuite) TestReviewerCandidatesAndStatsLimit() {
+	ctx := asSystem()
+	defer func() {
+		for _, query := range []string{
+			"DELETE FROM user_review_stats WHERE user_id LIKE 'u-cand-%'",
+			"DELETE FROM users WHERE team_name = 'candidates'",
+			"DELETE FROM teams WHERE name = 'candidates'",
+		} {
+			_, err := s.db.Exec(ctx, query)
+			s.NoError(err)
+		}
+	}()
+
+	_, err := s.ApiService.AddTeam(ctx, &model.AddTeamRequest{TeamName: "candidates", Members: []model.TeamMember{
+		{UserID: "u-cand-4", Username: "Dan", IsActive: true},
+		{UserID: "u-cand-2", Username: "Bob", IsActive: false},
+		{UserID: "u-cand-3", Username: "Cid", IsActive: true},
+		{UserID: "u-cand-1", Username: "Ann", IsActive: true},
+	}})
+	s.Require().NoError(err)
+
+	active, err := s.users.FindActiveUserIDsByTeam(ctx, "candidates")
+	s.Require().NoError(err)
+	s.Equal([]string{"u-cand-1", "u-cand-3", "u-cand-4"}, active)
+
+	for range 3 {
+		candidates, err := s.users.FindActiveUserIDsByTeamExcludeAuthor(ctx, "candidates", "u-cand-1", domain.ReviewersMaxCount)
+		s.Require().NoError(err)
+		s.Equal([]string{"u-cand-3", "u-cand-4"}, candidates)
+	}
+
+	all, err := s.users.GetStats(ctx, 0)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(all)
+	s.IsNonDecreasing(userIDs(all))
+	first, err := s.users.GetStats(ctx, 1)
+	s.Require().NoError(err)
+	s.Equal(all[:1], first)
+}
+
+// userIDs возвращает ID пользователей из статистики в порядке ответа.
+func userIDs(stats []domain.UserStat) []string {
+	ids := make([]string, 0, len(stats))
+	for _, stat := range stats {
+		ids = append(ids, stat.UserID)
+	}
+	return ids
+}
+
+func (s *TestSuite) TestIdempotency() {
+	ctx := asSystem()
+	defer func() {
+		for _, query := range []string{
+			"DELETE FROM review_events WHERE pull_request_id = 'pr-idem-1'",
+			"DELETE FROM pull_requests WHERE id = 'pr-idem-1'",
+			"DELETE FROM user_review_stats WHERE user_id LIKE 'u-idem-%'",
+			"DELETE FROM users WHERE team_name IN ('idempotency', 'idempotency-add')",
+			"DELETE FROM teams WHERE name IN ('idempotency', 'idempotency-add')",
+		} {
+			_, err := s.db.Exec(ctx, query)
+			s.NoError(err)
+		}
+	}()
+
+	members := make([]model.TeamMember, 0, 5)
+	for _, id := range []string{"u-idem-1", "u-idem-2", "u-idem-3", "u-idem-4", "u-idem-5"} {
+		members = append(members, model.TeamMember{UserID: id, Username: id, IsActive: true})
+	}
+	_, err := s.ApiService.AddTeam(ctx, &model.AddTeamRequest{TeamName: "idempotency", Members: members})
+	s.Require().NoError(err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(auth.System())
+	idempotent := idempotency.GinMiddleware(s.idempotency, time.Hour, slog.New(slog.DiscardHandler))
+	r.POST("/pullRequests/create", idempotent, s.ApiService.CreatePullRequestHandler)
+	r.POST("/pullRequests/reassign", idempotent, s.ApiService.ReassignPullRequestHandler)
+	r.POST("/pullRequests/merge", idempotent, s.ApiService.MergePullRequestHandler)
+	r.POST("/teams/add", idempotent, s.ApiService.AddTeamHandler)
+	r.PATCH("/teams/deactivate", idempotent, s.ApiService.DeactivateTeamHandler)
+	r.POST("/users/setIsActive", idempotent, s.ApiService.SetIsActiveUserHandler)
+	r.POST("/users/setRole", idempotent, s.ApiService.SetUserRoleHandler)
+
+	doMethod := func(method, path, key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(idempotency.KeyHeader, key)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	do := func(path, key, body string) *httptest.ResponseRecorder {
+		return doMethod(http.MethodPost, path, key, body)
+	}
+	// повтор возвращает сохранённый первый ответ
+	replays := func(first, replayed *httptest.ResponseRecorder) {
+		s.Equal(first.Code, replayed.Code)
+		s.Empty(first.Header().Get(idempotency.ReplayedHeader))
+		s.Equal("true", replayed.Header().Get(idempotency.ReplayedHeader))
+		s.JSONEq(first.Body.String(), replayed.Body.String())
+	}
+
+	createBody := `{"pull_request_id":"pr-idem-1","pull_request_name":"retry me","author_id":"u-idem-1"}`
+	first := do("/pullRequests/create", "create-1", createBody)
+	s.Require().Equal(http.StatusOK, first.Code)
+	s.Empty(first.Header().Get(idempotency.ReplayedHeader))
+
+	replayed := do("/pullRequests/create", "create-1", createBody)
+	s.Equal(http.StatusOK, replayed.Code)
+	s.Equal("true", replayed.Header().Get(idempotency.ReplayedHeader))
+	s.JSONEq(first.Body.String(), replayed.Body.String())
+
+	reused := do("/pullRequests/create", "create-1", `{"pull_request_id":"pr-idem-1","pull_request_name":"other","author_id":"u-idem-1"}`)
+	s.Equal(http.StatusUnprocessableEntity, reused.Code)
+
+	withoutKey := do("/pullRequests/create", "", createBody)
+	s.Equal(http.StatusConflict, withoutKey.Code)
+
+	var created model.CreatePullRequestResponse
+	s.Require().NoError(json.Unmarshal(first.Body.Bytes(), &created))
+	s.Require().Len(created.PR.AssignedReviewers, 2)
+
+	reassignBody := `{"pull_request_id":"pr-idem-1","old_reviewer_id":"` + created.PR.AssignedReviewers[0] + `"}`
+	first = do("/pullRequests/reassign", "reassign-1", reassignBody)
+	s.Require().Equal(http.StatusOK, first.Code)
+
+	replayed = do("/pullRequests/reassign", "reassign-1", reassignBody)
+	s.Equal(http.StatusOK, replayed.Code)
+	s.Equal("true", replayed.Header().Get(idempotency.ReplayedHeader))
+	s.JSONEq(first.Body.String(), replayed.Body.String())
+
+	mergeBody := `{"pull_request_id":"pr-idem-1"}`
+	first = do("/pullRequests/merge", "merge-1", mergeBody)
+	s.Require().Equal(http.StatusOK, first.Code)
+	replays(first, do("/pullRequests/merge", "merge-1", mergeBody))
+
+	addBody := `{"team_name":"idempotency-add","members":[{"user_id":"u-idem-6","username":"u-idem-6","is_active":true}]}`
+	first = do("/teams/add", "add-1", addBody)
+	s.Require().Equal(http.StatusOK, first.Code)
+	replays(first, do("/teams/add", "add-1", addBody))
+	// повтор не выполняет запрос снова: участник, добавленный после первого запроса, остаётся в команде
+	_, err = s.ApiService.AddTeam(ctx, &model.AddTeamRequest{TeamName: "idempotency-add", Members: []model.TeamMember{
+		{UserID: "u-idem-7", Username: "u-idem-7", IsActive: true},
+	}})
+	s.Require().NoError(err)
+	replays(first, do("/teams/add", "add-1", addBody))
+	team, err := s.prService.GetTeam(ctx, "idempotency-add")
+	s.Require().NoError(err)
+	s.Len(team, 2)
+
+	setIsActiveBody := `{"user_id":"u-idem-5","is_active":true}`
+	first = do("/users/setIsActive", "set-active-1", setIsActiveBody)
+	s.Require().Equal(http.StatusOK, first.Code)
+	_, err = s.prService.SetIsActiveUser(ctx, "u-idem-5", false)
+	s.Require().NoError(err)
+	replays(first, do("/users/setIsActive", "set-active-1", setIsActiveBody))
+	user, err := s.users.FindByID(ctx, "u-idem-5")
+	s.Require().NoError(err)
+	s.False(user.IsActive)
+
+	setRoleBody := `{"user_id":"u-idem-4","role":"team_lead"}`
+	first = do("/users/setRole", "set-role-1", setRoleBody)
+	s.Require().Equal(http.StatusOK, first.Code)
+	replays(first, do("/users/setRole", "set-role-1", setRoleBody))
+
+	// команда передаётся в query, поэтому тот же ключ для другой команды - это другой запрос
+	first = doMethod(http.MethodPatch, "/teams/deactivate?team_name=idempotency-add", "deactivate-1", "")
+	s.Require().Equal(http.StatusOK, first.Code)
+	replays(first, doMethod(http.MethodPatch, "/teams/deactivate?team_name=idempotency-add", "deactivate-1", ""))
+	reused = doMethod(http.MethodPatch, "/teams/deactivate?team_name=idempotency", "deactivate-1", "")
+	s.Equal(http.StatusUnprocessableEntity, reused.Code)
+}
+
+func (s *TestSuite) TestIdempotencyPerToken() {
+	ctx := asSystem()
+	defer func() {
+		for _, query := range []string{
+			"DELETE FROM review_events WHERE pull_request_id = 'pr-idem-token'",
+			"DELETE FROM pull_requests WHERE id = 'pr-idem-token'",
+			"DELETE FROM user_review_stats WHERE user_id LIKE 'u-idem-token-%'",
+			"DELETE FROM users WHERE team_name = 'idempotency-token'",
+			"DELETE FROM teams WHERE name = 'idempotency-token'",
+		} {
+			_, err := s.db.Exec(ctx, query)
+			s.NoError(err)
+		}
+	}()
+
+	_, err := s.ApiService.AddTeam(ctx, &model.AddTeamRequest{TeamName: "idempotency-token", Members: []model.TeamMember{
+		{UserID: "u-idem-token-1", Username: "u-idem-token-1", IsActive: true},
+		{UserID: "u-idem-token-2", Username: "u-idem-token-2", IsActive: true},
+	}})
+	s.Require().NoError(err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	// автор запроса - токен из заголовка, как после auth.GinMiddleware
+	r.Use(func(ctx *gin.Context) {
+		token := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		actor := domain.Actor{TokenID: token, Scopes: []domain.Scope{domain.ScopeAdmin}}
+		ctx.Request = ctx.Request.WithContext(domain.WithActor(ctx.Request.Context(), actor))
+		ctx.Next()
+	})
+	idempotent := idempotency.GinMiddleware(s.idempotency, time.Hour, slog.New(slog.DiscardHandler))
+	r.POST("/pullRequests/create", idempotent, s.ApiService.CreatePullRequestHandler)
+
+	create := model.CreatePullRequestRequest{PullRequestID: "pr-idem-token", PullRequestName: "retry me", AuthorID: "u-idem-token-1"}
+	key := withHeader(idempotency.KeyHeader, "create-1")
+	first := doJSON(r, http.MethodPost, "/pullRequests/create", create, withToken("token-a"), key)
+	s.Require().Equal(http.StatusOK, first.Code, first.Body.String())
+	replayed := doJSON(r, http.MethodPost, "/pullRequests/create", create, withToken("token-a"), key)
+	s.Equal("true", replayed.Header().Get(idempotency.ReplayedHeader))
+
+	// другой токен с тем же ключом и телом не получает чужой ответ: его запрос выполняется и PR уже существует
+	other := doJSON(r, http.MethodPost, "/pullRequests/create", create, withToken("token-b"), key)
+	s.Equal(http.StatusConflict, other.Code, other.Body.String())
+	s.Empty(other.Header().Get(idempotency.ReplayedHeader))
+	s.Equal(controller.CodePRExists, errorCode(s.T(), other))
+}
+
+func (s *TestSuite) TestVersionConflict() {
+	ctx := asSystem()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(auth.System())
+	r.POST("/pullRequests/merge", s.ApiService.MergePullRequestHandler)
+
+	prs, err := s.prService.GetReviewUser(ctx, "u4")
+	s.Require().NoError(err)
+	var version int64
+	for _, pr := range prs {
+		if pr.ID == "pr-102" {
+			version = pr.Version
+		}
+	}
+	s.Require().NotZero(version)
+
+	// PR изменили после того, как клиент его прочитал
+	_, err = s.db.Exec(ctx, "UPDATE pull_requests SET version = version + 1 WHERE id = 'pr-102'")
+	s.Require().NoError(err)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/pullRequests/merge", strings.NewReader(`{"pull_request_id":"pr-102"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", strconv.Quote(strconv.FormatInt(version, 10)))
+	r.ServeHTTP(w, req)
+
+	var res model.VersionConflictResponse
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &res))
+	s.Equal(http.StatusConflict, w.Code)
+	s.Equal(controller.CodeVersionConflict, res.Error.Code)
+	s.Equal(version+1, res.PR.Version)
+	s.Equal(domain.PRStatusOpen.String(), res.PR.Status)
+	s.Equal(strconv.Quote(strconv.FormatInt(version+1, 10)), w.Header().Get("ETag"))
+
+	_, _, err = s.prService.ReassignPR(ctx, "pr-102", res.PR.AssignedReviewers[0], version)
+	var conflict *domain.PRVersionConflictError
+	s.Require().ErrorAs(err, &conflict)
+	s.Equal(version+1, conflict.Current.Version)
+}
+
+func (s *TestSuite) TestTxManager() {
+	ctx := asSystem()
+	prRepo := s.prs
+
+	pr, err := domain.NewPullRequest("pr-tx-1", "rolled back", "u3", []string{})
+	s.Require().NoError(err)
+
+	errAbort := errors.New("abort")
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		s.Require().NoError(prRepo.CreatePR(ctx, *pr))
+
+		_, err := prRepo.FindByIDForUpdate(ctx, pr.ID)
+		s.Require().NoError(err)
+
+		return errAbort
+	})
+	s.ErrorIs(err, errAbort)
+
+	_, err = prRepo.FindByID(ctx, pr.ID)
+	s.ErrorIs(err, domain.ErrPRNotFound)
+}
+
+// requestOption дополняет тестовый запрос заголовками и адресом клиента.
+type requestOption func(*http.Request)
+
+// withToken передаёт токен в заголовке Authorization.
+func withToken(token string) requestOption {
+	return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+}
+
+// withRequestID передаёт ID запроса в заголовке X-Request-ID.
+func withRequestID(requestID string) requestOption {
+	return withHeader(logging.RequestIDHeader, requestID)
+}
+
+func withHeader(key, value string) requestOption {
+	return func(req *http.Request) { req.Header.Set(key, value) }
+}
+
+// from задаёт адрес соединения и, если он не пустой, заголовок X-Forwarded-For.
+func from(remoteAddr, forwardedFor string) requestOption {
+	return func(req *http.Request) {
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+	}
+}
+
+// doJSON выполняет запрос с телом body в JSON.
+func doJSON(r http.Handler, method, path string, body any, opts ...requestOption) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&payload).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// errorCode возвращает код ошибки из тела ответа.
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var res model.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.NotNil(t, res.Error)
+	return res.Error.Code
+}
+
+// newMemoryBackend возвращает репозитории поверх пустого хранилища в памяти.
+func newMemoryBackend(logger *slog.Logger) backend {
+	store := memory.NewStore()
+	return backend{
+		teams:       memory.NewTeamRepo(store, logger),
+		prs:         memory.NewPRRepo(store, logger),
+		users:       memory.NewUserRepo(store, logger),
+		tx:          memory.NewTxManager(store),
+		idempotency: memory.NewIdempotencyRepo(store, logger),
+		tokens:      memory.NewTokenRepo(store, logger),
+		audit:       memory.NewAuditRepo(store, logger),
+		outbox:      memory.NewOutboxRepo(store, logger),
+		webhooks:    memory.NewWebhookRepo(store, logger),
+	}
+}
+
+const bootstrapSecret = "bootstrap-secret"
+
+// authRouter собирает маршруты с проверкой токенов так же, как cmd/main.go.
+func authRouter(t *testing.T, verifier *auth.JWTVerifier) (*gin.Engine, *sqlite.Client) {
+	t.Helper()
+	logger := slog.New(slog.DiscardHandler)
+
+	client, b, err := connectSQLite(t)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	tokenService := service.NewTokenService(b.tokens, b.users, b.audit, b.tx, logger)
+	require.NoError(t, tokenService.EnsureBootstrapToken(context.Background(), bootstrapSecret))
+	prService := service.NewPRService(b.prs, b.users, b.teams, b.audit, b.tx, domain.ReviewersMaxCount, logger)
+	c := controller.NewApiService(prService, logger)
+	pools := map[string]*sql.DB{"primary": client.DB()}
+	admin := controller.NewAdminService(pools, tokenService, service.NewAuditService(b.audit, logger), service.NewWebhookService(b.webhooks, b.audit, b.tx, logger), logger)
+
+	r := gin.New()
+	r.Use(logging.GinMiddleware(logger))
+	authenticate := auth.GinMiddleware(tokenService, verifier, logger)
+	teams := r.Group("/teams", authenticate)
+	{
+		teams.POST("add", auth.Require(domain.ScopeTeamsWrite), c.AddTeamHandler)
+		teams.GET("get", c.GetTeamHandler)
+		teams.PATCH("deactivate", auth.Require(domain.ScopeTeamsWrite), c.DeactivateTeamHandler)
+	}
+	users := r.Group("/users", authenticate)
+	{
+		users.POST("setIsActive", c.SetIsActiveUserHandler)
+		users.POST("setRole", auth.Require(domain.ScopeTeamsWrite), c.SetUserRoleHandler)
+	}
+	pullRequests := r.Group("/pullRequests", authenticate, auth.Require(domain.ScopePRsWrite))
+	{
+		pullRequests.POST("create", c.CreatePullRequestHandler)
+	}
+	adminGroup := r.Group("/admin", authenticate, auth.Require(domain.ScopeAdmin))
+	{
+		adminGroup.GET("getPoolStats", admin.GetPoolStatsHandler)
+		adminGroup.POST("issueToken", admin.IssueTokenHandler)
+		adminGroup.GET("listTokens", admin.ListTokensHandler)
+		adminGroup.POST("revokeToken", admin.RevokeTokenHandler)
+		adminGroup.GET("getAuditLog", admin.GetAuditLogHandler)
+		adminGroup.POST("createWebhook", admin.CreateWebhookHandler)
+		adminGroup.GET("listWebhooks", admin.ListWebhooksHandler)
+		adminGroup.POST("deleteWebhook", admin.DeleteWebhookHandler)
+		adminGroup.GET("listWebhookDeliveries", admin.ListWebhookDeliveriesHandler)
+		adminGroup.GET("getWebhookDelivery", admin.GetWebhookDeliveryHandler)
+		adminGroup.POST("replayWebhookDelivery", admin.ReplayWebhookDeliveryHandler)
+	}
+
+	return r, client
+}
+
+// rbacService собирает сервис поверх хранилища в памяти с командами payments (лид u1) и backend (лид u3).
+func rbacService(t *testing.T) *service.PRService {
+	t.Helper()
+	logger := slog.New(slog.DiscardHandler)
+	ctx := asSystem()
+
+	b := newMemoryBackend(logger)
+	s := service.NewPRService(b.prs, b.users, b.teams, b.audit, b.tx, domain.ReviewersMaxCount, logger)
+
+	require.NoError(t, s.AddTeam(ctx, "payments", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u7", Username: "Kate", IsActive: true},
+	}))
+	require.NoError(t, s.AddTeam(ctx, "backend", []model.TeamMember{
+		{UserID: "u3", Username: "Paul", IsActive: true},
+		{UserID: "u4", Username: "Anna", IsActive: true},
+		{UserID: "u5", Username: "Nina", IsActive: true},
+		{UserID: "u6", Username: "Jack", IsActive: true},
+	}))
+	_, err := s.SetUserRole(ctx, "u1", string(domain.RoleTeamLead))
+	require.NoError(t, err)
+	_, err = s.SetUserRole(ctx, "u3", string(domain.RoleTeamLead))
+	require.NoError(t, err)
+
+	return s
+}
+
+const webhookSecret = "webhook-secret-0123"
+
+// webhookReceiver - подписчик, который проверяет подпись и отвечает status.
+type webhookReceiver struct {
+	t      *testing.T
+	status atomic.Int32
+
+	mu     sync.Mutex
+	events []string
+}
+
+// newWebhookReceiver запускает подписчика, который отвечает status.
+func newWebhookReceiver(t *testing.T, status int) (*webhookReceiver, *httptest.Server) {
+	r := &webhookReceiver{t: t}
+	r.status.Store(int32(status))
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return r, server
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	require.NoError(r.t, err)
+	timestamp, err := strconv.ParseInt(req.Header.Get(webhook.TimestampHeader), 10, 64)
+	require.NoError(r.t, err)
+	require.True(r.t, webhook.Verify(webhookSecret, req.Header.Get(webhook.SignatureHeader), timestamp, body))
+	require.False(r.t, webhook.Verify("another-secret-0123", req.Header.Get(webhook.SignatureHeader), timestamp, body))
+	require.NotEmpty(r.t, req.Header.Get(webhook.DeliveryHeader))
+
+	var event model.Event
+	require.NoError(r.t, json.Unmarshal(body, &event))
+	require.Equal(r.t, event.Type, req.Header.Get(webhook.EventHeader))
+
+	r.mu.Lock()
+	r.events = append(r.events, event.Type+" "+event.AggregateID)
+	r.mu.Unlock()
+	w.WriteHeader(int(r.status.Load()))
+}
+
+func (r *webhookReceiver) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/clients/postgres"
+	"avito-tech-go-task/internal/clients/sqlite"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"avito-tech-go-task/internal/infrastructure/storage"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func TestPostgresTxOptions(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	pg, err := postgres.Connect(dbDSN)
+	require.NoError(t, err)
+	defer pg.Close()
+
+	ctx := context.Background()
+	tx := storage.NewTxManager(storage.NewTracedDB(pg), slog.New(slog.DiscardHandler))
+	testTxOptions(t, tx)
+
+	err = tx.DoWith(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(ctx context.Context) error {
+		level, err := queryStrings(ctx, storage.Executor(ctx, nil), "SHOW transaction_isolation")
+		require.NoError(t, err)
+		require.Equal(t, []string{"serializable"}, level)
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = tx.DoWith(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context) error {
+		_, err := storage.Executor(ctx, nil).ExecContext(ctx, "INSERT INTO teams (name) VALUES ('read-only')")
+		return err
+	})
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, pq.ErrorCode("25006"), pqErr.Code) // read_only_sql_transaction
+}
+
+func TestSQLiteTxOptions(t *testing.T) {
+	client, _, err := connectSQLite(t)
+	require.NoError(t, err)
+	defer client.Close()
+
+	testTxOptions(t, storage.NewTxManager(storage.NewTracedDB(client), slog.New(slog.DiscardHandler)))
+}
+
+// beginHook вызывает onBegin после каждой попытки открыть транзакцию.
+type beginHook struct {
+	storage.DB
+	begins  atomic.Int32
+	onBegin func(err error)
+}
+
+func (db *beginHook) Begin(ctx context.Context, opts *sql.TxOptions) (storage.Tx, error) {
+	tx, err := db.DB.Begin(ctx, opts)
+	db.begins.Add(1)
+	db.onBegin(err)
+	return tx, err
+}
+
+func TestSQLiteTxRetry(t *testing.T) {
+	ctx := context.Background()
+	path := sqlite.Scheme + filepath.Join(t.TempDir(), "pr_reviewer.db")
+
+	writer, err := sqlite.Connect(path)
+	require.NoError(t, err)
+	defer writer.Close()
+	_, err = writer.Exec(ctx, "CREATE TABLE counters (value INTEGER NOT NULL)")
+	require.NoError(t, err)
+
+	// без ожидания блокировки занятая база сразу отвечает SQLITE_BUSY
+	client, err := sqlite.Connect(path + "?_pragma=busy_timeout(0)")
+	require.NoError(t, err)
+	defer client.Close()
+
+	// другой писатель держит блокировку, пока транзакция не получит SQLITE_BUSY, и отпускает её до повтора
+	lock, err := writer.Begin(ctx, nil)
+	require.NoError(t, err)
+	db := &beginHook{DB: storage.NewTracedDB(client)}
+	db.onBegin = func(err error) {
+		var sqliteErr *sqlitedriver.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_BUSY {
+			require.NoError(t, lock.Rollback())
+		}
+	}
+	tx := storage.NewTxManager(db, slog.New(slog.DiscardHandler))
+	err = tx.Do(ctx, func(ctx context.Context) error {
+		_, err := storage.Executor(ctx, nil).ExecContext(ctx, "INSERT INTO counters (value) VALUES (1)")
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), db.begins.Load())
+
+	// пока блокировку не отпустили, все попытки заканчиваются SQLITE_BUSY
+	lock, err = writer.Begin(ctx, nil)
+	require.NoError(t, err)
+	defer lock.Rollback()
+	db = &beginHook{DB: storage.NewTracedDB(client), onBegin: func(error) {}}
+	err = storage.NewTxManager(db, slog.New(slog.DiscardHandler)).Do(ctx, func(context.Context) error { return nil })
+	var sqliteErr *sqlitedriver.Error
+	require.ErrorAs(t, err, &sqliteErr)
+	require.Equal(t, sqlite3.SQLITE_BUSY, sqliteErr.Code())
+	require.Equal(t, int32(3), db.begins.Load())
+}
+
+func TestPostgresTxRetry(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	pg, err := postgres.Connect(dbDSN)
+	require.NoError(t, err)
+	defer pg.Close()
+
+	ctx := context.Background()
+	cleanup := func() {
+		_, err := pg.Exec(ctx, "DELETE FROM teams WHERE name LIKE 'tx-retry-%'")
+		require.NoError(t, err)
+	}
+	cleanup()
+	defer cleanup()
+
+	// write skew: обе транзакции читают команды tx-retry-* и добавляют свою, только если таких нет.
+	// В SERIALIZABLE одна из них откатывается с serialization failure, повторяется и видит команду другой.
+	tx := storage.NewTxManager(storage.NewTracedDB(pg), slog.New(slog.DiscardHandler))
+	var (
+		read     sync.WaitGroup
+		wg       sync.WaitGroup
+		attempts atomic.Int32
+	)
+	read.Add(2)
+	for _, name := range []string{"tx-retry-a", "tx-retry-b"} {
+		wg.Go(func() {
+			first := true
+			err := tx.DoWith(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(ctx context.Context) error {
+				attempts.Add(1)
+				existing, err := queryStrings(ctx, storage.Executor(ctx, nil), "SELECT name FROM teams WHERE name LIKE 'tx-retry-%'")
+				if err != nil {
+					return err
+				}
+				if first {
+					first = false
+					read.Done()
+					read.Wait()
+				}
+				if len(existing) > 0 {
+					return nil
+				}
+				_, err = storage.Executor(ctx, nil).ExecContext(ctx, "INSERT INTO teams (name) VALUES ($1)", name)
+				return err
+			})
+			require.NoError(t, err)
+		})
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(3), attempts.Load())
+	names, err := queryStrings(ctx, storage.Executor(ctx, storage.NewTracedDB(pg)), "SELECT name FROM teams WHERE name LIKE 'tx-retry-%'")
+	require.NoError(t, err)
+	require.Len(t, names, 1)
+}
+
+// testTxOptions проверяет общее для всех БД: отменённый контекст не открывает транзакцию,
+// транзакция только для чтения видит данные, вложенный вызов переиспользует внешнюю транзакцию.
+func testTxOptions(t *testing.T, tx *storage.TxManager) {
+	ctx := context.Background()
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	called := false
+	err := tx.Do(canceled, func(context.Context) error {
+		called = true
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, called)
+
+	err = tx.DoWith(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context) error {
+		outer := storage.Executor(ctx, nil)
+		return tx.Do(ctx, func(ctx context.Context) error {
+			require.Same(t, outer, storage.Executor(ctx, nil))
+			_, err := queryStrings(ctx, outer, "SELECT name FROM teams")
+			return err
+		})
+	})
+	require.NoError(t, err)
+}
+
+func TestPoolStats(t *testing.T) {
+	r, client := authRouter(t, nil)
+	client.DB().SetMaxOpenConns(3)
+
+	// статистика пула - только для admin, как и остальные ручки /admin
+	w := doJSON(r, http.MethodGet, "/admin/getPoolStats", nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON(r, http.MethodPost, "/admin/issueToken", model.IssueTokenRequest{Name: "dashboards", Scopes: []string{"stats:read"}}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var issued model.IssueTokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+	w = doJSON(r, http.MethodGet, "/admin/getPoolStats", nil, withToken(issued.Secret))
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON(r, http.MethodGet, "/admin/getPoolStats", nil, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res model.GetPoolStatsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Contains(t, res.Pools, "primary")
+	stats := res.Pools["primary"]
+	require.Equal(t, 3, stats.MaxOpenConnections)
+	require.GreaterOrEqual(t, stats.OpenConnections, 1)
+	require.Equal(t, stats.OpenConnections, stats.InUse+stats.Idle)
+}
+
+func queryStrings(ctx context.Context, q storage.Querier, query string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err = rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
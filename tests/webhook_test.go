@@ -0,0 +1,299 @@
+package tests
+
+import (
+	"avito-tech-go-task/internal/application/service"
+	"avito-tech-go-task/internal/domain"
+	"avito-tech-go-task/internal/infrastructure/http/controller"
+	"avito-tech-go-task/internal/infrastructure/http/model"
+	"avito-tech-go-task/internal/infrastructure/outbox"
+	"avito-tech-go-task/internal/infrastructure/webhook"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dispatchAll отправляет доставки, пока в очереди есть готовые к попытке.
+func dispatchAll(t *testing.T, dispatcher *webhook.Dispatcher, store webhookRepository) {
+	t.Helper()
+	ctx := asSystem()
+	q, err := domain.NewWebhookDeliveryQuery(domain.WebhookDeliveryQuery{Status: domain.WebhookPending})
+	require.NoError(t, err)
+	for range 100 {
+		_, err := dispatcher.Dispatch(ctx)
+		require.NoError(t, err)
+		pending, err := store.ListDeliveries(ctx, *q)
+		require.NoError(t, err)
+		if len(pending) == 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("webhook deliveries are still pending")
+}
+
+func TestWebhookRetryPolicy(t *testing.T) {
+	policy := domain.WebhookRetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Second, MaxDelay: 30 * time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: 0},
+		{attempts: 1, want: 10 * time.Second},
+		{attempts: 2, want: 20 * time.Second},
+		{attempts: 3, want: 30 * time.Second},
+		{attempts: 100, want: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, policy.Backoff(tt.attempts), "attempts %d", tt.attempts)
+	}
+
+	at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	failed := domain.WebhookAttempt{AttemptedAt: at, Error: "unexpected status 503", StatusCode: 503}
+	delivery := domain.WebhookDelivery{Status: domain.WebhookPending}
+	delivery.Record(failed, policy)
+	require.Equal(t, domain.WebhookPending, delivery.Status)
+	require.Equal(t, at.Add(10*time.Second), delivery.NextAttemptAt)
+	delivery.Record(failed, policy)
+	require.Equal(t, at.Add(20*time.Second), delivery.NextAttemptAt)
+	delivery.Record(failed, policy)
+	require.Equal(t, domain.WebhookDead, delivery.Status)
+	require.Equal(t, 3, delivery.Attempts)
+	require.Equal(t, "unexpected status 503", delivery.LastError)
+
+	delivery = domain.WebhookDelivery{Status: domain.WebhookPending}
+	delivery.Record(failed, policy)
+	delivery.Record(domain.WebhookAttempt{AttemptedAt: at, StatusCode: 202}, policy)
+	require.Equal(t, domain.WebhookDelivered, delivery.Status)
+	require.Empty(t, delivery.LastError)
+	require.Equal(t, at, *delivery.DeliveredAt)
+}
+
+func TestWebhookSubscriptionValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		events []domain.EventType
+		secret string
+		ok     bool
+	}{
+		{name: "all events", url: "https://chat.example.com/hooks", secret: webhookSecret, ok: true},
+		{name: "filter", url: "http://127.0.0.1:8081/hooks", events: []domain.EventType{domain.EventPRMerged}, secret: webhookSecret, ok: true},
+		{name: "relative url", url: "/hooks", secret: webhookSecret},
+		{name: "ftp url", url: "ftp://example.com/hooks", secret: webhookSecret},
+		{name: "short secret", url: "https://chat.example.com/hooks", secret: "short"},
+		{name: "unknown event", url: "https://chat.example.com/hooks", events: []domain.EventType{"pr.closed"}, secret: webhookSecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := domain.NewWebhookSubscription(tt.url, tt.events, tt.secret)
+			if tt.ok {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, domain.ErrInvalidWebhook)
+			}
+		})
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	ctx := asSystem()
+
+	b := newMemoryBackend(logger)
+	repo := b.webhooks
+	s := service.NewPRService(b.prs, b.users, b.teams, b.audit, b.tx, 1, logger)
+	webhooks := service.NewWebhookService(repo, b.audit, b.tx, logger)
+	relay := outbox.NewRelay(b.outbox, []outbox.Sink{webhook.NewSink(repo, logger)}, 100, 10, time.Minute, logger)
+	policy := domain.WebhookRetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	dispatcher := webhook.NewDispatcher(repo, policy, 10, time.Second, true, logger)
+
+	bot, botServer := newWebhookReceiver(t, http.StatusNoContent)
+	_, err := webhooks.Create(ctx, botServer.URL, []domain.EventType{domain.EventReviewerAssigned, domain.EventPRMerged}, webhookSecret)
+	require.NoError(t, err)
+	down, downServer := newWebhookReceiver(t, http.StatusServiceUnavailable)
+	dashboard, err := webhooks.Create(ctx, downServer.URL, nil, webhookSecret)
+	require.NoError(t, err)
+
+	deliveredBefore := counterValue(t, "pr_reviewer_webhook_attempts_total", map[string]string{"status": string(domain.WebhookDelivered)})
+	deadBefore := counterValue(t, "pr_reviewer_webhook_attempts_total", map[string]string{"status": string(domain.WebhookDead)})
+
+	require.NoError(t, s.AddTeam(ctx, "payments", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	}))
+	_, err = s.CreatePR(ctx, "pr-1", "Add search", "u1")
+	require.NoError(t, err)
+	_, err = s.MergePR(ctx, "pr-1", 0)
+	require.NoError(t, err)
+
+	published, err := relay.Relay(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, published)
+	dispatchAll(t, dispatcher, repo)
+
+	// доставки одной пачки отправляются параллельно
+	require.ElementsMatch(t, []string{"pr.reviewer_assigned pr-1", "pr.merged pr-1"}, bot.received())
+	// отказывающий подписчик получил каждое событие MaxAttempts раз
+	require.Len(t, down.received(), 9)
+
+	deliveries, err := webhooks.ListDeliveries(ctx, domain.WebhookDeliveryQuery{SubscriptionID: dashboard.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 3)
+	for _, delivery := range deliveries {
+		require.Equal(t, domain.WebhookDead, delivery.Status)
+		require.Equal(t, 3, delivery.Attempts)
+		require.Equal(t, "unexpected status 503", delivery.LastError)
+
+		_, attempts, err := webhooks.GetDelivery(ctx, delivery.ID)
+		require.NoError(t, err)
+		require.Len(t, attempts, 3)
+		for i, attempt := range attempts {
+			require.Equal(t, http.StatusServiceUnavailable, attempt.StatusCode)
+			if i > 0 {
+				require.False(t, attempt.AttemptedAt.Before(attempts[i-1].AttemptedAt.Add(policy.Backoff(i))))
+			}
+		}
+	}
+	require.Equal(t, deliveredBefore+2, counterValue(t, "pr_reviewer_webhook_attempts_total", map[string]string{"status": string(domain.WebhookDelivered)}))
+	require.Equal(t, deadBefore+3, counterValue(t, "pr_reviewer_webhook_attempts_total", map[string]string{"status": string(domain.WebhookDead)}))
+}
+
+func TestWebhookAdmin(t *testing.T) {
+	r, client := authRouter(t, nil)
+	b := newSQLiteBackend(client)
+	logger := slog.New(slog.DiscardHandler)
+	ctx := asSystem()
+	dispatcher := webhook.NewDispatcher(b.webhooks, domain.WebhookRetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}, 10, time.Second, true, logger)
+	receiver, server := newWebhookReceiver(t, http.StatusInternalServerError)
+
+	w := doJSON(r, http.MethodPost, "/admin/createWebhook", model.CreateWebhookRequest{URL: server.URL, Secret: "short"}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, controller.CodeInvalidRequest, errorCode(t, w))
+
+	w = doJSON(r, http.MethodPost, "/admin/createWebhook", model.CreateWebhookRequest{
+		URL:    server.URL,
+		Events: []string{"pr.merged"},
+		Secret: webhookSecret,
+	}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.NotContains(t, w.Body.String(), webhookSecret)
+	var created model.CreateWebhookResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.Equal(t, []string{"pr.merged"}, created.Webhook.Events)
+
+	w = doJSON(r, http.MethodGet, "/admin/listWebhooks", nil, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotContains(t, w.Body.String(), webhookSecret)
+	var list model.ListWebhooksResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Webhooks, 1)
+	subscriptions, err := service.NewWebhookService(b.webhooks, b.audit, b.tx, logger).List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, subscriptions[0].Secret)
+
+	event := domain.Event{ID: 7, Type: domain.EventPRMerged, AggregateType: domain.AggregatePullRequest, AggregateID: "pr-7", Payload: []byte(`{"pull_request_id": "pr-7"}`)}
+	require.NoError(t, webhook.NewSink(b.webhooks, logger).Publish(ctx, event))
+	dispatchAll(t, dispatcher, b.webhooks)
+
+	w = doJSON(r, http.MethodGet, "/admin/listWebhookDeliveries?status=lost", nil, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(r, http.MethodGet, "/admin/listWebhookDeliveries?status=dead&webhook_id="+created.Webhook.WebhookID, nil, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	var deliveries model.ListWebhookDeliveriesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deliveries))
+	require.Len(t, deliveries.Deliveries, 1)
+	deliveryID := deliveries.Deliveries[0].DeliveryID
+	require.Equal(t, "unexpected status 500", deliveries.Deliveries[0].LastError)
+
+	w = doJSON(r, http.MethodGet, "/admin/getWebhookDelivery?delivery_id="+strconv.FormatInt(deliveryID, 10), nil, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	var got model.GetWebhookDeliveryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	require.Equal(t, "dead", got.Delivery.Status)
+	require.Len(t, got.Attempts, 1)
+	require.Equal(t, http.StatusInternalServerError, got.Attempts[0].StatusCode)
+	var payload model.Event
+	require.NoError(t, json.Unmarshal(got.Payload, &payload))
+	require.Equal(t, "pr-7", payload.AggregateID)
+
+	w = doJSON(r, http.MethodGet, "/admin/getWebhookDelivery?delivery_id=1000", nil, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	// подписчик починился: повтор из dead letter доставляется
+	receiver.status.Store(http.StatusOK)
+	w = doJSON(r, http.MethodPost, "/admin/replayWebhookDelivery", model.ReplayWebhookDeliveryRequest{DeliveryID: deliveryID}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	var replayed model.ReplayWebhookDeliveryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &replayed))
+	require.Equal(t, "pending", replayed.Delivery.Status)
+	require.Zero(t, replayed.Delivery.Attempts)
+
+	w = doJSON(r, http.MethodPost, "/admin/replayWebhookDelivery", model.ReplayWebhookDeliveryRequest{DeliveryID: deliveryID}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusConflict, w.Code)
+	require.Equal(t, controller.CodeNotReplayable, errorCode(t, w))
+
+	dispatchAll(t, dispatcher, b.webhooks)
+	require.Equal(t, []string{"pr.merged pr-7", "pr.merged pr-7"}, receiver.received())
+
+	w = doJSON(r, http.MethodGet, "/admin/getWebhookDelivery?delivery_id="+strconv.FormatInt(deliveryID, 10), nil, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	require.Equal(t, "delivered", got.Delivery.Status)
+	require.NotNil(t, got.Delivery.DeliveredAt)
+	require.Len(t, got.Attempts, 2)
+	require.Equal(t, http.StatusOK, got.Attempts[1].StatusCode)
+
+	w = doJSON(r, http.MethodPost, "/admin/deleteWebhook", model.DeleteWebhookRequest{WebhookID: created.Webhook.WebhookID}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w = doJSON(r, http.MethodPost, "/admin/deleteWebhook", model.DeleteWebhookRequest{WebhookID: created.Webhook.WebhookID}, withToken(bootstrapSecret))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestWebhookDispatcherGuards(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	ctx := asSystem()
+	policy := domain.WebhookRetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	event := domain.Event{ID: 1, Type: domain.EventPRMerged, AggregateType: domain.AggregatePullRequest, AggregateID: "pr-1", Payload: []byte(`{"pull_request_id": "pr-1"}`)}
+
+	// подписчик на 127.0.0.1 недоступен, пока не разрешены внутренние сети
+	receiver, server := newWebhookReceiver(t, http.StatusNoContent)
+	b := newMemoryBackend(logger)
+	repo := b.webhooks
+	webhooks := service.NewWebhookService(repo, b.audit, b.tx, logger)
+	_, err := webhooks.Create(ctx, server.URL, nil, webhookSecret)
+	require.NoError(t, err)
+	require.NoError(t, webhook.NewSink(repo, logger).Publish(ctx, event))
+	dispatchAll(t, webhook.NewDispatcher(repo, policy, 10, time.Second, false, logger), repo)
+
+	require.Empty(t, receiver.received())
+	deliveries, err := webhooks.ListDeliveries(ctx, domain.WebhookDeliveryQuery{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, domain.WebhookDead, deliveries[0].Status)
+	require.Contains(t, deliveries[0].LastError, "not public")
+
+	// редирект не выполняется: ответ 3xx - неудачная попытка
+	target, targetServer := newWebhookReceiver(t, http.StatusNoContent)
+	redirect := httptest.NewServer(http.RedirectHandler(targetServer.URL, http.StatusTemporaryRedirect))
+	t.Cleanup(redirect.Close)
+	b = newMemoryBackend(logger)
+	repo = b.webhooks
+	webhooks = service.NewWebhookService(repo, b.audit, b.tx, logger)
+	_, err = webhooks.Create(ctx, redirect.URL, nil, webhookSecret)
+	require.NoError(t, err)
+	require.NoError(t, webhook.NewSink(repo, logger).Publish(ctx, event))
+	dispatchAll(t, webhook.NewDispatcher(repo, policy, 10, time.Second, true, logger), repo)
+
+	require.Empty(t, target.received())
+	deliveries, err = webhooks.ListDeliveries(ctx, domain.WebhookDeliveryQuery{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, domain.WebhookDead, deliveries[0].Status)
+	require.Equal(t, "unexpected status 307", deliveries[0].LastError)
+}